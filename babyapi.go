@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"html/template"
 	"log"
 	"log/slog"
 	"net/http"
@@ -18,25 +19,50 @@ import (
 // MethodGetAll is the same as http.MethodGet, but can be used when setting custom response codes
 const MethodGetAll = "GetAll"
 
+// CreatedHeader is the response header used by SetPutCreatedHeader to show if a PUT request created a new resource
+const CreatedHeader = "X-Created"
+
+// DefaultMaxNestingDepth is the default maximum number of levels of nested APIs, including the top-level API
+const DefaultMaxNestingDepth = 32
+
+// DefaultRequestIDHeader is the default header used to read and respond with request IDs
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// MethodPutCreate is the same as http.MethodPut, but can be used when setting custom response codes for PUT requests
+// that create a new resource instead of updating an existing one
+const MethodPutCreate = "PutCreate"
+
 // API encapsulates all handlers and other pieces of code required to run the CRUID API based on
 // the provided Resource type
 type API[T Resource] struct {
 	name string
 	base string
 
+	// nameFormatter is set by SetNameFormatter to change how the name is displayed by the CLI
+	nameFormatter func(string) string
+
 	subAPIs       map[string]relatedAPI
 	middlewares   []func(http.Handler) http.Handler
 	idMiddlewares []func(http.Handler) http.Handler
 
+	// methodMiddlewares are added by AddMethodMiddleware and only used for routes with the method
+	methodMiddlewares map[string][]func(http.Handler) http.Handler
+
 	// Storage is the interface used by the API server to read/write resources
 	Storage[T]
 
+	// snapshotDB is the database used by WithSnapshot so the final snapshot can be written when the server stops
+	snapshotDB *kv.SnapshotDB
+
 	// context is set by WithContext to allow external goroutines to control API shutdown
 	context context.Context
 
 	// quit is used for the Stop() method to send a shutdown signal to the server
 	quit chan struct{}
 
+	// quitOnce makes sure quit is only closed once since it can be closed by Stop, the context, or a parent API
+	quitOnce sync.Once
+
 	// shutdown is used so the Stop() method can block until the API is fully shutdown
 	shutdown chan struct{}
 
@@ -44,6 +70,9 @@ type API[T Resource] struct {
 	// I need to have pointer receivers on Bind and Render implementations, `new(T)` creates a nil instance
 	instance func() T
 
+	// instancePool reuses instances for request bodies when it is enabled by EnableInstancePool
+	instancePool *sync.Pool
+
 	// rootRoutes only applies if there are no parent APIs because otherwise it would conflict
 	rootRoutes []chi.Route
 
@@ -53,18 +82,125 @@ type API[T Resource] struct {
 	responseWrapper       func(T) render.Renderer
 	getAllResponseWrapper func([]T) render.Renderer
 
+	// computedFields are added to JSON responses by AddComputedField
+	computedFields []computedField[T]
+
+	// getAllHTMLTemplate is used to render GetAll responses as HTML when it is set
+	getAllHTMLTemplate *template.Template
+
+	// contentNegotiation is the preferred order of response content types set by SetContentNegotiation
+	contentNegotiation []string
+
 	getAllFilter func(*http.Request) FilterFunc[T]
 
+	// getAllFilters are named filters added by AddGetAllFilter which are combined with getAllFilter
+	getAllFilters map[string]func(*http.Request) FilterFunc[T]
+
+	// globalSearchFilter is used to include this API in a parent root API's global search
+	globalSearchFilter func(*http.Request) FilterFunc[T]
+
+	// pagination enables limit/offset pagination for GetAll when it is set
+	pagination *pagination
+
+	// etag enables ETag headers and 304 responses for Get and GetAll
+	etag bool
+
+	// partialPut enables merging PUT requests with an If-Match header into the stored resource
+	partialPut bool
+
+	// readOnlyResources disables the default handlers that modify resources. This is separate from the readOnly
+	// mutex, which prevents changing the API's configuration after it is routed
+	readOnlyResources bool
+
+	// fileFields are multipart form fields that are stored in a BlobStore, by form field name
+	fileFields map[string]fileField
+
 	beforeDelete beforeAfterFunc
 	afterDelete  beforeAfterFunc
 
 	onCreateOrUpdate    func(*http.Request, T) *ErrResponse
 	afterCreateOrUpdate func(*http.Request, T) *ErrResponse
 
+	// afterCreateOrUpdateErrorMode controls how errors from afterCreateOrUpdate are handled
+	afterCreateOrUpdateErrorMode AfterCreateOrUpdateErrorMode
+
 	parent relatedAPI
 
+	// maxNestingDepth limits the number of levels of nested APIs when routing a top-level API
+	maxNestingDepth int
+
+	// maxExpandDepth limits the number of levels of nested APIs in the expand query parameter. It is 0 when expand is
+	// disabled
+	maxExpandDepth int
+
 	responseCodes map[string]int
 
+	// notFoundResponse is the response for requests to resources that do not exist
+	notFoundResponse *ErrResponse
+
+	// hideUnauthorized enables replacing 403 Forbidden responses with notFoundResponse
+	hideUnauthorized bool
+
+	// skipLookupForNestedAPIs disables reading the resource from storage for requests to nested APIs
+	skipLookupForNestedAPIs bool
+
+	// cors is set by SetCORS. Nested APIs without it use the options from their parent
+	cors *CORSOptions
+
+	// responseHeaders are set on every response by SetResponseHeaders
+	responseHeaders http.Header
+
+	// streamingResponses is set by SetStreamingResponses. Nested APIs without it use the setting from their parent
+	streamingResponses *bool
+
+	// maxURILength is the longest request URI allowed by SetMaxURILength
+	maxURILength int
+
+	// responseTransformer is set by SetResponseTransformer. Nested APIs without it use the function from their parent
+	responseTransformer func(*http.Request, []byte) ([]byte, error)
+
+	// idValidator is used to validate IDs from the URL path before getting resources from storage
+	idValidator func(string) error
+
+	// createResponseMode controls the response body for POST requests
+	createResponseMode CreateResponseMode
+
+	// createLocationHeader enables setting the Location header in POST responses
+	createLocationHeader bool
+
+	// putCreatedHeader enables setting the X-Created header in PUT responses
+	putCreatedHeader bool
+
+	// dryRun enables skipping storage for requests with the X-Dry-Run header
+	dryRun bool
+
+	// deleteResponseMode controls the response body for DELETE requests
+	deleteResponseMode DeleteResponseMode
+
+	// requestIDHeader is the header used to read and respond with request IDs
+	requestIDHeader string
+
+	// logBodies enables logging request and response bodies
+	logBodies bool
+
+	// logRedactFields are the names of fields that are redacted when logging bodies
+	logRedactFields []string
+
+	// jsonOptions change how JSON responses are encoded when they are set by SetTimeFormat or SetOmitEmpty
+	jsonOptions *jsonOptions
+
+	// strictBinding is set by SetStrictBinding. When it is nil, the setting is inherited from parent APIs
+	strictBinding *bool
+
+	// maxSSEConnections limits concurrent connections to each server-sent events handler
+	maxSSEConnections int
+
+	// sseHeaders are additional headers set on responses from server-sent events handlers
+	sseHeaders map[string]string
+
+	// serverSentEvents stores the broadcast channel for each server-sent events handler by its route pattern
+	serverSentEvents map[string]*broadcastChannel[*ServerSentEvent]
+
 	// GetAll is the handler for /base and returns an array of resources
 	GetAll http.HandlerFunc
 
@@ -83,6 +219,9 @@ type API[T Resource] struct {
 	// Delete is used to delete the resource at /base/{ID}
 	Delete http.HandlerFunc
 
+	// BulkPatch is used to modify multiple resources at /base. It is only set by EnableBulkPatch
+	BulkPatch http.HandlerFunc
+
 	rootAPI bool
 
 	readOnly sync.Mutex
@@ -93,31 +232,74 @@ type API[T Resource] struct {
 }
 
 // NewAPI initializes an API using the provided name, base URL path, and function to create a new instance of
-// the resource with defaults
+// the resource with defaults. Request bodies for POST and PUT are decoded into a new instance, so default values are
+// kept for any fields that are not in the request. The default storage is in-memory only, so use WithSnapshot or
+// SetStorage to keep resources after the process exits
 func NewAPI[T Resource](name, base string, instance func() T) *API[T] {
 	api := &API[T]{
 		name,
 		base,
+		nil,
 		map[string]relatedAPI{},
 		nil,
 		nil,
+		map[string][]func(http.Handler) http.Handler{},
 		NewKVStorage[T](kv.NewDefaultDB(), name),
+		nil,
 		context.Background(),
 		make(chan struct{}, 1),
+		sync.Once{},
 		make(chan struct{}, 1),
 		instance,
 		nil,
 		nil,
 		nil,
+		nil,
 		func(r T) render.Renderer { return r },
 		nil,
+		nil,
+		nil,
+		nil,
 		func(*http.Request) FilterFunc[T] { return nil },
+		map[string]func(*http.Request) FilterFunc[T]{},
+		nil,
+		nil,
+		false,
+		false,
+		false,
+		map[string]fileField{},
 		defaultBeforeAfter,
 		defaultBeforeAfter,
 		func(*http.Request, T) *ErrResponse { return nil },
 		func(*http.Request, T) *ErrResponse { return nil },
+		AfterCreateOrUpdateErrorRespond,
 		nil,
+		DefaultMaxNestingDepth,
+		0,
 		defaultResponseCodes(),
+		ErrNotFoundResponse,
+		false,
+		false,
+		nil,
+		nil,
+		nil,
+		0,
+		nil,
+		nil,
+		CreateResponseFullBody,
+		true,
+		false,
+		false,
+		DeleteResponseNoContent,
+		DefaultRequestIDHeader,
+		false,
+		nil,
+		nil,
+		nil,
+		0,
+		nil,
+		map[string]*broadcastChannel[*ServerSentEvent]{},
+		nil,
 		nil,
 		nil,
 		nil,
@@ -168,7 +350,8 @@ func (a *API[T]) Name() string {
 }
 
 // SetCustomResponseCode will override the default response codes for the specified HTTP verb. Use MethodGetAll to set the
-// response code for listing all resources
+// response code for listing all resources. Use MethodPutCreate to set a different response code, like 201 Created, when
+// PUT creates a new resource. Otherwise, the PUT response code is used for creating and updating
 func (a *API[T]) SetCustomResponseCode(verb string, code int) *API[T] {
 	a.panicIfReadOnly()
 
@@ -176,6 +359,136 @@ func (a *API[T]) SetCustomResponseCode(verb string, code int) *API[T] {
 	return a
 }
 
+// SetNotFoundResponse sets the response used when a request is for a resource that does not exist, instead of
+// ErrNotFoundResponse. This can be used to change the message, or to respond with 403 Forbidden so clients cannot tell
+// which resources exist. It applies to the default handlers and anything that uses GetRequestedResource, including
+// requests to a nested API when this API's resource is the parent
+func (a *API[T]) SetNotFoundResponse(resp *ErrResponse) *API[T] {
+	a.panicIfReadOnly()
+
+	if resp == nil {
+		a.errors = append(a.errors, fmt.Errorf("SetNotFoundResponse: response must not be nil"))
+		return a
+	}
+
+	a.notFoundResponse = resp
+	return a
+}
+
+// NotFoundResponse returns the response used when a request is for a resource that does not exist. It is
+// ErrNotFoundResponse unless SetNotFoundResponse is used
+func (a *API[T]) NotFoundResponse() *ErrResponse {
+	return a.notFoundResponse
+}
+
+// CreateResponseMode determines how the default POST handler responds after creating a resource
+type CreateResponseMode int
+
+const (
+	// CreateResponseFullBody responds with the full created resource. This is the default
+	CreateResponseFullBody CreateResponseMode = iota
+	// CreateResponseIDOnly responds with a body that only contains the new resource's ID
+	CreateResponseIDOnly
+	// CreateResponseLocationOnly responds with an empty body and a Location header with the new resource's path
+	CreateResponseLocationOnly
+)
+
+// SetCreateResponseMode sets the type of response used by the default POST handler. This is useful for clients that
+// do not need the full resource to be returned
+func (a *API[T]) SetCreateResponseMode(mode CreateResponseMode) *API[T] {
+	a.panicIfReadOnly()
+
+	a.createResponseMode = mode
+	return a
+}
+
+// DeleteResponseMode determines how the default DELETE handler responds after deleting a resource
+type DeleteResponseMode int
+
+const (
+	// DeleteResponseNoContent responds with an empty body. This is the default
+	DeleteResponseNoContent DeleteResponseMode = iota
+	// DeleteResponseFullBody responds with the deleted resource, which is useful for clients that need its final
+	// state, like to undo the delete
+	DeleteResponseFullBody
+)
+
+// SetDeleteResponseMode sets the type of response used by the default DELETE handler. If the DELETE response code has
+// not been changed with SetCustomResponseCode, it is set to 200 OK for DeleteResponseFullBody and 204 No Content for
+// DeleteResponseNoContent
+func (a *API[T]) SetDeleteResponseMode(mode DeleteResponseMode) *API[T] {
+	a.panicIfReadOnly()
+
+	code := a.responseCodes[http.MethodDelete]
+	switch {
+	case mode == DeleteResponseFullBody && code == http.StatusNoContent:
+		a.responseCodes[http.MethodDelete] = http.StatusOK
+	case mode == DeleteResponseNoContent && code == http.StatusOK:
+		a.responseCodes[http.MethodDelete] = http.StatusNoContent
+	}
+
+	a.deleteResponseMode = mode
+	return a
+}
+
+// SetCreateLocationHeader enables or disables the Location header in responses from the default POST handler. It is
+// enabled by default and contains the path to the created resource, including any parent and prefix paths. The
+// header is always set when using CreateResponseLocationOnly
+func (a *API[T]) SetCreateLocationHeader(enabled bool) *API[T] {
+	a.panicIfReadOnly()
+
+	a.createLocationHeader = enabled
+	return a
+}
+
+// SetPutCreatedHeader enables or disables the X-Created header in responses from the default PUT handler. The header
+// is "true" if the resource was created and "false" if an existing resource was updated. It is disabled by default
+func (a *API[T]) SetPutCreatedHeader(enabled bool) *API[T] {
+	a.panicIfReadOnly()
+
+	a.putCreatedHeader = enabled
+	return a
+}
+
+// SetRequestIDHeader sets the header used for request IDs by DefaultMiddleware. If a request has a valid ID in this
+// header, it is used instead of generating a new one so requests can be traced across services. The ID is also set
+// in this response header. The default is X-Request-ID
+func (a *API[T]) SetRequestIDHeader(header string) *API[T] {
+	a.panicIfReadOnly()
+
+	if header == "" {
+		a.errors = append(a.errors, fmt.Errorf("SetRequestIDHeader: header must not be empty"))
+		return a
+	}
+
+	a.requestIDHeader = header
+	return a
+}
+
+// SetInstance replaces the function used to create new instances of the resource. Request bodies for POST and PUT
+// are decoded into a new instance, so any defaults set by the function are kept for fields that are not in the request.
+// When EnableInstancePool is used, the resource's Reset method must also set the defaults
+func (a *API[T]) SetInstance(instance func() T) *API[T] {
+	a.panicIfReadOnly()
+
+	if instance == nil {
+		a.errors = append(a.errors, fmt.Errorf("SetInstance: instance function must not be nil"))
+		return a
+	}
+
+	a.instance = instance
+	return a
+}
+
+// SetIDValidator sets a function that validates the resource ID from the URL path before the resource is looked up
+// in storage. If the function returns an error, the API responds with 400 Bad Request instead of 404 Not Found
+func (a *API[T]) SetIDValidator(validator func(id string) error) *API[T] {
+	a.panicIfReadOnly()
+
+	a.idValidator = validator
+	return a
+}
+
 // SetGetAllResponseWrapper sets a function that can create a custom response for GetAll. This function will receive
 // a slice of Resources from storage and must return a render.Renderer
 func (a *API[T]) SetGetAllResponseWrapper(getAllResponder func([]T) render.Renderer) *API[T] {
@@ -185,6 +498,17 @@ func (a *API[T]) SetGetAllResponseWrapper(getAllResponder func([]T) render.Rende
 	return a
 }
 
+// SetGetAllHTMLTemplate sets a template that is used to render GetAll responses when the accepted content type is
+// text/html. Each item's HTML is rendered and the wrapper template receives the results as a []template.HTML. Items
+// must implement HTMLer, including responses from SetResponseWrapper, or HTML requests respond with 500 Internal
+// Server Error. This has no effect if SetGetAllResponseWrapper is used
+func (a *API[T]) SetGetAllHTMLTemplate(tmpl *template.Template) *API[T] {
+	a.panicIfReadOnly()
+
+	a.getAllHTMLTemplate = tmpl
+	return a
+}
+
 // SetOnCreateOrUpdate runs on POST, PATCH, and PUT requests before saving the created/updated resource.
 // This is useful for adding more validations or performing tasks related to resources such as initializing
 // schedules or sending events
@@ -202,6 +526,31 @@ func (a *API[T]) SetAfterCreateOrUpdate(afterCreateOrUpdate func(*http.Request,
 	return a
 }
 
+// AfterCreateOrUpdateErrorMode determines how the default handlers respond when the function from
+// SetAfterCreateOrUpdate returns an error. Since it runs after the resource is stored, the resource is kept in
+// storage unless AfterCreateOrUpdateErrorRollback is used
+type AfterCreateOrUpdateErrorMode int
+
+const (
+	// AfterCreateOrUpdateErrorRespond responds with the error and keeps the stored resource. This is the default
+	AfterCreateOrUpdateErrorRespond AfterCreateOrUpdateErrorMode = iota
+	// AfterCreateOrUpdateErrorRollback restores storage before responding with the error. If the Storage implements
+	// Transactional, the transaction is rolled back. Otherwise, created resources are removed with Storage.Delete and
+	// updated resources are set back to their previous state. Delete is called again for EndDateable resources so they
+	// are removed instead of end-dated. This is not atomic, so concurrent requests may observe the stored resource
+	AfterCreateOrUpdateErrorRollback
+	// AfterCreateOrUpdateErrorPartialSuccess responds with the stored resource and adds the error to a Warning header
+	AfterCreateOrUpdateErrorPartialSuccess
+)
+
+// SetAfterCreateOrUpdateErrorMode sets how errors from the SetAfterCreateOrUpdate function are handled
+func (a *API[T]) SetAfterCreateOrUpdateErrorMode(mode AfterCreateOrUpdateErrorMode) *API[T] {
+	a.panicIfReadOnly()
+
+	a.afterCreateOrUpdateErrorMode = mode
+	return a
+}
+
 // SetBeforeDelete sets a function that is executing before deleting a resource. It is useful for additional
 // validation before completing the delete
 func (a *API[T]) SetBeforeDelete(before func(*http.Request) *ErrResponse) *API[T] {
@@ -239,6 +588,16 @@ func (a *API[T]) SetGetAllFilter(f func(*http.Request) FilterFunc[T]) *API[T] {
 	return a
 }
 
+// AddGetAllFilter adds a named filter for GetAll. All named filters and the filter from SetGetAllFilter are combined
+// using FilterAnd, so each can be responsible for different query parameters. Adding a filter with an existing name
+// replaces it
+func (a *API[T]) AddGetAllFilter(name string, f func(*http.Request) FilterFunc[T]) *API[T] {
+	a.panicIfReadOnly()
+
+	a.getAllFilters[name] = f
+	return a
+}
+
 // SetResponseWrapper sets a function that returns a new Renderer before responding with T. This is used to add
 // more data to responses that isn't directly from storage
 func (a *API[T]) SetResponseWrapper(responseWrapper func(T) render.Renderer) *API[T] {
@@ -258,7 +617,7 @@ func (a *API[T]) Client(addr string) *Client[T] {
 func (a *API[T]) AnyClient(addr string) *Client[*AnyResource] {
 	client := NewClient[*AnyResource](addr, makePathWithRoot(a.base, a.parent)).
 		SetCustomResponseCodeMap(a.responseCodes)
-	client.name = a.name
+	client.name = a.DisplayName()
 	return client
 }
 
@@ -280,7 +639,8 @@ func (a *API[T]) AddCustomRootRoute(method, pattern string, handler http.Handler
 	return a
 }
 
-// AddCustomRoute appends a custom API route to the base path: /base/custom-route
+// AddCustomRoute appends a custom API route to the base path: /base/custom-route. Route returns an error if the
+// custom route conflicts with a default handler or the ID route
 func (a *API[T]) AddCustomRoute(method, pattern string, handler http.Handler) *API[T] {
 	a.panicIfReadOnly()
 
@@ -294,7 +654,8 @@ func (a *API[T]) AddCustomRoute(method, pattern string, handler http.Handler) *A
 }
 
 // AddCustomIDRoute appends a custom API route to the base path after the ID URL parameter: /base/{ID}/custom-route.
-// The handler for this route can access the requested resource using GetResourceFromContext
+// The handler for this route can access the requested resource using GetResourceFromContext. Route returns an error
+// if the custom route conflicts with a default handler or nested API
 func (a *API[T]) AddCustomIDRoute(method, pattern string, handler http.Handler) *API[T] {
 	a.panicIfReadOnly()
 
@@ -331,6 +692,25 @@ func (a *API[T]) AddIDMiddleware(m func(http.Handler) http.Handler) *API[T] {
 	return a
 }
 
+// AddMethodMiddleware adds a middleware which is only active on this API's routes for the method, like http.MethodPost,
+// including custom routes. Use MethodGetAll for only the GetAll route, while http.MethodGet is used for every GET
+// route. The middleware runs after the other middlewares and right before the handler, so it can use the resource
+// and request body from the context. Unlike AddMiddleware, it is not used for nested APIs
+func (a *API[T]) AddMethodMiddleware(method string, m func(http.Handler) http.Handler) *API[T] {
+	a.panicIfReadOnly()
+
+	switch method {
+	case MethodGetAll, http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+	default:
+		a.errors = append(a.errors, fmt.Errorf("AddMethodMiddleware: unsupported method %q", method))
+		return a
+	}
+
+	a.methodMiddlewares[method] = append(a.methodMiddlewares[method], m)
+	return a
+}
+
 // Serve will serve the API on the given port
 func (a *API[T]) Serve(address string) error {
 	if address == "" {
@@ -353,7 +733,7 @@ func (a *API[T]) Serve(address string) error {
 		case <-a.Done():
 		case <-a.context.Done():
 			// if shutdown by context, need to close a.quit for a.Done()
-			close(a.quit)
+			a.closeQuit()
 		}
 
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -373,6 +753,8 @@ func (a *API[T]) Serve(address string) error {
 		if err != nil {
 			log.Fatal(err)
 		}
+
+		a.closeStorage()
 	}()
 
 	slog.Info("starting server", "address", address, "api", a.name)
@@ -386,17 +768,28 @@ func (a *API[T]) Serve(address string) error {
 	return nil
 }
 
-// Stop will stop the API
+// Stop will stop the API. Child APIs are also stopped so their server-sent events handlers and input channel workers
+// exit instead of blocking the server shutdown
 func (a *API[T]) Stop() {
-	close(a.quit)
+	a.closeQuit()
 	<-a.shutdown
 }
 
-// Done returns a channel that's closed when the API stops, similar to context.Done()
+// Done returns a channel that's closed when the API or any of its parents stop, similar to context.Done()
 func (a *API[T]) Done() <-chan struct{} {
 	return a.quit
 }
 
+// closeQuit closes the quit channel for this API and all of its children. It is safe to call more than once
+func (a *API[T]) closeQuit() {
+	a.quitOnce.Do(func() {
+		close(a.quit)
+		for _, child := range a.subAPIs {
+			child.closeQuit()
+		}
+	})
+}
+
 type beforeAfterFunc func(*http.Request) *ErrResponse
 
 func defaultBeforeAfter(*http.Request) *ErrResponse {
@@ -412,6 +805,7 @@ func (a *API[T]) ChildAPIs() map[string]RelatedAPI {
 	return children
 }
 
+// SetStorage sets the Storage implementation used by the API. The default is KVStorage with an in-memory database
 func (a *API[T]) SetStorage(s Storage[T]) *API[T] {
 	a.panicIfReadOnly()
 
@@ -419,6 +813,39 @@ func (a *API[T]) SetStorage(s Storage[T]) *API[T] {
 	return a
 }
 
+// WithSnapshot keeps the default in-memory storage, but loads resources from a JSON file when the API is created and
+// writes them to the file every interval if they changed. The final snapshot is written when Serve stops, so resources
+// persist across restarts without using a separate database. Changes since the last snapshot are lost if the process
+// exits without stopping the server. Without this or SetStorage, resources are only stored in memory and are lost when
+// the process exits
+func (a *API[T]) WithSnapshot(path string, interval time.Duration) *API[T] {
+	a.panicIfReadOnly()
+
+	db, err := kv.NewSnapshotDB(path, interval)
+	if err != nil {
+		a.errors = append(a.errors, fmt.Errorf("WithSnapshot: %w", err))
+		return a
+	}
+
+	if a.snapshotDB != nil {
+		a.snapshotDB.Close()
+	}
+	a.snapshotDB = db
+	a.Storage = NewKVStorage[T](db, a.name)
+	return a
+}
+
+// closeStorage writes the final snapshot for this API and its children if WithSnapshot is used
+func (a *API[T]) closeStorage() {
+	if a.snapshotDB != nil {
+		a.snapshotDB.Close()
+	}
+
+	for _, child := range a.subAPIs {
+		child.closeStorage()
+	}
+}
+
 // WithContext adds a context to the API so that it will automatically shutdown when the context is closed
 func (a *API[T]) WithContext(ctx context.Context) *API[T] {
 	a.panicIfReadOnly()
@@ -427,8 +854,46 @@ func (a *API[T]) WithContext(ctx context.Context) *API[T] {
 	return a
 }
 
+// DisableMethods removes the default handlers for the provided methods so the routes are not registered and requests
+// receive a 405 response. Use http.MethodGet to disable getting a resource by ID and MethodGetAll to disable GetAll
+func (a *API[T]) DisableMethods(methods ...string) *API[T] {
+	a.panicIfReadOnly()
+
+	for _, method := range methods {
+		switch method {
+		case MethodGetAll:
+			a.GetAll = nil
+		case http.MethodGet:
+			a.Get = nil
+		case http.MethodPost:
+			a.Post = nil
+		case http.MethodPut:
+			a.Put = nil
+		case http.MethodPatch:
+			a.Patch = nil
+		case http.MethodDelete:
+			a.Delete = nil
+		default:
+			a.errors = append(a.errors, fmt.Errorf("DisableMethods: unsupported method %q", method))
+		}
+	}
+
+	return a
+}
+
+// SetReadOnly only registers the default Get and GetAll handlers when enabled, so POST, PUT, PATCH, and DELETE
+// requests receive a 405 response. This is useful for safely exposing data that is managed elsewhere. Custom routes and
+// nested APIs are not affected
+func (a *API[T]) SetReadOnly(readOnly bool) *API[T] {
+	a.panicIfReadOnly()
+
+	a.readOnlyResources = readOnly
+	return a
+}
+
 // Modify allows inline, fluent-style modification of the API, so custom modifications can be made in the same
-// fluent style as the built-in methods
+// fluent style as the built-in methods. This is useful for overriding exported handlers, like Get or Post, on a child
+// API while passing it to AddNestedAPI
 func (a *API[T]) Modify(modify func(*API[T])) *API[T] {
 	a.panicIfReadOnly()
 
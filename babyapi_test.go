@@ -4,17 +4,30 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
+	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"os"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/calvinmclean/babyapi"
+	"github.com/calvinmclean/babyapi/storage/kv"
 	babytest "github.com/calvinmclean/babyapi/test"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/rs/xid"
 	"github.com/spf13/cobra"
@@ -262,6 +275,400 @@ type Artist struct {
 	Name string `json:"name"`
 }
 
+func TestCustomRouteConflicts(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name     string
+		api      func() babyapi.RelatedAPI
+		expected string
+	}{
+		{
+			"BaseGetConflictsWithGetAll",
+			func() babyapi.RelatedAPI {
+				return babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+					AddCustomRoute(http.MethodGet, "/", handler)
+			},
+			`AddCustomRoute: GET "/" conflicts with the default GET handler`,
+		},
+		{
+			"ParamConflictsWithIDRoute",
+			func() babyapi.RelatedAPI {
+				return babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+					AddCustomRoute(http.MethodGet, "/{id}", handler)
+			},
+			`AddCustomRoute: GET "/{id}" conflicts with the ID route`,
+		},
+		{
+			"IDRouteConflictsWithGet",
+			func() babyapi.RelatedAPI {
+				return babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+					AddCustomIDRoute(http.MethodPatch, "", handler)
+			},
+			`AddCustomIDRoute: PATCH "" conflicts with the default PATCH handler`,
+		},
+		{
+			"IDRouteConflictsWithNestedAPI",
+			func() babyapi.RelatedAPI {
+				return babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} }).
+					AddNestedAPI(babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })).
+					AddCustomIDRoute(http.MethodGet, "/albums/count", handler)
+			},
+			`AddCustomIDRoute: GET "/albums/count" conflicts with the routes for "Albums"`,
+		},
+		{
+			"RootAPIConflictsWithNestedAPI",
+			func() babyapi.RelatedAPI {
+				return babyapi.NewRootAPI("root", "/").
+					AddNestedAPI(babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })).
+					AddCustomRoute(http.MethodGet, "/albums", handler)
+			},
+			`AddCustomRoute: GET "/albums" conflicts with the routes for "Albums"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.api().Route(chi.NewRouter())
+			require.Error(t, err)
+			require.ErrorAs(t, err, &babyapi.BuilderError{})
+			require.Equal(t, "encountered 1 errors constructing API:\n- "+tt.expected+"\n", err.Error())
+		})
+	}
+
+	t.Run("NoConflictWhenDefaultHandlerRemoved", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			AddCustomRoute(http.MethodGet, "/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			})).
+			Modify(func(a *babyapi.API[*Album]) {
+				a.GetAll = nil
+			})
+
+		r := httptest.NewRequest(http.MethodGet, "/albums", http.NoBody)
+		w := babytest.TestRequest(t, api, r)
+		require.Equal(t, http.StatusTeapot, w.Result().StatusCode)
+	})
+
+	t.Run("NoConflictForStaticRoutes", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			AddCustomRoute(http.MethodGet, "/count", handler).
+			AddCustomIDRoute(http.MethodPost, "/play", handler)
+
+		require.NoError(t, api.Route(chi.NewRouter()))
+	})
+}
+
+func TestDisableMethods(t *testing.T) {
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+		DisableMethods(http.MethodPut, http.MethodPatch)
+	api := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} }).
+		DisableMethods(babyapi.MethodGetAll, http.MethodDelete).
+		AddNestedAPI(albumAPI)
+
+	artist := &Artist{DefaultResource: babyapi.NewDefaultResource(), Name: "Artist"}
+	require.NoError(t, api.Storage.Set(context.Background(), artist))
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+	require.NoError(t, albumAPI.Storage.Set(context.Background(), album))
+
+	albumPath := "/artists/" + artist.GetID() + "/albums/" + album.GetID()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"GetAllDisabled", http.MethodGet, "/artists", "", http.StatusMethodNotAllowed},
+		{"DeleteDisabled", http.MethodDelete, "/artists/" + artist.GetID(), "", http.StatusMethodNotAllowed},
+		{"GetAllowed", http.MethodGet, "/artists/" + artist.GetID(), "", http.StatusOK},
+		{"NestedPutDisabled", http.MethodPut, albumPath, `{"id":"` + album.GetID() + `","title":"New"}`, http.StatusMethodNotAllowed},
+		{"NestedPatchDisabled", http.MethodPatch, albumPath, `{"title":"New"}`, http.StatusMethodNotAllowed},
+		{"NestedGetAllowed", http.MethodGet, albumPath, "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := babytest.TestRequest(t, api, r)
+			require.Equal(t, tt.expectedStatus, w.Result().StatusCode)
+			if tt.expectedStatus == http.StatusMethodNotAllowed {
+				require.Equal(t, `{"status":"Method not allowed."}`, strings.TrimSpace(w.Body.String()))
+			}
+		})
+	}
+
+	t.Run("UnsupportedMethod", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			DisableMethods(http.MethodOptions)
+
+		err := api.Route(chi.NewRouter())
+		require.Error(t, err)
+		require.ErrorAs(t, err, &babyapi.BuilderError{})
+		require.Contains(t, err.Error(), `DisableMethods: unsupported method "OPTIONS"`)
+	})
+}
+
+func TestSetReadOnly(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+		SetReadOnly(true)
+
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+	require.NoError(t, api.Storage.Set(context.Background(), album))
+
+	address, closer := babytest.TestServe[*Album](t, api)
+	defer closer()
+
+	client := api.Client(address)
+
+	t.Run("GetAllowed", func(t *testing.T) {
+		resp, err := client.Get(context.Background(), album.GetID())
+		require.NoError(t, err)
+		require.Equal(t, "Album", resp.Data.Title)
+	})
+
+	t.Run("GetAllAllowed", func(t *testing.T) {
+		resp, err := client.GetAll(context.Background(), "")
+		require.NoError(t, err)
+		require.Len(t, resp.Data.Items, 1)
+	})
+
+	t.Run("MutationsNotAllowed", func(t *testing.T) {
+		expected := "unexpected response with text: Method not allowed."
+
+		_, err := client.Post(context.Background(), &Album{Title: "New"})
+		require.ErrorContains(t, err, expected)
+
+		_, err = client.Put(context.Background(), &Album{DefaultResource: album.DefaultResource, Title: "New"})
+		require.ErrorContains(t, err, expected)
+
+		_, err = client.Patch(context.Background(), album.GetID(), &Album{Title: "New"})
+		require.ErrorContains(t, err, expected)
+
+		_, err = client.Delete(context.Background(), album.GetID())
+		require.ErrorContains(t, err, expected)
+
+		resp, err := client.Get(context.Background(), album.GetID())
+		require.NoError(t, err)
+		require.Equal(t, "Album", resp.Data.Title)
+	})
+}
+
+func TestServeBlobWithoutLogger(t *testing.T) {
+	// the content is not seekable, so reading errors can only be logged after writing starts
+	content := io.NopCloser(iotest.ErrReader(errors.New("read error")))
+
+	w := httptest.NewRecorder()
+	require.NotPanics(t, func() {
+		babyapi.ServeBlob(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody), "", content)
+	})
+	require.Equal(t, "none", w.Header().Get("Accept-Ranges"))
+}
+
+type Attachment struct {
+	babyapi.DefaultResource
+	Name string                 `json:"name"`
+	File *babyapi.FileReference `json:"file"`
+}
+
+// newAttachmentMultipartBody creates a multipart form with the fields and an optional file for the "file" field
+func newAttachmentMultipartBody(t *testing.T, fields map[string]string, file []byte) (io.Reader, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for key, value := range fields {
+		require.NoError(t, writer.WriteField(key, value))
+	}
+
+	if file != nil {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="file"; filename="hello.txt"`)
+		header.Set("Content-Type", "text/plain")
+		part, err := writer.CreatePart(header)
+		require.NoError(t, err)
+		_, err = part.Write(file)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	return &body, writer.FormDataContentType()
+}
+
+func TestEnableFileField(t *testing.T) {
+	store := babyapi.NewKVBlobStore(kv.NewDefaultDB(), "Attachments")
+	api := babyapi.NewAPI("Attachments", "/attachments", func() *Attachment { return &Attachment{} }).
+		EnableFileField("file", store).
+		EnableDryRun()
+
+	address, closer := babytest.TestServe[*Attachment](t, api)
+	defer closer()
+
+	client := api.Client(address)
+
+	newMultipartRequest := func(t *testing.T, name string, file []byte) *http.Request {
+		body, contentType := newAttachmentMultipartBody(t, map[string]string{"name": name}, file)
+
+		req, err := client.PostRequest(context.Background(), body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+
+	t.Run("Upload", func(t *testing.T) {
+		resp, err := client.MakeRequest(newMultipartRequest(t, "Hello", []byte("hello world")), http.StatusCreated)
+		require.NoError(t, err)
+		require.Equal(t, "Hello", resp.Data.Name)
+		require.NotNil(t, resp.Data.File)
+		require.Equal(t, "hello.txt", resp.Data.File.Filename)
+		require.Equal(t, "text/plain", resp.Data.File.ContentType)
+		require.Equal(t, int64(11), resp.Data.File.Size)
+
+		t.Run("Download", func(t *testing.T) {
+			download, err := http.Get(address + "/attachments/" + resp.Data.GetID() + "/file")
+			require.NoError(t, err)
+			defer download.Body.Close()
+
+			require.Equal(t, http.StatusOK, download.StatusCode)
+			require.Equal(t, "text/plain", download.Header.Get("Content-Type"))
+			require.Equal(t, `attachment; filename="hello.txt"`, download.Header.Get("Content-Disposition"))
+
+			content, err := io.ReadAll(download.Body)
+			require.NoError(t, err)
+			require.Equal(t, "hello world", string(content))
+		})
+
+		t.Run("DownloadRange", func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, address+"/attachments/"+resp.Data.GetID()+"/file", http.NoBody)
+			require.NoError(t, err)
+			req.Header.Set("Range", "bytes=0-4")
+
+			download, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer download.Body.Close()
+
+			require.Equal(t, http.StatusPartialContent, download.StatusCode)
+			require.Equal(t, "bytes", download.Header.Get("Accept-Ranges"))
+			require.Equal(t, "bytes 0-4/11", download.Header.Get("Content-Range"))
+
+			content, err := io.ReadAll(download.Body)
+			require.NoError(t, err)
+			require.Equal(t, "hello", string(content))
+		})
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		req := newMultipartRequest(t, "DryRun", []byte("hello world"))
+		req.Header.Set(babyapi.DryRunHeader, "true")
+
+		resp, err := client.MakeRequest(req, http.StatusCreated)
+		require.NoError(t, err)
+		require.NotNil(t, resp.Data.File)
+		require.Equal(t, "hello.txt", resp.Data.File.Filename)
+
+		_, err = store.Get(context.Background(), resp.Data.File.Key)
+		require.ErrorIs(t, err, babyapi.ErrNotFound)
+	})
+
+	t.Run("ReplaceDeletesPreviousFile", func(t *testing.T) {
+		created, err := client.MakeRequest(newMultipartRequest(t, "Replace", []byte("first")), http.StatusCreated)
+		require.NoError(t, err)
+		previousKey := created.Data.File.Key
+
+		id := created.Data.GetID()
+		body, contentType := newAttachmentMultipartBody(t, map[string]string{"id": id, "name": "Replace"}, []byte("second"))
+		req, err := http.NewRequest(http.MethodPut, address+"/attachments/"+id, body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+
+		updated, err := client.MakeRequest(req, http.StatusOK)
+		require.NoError(t, err)
+		require.NotEqual(t, previousKey, updated.Data.File.Key)
+
+		_, err = store.Get(context.Background(), previousKey)
+		require.ErrorIs(t, err, babyapi.ErrNotFound)
+
+		content, err := store.Get(context.Background(), updated.Data.File.Key)
+		require.NoError(t, err)
+		defer content.Close()
+		data, err := io.ReadAll(content)
+		require.NoError(t, err)
+		require.Equal(t, "second", string(data))
+	})
+
+	t.Run("FailedRequestDeletesFile", func(t *testing.T) {
+		db := kv.NewDefaultDB()
+		api := babyapi.NewAPI("Attachments", "/attachments", func() *Attachment { return &Attachment{} }).
+			EnableFileField("file", babyapi.NewKVBlobStore(db, "Attachments")).
+			SetOnCreateOrUpdate(func(_ *http.Request, _ *Attachment) *babyapi.ErrResponse {
+				return babyapi.ErrInvalidRequest(errors.New("rejected"))
+			})
+
+		body, contentType := newAttachmentMultipartBody(t, map[string]string{"name": "Rejected"}, []byte("hello world"))
+		r := httptest.NewRequest(http.MethodPost, "/attachments", body)
+		r.Header.Set("Content-Type", contentType)
+
+		w := babytest.TestRequest(t, api, r)
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+
+		keys, err := db.Keys()
+		require.NoError(t, err)
+		require.Empty(t, keys)
+	})
+
+	t.Run("NoFile", func(t *testing.T) {
+		resp, err := client.MakeRequest(newMultipartRequest(t, "Empty", nil), http.StatusCreated)
+		require.NoError(t, err)
+		require.Equal(t, "Empty", resp.Data.Name)
+		require.Nil(t, resp.Data.File)
+
+		download, err := http.Get(address + "/attachments/" + resp.Data.GetID() + "/file")
+		require.NoError(t, err)
+		defer download.Body.Close()
+		require.Equal(t, http.StatusNotFound, download.StatusCode)
+	})
+
+	t.Run("MissingField", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			EnableFileField("cover", store)
+
+		err := api.Route(chi.NewRouter())
+		require.Error(t, err)
+		require.ErrorAs(t, err, &babyapi.BuilderError{})
+		require.Contains(t, err.Error(), `EnableFileField: *babyapi_test.Album does not have a FileReference field for "cover"`)
+	})
+}
+
+func TestModifyTypedAPI(t *testing.T) {
+	api := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} }).
+		AddNestedAPI(
+			babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+				Modify(func(a *babyapi.API[*Album]) {
+					a.GetAll = func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusTeapot)
+					}
+				}),
+		).
+		Modify(func(a *babyapi.API[*Artist]) {
+			a.Delete = nil
+		})
+
+	artist := &Artist{DefaultResource: babyapi.NewDefaultResource(), Name: "Artist"}
+	require.NoError(t, api.Storage.Set(context.Background(), artist))
+
+	t.Run("ChildHandlerOverridden", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/artists/"+artist.GetID()+"/albums", http.NoBody)
+		w := babytest.TestRequest(t, api, r)
+		require.Equal(t, http.StatusTeapot, w.Result().StatusCode)
+	})
+
+	t.Run("ParentHandlerRemoved", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodDelete, "/artists/"+artist.GetID(), http.NoBody)
+		w := babytest.TestRequest(t, api, r)
+		require.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+	})
+}
+
 func TestNestedAPI(t *testing.T) {
 	artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
 	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
@@ -412,6 +819,70 @@ func TestNestedAPI(t *testing.T) {
 	})
 }
 
+func TestNestedAPIPutParentValidation(t *testing.T) {
+	artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
+
+	artistAPI.AddNestedAPI(albumAPI)
+	albumAPI.AddNestedAPI(songAPI)
+
+	serverURL, stop := babytest.TestServe[*Artist](t, artistAPI)
+	defer stop()
+
+	artistClient := artistAPI.Client(serverURL)
+	albumClient := babyapi.NewSubClient[*Artist, *Album](artistClient, "/albums")
+	songClient := babyapi.NewSubClient[*Album, *Song](albumClient, "/songs")
+
+	artist := &Artist{DefaultResource: babyapi.NewDefaultResource(), Name: "Artist"}
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+
+	t.Run("PutArtist", func(t *testing.T) {
+		_, err := artistClient.Put(context.Background(), artist)
+		require.NoError(t, err)
+	})
+
+	t.Run("PutAlbum", func(t *testing.T) {
+		t.Run("Successful", func(t *testing.T) {
+			_, err := albumClient.Put(context.Background(), album, artist.GetID())
+			require.NoError(t, err)
+		})
+
+		t.Run("ErrorParentArtistDNE", func(t *testing.T) {
+			newAlbum := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album2"}
+			_, err := albumClient.Put(context.Background(), newAlbum, "cljcqg5o402e9s28rbp0")
+			require.Error(t, err)
+			require.Equal(t, "error putting resource: unexpected response with text: Resource not found.", err.Error())
+
+			exists, err := albumClient.Exists(context.Background(), newAlbum.GetID(), artist.GetID())
+			require.NoError(t, err)
+			require.False(t, exists)
+		})
+	})
+
+	t.Run("PutAlbumSong", func(t *testing.T) {
+		t.Run("Successful", func(t *testing.T) {
+			song := &Song{DefaultResource: babyapi.NewDefaultResource(), Title: "Song"}
+			_, err := songClient.Put(context.Background(), song, artist.GetID(), album.GetID())
+			require.NoError(t, err)
+		})
+
+		t.Run("ErrorParentArtistDNE", func(t *testing.T) {
+			song := &Song{DefaultResource: babyapi.NewDefaultResource(), Title: "Song2"}
+			_, err := songClient.Put(context.Background(), song, "cljcqg5o402e9s28rbp0", album.GetID())
+			require.Error(t, err)
+			require.Equal(t, "error putting resource: unexpected response with text: Resource not found.", err.Error())
+		})
+
+		t.Run("ErrorParentAlbumDNE", func(t *testing.T) {
+			song := &Song{DefaultResource: babyapi.NewDefaultResource(), Title: "Song2"}
+			_, err := songClient.Put(context.Background(), song, artist.GetID(), "cljcqg5o402e9s28rbp0")
+			require.Error(t, err)
+			require.Equal(t, "error putting resource: unexpected response with text: Resource not found.", err.Error())
+		})
+	})
+}
+
 func runCommand(cmd *cobra.Command, args []string) (string, error) {
 	var buf bytes.Buffer
 	cmd.SetArgs(args)
@@ -714,835 +1185,5581 @@ func TestHTML(t *testing.T) {
 	})
 }
 
-func TestServerSentEvents(t *testing.T) {
+func TestGetAllHTMLTemplate(t *testing.T) {
 	api := babyapi.NewAPI("Items", "/items", func() *ListItem { return &ListItem{} })
+	api.SetGetAllHTMLTemplate(template.Must(template.New("ul").Parse(`<ul>{{ range . }}{{ . }}{{ end }}</ul>`)))
 
-	api.SetGetAllResponseWrapper(func(d []*ListItem) render.Renderer {
-		return &UnorderedList{d}
-	})
+	for _, content := range []string{"Item1", "Item2"} {
+		err := api.Storage.Set(context.Background(), &ListItem{DefaultResource: babyapi.NewDefaultResource(), Content: content})
+		require.NoError(t, err)
+	}
 
-	events := api.AddServerSentEventHandler("/events")
+	t.Run("HTML", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/items", http.NoBody)
+		r.Header.Set("Accept", "text/html")
+		w := babytest.TestRequest(t, api, r)
 
-	address, closer := babytest.TestServe[*ListItem](t, api)
-	defer closer()
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Regexp(t, `^<ul>(<li>Item1</li><li>Item2</li>|<li>Item2</li><li>Item1</li>)</ul>$`, w.Body.String())
+	})
 
-	item1 := &ListItem{
-		DefaultResource: babyapi.NewDefaultResource(),
-		Content:         "Item1",
-	}
-	t.Run("CreateItem", func(t *testing.T) {
-		err := api.Storage.Set(context.Background(), item1)
-		require.NoError(t, err)
+	t.Run("JSON", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/items", http.NoBody)
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Regexp(t, `^{"items":\[{"id":"[0-9a-v]{20}","Content":"Item[12]"},{"id":"[0-9a-v]{20}","Content":"Item[12]"}\]}`, w.Body.String())
 	})
 
-	t.Run("GetServerSentEventsEndpoint", func(t *testing.T) {
-		quitTest := make(chan bool)
-		go func() {
-			for {
-				select {
-				case <-quitTest:
-					return
-				default:
-					events <- &babyapi.ServerSentEvent{
-						Event: "event",
-						Data:  "hello",
-					}
-				}
-			}
-		}()
-		response, err := http.Get(address + "/items/events")
-		quitTest <- true
+	t.Run("ItemsWithoutHTML", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		api.SetGetAllHTMLTemplate(template.Must(template.New("ul").Parse(`<ul>{{ range . }}{{ . }}{{ end }}</ul>`)))
+
+		err := api.Storage.Set(context.Background(), &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"})
 		require.NoError(t, err)
-		defer response.Body.Close()
 
-		require.Equal(t, http.StatusOK, response.StatusCode)
+		r := httptest.NewRequest(http.MethodGet, "/albums", http.NoBody)
+		r.Header.Set("Accept", "text/html")
+		w := babytest.TestRequest(t, api, r)
 
-		expected := `event: event
-data: hello
-`
-		body := make([]byte, len(expected))
-		n, err := response.Body.Read(body)
-		require.NoError(t, err)
-
-		require.Equal(t, expected, string(body[:n]))
+		require.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+		require.Contains(t, w.Body.String(), "GetAll HTML template requires items to implement HTMLer: *babyapi_test.Album")
 	})
 }
 
-func TestMustRenderHTML(t *testing.T) {
-	tmpl := template.Must(template.New("test").Parse("{{ .UndefinedVariable }}"))
-	require.Panics(t, func() {
-		babyapi.MustRenderHTML(tmpl, "string is bad input")
-	})
-}
+func TestContentNegotiation(t *testing.T) {
+	api := babyapi.NewAPI("Items", "/items", func() *ListItem { return &ListItem{} })
+	api.SetContentNegotiation([]string{"application/json", "text/html"})
 
-func TestAPIModifiers(t *testing.T) {
-	middleware := 0
-	idMiddlewareWithRequestResource := 0
-	idMiddleware := 0
-	beforeDelete := 0
-	afterDelete := 0
-	onCreateOrUpdate := 0
-	afterCreateOrUpdate := 0
+	item := &ListItem{DefaultResource: babyapi.NewDefaultResource(), Content: "Item1"}
+	err := api.Storage.Set(context.Background(), item)
+	require.NoError(t, err)
 
-	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
-		SetCustomResponseCode(http.MethodPut, http.StatusTeapot).
-		AddMiddleware(func(next http.Handler) http.Handler {
-			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				middleware++
-				next.ServeHTTP(w, r)
-			})
-		}).
-		SetOnCreateOrUpdate(func(r *http.Request, a *Album) *babyapi.ErrResponse {
-			onCreateOrUpdate++
-			return nil
-		}).
-		SetAfterCreateOrUpdate(func(r *http.Request, a *Album) *babyapi.ErrResponse {
-			afterCreateOrUpdate++
-			return nil
-		}).
-		SetBeforeDelete(func(r *http.Request) *babyapi.ErrResponse {
-			beforeDelete++
-			return nil
-		}).
-		SetAfterDelete(func(r *http.Request) *babyapi.ErrResponse {
-			afterDelete++
-			return nil
-		})
+	jsonBody := fmt.Sprintf(`{"id":"%s","Content":"Item1"}`, item.GetID())
+	htmlBody := "<li>Item1</li>"
 
-	api.AddIDMiddleware(api.GetRequestedResourceAndDoMiddleware(
-		func(r *http.Request, a *Album) (*http.Request, *babyapi.ErrResponse) {
-			require.NotNil(t, a)
-			idMiddlewareWithRequestResource++
-			return r, nil
-		},
-	))
+	tests := []struct {
+		name     string
+		accept   string
+		expected string
+	}{
+		{"NoAcceptHeader", "", jsonBody},
+		{"Wildcard", "*/*", jsonBody},
+		{"HTML", "text/html", htmlBody},
+		{"BrowserDefault", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", htmlBody},
+		{"SameQualityUsesOrder", "text/html, application/json", jsonBody},
+		{"HigherQualityHTML", "application/json;q=0.5, text/html", htmlBody},
+		{"TextWildcard", "text/*", htmlBody},
+		{"NotAcceptableUsesDefault", "application/json;q=0, text/html;q=0", jsonBody},
+	}
 
-	api.AddIDMiddleware(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			idMiddleware++
-			next.ServeHTTP(w, r)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/items/"+item.GetID(), http.NoBody)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := babytest.TestRequest(t, api, r)
+
+			require.Equal(t, http.StatusOK, w.Result().StatusCode)
+			require.Equal(t, tt.expected, strings.TrimSpace(w.Body.String()))
 		})
+	}
+
+	t.Run("UnsupportedContentType", func(t *testing.T) {
+		api := babyapi.NewAPI("Items", "/items", func() *ListItem { return &ListItem{} })
+		api.SetContentNegotiation([]string{"image/png"})
+
+		err := api.Route(chi.NewRouter())
+		require.Error(t, err)
+		require.ErrorAs(t, err, &babyapi.BuilderError{})
+		require.Contains(t, err.Error(), `SetContentNegotiation: unsupported content type "image/png"`)
 	})
+}
 
-	albumID := "cljcqg5o402e9s28rbp0"
-	t.Run("CreateResource", func(t *testing.T) {
-		body := bytes.NewBufferString(fmt.Sprintf(`{"Title": "NewAlbum", "ID": "%s"}`, albumID))
-		r, err := http.NewRequest(http.MethodPut, "/albums/"+albumID, body)
-		require.NoError(t, err)
-		r.Header.Add("Content-Type", "application/json")
+func TestServerSentEvents(t *testing.T) {
+	api := babyapi.NewAPI("Items", "/items", func() *ListItem { return &ListItem{} })
 
-		w := babytest.TestRequest[*Album](t, api, r)
-		require.Equal(t, http.StatusTeapot, w.Result().StatusCode)
+	api.SetGetAllResponseWrapper(func(d []*ListItem) render.Renderer {
+		return &UnorderedList{d}
 	})
 
-	t.Run("DeleteResource", func(t *testing.T) {
-		r, err := http.NewRequest(http.MethodDelete, "/albums/"+albumID, http.NoBody)
-		require.NoError(t, err)
+	events := api.AddServerSentEventHandler("/events")
 
-		w := babytest.TestRequest[*Album](t, api, r)
-		require.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+	address, closer := babytest.TestServe[*ListItem](t, api)
+	defer closer()
+
+	item1 := &ListItem{
+		DefaultResource: babyapi.NewDefaultResource(),
+		Content:         "Item1",
+	}
+	t.Run("CreateItem", func(t *testing.T) {
+		err := api.Storage.Set(context.Background(), item1)
+		require.NoError(t, err)
 	})
 
-	t.Run("GetResourceNotFound", func(t *testing.T) {
-		r, err := http.NewRequest(http.MethodGet, "/albums/DoesNotExist", http.NoBody)
+	t.Run("GetServerSentEventsEndpoint", func(t *testing.T) {
+		quitTest := make(chan bool)
+		go func() {
+			for {
+				select {
+				case <-quitTest:
+					return
+				default:
+					events <- &babyapi.ServerSentEvent{
+						Event: "event",
+						Data:  "hello",
+					}
+				}
+			}
+		}()
+		response, err := http.Get(address + "/items/events")
+		quitTest <- true
 		require.NoError(t, err)
+		defer response.Body.Close()
 
-		w := babytest.TestRequest[*Album](t, api, r)
-		require.Equal(t, http.StatusNotFound, w.Result().StatusCode)
-	})
+		require.Equal(t, http.StatusOK, response.StatusCode)
 
-	t.Run("AssertAllMiddlewaresUsed", func(t *testing.T) {
-		// All requests hit this middleware
-		require.Equal(t, 3, middleware)
-		// All ID requests hit this except for the GET 404
-		require.Equal(t, 2, idMiddleware)
-		// Only hit for DELETE because PUT doesn't use it for creating new resources and GET had 404
-		require.Equal(t, 1, idMiddlewareWithRequestResource)
-		require.Equal(t, 1, beforeDelete)
-		require.Equal(t, 1, afterDelete)
-		require.Equal(t, 1, onCreateOrUpdate)
-		require.Equal(t, 1, afterCreateOrUpdate)
+		expected := `event: event
+data: hello
+`
+		body := make([]byte, len(expected))
+		n, err := response.Body.Read(body)
+		require.NoError(t, err)
+
+		require.Equal(t, expected, string(body[:n]))
 	})
-}
 
-func TestAPIModifierErrors(t *testing.T) {
-	t.Run("OnCreateOrUpdateErrors", func(t *testing.T) {
-		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
-		albumID := "cljcqg5o402e9s28rbp0"
+	t.Run("SubscribeWithClient", func(t *testing.T) {
+		quitTest := make(chan bool)
+		go func() {
+			for {
+				select {
+				case <-quitTest:
+					return
+				default:
+					events <- &babyapi.ServerSentEvent{
+						Event: "event",
+						Data:  "hello",
+					}
+				}
+			}
+		}()
 
-		api.SetOnCreateOrUpdate(func(_ *http.Request, _ *Album) *babyapi.ErrResponse {
-			return babyapi.ErrRender(fmt.Errorf("test error"))
-		})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
 
-		body := bytes.NewBufferString(fmt.Sprintf(`{"Title": "NewAlbum", "ID": "%s"}`, albumID))
-		r, err := http.NewRequest(http.MethodPut, "/albums/"+albumID, body)
-		require.NoError(t, err)
-		r.Header.Add("Content-Type", "application/json")
+		received, errs := api.Client(address).Subscribe(ctx, "/events")
 
-		w := babytest.TestRequest[*Album](t, api, r)
-		require.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+		select {
+		case e := <-received:
+			require.Equal(t, babyapi.ServerSentEvent{Event: "event", Data: "hello"}, e)
+		case err := <-errs:
+			require.NoError(t, err)
+		case <-time.After(2 * time.Second):
+			require.Fail(t, "timed out waiting for event")
+		}
 
-		allAlbums, err := api.Storage.GetAll(context.Background(), nil)
-		require.NoError(t, err)
+		quitTest <- true
+		cancel()
 
-		require.Equal(t, 0, len(allAlbums))
+		for range received {
+		}
+		require.NoError(t, <-errs)
 	})
+}
 
-	t.Run("AfterCreateOrUpdateErrors", func(t *testing.T) {
-		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
-		albumID := "cljcqg5o402e9s28rbp0"
+func TestMaxSSEConnections(t *testing.T) {
+	api := babyapi.NewAPI("Items", "/items", func() *ListItem { return &ListItem{} })
+	api.SetMaxSSEConnections(1)
+	_ = api.AddServerSentEventHandler("/events")
 
-		api.SetAfterCreateOrUpdate(func(_ *http.Request, _ *Album) *babyapi.ErrResponse {
-			return babyapi.ErrRender(fmt.Errorf("test error"))
-		})
+	address, closer := babytest.TestServe[*ListItem](t, api)
+	defer closer()
 
-		body := bytes.NewBufferString(fmt.Sprintf(`{"Title": "NewAlbum", "ID": "%s"}`, albumID))
-		r, err := http.NewRequest(http.MethodPut, "/albums/"+albumID, body)
-		require.NoError(t, err)
-		r.Header.Add("Content-Type", "application/json")
-		w := babytest.TestRequest[*Album](t, api, r)
-		require.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+	first, err := http.Get(address + "/items/events")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, first.StatusCode)
 
-		allAlbums, err := api.Storage.GetAll(context.Background(), nil)
+	t.Run("SecondConnectionRejected", func(t *testing.T) {
+		second, err := http.Get(address + "/items/events")
 		require.NoError(t, err)
+		defer second.Body.Close()
 
-		require.Greater(t, len(allAlbums), 0)
+		require.Equal(t, http.StatusServiceUnavailable, second.StatusCode)
 	})
 
-	t.Run("BeforeDeleteErrors", func(t *testing.T) {
+	t.Run("NewConnectionAllowedAfterClose", func(t *testing.T) {
+		require.NoError(t, first.Body.Close())
 
+		require.Eventually(t, func() bool {
+			resp, err := http.Get(address + "/items/events")
+			if err != nil {
+				return false
+			}
+			defer resp.Body.Close()
+			return resp.StatusCode == http.StatusOK
+		}, 2*time.Second, 50*time.Millisecond)
 	})
+}
 
-	t.Run("BeforeDeleteErrors", func(t *testing.T) {
-		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
-		albumID := "cljcqg5o402e9s28rbp0"
+func TestSSEHeaders(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		api := babyapi.NewAPI("Items", "/items", func() *ListItem { return &ListItem{} })
+		_ = api.AddServerSentEventHandler("/events")
 
-		api.SetBeforeDelete(func(_ *http.Request) *babyapi.ErrResponse {
-			return babyapi.ErrRender(fmt.Errorf("test error"))
-		})
+		address, closer := babytest.TestServe[*ListItem](t, api)
+		defer closer()
 
-		t.Run("CreateInitialAlbum", func(t *testing.T) {
-			body := bytes.NewBufferString(fmt.Sprintf(`{"Title": "NewAlbum", "ID": "%s"}`, albumID))
-			r, err := http.NewRequest(http.MethodPut, "/albums/"+albumID, body)
-			require.NoError(t, err)
-			r.Header.Add("Content-Type", "application/json")
-			babytest.TestRequest[*Album](t, api, r)
+		resp, err := http.Get(address + "/items/events")
+		require.NoError(t, err)
+		defer resp.Body.Close()
 
-			allAlbums, err := api.Storage.GetAll(context.Background(), nil)
-			require.NoError(t, err)
+		require.Equal(t, "text/event-stream; charset=utf-8", resp.Header.Get("Content-Type"))
+		require.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+		require.Empty(t, resp.Header.Get("X-Accel-Buffering"))
+	})
 
-			require.Greater(t, len(allAlbums), 0)
+	t.Run("Custom", func(t *testing.T) {
+		api := babyapi.NewAPI("Items", "/items", func() *ListItem { return &ListItem{} })
+		api.SetSSEHeaders(map[string]string{
+			"X-Accel-Buffering": "no",
+			"Cache-Control":     "no-store",
 		})
+		_ = api.AddServerSentEventHandler("/events")
 
-		t.Run("MakeDeleteRequest", func(t *testing.T) {
-			r, err := http.NewRequest(http.MethodDelete, "/albums/"+albumID, http.NoBody)
-			require.NoError(t, err)
-
-			w := babytest.TestRequest[*Album](t, api, r)
-
-			require.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+		address, closer := babytest.TestServe[*ListItem](t, api)
+		defer closer()
 
-			allAlbums, err := api.Storage.GetAll(context.Background(), nil)
-			require.NoError(t, err)
+		resp, err := http.Get(address + "/items/events")
+		require.NoError(t, err)
+		defer resp.Body.Close()
 
-			require.Equal(t, len(allAlbums), 1)
-		})
+		require.Equal(t, "text/event-stream; charset=utf-8", resp.Header.Get("Content-Type"))
+		require.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+		require.Equal(t, "no", resp.Header.Get("X-Accel-Buffering"))
 	})
+}
 
-	t.Run("AfterDeleteErrors", func(t *testing.T) {
-		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
-		albumID := "cljcqg5o402e9s28rbp0"
+func TestServerSentEventListeners(t *testing.T) {
+	api := babyapi.NewAPI("Items", "/items", func() *ListItem { return &ListItem{} })
+	_ = api.AddServerSentEventHandler("/events")
 
-		api.SetAfterDelete(func(_ *http.Request) *babyapi.ErrResponse {
-			return babyapi.ErrRender(fmt.Errorf("test error"))
-		})
+	address, closer := babytest.TestServe[*ListItem](t, api)
+	defer closer()
 
-		t.Run("CreateInitialAlbum", func(t *testing.T) {
-			body := bytes.NewBufferString(fmt.Sprintf(`{"Title": "NewAlbum", "ID": "%s"}`, albumID))
-			r, err := http.NewRequest(http.MethodPut, "/albums/"+albumID, body)
-			require.NoError(t, err)
-			r.Header.Add("Content-Type", "application/json")
-			babytest.TestRequest[*Album](t, api, r)
+	require.Equal(t, 0, api.ServerSentEventListeners("/events"))
+	require.Equal(t, 0, api.ServerSentEventListeners("/not-found"))
 
-			allAlbums, err := api.Storage.GetAll(context.Background(), nil)
-			require.NoError(t, err)
+	resp, err := http.Get(address + "/items/events")
+	require.NoError(t, err)
 
-			require.Greater(t, len(allAlbums), 0)
-		})
+	t.Run("CountIncludesConnection", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			return api.ServerSentEventListeners("/events") == 1
+		}, 2*time.Second, 10*time.Millisecond)
+	})
 
-		t.Run("MakeDeleteRequest", func(t *testing.T) {
-			r, err := http.NewRequest(http.MethodDelete, "/albums/"+albumID, http.NoBody)
-			require.NoError(t, err)
+	t.Run("CountDecreasesAfterClose", func(t *testing.T) {
+		require.NoError(t, resp.Body.Close())
 
-			w := babytest.TestRequest[*Album](t, api, r)
+		require.Eventually(t, func() bool {
+			return api.ServerSentEventListeners("/events") == 0
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+}
 
-			require.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+func TestServerSentEventsReplay(t *testing.T) {
+	api := babyapi.NewAPI("Items", "/items", func() *ListItem { return &ListItem{} })
+	events := api.AddServerSentEventHandlerWithReplay("/events", 2)
 
-			allAlbums, err := api.Storage.GetAll(context.Background(), nil)
-			require.NoError(t, err)
-			afterCount := len(allAlbums)
+	address, closer := babytest.TestServe[*ListItem](t, api)
+	defer closer()
 
-			require.Less(t, afterCount, 1)
-		})
-	})
+	for _, data := range []string{"1", "2", "3"} {
+		events <- &babyapi.ServerSentEvent{Event: "item", Data: data}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received, _ := api.Client(address).Subscribe(ctx, "/events")
+
+	result := []string{}
+	for len(result) < 2 {
+		select {
+		case e := <-received:
+			result = append(result, e.Data)
+		case <-time.After(2 * time.Second):
+			require.Fail(t, "timed out waiting for replayed events")
+		}
+	}
+
+	require.Equal(t, []string{"2", "3"}, result)
 }
 
-func TestRootAPIWithMiddlewareAndCustomHandlers(t *testing.T) {
-	t.Run("CustomizationsForIDsCauseError", func(t *testing.T) {
-		api := babyapi.NewRootAPI("root", "/")
-		api.AddCustomIDRoute("", "", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
-		api.AddIDMiddleware(func(h http.Handler) http.Handler {
-			return nil
+func TestCreateResponseMode(t *testing.T) {
+	tests := []struct {
+		name string
+		mode babyapi.CreateResponseMode
+		body string
+	}{
+		{"FullBody", babyapi.CreateResponseFullBody, `{"id":"[0-9a-v]{20}","title":"New Album"}`},
+		{"IDOnly", babyapi.CreateResponseIDOnly, `^{"id":"[0-9a-v]{20}"}$`},
+		{"LocationOnly", babyapi.CreateResponseLocationOnly, `^$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+			api.SetCreateResponseMode(tt.mode)
+
+			r := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader(`{"title":"New Album"}`))
+			r.Header.Set("Content-Type", "application/json")
+			w := babytest.TestRequest(t, api, r)
+
+			require.Equal(t, http.StatusCreated, w.Result().StatusCode)
+			require.Regexp(t, tt.body, strings.TrimSpace(w.Body.String()))
+
+			location := w.Result().Header.Get("Location")
+			require.Regexp(t, `^/albums/[0-9a-v]{20}$`, location)
+
+			r = httptest.NewRequest(http.MethodGet, location, http.NoBody)
+			w = babytest.TestRequest(t, api, r)
+			require.Equal(t, http.StatusOK, w.Result().StatusCode)
+			require.Regexp(t, `{"id":"[0-9a-v]{20}","title":"New Album"}`, w.Body.String())
 		})
+	}
+}
 
-		_, err := runCommand(api.Command(), []string{"serve"})
-		require.Error(t, err)
-		require.ErrorAs(t, err, &babyapi.BuilderError{})
-		require.Equal(t, `error creating router: encountered 2 errors constructing API:
-- AddCustomIDRoute: ID routes cannot be used with a root API
-- AddIDMiddleware: ID middleware cannot be used with a root API
-`, err.Error())
+func TestCreateLocationHeader(t *testing.T) {
+	t.Run("NestedAPI", func(t *testing.T) {
+		artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
+		albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		artistAPI.AddNestedAPI(albumAPI)
+
+		artist := &Artist{DefaultResource: babyapi.NewDefaultResource()}
+		err := artistAPI.Storage.Set(context.Background(), artist)
+		require.NoError(t, err)
+
+		r := httptest.NewRequest(http.MethodPost, "/artists/"+artist.GetID()+"/albums", strings.NewReader(`{"title":"New Album"}`))
+		r.Header.Set("Content-Type", "application/json")
+		w := babytest.TestRequest(t, artistAPI, r)
+
+		require.Equal(t, http.StatusCreated, w.Result().StatusCode)
+		require.Regexp(t, `^/artists/`+artist.GetID()+`/albums/[0-9a-v]{20}$`, w.Result().Header.Get("Location"))
 	})
 
-	middlewareHits := 0
-	api := babyapi.NewRootAPI("root", "/").
-		Modify(func(a *babyapi.API[*babyapi.NilResource]) {
-			a.Get = func(w http.ResponseWriter, r *http.Request) {
-				w.WriteHeader(201)
-			}
-			a.Delete = func(w http.ResponseWriter, r *http.Request) {
-				w.WriteHeader(202)
-			}
-			a.Patch = func(w http.ResponseWriter, r *http.Request) {
-				w.WriteHeader(203)
-			}
-			a.Post = func(w http.ResponseWriter, r *http.Request) {
-				w.WriteHeader(204)
-			}
-			a.Put = func(w http.ResponseWriter, r *http.Request) {
-				w.WriteHeader(205)
-			}
-		}).
-		AddCustomRoute(http.MethodGet, "/customRoute", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(206)
-		})).
-		AddCustomRootRoute(http.MethodGet, "/customRootRoute", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(207)
-		})).
-		AddMiddleware(func(next http.Handler) http.Handler {
-			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				middlewareHits++
-				next.ServeHTTP(w, r)
-			})
-		})
+	t.Run("Disabled", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		api.SetCreateLocationHeader(false)
+
+		r := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader(`{"title":"New Album"}`))
+		r.Header.Set("Content-Type", "application/json")
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusCreated, w.Result().StatusCode)
+		require.Empty(t, w.Result().Header.Get("Location"))
+	})
+}
+
+func TestIDValidator(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	api.SetIDValidator(func(id string) error {
+		_, err := xid.FromString(id)
+		return err
+	})
 
 	tests := []struct {
-		method         string
-		path           string
-		expectedStatus int
+		name   string
+		method string
+		id     string
+		body   string
+		status int
 	}{
-		{http.MethodGet, "/", 201},
-		{http.MethodDelete, "/", 202},
-		{http.MethodPatch, "/", 203},
-		{http.MethodPost, "/", 204},
-		{http.MethodPut, "/", 205},
-		{http.MethodGet, "/customRoute", 206},
-		{http.MethodGet, "/customRootRoute", 207},
+		{"GetInvalidID", http.MethodGet, "not-an-id", "", http.StatusBadRequest},
+		{"DeleteInvalidID", http.MethodDelete, "not-an-id", "", http.StatusBadRequest},
+		{"PutInvalidID", http.MethodPut, "not-an-id", `{"id":"not-an-id","title":"Album"}`, http.StatusBadRequest},
+		{"GetValidIDNotFound", http.MethodGet, "cljcqg5o402e9s28rbp0", "", http.StatusNotFound},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.method+tt.path, func(t *testing.T) {
-			r := httptest.NewRequest(tt.method, tt.path, http.NoBody)
-			w := babytest.TestRequest[*babyapi.NilResource](t, api, r)
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/albums/"+tt.id, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := babytest.TestRequest(t, api, r)
 
-			require.Equal(t, tt.expectedStatus, w.Result().StatusCode)
+			require.Equal(t, tt.status, w.Result().StatusCode)
+			if tt.status == http.StatusBadRequest {
+				require.Equal(t, `{"status":"Invalid request.","error":"invalid ID: xid: invalid ID"}`, strings.TrimSpace(w.Body.String()))
+			}
 		})
 	}
+}
 
-	t.Run("MiddlewareIsHitForEachRequest", func(t *testing.T) {
-		require.Equal(t, len(tests), middlewareHits)
-	})
+// uniqueTitleStorage returns ErrConflict when saving an Album with the same title as an existing Album
+type uniqueTitleStorage struct {
+	babyapi.Storage[*Album]
 }
 
-func TestRootAPIAsChildOfResourceAPI(t *testing.T) {
-	musicVideoAPI := babyapi.NewAPI("MusicVideos", "/music_videos", func() *MusicVideo { return &MusicVideo{} })
-	songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
-	rootAPI := babyapi.
-		NewRootAPI("root", "/").
-		AddNestedAPI(musicVideoAPI).
-		AddNestedAPI(songAPI)
+func (s uniqueTitleStorage) Set(ctx context.Context, album *Album) error {
+	albums, err := s.GetAll(ctx, nil)
+	if err != nil {
+		return err
+	}
 
-	artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
-	artistAPI.AddNestedAPI(rootAPI)
+	for _, existing := range albums {
+		if existing.Title == album.Title && existing.GetID() != album.GetID() {
+			return fmt.Errorf("%w: title %q already exists", babyapi.ErrConflict, album.Title)
+		}
+	}
 
-	go func() {
-		_, err := runCommand(artistAPI.Command(), []string{"serve", "--address", "localhost:8080"})
+	return s.Storage.Set(ctx, album)
+}
+
+func TestStorageConflict(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	api.SetStorage(uniqueTitleStorage{api.Storage})
+
+	existing := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Existing"}
+	other := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Other"}
+	for _, album := range []*Album{existing, other} {
+		err := api.Storage.Set(context.Background(), album)
 		require.NoError(t, err)
-	}()
+	}
 
-	address := "http://localhost:8080"
+	expectedBody := `{"status":"Conflict.","error":"resource conflict: title \"Existing\" already exists"}`
 
-	waitForAPI(address)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"Post", http.MethodPost, "/albums", `{"title":"Existing"}`},
+		{"Put", http.MethodPut, "/albums/" + other.GetID(), fmt.Sprintf(`{"id":"%s","title":"Existing"}`, other.GetID())},
+		{"Patch", http.MethodPatch, "/albums/" + other.GetID(), `{"title":"Existing"}`},
+	}
 
-	artist1 := &Artist{Name: "Artist1"}
-	t.Run("CreateParentArtist", func(t *testing.T) {
-		result, err := artistAPI.Client(address).Post(context.Background(), artist1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := babytest.TestRequest(t, api, r)
+
+			require.Equal(t, http.StatusConflict, w.Result().StatusCode)
+			require.Equal(t, expectedBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
+func TestPutCreateResponseCode(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	api.SetCustomResponseCode(babyapi.MethodPutCreate, http.StatusCreated)
+
+	address, closer := babytest.TestServe[*Album](t, api)
+	defer closer()
+
+	client := api.Client(address)
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+
+	t.Run("Create", func(t *testing.T) {
+		resp, err := client.Put(context.Background(), album)
 		require.NoError(t, err)
-		artist1 = result.Data
+		require.Equal(t, http.StatusCreated, resp.Response.StatusCode)
 	})
 
-	t.Run("TestGetAllSongsEmpty", func(t *testing.T) {
-		out, err := runCommand(artistAPI.Command(), []string{"client", "--pretty=false", "--address", address, "Songs", "list", "--artists-id", artist1.GetID()})
+	t.Run("Update", func(t *testing.T) {
+		album.Title = "Updated"
+		resp, err := client.Put(context.Background(), album)
 		require.NoError(t, err)
-		require.Regexp(t, `{"items":\[\]}`, strings.TrimSpace(out))
+		require.Equal(t, http.StatusOK, resp.Response.StatusCode)
+		require.Equal(t, "Updated", resp.Data.Title)
 	})
+}
 
-	t.Run("CreateSong", func(t *testing.T) {
-		out, err := runCommand(artistAPI.Command(), []string{"client", "--pretty=false", "--address", address, "Songs", "post", "-d", `{"title": "new song"}`, "--artists-id", artist1.GetID()})
+func TestPutCreatedHeader(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	api.SetPutCreatedHeader(true)
+
+	address, closer := babytest.TestServe[*Album](t, api)
+	defer closer()
+
+	client := api.Client(address)
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+
+	t.Run("Create", func(t *testing.T) {
+		resp, err := client.Put(context.Background(), album)
 		require.NoError(t, err)
-		require.Regexp(t, `\{"id":"[0-9a-v]{20}","title":"new song"\}`, strings.TrimSpace(out))
+		require.Equal(t, "true", resp.Response.Header.Get(babyapi.CreatedHeader))
 	})
 
-	artistAPI.Stop()
+	t.Run("Update", func(t *testing.T) {
+		resp, err := client.Put(context.Background(), album)
+		require.NoError(t, err)
+		require.Equal(t, "false", resp.Response.Header.Get(babyapi.CreatedHeader))
+	})
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+		body := fmt.Sprintf(`{"id":"%s","title":"Album"}`, album.GetID())
+		r := httptest.NewRequest(http.MethodPut, "/albums/"+album.GetID(), strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Empty(t, w.Result().Header.Get(babyapi.CreatedHeader))
+	})
 }
 
-func TestRootAPICLI(t *testing.T) {
+func TestFilterAndOr(t *testing.T) {
+	even := babyapi.FilterFunc[int](func(i int) bool { return i%2 == 0 })
+	large := babyapi.FilterFunc[int](func(i int) bool { return i > 3 })
+	input := []int{1, 2, 3, 4, 5, 6}
+
+	require.Equal(t, []int{4, 6}, babyapi.FilterAnd(even, large).Filter(input))
+	require.Equal(t, []int{2, 4, 5, 6}, babyapi.FilterOr(even, large).Filter(input))
+	require.Equal(t, []int{2, 4, 6}, babyapi.FilterAnd(even, nil).Filter(input))
+	require.Equal(t, input, babyapi.FilterAnd[int](nil, nil).Filter(input))
+	require.Equal(t, input, babyapi.FilterOr[int]().Filter(input))
+}
+
+func TestAddGetAllFilter(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+	queryParamFilter := func(param string, match func(*Album, string) bool) func(*http.Request) babyapi.FilterFunc[*Album] {
+		return func(r *http.Request) babyapi.FilterFunc[*Album] {
+			value := r.URL.Query().Get(param)
+			if value == "" {
+				return nil
+			}
+			return func(a *Album) bool {
+				return match(a, value)
+			}
+		}
+	}
+
+	api.AddGetAllFilter("prefix", queryParamFilter("prefix", func(a *Album, v string) bool {
+		return strings.HasPrefix(a.Title, v)
+	}))
+	api.AddGetAllFilter("contains", queryParamFilter("contains", func(a *Album, v string) bool {
+		return strings.Contains(a.Title, v)
+	}))
+
+	for _, title := range []string{"Blue Train", "Blue Lines", "Kind of Blue"} {
+		err := api.Storage.Set(context.Background(), &Album{DefaultResource: babyapi.NewDefaultResource(), Title: title})
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"NoFilters", "", []string{"Blue Lines", "Blue Train", "Kind of Blue"}},
+		{"SingleFilter", "prefix=Blue", []string{"Blue Lines", "Blue Train"}},
+		{"CombinedFilters", "prefix=Blue&contains=Train", []string{"Blue Train"}},
+		{"NoMatches", "prefix=Kind&contains=Train", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/albums?"+tt.query, http.NoBody)
+			w := babytest.TestRequest(t, api, r)
+			require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+			var resp struct {
+				Items []*Album `json:"items"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+			titles := []string{}
+			for _, item := range resp.Items {
+				titles = append(titles, item.Title)
+			}
+			require.ElementsMatch(t, tt.expected, titles)
+		})
+	}
+}
+
+func TestPagination(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	api.EnablePagination(2, 3)
+
+	albums := []*Album{}
+	for i := 0; i < 5; i++ {
+		album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: fmt.Sprintf("Album %d", i)}
+		require.NoError(t, api.Storage.Set(context.Background(), album))
+		albums = append(albums, album)
+	}
+	// Pages are sorted by ID
+	slices.SortFunc(albums, func(a, b *Album) int {
+		return strings.Compare(a.GetID(), b.GetID())
+	})
+
+	address, closer := babytest.TestServe[*Album](t, api)
+	defer closer()
+
+	client := api.Client(address)
+
 	tests := []struct {
 		name           string
-		args           []string
-		expectedRegexp string
-		expectedErr    bool
+		query          string
+		expected       []*Album
+		expectedLimit  int
+		expectedOffset int
+	}{
+		{"DefaultLimit", "", albums[0:2], 2, 0},
+		{"Offset", "offset=2", albums[2:4], 2, 2},
+		{"LastPage", "offset=4", albums[4:], 2, 4},
+		{"OffsetPastEnd", "offset=10", []*Album{}, 2, 10},
+		{"CustomLimit", "limit=1&offset=1", albums[1:2], 1, 1},
+		{"MaxLimit", "limit=10", albums[0:3], 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.GetAll(context.Background(), tt.query)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, resp.Data.Items)
+			require.Equal(t, 5, resp.Data.Total)
+			require.Equal(t, tt.expectedLimit, resp.Data.Limit)
+			require.Equal(t, tt.expectedOffset, resp.Data.Offset)
+		})
+	}
+
+	t.Run("InvalidLimit", func(t *testing.T) {
+		_, err := client.GetAll(context.Background(), "limit=0")
+		require.Error(t, err)
+		require.Equal(t, "error getting all resources: unexpected response with text: Invalid request.", err.Error())
+	})
+
+	t.Run("InvalidOffset", func(t *testing.T) {
+		_, err := client.GetAll(context.Background(), "offset=-1")
+		require.Error(t, err)
+		require.Equal(t, "error getting all resources: unexpected response with text: Invalid request.", err.Error())
+	})
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		for _, album := range albums {
+			require.NoError(t, api.Storage.Set(context.Background(), album))
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/albums?limit=1", http.NoBody)
+		w := babytest.TestRequest(t, api, r)
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.NotContains(t, w.Body.String(), "total")
+
+		var resp babyapi.ResourceList[*Album]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Items, 5)
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		api.EnablePagination(0, 0)
+		api.EnablePagination(5, 2)
+
+		err := api.Route(chi.NewRouter())
+		require.Error(t, err)
+		require.ErrorAs(t, err, &babyapi.BuilderError{})
+		require.Contains(t, err.Error(), "EnablePagination: defaultLimit must be positive: 0")
+		require.Contains(t, err.Error(), "EnablePagination: maxLimit 2 is less than defaultLimit 5")
+	})
+}
+
+func TestETag(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	api.EnableETag()
+
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+	require.NoError(t, api.Storage.Set(context.Background(), album))
+
+	for _, path := range []string{"/albums", "/albums/" + album.GetID()} {
+		t.Run(path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+			w := babytest.TestRequest(t, api, r)
+			require.Equal(t, http.StatusOK, w.Result().StatusCode)
+			require.Contains(t, w.Body.String(), `"title":"Album"`)
+
+			etag := w.Result().Header.Get("ETag")
+			require.Regexp(t, `^W/"[0-9a-f]{32}"$`, etag)
+
+			t.Run("NotModified", func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+				r.Header.Set("If-None-Match", `"other", `+etag)
+				w := babytest.TestRequest(t, api, r)
+				require.Equal(t, http.StatusNotModified, w.Result().StatusCode)
+				require.Empty(t, w.Body.String())
+				require.Equal(t, etag, w.Result().Header.Get("ETag"))
+			})
+
+			t.Run("ChangedAfterUpdate", func(t *testing.T) {
+				updated := &Album{DefaultResource: album.DefaultResource, Title: "Updated"}
+				require.NoError(t, api.Storage.Set(context.Background(), updated))
+				defer func() {
+					require.NoError(t, api.Storage.Set(context.Background(), album))
+				}()
+
+				r := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+				r.Header.Set("If-None-Match", etag)
+				w := babytest.TestRequest(t, api, r)
+				require.Equal(t, http.StatusOK, w.Result().StatusCode)
+				require.Contains(t, w.Body.String(), `"title":"Updated"`)
+				require.NotEqual(t, etag, w.Result().Header.Get("ETag"))
+			})
+		})
+	}
+
+	t.Run("NoETagForErrors", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/albums/cljcqg5o402e9s28rbp0", http.NoBody)
+		w := babytest.TestRequest(t, api, r)
+		require.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+		require.Empty(t, w.Result().Header.Get("ETag"))
+	})
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+		r := httptest.NewRequest(http.MethodGet, "/albums", http.NoBody)
+		w := babytest.TestRequest(t, api, r)
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Empty(t, w.Result().Header.Get("ETag"))
+	})
+}
+
+// recordingStorage wraps a Storage and records each call so tests can verify how the API uses it
+type recordingStorage struct {
+	babyapi.Storage[*Album]
+	calls []string
+	query url.Values
+}
+
+func (s *recordingStorage) Get(ctx context.Context, id string) (*Album, error) {
+	s.calls = append(s.calls, "Get")
+	return s.Storage.Get(ctx, id)
+}
+
+func (s *recordingStorage) GetAll(ctx context.Context, query url.Values) ([]*Album, error) {
+	s.calls = append(s.calls, "GetAll")
+	s.query = query
+	return s.Storage.GetAll(ctx, query)
+}
+
+func (s *recordingStorage) Set(ctx context.Context, album *Album) error {
+	s.calls = append(s.calls, "Set")
+	return s.Storage.Set(ctx, album)
+}
+
+func (s *recordingStorage) Delete(ctx context.Context, id string) error {
+	s.calls = append(s.calls, "Delete")
+	return s.Storage.Delete(ctx, id)
+}
+
+func TestAPIUsesStorageInterface(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	storage := &recordingStorage{Storage: api.Storage}
+	api.SetStorage(storage)
+
+	address, closer := babytest.TestServe[*Album](t, api)
+	defer closer()
+
+	client := api.Client(address)
+
+	var album *Album
+	tests := []struct {
+		name          string
+		do            func(t *testing.T)
+		expectedCalls []string
 	}{
 		{
-			"GetAll",
-			[]string{"MusicVideos", "list"},
-			`\[\{"id":"cljcqg5o402e9s28rbp0","title":"New Video"\}\]`,
-			false,
-		},
-		{
-			"Post",
-			[]string{"MusicVideos", "post", "--data", `{"title": "OtherNewMusicVideo"}`},
-			`\{"id":"[0-9a-v]{20}","title":"OtherNewMusicVideo"\}`,
-			false,
-		},
-		{
-			"PostMissingArgs",
-			[]string{"MusicVideos", "post"},
-			`required flag\(s\) "data" not set`,
-			true,
-		},
-		{
-			"PostError",
-			[]string{"MusicVideos", "post", "--data", `bad request`},
-			`{"error":"invalid character 'b' looking for beginning of value","status":"Invalid request."}`,
-			false,
-		},
-		{
-			"Patch",
-			[]string{"MusicVideos", "patch", "cljcqg5o402e9s28rbp0", "--data", `{"title":"NewTitle"}`},
-			`\{"id":"cljcqg5o402e9s28rbp0","title":"NewTitle"\}`,
-			false,
-		},
-		{
-			"Put",
-			[]string{"MusicVideos", "put", "cljcqg5o402e9s28rbp0", "--data", `{"id":"cljcqg5o402e9s28rbp0","title":"NewMusicVideo"}`},
-			`\{"id":"cljcqg5o402e9s28rbp0","title":"NewMusicVideo"\}`,
-			false,
-		},
-		{
-			"PutError",
-			[]string{"MusicVideos", "put", "cljcqg5o402e9s28rbp0", "--data", `{"title":"NewMusicVideo"}`},
-			`{"error":"missing required id field","status":"Invalid request."}`,
-			false,
-		},
-		{
-			"GetByID",
-			[]string{"MusicVideos", "get", "cljcqg5o402e9s28rbp0"},
-			`\{"id":"cljcqg5o402e9s28rbp0","title":"NewMusicVideo"\}`,
-			false,
+			"Post",
+			func(t *testing.T) {
+				resp, err := client.Post(context.Background(), &Album{Title: "Album"})
+				require.NoError(t, err)
+				album = resp.Data
+			},
+			[]string{"Set"},
 		},
 		{
-			"GetByIDMissingArgs",
-			[]string{"MusicVideos", "get"},
-			`at least one argument required`,
-			true,
+			"Get",
+			func(t *testing.T) {
+				_, err := client.Get(context.Background(), album.GetID())
+				require.NoError(t, err)
+			},
+			[]string{"Get"},
 		},
 		{
-			"GetAllSongs",
-			[]string{"Songs", "list"},
-			`\[{"id":"clknc0do4023onrn3bqg","title":"NewSong"}\]`,
-			false,
+			"GetAll",
+			func(t *testing.T) {
+				_, err := client.GetAll(context.Background(), "title=Album")
+				require.NoError(t, err)
+				require.Equal(t, url.Values{"title": []string{"Album"}}, storage.query)
+			},
+			[]string{"GetAll"},
 		},
 		{
-			"GetSongByID",
-			[]string{"Songs", "get", "clknc0do4023onrn3bqg"},
-			`{"id":"clknc0do4023onrn3bqg","title":"NewSong"}`,
-			false,
+			"Put",
+			func(t *testing.T) {
+				_, err := client.Put(context.Background(), album)
+				require.NoError(t, err)
+			},
+			[]string{"Get", "Set"},
 		},
 		{
-			"PostSong",
-			[]string{"Songs", "post", "--data", `{"title": "new song"}`},
-			`\{"id":"[0-9a-v]{20}","title":"new song"\}`,
-			false,
+			"Patch",
+			func(t *testing.T) {
+				_, err := client.Patch(context.Background(), album.GetID(), &Album{Title: "New Title"})
+				require.NoError(t, err)
+			},
+			[]string{"Get", "Set"},
 		},
 		{
 			"Delete",
-			[]string{"MusicVideos", "delete", "cljcqg5o402e9s28rbp0"},
-			``,
-			false,
-		},
-		{
-			"DeleteMissingArgs",
-			[]string{"MusicVideos", "delete"},
-			`at least one argument required`,
-			true,
-		},
-		{
-			"GetByIDNotFound",
-			[]string{"MusicVideos", "get", "cljcqg5o402e9s28rbp0"},
-			`{"status":"Resource not found."}`,
-			false,
-		},
-		{
-			"DeleteNotFound",
-			[]string{"MusicVideos", "delete", "cljcqg5o402e9s28rbp0"},
-			`{"status":"Resource not found."}`,
-			false,
-		},
-		{
-			"PatchNotFound",
-			[]string{"MusicVideos", "patch", "cljcqg5o402e9s28rbp0", "--data", ""},
-			`{"status":"Resource not found."}`,
-			false,
-		},
-		{
-			"PatchMissingArgs",
-			[]string{"MusicVideos", "patch"},
-			`required flag\(s\) "data" not set`,
-			true,
-		},
-		{
-			"PutMissingArgs",
-			[]string{"MusicVideos", "put"},
-			`required flag\(s\) "data" not set`,
-			true,
+			func(t *testing.T) {
+				_, err := client.Delete(context.Background(), album.GetID())
+				require.NoError(t, err)
+			},
+			[]string{"Get", "Delete"},
 		},
 	}
 
-	basePaths := []string{
-		"/",
-		"/api",
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage.calls = nil
+			tt.do(t)
+
+			// Only check which methods are used since some handlers get the resource more than once
+			uniqueCalls := []string{}
+			for _, call := range storage.calls {
+				if !slices.Contains(uniqueCalls, call) {
+					uniqueCalls = append(uniqueCalls, call)
+				}
+			}
+			require.Equal(t, tt.expectedCalls, uniqueCalls)
+		})
 	}
+}
 
-	for _, base := range basePaths {
-		t.Run("BasePath"+base, func(t *testing.T) {
-			musicVideoAPI := babyapi.NewAPI("MusicVideos", "/music_videos", func() *MusicVideo { return &MusicVideo{} })
-			songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
-			rootAPI := babyapi.
-				NewRootAPI("root", base).
-				AddNestedAPI(musicVideoAPI).
-				AddNestedAPI(songAPI)
+type QueryFilterItem struct {
+	babyapi.DefaultResource
+	Name      string     `json:"name"`
+	Count     int        `json:"count"`
+	Ratio     float64    `json:"ratio"`
+	Enabled   bool       `json:"enabled"`
+	Optional  *string    `json:"optional"`
+	CreatedAt *time.Time `json:"created_at"`
+	Tags      []string   `json:"tags"`
+}
 
-			go func() {
-				_, err := runCommand(rootAPI.Command(), []string{"serve", "--address", "localhost:8080"})
-				require.NoError(t, err)
-			}()
-			defer rootAPI.Stop()
+type queryFilterDetails struct {
+	Color string `json:"color"`
+	notes string
+}
 
-			songAPI.SetGetAllFilter(func(r *http.Request) babyapi.FilterFunc[*Song] {
-				return func(s *Song) bool {
-					title := r.URL.Query().Get("title")
-					return title == "" || s.Title == title
-				}
-			})
+type EmbeddedUnexportedQueryFilterItem struct {
+	babyapi.DefaultResource
+	queryFilterDetails
+	Name string `json:"name"`
+}
 
-			musicVideoAPI.SetGetAllFilter(func(r *http.Request) babyapi.FilterFunc[*MusicVideo] {
-				return func(m *MusicVideo) bool {
-					title := r.URL.Query().Get("title")
-					return title == "" || m.Title == title
-				}
-			})
+func TestQueryFilter(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	optional := "value"
 
-			address := "http://localhost:8080"
+	first := &QueryFilterItem{DefaultResource: babyapi.NewDefaultResource(), Name: "first", Count: 1, Ratio: 0.5, Enabled: true, Optional: &optional, CreatedAt: &createdAt, Tags: []string{"a"}}
+	second := &QueryFilterItem{DefaultResource: babyapi.NewDefaultResource(), Name: "second", Count: 2, Ratio: 1.5}
+	items := []*QueryFilterItem{first, second}
 
-			waitForAPI(address)
+	tests := []struct {
+		name     string
+		query    string
+		expected []*QueryFilterItem
+	}{
+		{"NoQuery", "", items},
+		{"String", "name=first", []*QueryFilterItem{first}},
+		{"CaseInsensitiveKey", "Name=second", []*QueryFilterItem{second}},
+		{"Int", "count=2", []*QueryFilterItem{second}},
+		{"Float", "ratio=0.5", []*QueryFilterItem{first}},
+		{"Bool", "enabled=false", []*QueryFilterItem{second}},
+		{"Pointer", "optional=value", []*QueryFilterItem{first}},
+		{"Time", "created_at=2024-01-01T00:00:00Z", []*QueryFilterItem{first}},
+		{"ID", "id=" + second.GetID(), []*QueryFilterItem{second}},
+		{"MultipleValues", "name=first&name=second", items},
+		{"MultipleParams", "name=first&count=2", []*QueryFilterItem{}},
+		{"InvalidValue", "count=abc", []*QueryFilterItem{}},
+		{"UnknownParam", "end_dated=true", items},
+		{"EmptyValue", "count=", items},
+		{"UnsupportedType", "tags=a", items},
+	}
 
-			// Create hard-coded musicVideo so we can use the ID
-			musicVideo := &MusicVideo{DefaultResource: babyapi.NewDefaultResource(), Title: "New Video"}
-			musicVideo.DefaultResource.ID.ID, _ = xid.FromString("cljcqg5o402e9s28rbp0")
-			_, err := musicVideoAPI.Client(address).Put(context.Background(), musicVideo)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
 			require.NoError(t, err)
 
-			// Create hard-coded song so we can use the ID
-			song := &Song{DefaultResource: babyapi.NewDefaultResource(), Title: "NewSong"}
-			song.DefaultResource.ID.ID, _ = xid.FromString("clknc0do4023onrn3bqg")
-			_, err = songAPI.Client(address).Put(context.Background(), song)
+			require.Equal(t, tt.expected, babyapi.QueryFilter[*QueryFilterItem](query).Filter(items))
+		})
+	}
+
+	t.Run("UnexportedEmbeddedStruct", func(t *testing.T) {
+		red := &EmbeddedUnexportedQueryFilterItem{DefaultResource: babyapi.NewDefaultResource(), Name: "red", queryFilterDetails: queryFilterDetails{Color: "red", notes: "a"}}
+		blue := &EmbeddedUnexportedQueryFilterItem{DefaultResource: babyapi.NewDefaultResource(), Name: "blue", queryFilterDetails: queryFilterDetails{Color: "blue"}}
+		items := []*EmbeddedUnexportedQueryFilterItem{red, blue}
+
+		filter := babyapi.QueryFilter[*EmbeddedUnexportedQueryFilterItem](url.Values{"color": []string{"red"}})
+		require.Equal(t, []*EmbeddedUnexportedQueryFilterItem{red}, filter.Filter(items))
+
+		filter = babyapi.QueryFilter[*EmbeddedUnexportedQueryFilterItem](url.Values{"notes": []string{"a"}})
+		require.Equal(t, items, filter.Filter(items))
+	})
+
+	t.Run("EnableQueryFilter", func(t *testing.T) {
+		api := babyapi.NewAPI("Items", "/items", func() *QueryFilterItem { return &QueryFilterItem{} })
+		api.EnableQueryFilter()
+
+		for _, item := range items {
+			err := api.Storage.Set(context.Background(), item)
 			require.NoError(t, err)
+		}
 
-			t.Run("GetAllQueryParams", func(t *testing.T) {
-				t.Run("Successful", func(t *testing.T) {
-					out, err := runCommand(rootAPI.Command(), []string{"client", "--pretty=false", "--address", "http://localhost:8080", "--query", "title=New Video", "MusicVideos", "list"})
-					require.NoError(t, err)
-					require.Equal(t, `{"items":[{"id":"cljcqg5o402e9s28rbp0","title":"New Video"}]}`, strings.TrimSpace(out))
-				})
+		r := httptest.NewRequest(http.MethodGet, "/items?name=second", http.NoBody)
+		w := babytest.TestRequest(t, api, r)
 
-				t.Run("NoMatch", func(t *testing.T) {
-					out, err := runCommand(rootAPI.Command(), []string{"client", "--pretty=false", "--address", "http://localhost:8080", "--query", "title=badTitle", "MusicVideos", "list"})
-					require.NoError(t, err)
-					require.Equal(t, `{"items":[]}`, strings.TrimSpace(out))
-				})
-			})
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Regexp(t, `^{"items":\[{"id":"[0-9a-v]{20}","name":"second","count":2,"ratio":1.5,"enabled":false,"optional":null,"created_at":null,"tags":null}\]}`, w.Body.String())
+	})
+}
 
-			for _, tt := range tests {
-				t.Run(tt.name, func(t *testing.T) {
-					baseArgs := []string{"client", "--pretty=false", "--address", address}
-					out, err := runCommand(rootAPI.Command(), append(baseArgs, tt.args...))
-					if !tt.expectedErr {
-						require.NoError(t, err)
-						require.Regexp(t, tt.expectedRegexp, strings.TrimSpace(out))
-						if tt.expectedRegexp == "" {
-							require.Equal(t, tt.expectedRegexp, strings.TrimSpace(out))
-						}
-					} else {
-						require.Error(t, err)
-						require.Regexp(t, tt.expectedRegexp, err.Error())
-					}
-				})
+// txStorage implements Transactional by buffering writes until the transaction is committed
+type txStorage struct {
+	babyapi.Storage[*Album]
+	pending   []*Album
+	commits   int
+	rollbacks int
+}
+
+func (s *txStorage) Set(_ context.Context, album *Album) error {
+	s.pending = append(s.pending, album)
+	return nil
+}
+
+func (s *txStorage) WithTx(ctx context.Context, fn func(context.Context, babyapi.Storage[*Album]) error) error {
+	s.pending = nil
+
+	err := fn(ctx, s)
+	if err != nil {
+		s.rollbacks++
+		s.pending = nil
+		return err
+	}
+
+	for _, album := range s.pending {
+		err = s.Storage.Set(ctx, album)
+		if err != nil {
+			return err
+		}
+	}
+	s.commits++
+	s.pending = nil
+
+	return nil
+}
+
+func TestTransactionalStorage(t *testing.T) {
+	setup := func(mode babyapi.AfterCreateOrUpdateErrorMode) (*babyapi.API[*Album], *txStorage) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		storage := &txStorage{Storage: api.Storage}
+		api.SetStorage(storage)
+		api.SetAfterCreateOrUpdateErrorMode(mode)
+		api.SetAfterCreateOrUpdate(func(_ *http.Request, a *Album) *babyapi.ErrResponse {
+			if a.Title == "Bad" {
+				return babyapi.ErrRender(fmt.Errorf("test error"))
 			}
+			return nil
+		})
+		return api, storage
+	}
+
+	post := func(t *testing.T, api *babyapi.API[*Album], title string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader(fmt.Sprintf(`{"title":"%s"}`, title)))
+		r.Header.Set("Content-Type", "application/json")
+		return babytest.TestRequest(t, api, r)
+	}
+
+	t.Run("Commit", func(t *testing.T) {
+		api, storage := setup(babyapi.AfterCreateOrUpdateErrorRollback)
+
+		w := post(t, api, "Good")
+		require.Equal(t, http.StatusCreated, w.Result().StatusCode)
+		require.Equal(t, 1, storage.commits)
+
+		albums, err := storage.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.Len(t, albums, 1)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		api, storage := setup(babyapi.AfterCreateOrUpdateErrorRollback)
+
+		w := post(t, api, "Bad")
+		require.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+		require.Equal(t, 1, storage.rollbacks)
+		require.Equal(t, 0, storage.commits)
+
+		albums, err := storage.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.Empty(t, albums)
+	})
+
+	t.Run("RespondModeCommits", func(t *testing.T) {
+		api, storage := setup(babyapi.AfterCreateOrUpdateErrorRespond)
+
+		w := post(t, api, "Bad")
+		require.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+		require.Equal(t, 1, storage.commits)
+
+		albums, err := storage.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.Len(t, albums, 1)
+	})
+
+	t.Run("CustomRoute", func(t *testing.T) {
+		api, storage := setup(babyapi.AfterCreateOrUpdateErrorRespond)
+		api.AddCustomRoute(http.MethodPost, "/pair", babyapi.Handler(func(w http.ResponseWriter, r *http.Request) render.Renderer {
+			err := api.WithTx(r.Context(), func(ctx context.Context, storage babyapi.Storage[*Album]) error {
+				for _, title := range []string{"First", "Second"} {
+					err := storage.Set(ctx, &Album{DefaultResource: babyapi.NewDefaultResource(), Title: title})
+					if err != nil {
+						return err
+					}
+				}
+				return errors.New("fail after writes")
+			})
+			if err != nil {
+				return babyapi.InternalServerError(err)
+			}
+			return nil
+		}))
+
+		r := httptest.NewRequest(http.MethodPost, "/albums/pair", http.NoBody)
+		w := babytest.TestRequest(t, api, r)
+		require.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+		require.Equal(t, 1, storage.rollbacks)
+
+		albums, err := storage.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.Empty(t, albums)
+	})
+}
+
+func TestClientExists(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	api.AddMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "bad" {
+				_ = render.Render(w, r, babyapi.ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+	err := api.Storage.Set(context.Background(), album)
+	require.NoError(t, err)
+
+	address, closer := babytest.TestServe[*Album](t, api)
+	defer closer()
+
+	client := api.Client(address)
+
+	t.Run("Exists", func(t *testing.T) {
+		exists, err := client.Exists(context.Background(), album.GetID())
+		require.NoError(t, err)
+		require.True(t, exists)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		exists, err := client.Exists(context.Background(), "cljcqg5o402e9s28rbp0")
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		exists, err := client.ExistsWithEditor(context.Background(), album.GetID(), func(r *http.Request) error {
+			r.Header.Set("Authorization", "bad")
+			return nil
+		})
+		require.Error(t, err)
+		require.Equal(t, "error checking if resource exists: unexpected response with text: Forbidden", err.Error())
+		require.False(t, exists)
+	})
+}
+
+func TestClientSubscribe(t *testing.T) {
+	api := babyapi.NewAPI("Items", "/items", func() *ListItem { return &ListItem{} })
+
+	api.AddCustomRoute(http.MethodGet, "/events", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		(&babyapi.ServerSentEvent{Event: "first", Data: "hello"}).Write(w)
+		fmt.Fprint(w, ": comment\n\n")
+		fmt.Fprint(w, "event: second\ndata: line1\ndata: line2\n\n")
+	}))
+
+	address, closer := babytest.TestServe[*ListItem](t, api)
+	defer closer()
+
+	client := api.Client(address)
+
+	t.Run("Successful", func(t *testing.T) {
+		received, errs := client.Subscribe(context.Background(), "/events")
+
+		result := []babyapi.ServerSentEvent{}
+		for e := range received {
+			result = append(result, e)
+		}
+		require.NoError(t, <-errs)
+
+		require.Equal(t, []babyapi.ServerSentEvent{
+			{Event: "first", Data: "hello"},
+			{Event: "second", Data: "line1\nline2"},
+		}, result)
+	})
+
+	t.Run("ErrorNotFound", func(t *testing.T) {
+		received, errs := client.Subscribe(context.Background(), "/not-found")
+
+		err := <-errs
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error subscribing to events")
+
+		_, ok := <-received
+		require.False(t, ok)
+	})
+}
+
+func TestMustRenderHTML(t *testing.T) {
+	tmpl := template.Must(template.New("test").Parse("{{ .UndefinedVariable }}"))
+	require.Panics(t, func() {
+		babyapi.MustRenderHTML(tmpl, "string is bad input")
+	})
+}
+
+func TestAPIModifiers(t *testing.T) {
+	middleware := 0
+	idMiddlewareWithRequestResource := 0
+	idMiddleware := 0
+	beforeDelete := 0
+	afterDelete := 0
+	onCreateOrUpdate := 0
+	afterCreateOrUpdate := 0
+
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+		SetCustomResponseCode(http.MethodPut, http.StatusTeapot).
+		AddMiddleware(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				middleware++
+				next.ServeHTTP(w, r)
+			})
+		}).
+		SetOnCreateOrUpdate(func(r *http.Request, a *Album) *babyapi.ErrResponse {
+			onCreateOrUpdate++
+			return nil
+		}).
+		SetAfterCreateOrUpdate(func(r *http.Request, a *Album) *babyapi.ErrResponse {
+			afterCreateOrUpdate++
+			return nil
+		}).
+		SetBeforeDelete(func(r *http.Request) *babyapi.ErrResponse {
+			beforeDelete++
+			return nil
+		}).
+		SetAfterDelete(func(r *http.Request) *babyapi.ErrResponse {
+			afterDelete++
+			return nil
+		})
+
+	api.AddIDMiddleware(api.GetRequestedResourceAndDoMiddleware(
+		func(r *http.Request, a *Album) (*http.Request, *babyapi.ErrResponse) {
+			require.NotNil(t, a)
+			idMiddlewareWithRequestResource++
+			return r, nil
+		},
+	))
+
+	api.AddIDMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			idMiddleware++
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	albumID := "cljcqg5o402e9s28rbp0"
+	t.Run("CreateResource", func(t *testing.T) {
+		body := bytes.NewBufferString(fmt.Sprintf(`{"Title": "NewAlbum", "ID": "%s"}`, albumID))
+		r, err := http.NewRequest(http.MethodPut, "/albums/"+albumID, body)
+		require.NoError(t, err)
+		r.Header.Add("Content-Type", "application/json")
+
+		w := babytest.TestRequest[*Album](t, api, r)
+		require.Equal(t, http.StatusTeapot, w.Result().StatusCode)
+	})
+
+	t.Run("DeleteResource", func(t *testing.T) {
+		r, err := http.NewRequest(http.MethodDelete, "/albums/"+albumID, http.NoBody)
+		require.NoError(t, err)
+
+		w := babytest.TestRequest[*Album](t, api, r)
+		require.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+	})
+
+	t.Run("GetResourceNotFound", func(t *testing.T) {
+		r, err := http.NewRequest(http.MethodGet, "/albums/DoesNotExist", http.NoBody)
+		require.NoError(t, err)
+
+		w := babytest.TestRequest[*Album](t, api, r)
+		require.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+	})
+
+	t.Run("AssertAllMiddlewaresUsed", func(t *testing.T) {
+		// All requests hit this middleware
+		require.Equal(t, 3, middleware)
+		// All ID requests hit this except for the GET 404
+		require.Equal(t, 2, idMiddleware)
+		// Only hit for DELETE because PUT doesn't use it for creating new resources and GET had 404
+		require.Equal(t, 1, idMiddlewareWithRequestResource)
+		require.Equal(t, 1, beforeDelete)
+		require.Equal(t, 1, afterDelete)
+		require.Equal(t, 1, onCreateOrUpdate)
+		require.Equal(t, 1, afterCreateOrUpdate)
+	})
+}
+
+type EndDateableAlbum struct {
+	babyapi.DefaultResource
+	Title   string     `json:"title"`
+	EndDate *time.Time `json:"end_date,omitempty"`
+}
+
+func (a *EndDateableAlbum) EndDated() bool {
+	return a.EndDate != nil && !a.EndDate.After(time.Now())
+}
+
+func (a *EndDateableAlbum) SetEndDate(now time.Time) {
+	a.EndDate = &now
+}
+
+func TestAPIModifierErrors(t *testing.T) {
+	t.Run("OnCreateOrUpdateErrors", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		albumID := "cljcqg5o402e9s28rbp0"
+
+		api.SetOnCreateOrUpdate(func(_ *http.Request, _ *Album) *babyapi.ErrResponse {
+			return babyapi.ErrRender(fmt.Errorf("test error"))
+		})
+
+		body := bytes.NewBufferString(fmt.Sprintf(`{"Title": "NewAlbum", "ID": "%s"}`, albumID))
+		r, err := http.NewRequest(http.MethodPut, "/albums/"+albumID, body)
+		require.NoError(t, err)
+		r.Header.Add("Content-Type", "application/json")
+
+		w := babytest.TestRequest[*Album](t, api, r)
+		require.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+
+		allAlbums, err := api.Storage.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+
+		require.Equal(t, 0, len(allAlbums))
+	})
+
+	t.Run("AfterCreateOrUpdateErrors", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		albumID := "cljcqg5o402e9s28rbp0"
+
+		api.SetAfterCreateOrUpdate(func(_ *http.Request, _ *Album) *babyapi.ErrResponse {
+			return babyapi.ErrRender(fmt.Errorf("test error"))
+		})
+
+		body := bytes.NewBufferString(fmt.Sprintf(`{"Title": "NewAlbum", "ID": "%s"}`, albumID))
+		r, err := http.NewRequest(http.MethodPut, "/albums/"+albumID, body)
+		require.NoError(t, err)
+		r.Header.Add("Content-Type", "application/json")
+		w := babytest.TestRequest[*Album](t, api, r)
+		require.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+
+		allAlbums, err := api.Storage.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+
+		require.Greater(t, len(allAlbums), 0)
+	})
+
+	t.Run("AfterCreateOrUpdateErrorsRollback", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		api.SetAfterCreateOrUpdateErrorMode(babyapi.AfterCreateOrUpdateErrorRollback)
+
+		album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Original"}
+		err := api.Storage.Set(context.Background(), album)
+		require.NoError(t, err)
+
+		api.SetAfterCreateOrUpdate(func(_ *http.Request, _ *Album) *babyapi.ErrResponse {
+			return babyapi.ErrRender(fmt.Errorf("test error"))
+		})
+
+		newAlbumID := "cljcqg5o402e9s28rbp0"
+		tests := []struct {
+			name   string
+			method string
+			path   string
+			body   string
+		}{
+			{"Post", http.MethodPost, "/albums", `{"title":"New"}`},
+			{"PutCreate", http.MethodPut, "/albums/" + newAlbumID, fmt.Sprintf(`{"id":"%s","title":"New"}`, newAlbumID)},
+			{"PutUpdate", http.MethodPut, "/albums/" + album.GetID(), fmt.Sprintf(`{"id":"%s","title":"New"}`, album.GetID())},
+			{"Patch", http.MethodPatch, "/albums/" + album.GetID(), `{"title":"New"}`},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+				r.Header.Add("Content-Type", "application/json")
+				w := babytest.TestRequest[*Album](t, api, r)
+				require.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+
+				allAlbums, err := api.Storage.GetAll(context.Background(), nil)
+				require.NoError(t, err)
+				require.Len(t, allAlbums, 1)
+				require.Equal(t, "Original", allAlbums[0].Title)
+			})
+		}
+	})
+
+	t.Run("AfterCreateOrUpdateErrorsRollbackEndDateable", func(t *testing.T) {
+		db := kv.NewDefaultDB()
+		api := babyapi.NewAPI("Albums", "/albums", func() *EndDateableAlbum { return &EndDateableAlbum{} })
+		api.SetStorage(babyapi.NewKVStorage[*EndDateableAlbum](db, "Albums"))
+		api.SetAfterCreateOrUpdateErrorMode(babyapi.AfterCreateOrUpdateErrorRollback)
+		api.SetAfterCreateOrUpdate(func(_ *http.Request, _ *EndDateableAlbum) *babyapi.ErrResponse {
+			return babyapi.ErrRender(fmt.Errorf("test error"))
+		})
+
+		r := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader(`{"title":"New"}`))
+		r.Header.Add("Content-Type", "application/json")
+		w := babytest.TestRequest[*EndDateableAlbum](t, api, r)
+		require.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+
+		allAlbums, err := api.Storage.GetAll(context.Background(), babyapi.EndDatedQueryParam(true))
+		require.NoError(t, err)
+		require.Empty(t, allAlbums)
+
+		keys, err := db.Keys()
+		require.NoError(t, err)
+		require.Empty(t, keys)
+	})
+
+	t.Run("AfterCreateOrUpdateErrorsPartialSuccess", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		api.SetAfterCreateOrUpdateErrorMode(babyapi.AfterCreateOrUpdateErrorPartialSuccess)
+
+		api.SetAfterCreateOrUpdate(func(_ *http.Request, _ *Album) *babyapi.ErrResponse {
+			return babyapi.ErrRender(fmt.Errorf("test error"))
+		})
+
+		r := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader(`{"title":"New"}`))
+		r.Header.Add("Content-Type", "application/json")
+		w := babytest.TestRequest[*Album](t, api, r)
+		require.Equal(t, http.StatusCreated, w.Result().StatusCode)
+		require.Equal(t, `199 - "test error"`, w.Result().Header.Get("Warning"))
+		require.Regexp(t, `{"id":"[0-9a-v]{20}","title":"New"}`, w.Body.String())
+
+		allAlbums, err := api.Storage.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.Len(t, allAlbums, 1)
+	})
+
+	t.Run("BeforeDeleteErrors", func(t *testing.T) {
+
+	})
+
+	t.Run("BeforeDeleteErrors", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		albumID := "cljcqg5o402e9s28rbp0"
+
+		api.SetBeforeDelete(func(_ *http.Request) *babyapi.ErrResponse {
+			return babyapi.ErrRender(fmt.Errorf("test error"))
+		})
+
+		t.Run("CreateInitialAlbum", func(t *testing.T) {
+			body := bytes.NewBufferString(fmt.Sprintf(`{"Title": "NewAlbum", "ID": "%s"}`, albumID))
+			r, err := http.NewRequest(http.MethodPut, "/albums/"+albumID, body)
+			require.NoError(t, err)
+			r.Header.Add("Content-Type", "application/json")
+			babytest.TestRequest[*Album](t, api, r)
+
+			allAlbums, err := api.Storage.GetAll(context.Background(), nil)
+			require.NoError(t, err)
+
+			require.Greater(t, len(allAlbums), 0)
+		})
+
+		t.Run("MakeDeleteRequest", func(t *testing.T) {
+			r, err := http.NewRequest(http.MethodDelete, "/albums/"+albumID, http.NoBody)
+			require.NoError(t, err)
+
+			w := babytest.TestRequest[*Album](t, api, r)
+
+			require.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+
+			allAlbums, err := api.Storage.GetAll(context.Background(), nil)
+			require.NoError(t, err)
+
+			require.Equal(t, len(allAlbums), 1)
+		})
+	})
+
+	t.Run("AfterDeleteErrors", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		albumID := "cljcqg5o402e9s28rbp0"
+
+		api.SetAfterDelete(func(_ *http.Request) *babyapi.ErrResponse {
+			return babyapi.ErrRender(fmt.Errorf("test error"))
+		})
+
+		t.Run("CreateInitialAlbum", func(t *testing.T) {
+			body := bytes.NewBufferString(fmt.Sprintf(`{"Title": "NewAlbum", "ID": "%s"}`, albumID))
+			r, err := http.NewRequest(http.MethodPut, "/albums/"+albumID, body)
+			require.NoError(t, err)
+			r.Header.Add("Content-Type", "application/json")
+			babytest.TestRequest[*Album](t, api, r)
+
+			allAlbums, err := api.Storage.GetAll(context.Background(), nil)
+			require.NoError(t, err)
+
+			require.Greater(t, len(allAlbums), 0)
+		})
+
+		t.Run("MakeDeleteRequest", func(t *testing.T) {
+			r, err := http.NewRequest(http.MethodDelete, "/albums/"+albumID, http.NoBody)
+			require.NoError(t, err)
+
+			w := babytest.TestRequest[*Album](t, api, r)
+
+			require.Equal(t, http.StatusUnprocessableEntity, w.Result().StatusCode)
+
+			allAlbums, err := api.Storage.GetAll(context.Background(), nil)
+			require.NoError(t, err)
+			afterCount := len(allAlbums)
+
+			require.Less(t, afterCount, 1)
+		})
+	})
+}
+
+func TestRootAPIWithMiddlewareAndCustomHandlers(t *testing.T) {
+	t.Run("CustomizationsForIDsCauseError", func(t *testing.T) {
+		api := babyapi.NewRootAPI("root", "/")
+		api.AddCustomIDRoute("", "", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		api.AddIDMiddleware(func(h http.Handler) http.Handler {
+			return nil
+		})
+
+		_, err := runCommand(api.Command(), []string{"serve"})
+		require.Error(t, err)
+		require.ErrorAs(t, err, &babyapi.BuilderError{})
+		require.Equal(t, `error creating router: encountered 2 errors constructing API:
+- AddCustomIDRoute: ID routes cannot be used with a root API
+- AddIDMiddleware: ID middleware cannot be used with a root API
+`, err.Error())
+	})
+
+	middlewareHits := 0
+	api := babyapi.NewRootAPI("root", "/").
+		Modify(func(a *babyapi.API[*babyapi.NilResource]) {
+			a.Get = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(201)
+			}
+			a.Delete = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(202)
+			}
+			a.Patch = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(203)
+			}
+			a.Post = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(204)
+			}
+			a.Put = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(205)
+			}
+		}).
+		AddCustomRoute(http.MethodGet, "/customRoute", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(206)
+		})).
+		AddCustomRootRoute(http.MethodGet, "/customRootRoute", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(207)
+		})).
+		AddMiddleware(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				middlewareHits++
+				next.ServeHTTP(w, r)
+			})
+		})
+
+	tests := []struct {
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{http.MethodGet, "/", 201},
+		{http.MethodDelete, "/", 202},
+		{http.MethodPatch, "/", 203},
+		{http.MethodPost, "/", 204},
+		{http.MethodPut, "/", 205},
+		{http.MethodGet, "/customRoute", 206},
+		{http.MethodGet, "/customRootRoute", 207},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+tt.path, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, http.NoBody)
+			w := babytest.TestRequest[*babyapi.NilResource](t, api, r)
+
+			require.Equal(t, tt.expectedStatus, w.Result().StatusCode)
+		})
+	}
+
+	t.Run("MiddlewareIsHitForEachRequest", func(t *testing.T) {
+		require.Equal(t, len(tests), middlewareHits)
+	})
+}
+
+func TestRootAPIAsChildOfResourceAPI(t *testing.T) {
+	musicVideoAPI := babyapi.NewAPI("MusicVideos", "/music_videos", func() *MusicVideo { return &MusicVideo{} })
+	songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
+	rootAPI := babyapi.
+		NewRootAPI("root", "/").
+		AddNestedAPI(musicVideoAPI).
+		AddNestedAPI(songAPI)
+
+	artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
+	artistAPI.AddNestedAPI(rootAPI)
+
+	go func() {
+		_, err := runCommand(artistAPI.Command(), []string{"serve", "--address", "localhost:8080"})
+		require.NoError(t, err)
+	}()
+
+	address := "http://localhost:8080"
+
+	waitForAPI(address)
+
+	artist1 := &Artist{Name: "Artist1"}
+	t.Run("CreateParentArtist", func(t *testing.T) {
+		result, err := artistAPI.Client(address).Post(context.Background(), artist1)
+		require.NoError(t, err)
+		artist1 = result.Data
+	})
+
+	t.Run("TestGetAllSongsEmpty", func(t *testing.T) {
+		out, err := runCommand(artistAPI.Command(), []string{"client", "--pretty=false", "--address", address, "Songs", "list", "--artists-id", artist1.GetID()})
+		require.NoError(t, err)
+		require.Regexp(t, `{"items":\[\]}`, strings.TrimSpace(out))
+	})
+
+	t.Run("CreateSong", func(t *testing.T) {
+		out, err := runCommand(artistAPI.Command(), []string{"client", "--pretty=false", "--address", address, "Songs", "post", "-d", `{"title": "new song"}`, "--artists-id", artist1.GetID()})
+		require.NoError(t, err)
+		require.Regexp(t, `\{"id":"[0-9a-v]{20}","title":"new song"\}`, strings.TrimSpace(out))
+	})
+
+	artistAPI.Stop()
+}
+
+func TestGlobalSearch(t *testing.T) {
+	api := babyapi.NewRootAPI("root", "/").EnableGlobalSearch()
+
+	songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
+	songAPI.SetGlobalSearchFilter(func(r *http.Request) babyapi.FilterFunc[*Song] {
+		return func(s *Song) bool {
+			return strings.Contains(s.Title, r.URL.Query().Get(babyapi.GlobalSearchQueryParam))
+		}
+	})
+
+	musicVideoAPI := babyapi.NewAPI("MusicVideos", "/music_videos", func() *MusicVideo { return &MusicVideo{} })
+	musicVideoAPI.SetGlobalSearchFilter(func(r *http.Request) babyapi.FilterFunc[*MusicVideo] {
+		return func(mv *MusicVideo) bool {
+			return strings.Contains(mv.Title, r.URL.Query().Get(babyapi.GlobalSearchQueryParam))
+		}
+	})
+	musicVideoAPI.AddMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "secret" {
+				_ = render.Render(w, r, babyapi.ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	// Albums do not have a search filter so they are not included
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+	api.AddNestedAPI(songAPI).AddNestedAPI(musicVideoAPI).AddNestedAPI(albumAPI)
+
+	song := &Song{DefaultResource: babyapi.NewDefaultResource(), Title: "Hello World"}
+	require.NoError(t, songAPI.Storage.Set(context.Background(), song))
+	require.NoError(t, songAPI.Storage.Set(context.Background(), &Song{DefaultResource: babyapi.NewDefaultResource(), Title: "Other"}))
+	musicVideo := &MusicVideo{DefaultResource: babyapi.NewDefaultResource(), Title: "Hello Video"}
+	require.NoError(t, musicVideoAPI.Storage.Set(context.Background(), musicVideo))
+	require.NoError(t, albumAPI.Storage.Set(context.Background(), &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Hello Album"}))
+
+	t.Run("SuccessfulWithAuthorization", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/search?q=Hello", http.NoBody)
+		r.Header.Set("Authorization", "secret")
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Equal(t,
+			fmt.Sprintf(`{"results":{"MusicVideos":[{"id":"%s","title":"Hello Video"}],"Songs":[{"id":"%s","title":"Hello World"}]}}`, musicVideo.GetID(), song.GetID()),
+			strings.TrimSpace(w.Body.String()),
+		)
+	})
+
+	t.Run("UnauthorizedChildIsExcluded", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/search?q=Hello", http.NoBody)
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Equal(t,
+			fmt.Sprintf(`{"results":{"Songs":[{"id":"%s","title":"Hello World"}]}}`, song.GetID()),
+			strings.TrimSpace(w.Body.String()),
+		)
+	})
+
+	t.Run("ErrorOnNonRootAPI", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).EnableGlobalSearch()
+		_, err := api.Router()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "EnableGlobalSearch: global search can only be used with a root API")
+	})
+}
+
+type SchemaAlbum struct {
+	babyapi.DefaultResource
+	Title string `json:"title" jsonschema:"required,description=Title of the album"`
+	Year  int    `json:"year,omitempty"`
+}
+
+func TestSchemaEndpoint(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *SchemaAlbum { return &SchemaAlbum{} }).
+		EnableSchemaEndpoint()
+
+	r := httptest.NewRequest(http.MethodGet, "/albums/schema", http.NoBody)
+	w := babytest.TestRequest(t, api, r)
+
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	require.Equal(t,
+		`{"$schema":"https://json-schema.org/draft/2020-12/schema","properties":{"id":{"type":"string"},"title":{"type":"string","description":"Title of the album"},"year":{"type":"integer"}},"additionalProperties":false,"type":"object","required":["title"]}`,
+		strings.TrimSpace(w.Body.String()),
+	)
+}
+
+type FormAlbum struct {
+	babyapi.DefaultResource
+	Title    string `json:"title"`
+	Year     int    `json:"year"`
+	Explicit *bool  `json:"explicit"`
+	Ignored  string `json:"-"`
+	Tracks   []string
+}
+
+type FormBinderAlbum struct {
+	babyapi.DefaultResource
+	Title string `json:"title"`
+}
+
+func (a *FormBinderAlbum) BindForm(form url.Values) error {
+	a.Title = strings.ToUpper(form.Get("name"))
+	return nil
+}
+
+type formAlbumDetails struct {
+	Label string `json:"label"`
+	notes string
+}
+
+type EmbeddedUnexportedFormAlbum struct {
+	babyapi.DefaultResource
+	formAlbumDetails
+	Title string `json:"title"`
+}
+
+func TestFormBody(t *testing.T) {
+	t.Run("MapFormFieldsToResource", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *FormAlbum { return &FormAlbum{} })
+
+		form := url.Values{
+			"title":    []string{"Form Album"},
+			"Year":     []string{"2024"},
+			"explicit": []string{"on"},
+			"Ignored":  []string{"value"},
+			"Tracks":   []string{"one", "two"},
+		}
+		r := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusCreated, w.Result().StatusCode)
+		require.Regexp(t, `{"id":"[0-9a-v]{20}","title":"Form Album","year":2024,"explicit":true,"Tracks":\["one","two"\]}`, w.Body.String())
+	})
+
+	t.Run("InvalidFormValue", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *FormAlbum { return &FormAlbum{} })
+
+		r := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader("year=abc"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		require.Contains(t, w.Body.String(), `invalid value for form field \"year\"`)
+	})
+
+	t.Run("FormBinder", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *FormBinderAlbum { return &FormBinderAlbum{} })
+
+		r := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader("name=binder"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusCreated, w.Result().StatusCode)
+		require.Regexp(t, `{"id":"[0-9a-v]{20}","title":"BINDER"}`, w.Body.String())
+	})
+
+	t.Run("UnexportedEmbeddedStruct", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *EmbeddedUnexportedFormAlbum { return &EmbeddedUnexportedFormAlbum{} })
+
+		r := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader("title=Album&label=Records&notes=ignored"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusCreated, w.Result().StatusCode)
+		require.Regexp(t, `{"id":"[0-9a-v]{20}","label":"Records","title":"Album"}`, w.Body.String())
+	})
+}
+
+func TestRootAPICLI(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		expectedRegexp string
+		expectedErr    bool
+	}{
+		{
+			"GetAll",
+			[]string{"MusicVideos", "list"},
+			`\[\{"id":"cljcqg5o402e9s28rbp0","title":"New Video"\}\]`,
+			false,
+		},
+		{
+			"Post",
+			[]string{"MusicVideos", "post", "--data", `{"title": "OtherNewMusicVideo"}`},
+			`\{"id":"[0-9a-v]{20}","title":"OtherNewMusicVideo"\}`,
+			false,
+		},
+		{
+			"PostMissingArgs",
+			[]string{"MusicVideos", "post"},
+			`required flag\(s\) "data" not set`,
+			true,
+		},
+		{
+			"PostError",
+			[]string{"MusicVideos", "post", "--data", `bad request`},
+			`{"error":"invalid character 'b' looking for beginning of value","status":"Invalid request."}`,
+			false,
+		},
+		{
+			"Patch",
+			[]string{"MusicVideos", "patch", "cljcqg5o402e9s28rbp0", "--data", `{"title":"NewTitle"}`},
+			`\{"id":"cljcqg5o402e9s28rbp0","title":"NewTitle"\}`,
+			false,
+		},
+		{
+			"Put",
+			[]string{"MusicVideos", "put", "cljcqg5o402e9s28rbp0", "--data", `{"id":"cljcqg5o402e9s28rbp0","title":"NewMusicVideo"}`},
+			`\{"id":"cljcqg5o402e9s28rbp0","title":"NewMusicVideo"\}`,
+			false,
+		},
+		{
+			"PutError",
+			[]string{"MusicVideos", "put", "cljcqg5o402e9s28rbp0", "--data", `{"title":"NewMusicVideo"}`},
+			`{"error":"missing required id field","status":"Invalid request."}`,
+			false,
+		},
+		{
+			"GetByID",
+			[]string{"MusicVideos", "get", "cljcqg5o402e9s28rbp0"},
+			`\{"id":"cljcqg5o402e9s28rbp0","title":"NewMusicVideo"\}`,
+			false,
+		},
+		{
+			"GetByIDMissingArgs",
+			[]string{"MusicVideos", "get"},
+			`at least one argument required`,
+			true,
+		},
+		{
+			"GetAllSongs",
+			[]string{"Songs", "list"},
+			`\[{"id":"clknc0do4023onrn3bqg","title":"NewSong"}\]`,
+			false,
+		},
+		{
+			"GetSongByID",
+			[]string{"Songs", "get", "clknc0do4023onrn3bqg"},
+			`{"id":"clknc0do4023onrn3bqg","title":"NewSong"}`,
+			false,
+		},
+		{
+			"PostSong",
+			[]string{"Songs", "post", "--data", `{"title": "new song"}`},
+			`\{"id":"[0-9a-v]{20}","title":"new song"\}`,
+			false,
+		},
+		{
+			"Delete",
+			[]string{"MusicVideos", "delete", "cljcqg5o402e9s28rbp0"},
+			``,
+			false,
+		},
+		{
+			"DeleteMissingArgs",
+			[]string{"MusicVideos", "delete"},
+			`at least one argument required`,
+			true,
+		},
+		{
+			"GetByIDNotFound",
+			[]string{"MusicVideos", "get", "cljcqg5o402e9s28rbp0"},
+			`{"status":"Resource not found."}`,
+			false,
+		},
+		{
+			"DeleteNotFound",
+			[]string{"MusicVideos", "delete", "cljcqg5o402e9s28rbp0"},
+			`{"status":"Resource not found."}`,
+			false,
+		},
+		{
+			"PatchNotFound",
+			[]string{"MusicVideos", "patch", "cljcqg5o402e9s28rbp0", "--data", ""},
+			`{"status":"Resource not found."}`,
+			false,
+		},
+		{
+			"PatchMissingArgs",
+			[]string{"MusicVideos", "patch"},
+			`required flag\(s\) "data" not set`,
+			true,
+		},
+		{
+			"PutMissingArgs",
+			[]string{"MusicVideos", "put"},
+			`required flag\(s\) "data" not set`,
+			true,
+		},
+	}
+
+	basePaths := []string{
+		"/",
+		"/api",
+	}
+
+	for _, base := range basePaths {
+		t.Run("BasePath"+base, func(t *testing.T) {
+			musicVideoAPI := babyapi.NewAPI("MusicVideos", "/music_videos", func() *MusicVideo { return &MusicVideo{} })
+			songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
+			rootAPI := babyapi.
+				NewRootAPI("root", base).
+				AddNestedAPI(musicVideoAPI).
+				AddNestedAPI(songAPI)
+
+			go func() {
+				_, err := runCommand(rootAPI.Command(), []string{"serve", "--address", "localhost:8080"})
+				require.NoError(t, err)
+			}()
+			defer rootAPI.Stop()
+
+			songAPI.SetGetAllFilter(func(r *http.Request) babyapi.FilterFunc[*Song] {
+				return func(s *Song) bool {
+					title := r.URL.Query().Get("title")
+					return title == "" || s.Title == title
+				}
+			})
+
+			musicVideoAPI.SetGetAllFilter(func(r *http.Request) babyapi.FilterFunc[*MusicVideo] {
+				return func(m *MusicVideo) bool {
+					title := r.URL.Query().Get("title")
+					return title == "" || m.Title == title
+				}
+			})
+
+			address := "http://localhost:8080"
+
+			waitForAPI(address)
+
+			// Create hard-coded musicVideo so we can use the ID
+			musicVideo := &MusicVideo{DefaultResource: babyapi.NewDefaultResource(), Title: "New Video"}
+			musicVideo.DefaultResource.ID.ID, _ = xid.FromString("cljcqg5o402e9s28rbp0")
+			_, err := musicVideoAPI.Client(address).Put(context.Background(), musicVideo)
+			require.NoError(t, err)
+
+			// Create hard-coded song so we can use the ID
+			song := &Song{DefaultResource: babyapi.NewDefaultResource(), Title: "NewSong"}
+			song.DefaultResource.ID.ID, _ = xid.FromString("clknc0do4023onrn3bqg")
+			_, err = songAPI.Client(address).Put(context.Background(), song)
+			require.NoError(t, err)
+
+			t.Run("GetAllQueryParams", func(t *testing.T) {
+				t.Run("Successful", func(t *testing.T) {
+					out, err := runCommand(rootAPI.Command(), []string{"client", "--pretty=false", "--address", "http://localhost:8080", "--query", "title=New Video", "MusicVideos", "list"})
+					require.NoError(t, err)
+					require.Equal(t, `{"items":[{"id":"cljcqg5o402e9s28rbp0","title":"New Video"}]}`, strings.TrimSpace(out))
+				})
+
+				t.Run("NoMatch", func(t *testing.T) {
+					out, err := runCommand(rootAPI.Command(), []string{"client", "--pretty=false", "--address", "http://localhost:8080", "--query", "title=badTitle", "MusicVideos", "list"})
+					require.NoError(t, err)
+					require.Equal(t, `{"items":[]}`, strings.TrimSpace(out))
+				})
+			})
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					baseArgs := []string{"client", "--pretty=false", "--address", address}
+					out, err := runCommand(rootAPI.Command(), append(baseArgs, tt.args...))
+					if !tt.expectedErr {
+						require.NoError(t, err)
+						require.Regexp(t, tt.expectedRegexp, strings.TrimSpace(out))
+						if tt.expectedRegexp == "" {
+							require.Equal(t, tt.expectedRegexp, strings.TrimSpace(out))
+						}
+					} else {
+						require.Error(t, err)
+						require.Regexp(t, tt.expectedRegexp, err.Error())
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestReadOnlyPanicAfterStart(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+	_, err := api.Router()
+	require.NoError(t, err)
+
+	require.PanicsWithError(t, "API cannot be modified after starting", func() {
+		api.SetOnCreateOrUpdate(func(_ *http.Request, _ *Album) *babyapi.ErrResponse {
+			return nil
+		})
+	})
+}
+
+func TestAddRouteWorksWithMultipleMethodSamePath(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+	api.AddCustomRoute(http.MethodGet, "/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	api.AddCustomRoute(http.MethodPost, "/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	babytest.RunTableTest(t, api, []babytest.TestCase[*babyapi.AnyResource]{
+		{
+			Name: "GetSuccess",
+			Test: babytest.RequestFuncTest[*babyapi.AnyResource](func(getResponse babytest.PreviousResponseGetter, address string) *http.Request {
+				req, err := http.NewRequest(http.MethodGet, address+"/albums/test", http.NoBody)
+				require.NoError(t, err)
+				return req
+			}),
+			ExpectedResponse: babytest.ExpectedResponse{
+				Status: http.StatusOK,
+			},
+		},
+		{
+			Name: "PostSuccess",
+			Test: babytest.RequestFuncTest[*babyapi.AnyResource](func(getResponse babytest.PreviousResponseGetter, address string) *http.Request {
+				req, err := http.NewRequest(http.MethodPost, address+"/albums/test", http.NoBody)
+				require.NoError(t, err)
+				return req
+			}),
+
+			ExpectedResponse: babytest.ExpectedResponse{
+				Status: http.StatusOK,
+			},
+		},
+	})
+}
+
+func TestRunTableTestWithAddress(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+	address, stop := babytest.TestServe[*Album](t, api)
+	defer stop()
+
+	babytest.RunTableTestWithAddress(t, api, address, []babytest.TestCase[*babyapi.AnyResource]{
+		{
+			Name: "CreateAlbum",
+			Test: babytest.RequestTest[*babyapi.AnyResource]{
+				Method: http.MethodPost,
+				Body:   `{"title": "Album"}`,
+			},
+			ExpectedResponse: babytest.ExpectedResponse{
+				Status:    http.StatusCreated,
+				JSONMatch: map[string]any{"title": "Album"},
+			},
+		},
+		{
+			Name: "GetAlbum",
+			Test: babytest.RequestTest[*babyapi.AnyResource]{
+				Method: http.MethodGet,
+				IDFunc: func(getResponse babytest.PreviousResponseGetter) string {
+					return getResponse("CreateAlbum").Data.GetID()
+				},
+			},
+			ExpectedResponse: babytest.ExpectedResponse{
+				Status:    http.StatusOK,
+				JSONMatch: map[string]any{"title": "Album"},
+			},
+		},
+	})
+
+	t.Run("ResourceExistsOnServer", func(t *testing.T) {
+		albums, err := api.Client(address).GetAll(context.Background(), "")
+		require.NoError(t, err)
+		require.Len(t, albums.Data.Items, 1)
+	})
+}
+
+func TestWithContextShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).WithContext(ctx)
+
+	go func() {
+		err := api.Serve(":8080")
+		require.NoError(t, err)
+	}()
+
+	// Stop the API via context after 1s
+	time.AfterFunc(1*time.Second, cancel)
+
+	timeout := time.After(2 * time.Second)
+	select {
+	case <-api.Done():
+		require.True(t, true)
+	case <-timeout:
+		require.Fail(t, "API failed to stop after context is cancelled")
+	}
+}
+
+type AllAlbumsWrapper []*Album
+
+func (AllAlbumsWrapper) Render(http.ResponseWriter, *http.Request) error {
+	return nil
+}
+
+func TestGetAllResponseWrapperWithClient(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	api.SetGetAllResponseWrapper(func(a []*Album) render.Renderer {
+		return AllAlbumsWrapper(a)
+	})
+
+	client, stop := babytest.NewTestClient(t, api)
+	defer stop()
+
+	t.Run("CreateAlbum", func(t *testing.T) {
+		_, err := client.Post(context.Background(), &Album{Title: "Album"})
+		require.NoError(t, err)
+	})
+
+	t.Run("RegularGetAllRequestErrors", func(t *testing.T) {
+		_, err := client.GetAll(context.Background(), "")
+		require.Error(t, err)
+	})
+
+	t.Run("MakeRequestCanBeUsed", func(t *testing.T) {
+		req, err := client.GetAllRequest(context.Background(), "")
+		require.NoError(t, err)
+
+		resp, err := babyapi.MakeRequest[AllAlbumsWrapper](req, http.DefaultClient, http.StatusOK, nil)
+		require.NoError(t, err)
+		require.Equal(t, "Album", resp.Data[0].Title)
+	})
+
+	t.Run("MakeGenericRequestCanBeUsed", func(t *testing.T) {
+		req, err := client.GetAllRequest(context.Background(), "")
+		require.NoError(t, err)
+
+		var albums AllAlbumsWrapper
+		resp, err := client.MakeGenericRequest(req, &albums)
+		require.NoError(t, err)
+		require.Equal(t, "Album", albums[0].Title)
+		require.NotNil(t, resp.Data)
+		require.Equal(t, http.StatusOK, resp.Response.StatusCode)
+	})
+
+	t.Run("GetAllCLI", func(t *testing.T) {
+		out, err := runCommand(api.Command(), []string{"client", "--pretty=false", "--address", client.Address, "Albums", "list"})
+		require.NoError(t, err)
+		require.Regexp(t, `[{"id":"[0-9a-v]{20}","title":"Album"}]`, strings.TrimSpace(out))
+	})
+}
+
+func TestClient(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	api.SetGetAllResponseWrapper(func(a []*Album) render.Renderer {
+		return AllAlbumsWrapper(a)
+	})
+
+	client, stop := babytest.NewTestClient(t, api)
+	defer stop()
+
+	t.Run("CustomResponseCodeSuccess", func(t *testing.T) {
+		client.SetCustomResponseCode(babyapi.MethodGetAll, http.StatusCreated)
+		resp, err := client.GetAllAny(context.Background(), "")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.Response.StatusCode)
+	})
+
+	t.Run("SetHTTPClient", func(t *testing.T) {
+		client.SetHTTPClient(http.DefaultClient)
+
+		_, err := client.GetAllAny(context.Background(), "")
+		require.NoError(t, err)
+	})
+}
+
+// Test that a root API can have children with their own middleware operating independently
+func TestChildAPIWithMiddleware(t *testing.T) {
+	api := babyapi.NewRootAPI("root", "/")
+
+	api.Get = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}
+
+	api.AddCustomRoute(http.MethodGet, "/teapot", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	rootMiddlewareHits := 0
+	api.AddMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rootMiddlewareHits++
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
+	api.AddNestedAPI(songAPI)
+
+	songMiddlewareHits := 0
+	songAPI.AddMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			songMiddlewareHits++
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	musicVideoAPI := babyapi.NewAPI("MusicVideos", "/music_videos", func() *MusicVideo { return &MusicVideo{} })
+	api.AddNestedAPI(musicVideoAPI)
+	musicVideoMiddlewareHits := 0
+	musicVideoAPI.AddMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			musicVideoMiddlewareHits++
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	t.Run("GetRootAPI", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Equal(t, 1, rootMiddlewareHits)
+	})
+
+	t.Run("GetRootAPICustomRoute", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/teapot", http.NoBody)
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusTeapot, w.Result().StatusCode)
+		require.Equal(t, 2, rootMiddlewareHits)
+	})
+
+	t.Run("GetSongAPI", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/songs", http.NoBody)
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+		require.Equal(t, 3, rootMiddlewareHits)
+		require.Equal(t, 1, songMiddlewareHits)
+		require.Equal(t, 0, musicVideoMiddlewareHits)
+	})
+
+	t.Run("GetMusicVideoAPI", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/music_videos", http.NoBody)
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+		require.Equal(t, 4, rootMiddlewareHits)
+		require.Equal(t, 1, songMiddlewareHits)
+		require.Equal(t, 1, musicVideoMiddlewareHits)
+	})
+
+	t.Run("AssertMiddlewareHits", func(t *testing.T) {
+		require.Equal(t, 4, rootMiddlewareHits)
+		require.Equal(t, 1, songMiddlewareHits)
+		require.Equal(t, 1, musicVideoMiddlewareHits)
+	})
+}
+
+func TestClientPatchFields(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+	var requestBody string
+	api.AddMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPatch {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				requestBody = string(body)
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+	err := api.Storage.Set(context.Background(), album)
+	require.NoError(t, err)
+
+	address, closer := babytest.TestServe[*Album](t, api)
+	defer closer()
+
+	client := api.Client(address)
+
+	t.Run("Successful", func(t *testing.T) {
+		resp, err := client.PatchFields(context.Background(), album.GetID(), map[string]any{"title": "New Title"})
+		require.NoError(t, err)
+		require.Equal(t, "New Title", resp.Data.Title)
+		require.Equal(t, album.GetID(), resp.Data.GetID())
+		require.Equal(t, `{"title":"New Title"}`+"\n", requestBody)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := client.PatchFields(context.Background(), "cljcqg5o402e9s28rbp0", map[string]any{"title": "New Title"})
+		require.Error(t, err)
+		require.Equal(t, "error patching resource: unexpected response with text: Resource not found.", err.Error())
+	})
+
+	t.Run("EncodingError", func(t *testing.T) {
+		_, err := client.PatchFields(context.Background(), album.GetID(), map[string]any{"title": make(chan int)})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error encoding request body")
+	})
+}
+
+func TestAnyResourceNumbers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":9007199254740993,"weight":6.9}`))
+	}))
+	defer server.Close()
+
+	client := babyapi.NewClient[*babyapi.AnyResource](server.URL, "/pokemon")
+
+	resp, err := client.Get(context.Background(), "9007199254740993")
+	require.NoError(t, err)
+
+	require.Equal(t, "9007199254740993", resp.Data.GetID())
+	require.Equal(t, json.Number("6.9"), (*resp.Data)["weight"])
+
+	var out bytes.Buffer
+	err = resp.Fprint(&out, false)
+	require.NoError(t, err)
+	require.Equal(t, `{"id":9007199254740993,"weight":6.9}`+"\n", out.String())
+}
+
+func TestClientDo(t *testing.T) {
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	albumAPI.AddCustomRoute(http.MethodPost, "/export", babyapi.Handler(func(w http.ResponseWriter, r *http.Request) render.Renderer {
+		return &Album{Title: "Export " + albumAPI.GetParentIDParam(r)}
+	}))
+	albumAPI.AddCustomIDRoute(http.MethodPost, "/play", albumAPI.GetRequestedResourceAndDo(func(r *http.Request, album *Album) (render.Renderer, *babyapi.ErrResponse) {
+		render.Status(r, http.StatusAccepted)
+		return album, nil
+	}))
+	api := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} }).
+		AddNestedAPI(albumAPI)
+
+	artist := &Artist{DefaultResource: babyapi.NewDefaultResource(), Name: "Artist"}
+	require.NoError(t, api.Storage.Set(context.Background(), artist))
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+	require.NoError(t, albumAPI.Storage.Set(context.Background(), album))
+
+	address, closer := babytest.TestServe[*Artist](t, api)
+	defer closer()
+
+	client := babyapi.NewSubClient[*Artist, *Album](api.Client(address), "/albums")
+
+	t.Run("CustomRoute", func(t *testing.T) {
+		resp, err := client.Do(context.Background(), http.MethodPost, "/export", http.NoBody, artist.GetID())
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.Response.StatusCode)
+		require.Equal(t, "Export "+artist.GetID(), resp.Data.Title)
+	})
+
+	t.Run("CustomIDRoute", func(t *testing.T) {
+		resp, err := client.Do(context.Background(), http.MethodPost, album.GetID()+"/play", http.NoBody, artist.GetID())
+		require.NoError(t, err)
+		require.Equal(t, http.StatusAccepted, resp.Response.StatusCode)
+		require.Equal(t, "Album", resp.Data.Title)
+	})
+
+	t.Run("ErrorResponse", func(t *testing.T) {
+		_, err := client.Do(context.Background(), http.MethodPost, "cljcqg5o402e9s28rbp0/play", http.NoBody, artist.GetID())
+		require.Error(t, err)
+
+		var errResp *babyapi.ErrResponse
+		require.ErrorAs(t, err, &errResp)
+		require.Equal(t, http.StatusNotFound, errResp.HTTPStatusCode)
+	})
+
+	t.Run("MissingParentIDs", func(t *testing.T) {
+		_, err := client.Do(context.Background(), http.MethodPost, "/export", http.NoBody)
+		require.Error(t, err)
+		require.Equal(t, "error creating request: error creating target URL: expected 1 parentIDs", err.Error())
+	})
+}
+
+func TestClientAction(t *testing.T) {
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	albumAPI.AddCustomRoute(http.MethodGet, "/export", babyapi.Handler(func(w http.ResponseWriter, r *http.Request) render.Renderer {
+		return &Album{Title: "Export " + albumAPI.GetParentIDParam(r)}
+	}))
+	albumAPI.AddCustomIDRoute(http.MethodPut, "/rename", albumAPI.GetRequestedResourceAndDo(func(r *http.Request, album *Album) (render.Renderer, *babyapi.ErrResponse) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, babyapi.ErrInvalidRequest(err)
+		}
+		album.Title = string(body)
+		return album, nil
+	}))
+	api := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} }).
+		AddNestedAPI(albumAPI)
+
+	artist := &Artist{DefaultResource: babyapi.NewDefaultResource(), Name: "Artist"}
+	require.NoError(t, api.Storage.Set(context.Background(), artist))
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+	require.NoError(t, albumAPI.Storage.Set(context.Background(), album))
+
+	address, closer := babytest.TestServe[*Artist](t, api)
+	defer closer()
+
+	client := babyapi.NewSubClient[*Artist, *Album](api.Client(address), "/albums").
+		RegisterClientAction("export", http.MethodGet, "/export").
+		RegisterClientAction("rename", http.MethodPut, "/rename")
+
+	t.Run("Action", func(t *testing.T) {
+		resp, err := client.Action("export")(context.Background(), "", nil, artist.GetID())
+		require.NoError(t, err)
+		require.Equal(t, "Export "+artist.GetID(), resp.Data.Title)
+	})
+
+	t.Run("IDAction", func(t *testing.T) {
+		resp, err := client.Action("rename")(context.Background(), album.GetID(), strings.NewReader("New Title"), artist.GetID())
+		require.NoError(t, err)
+		require.Equal(t, "New Title", resp.Data.Title)
+	})
+
+	t.Run("NotRegistered", func(t *testing.T) {
+		_, err := client.Action("missing")(context.Background(), "", nil, artist.GetID())
+		require.Error(t, err)
+		require.Equal(t, `client action "missing" is not registered`, err.Error())
+	})
+}
+
+func TestGetParentChain(t *testing.T) {
+	var chain []any
+	recordChain := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			chain = babyapi.GetParentChain(r.Context())
+			next(w, r)
+		}
+	}
+
+	artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
+
+	songAPI.GetAll = recordChain(songAPI.GetAll)
+	songAPI.Get = recordChain(songAPI.Get)
+	songAPI.AddCustomIDRoute(http.MethodGet, "/play", recordChain(func(w http.ResponseWriter, r *http.Request) {}))
+
+	artistAPI.AddNestedAPI(albumAPI)
+	albumAPI.AddNestedAPI(songAPI)
+
+	artist := &Artist{DefaultResource: babyapi.NewDefaultResource(), Name: "Artist"}
+	require.NoError(t, artistAPI.Storage.Set(context.Background(), artist))
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+	require.NoError(t, albumAPI.Storage.Set(context.Background(), album))
+	song := &Song{DefaultResource: babyapi.NewDefaultResource(), Title: "Song"}
+	require.NoError(t, songAPI.Storage.Set(context.Background(), song))
+
+	router, err := artistAPI.Router()
+	require.NoError(t, err)
+
+	songsPath := "/artists/" + artist.GetID() + "/albums/" + album.GetID() + "/songs"
+
+	tests := []struct {
+		name     string
+		path     string
+		expected []any
+	}{
+		{"GetAll", songsPath, []any{artist, album}},
+		{"Get", songsPath + "/" + song.GetID(), []any{artist, album}},
+		{"CustomIDRoute", songsPath + "/" + song.GetID() + "/play", []any{artist, album, song}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain = nil
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, http.NoBody))
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, tt.expected, chain)
+		})
+	}
+
+	t.Run("Empty", func(t *testing.T) {
+		require.Nil(t, babyapi.GetParentChain(context.Background()))
+	})
+}
+
+func TestGetRoutePattern(t *testing.T) {
+	var pattern string
+	recordPattern := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			pattern = babyapi.GetRoutePattern(r)
+			next(w, r)
+		}
+	}
+
+	artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+	artistAPI.Get = recordPattern(artistAPI.Get)
+	albumAPI.GetAll = recordPattern(albumAPI.GetAll)
+	albumAPI.Get = recordPattern(albumAPI.Get)
+	albumAPI.AddCustomIDRoute(http.MethodGet, "/play", recordPattern(func(w http.ResponseWriter, r *http.Request) {}))
+
+	artistAPI.AddNestedAPI(albumAPI)
+
+	artist := &Artist{DefaultResource: babyapi.NewDefaultResource(), Name: "Artist"}
+	require.NoError(t, artistAPI.Storage.Set(context.Background(), artist))
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+	require.NoError(t, albumAPI.Storage.Set(context.Background(), album))
+
+	router, err := artistAPI.Router()
+	require.NoError(t, err)
+
+	albumsPath := "/artists/" + artist.GetID() + "/albums"
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"Get", "/artists/" + artist.GetID(), "/artists/{ArtistsID}"},
+		{"NestedGetAll", albumsPath, "/artists/{ArtistsID}/albums"},
+		{"NestedGet", albumsPath + "/" + album.GetID(), "/artists/{ArtistsID}/albums/{AlbumsID}"},
+		{"CustomIDRoute", albumsPath + "/" + album.GetID() + "/play", "/artists/{ArtistsID}/albums/{AlbumsID}/play"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern = ""
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, http.NoBody))
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, tt.expected, pattern)
+		})
+	}
+
+	t.Run("NotRouted", func(t *testing.T) {
+		require.Empty(t, babyapi.GetRoutePattern(httptest.NewRequest(http.MethodGet, "/artists", http.NoBody)))
+	})
+}
+
+func TestNestedAncestorsExist(t *testing.T) {
+	artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
+
+	var customRouteCalled bool
+	songAPI.AddCustomRoute(http.MethodGet, "/count", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		customRouteCalled = true
+	}))
+
+	artistAPI.AddNestedAPI(albumAPI)
+	albumAPI.AddNestedAPI(songAPI)
+
+	artist := &Artist{DefaultResource: babyapi.NewDefaultResource(), Name: "Artist"}
+	require.NoError(t, artistAPI.Storage.Set(context.Background(), artist))
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+	require.NoError(t, albumAPI.Storage.Set(context.Background(), album))
+	song := &Song{DefaultResource: babyapi.NewDefaultResource(), Title: "Song"}
+	require.NoError(t, songAPI.Storage.Set(context.Background(), song))
+
+	router, err := artistAPI.Router()
+	require.NoError(t, err)
+
+	missingID := "cljcqg5o402e9s28rbp0"
+	newSongID := babyapi.NewID().String()
+
+	paths := map[string]string{
+		"MissingGrandparent": "/artists/" + missingID + "/albums/" + album.GetID() + "/songs",
+		"MissingParent":      "/artists/" + artist.GetID() + "/albums/" + missingID + "/songs",
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"GetAll", http.MethodGet, "", ""},
+		{"Get", http.MethodGet, "/" + song.GetID(), ""},
+		{"Post", http.MethodPost, "", `{"title":"New Song"}`},
+		{"PutCreate", http.MethodPut, "/" + newSongID, `{"id":"` + newSongID + `","title":"New Song"}`},
+		{"Patch", http.MethodPatch, "/" + song.GetID(), `{"title":"New Title"}`},
+		{"Delete", http.MethodDelete, "/" + song.GetID(), ""},
+		{"CustomRoute", http.MethodGet, "/count", ""},
+	}
+
+	for missing, base := range paths {
+		t.Run(missing, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					r := httptest.NewRequest(tt.method, base+tt.path, strings.NewReader(tt.body))
+					r.Header.Set("Content-Type", "application/json")
+					w := httptest.NewRecorder()
+					router.ServeHTTP(w, r)
+
+					require.Equal(t, http.StatusNotFound, w.Code)
+					require.Equal(t, `{"status":"Resource not found."}`, strings.TrimSpace(w.Body.String()))
+				})
+			}
+		})
+	}
+
+	require.False(t, customRouteCalled)
+
+	songs, err := songAPI.Storage.GetAll(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, []*Song{song}, songs)
+}
+
+func TestSetLookupForNestedAPIs(t *testing.T) {
+	artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} }).
+		SetLookupForNestedAPIs(false)
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
+
+	var parentChain []any
+	songAPI.AddCustomRoute(http.MethodGet, "/chain", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parentChain = babyapi.GetParentChain(r.Context())
+	}))
+
+	artistAPI.AddNestedAPI(albumAPI)
+	albumAPI.AddNestedAPI(songAPI)
+
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+	require.NoError(t, albumAPI.Storage.Set(context.Background(), album))
+
+	router, err := artistAPI.Router()
+	require.NoError(t, err)
+
+	missingID := "cljcqg5o402e9s28rbp0"
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+		return w
+	}
+
+	t.Run("MissingGrandparentSkipped", func(t *testing.T) {
+		w := serve("/artists/" + missingID + "/albums/" + album.GetID() + "/songs")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = serve("/artists/" + missingID + "/albums/" + album.GetID() + "/songs/chain")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, []any{album}, parentChain)
+	})
+
+	t.Run("MissingParentStillChecked", func(t *testing.T) {
+		w := serve("/artists/" + missingID + "/albums/" + missingID + "/songs")
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("ResourceRoutesStillChecked", func(t *testing.T) {
+		w := serve("/artists/" + missingID)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+type Track struct {
+	babyapi.DefaultResource
+	AlbumID string `json:"album_id"`
+	Title   string `json:"title"`
+}
+
+func (t *Track) ParentID() string {
+	return t.AlbumID
+}
+
+func TestGetAllByParent(t *testing.T) {
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	trackAPI := babyapi.NewAPI("Tracks", "/tracks", func() *Track { return &Track{} })
+	albumAPI.AddNestedAPI(trackAPI)
+
+	album1 := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album1"}
+	album2 := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album2"}
+	track1 := &Track{DefaultResource: babyapi.NewDefaultResource(), AlbumID: album1.GetID(), Title: "Track1"}
+	track2 := &Track{DefaultResource: babyapi.NewDefaultResource(), AlbumID: album2.GetID(), Title: "Track2"}
+
+	for _, album := range []*Album{album1, album2} {
+		require.NoError(t, albumAPI.Storage.Set(context.Background(), album))
+	}
+	for _, track := range []*Track{track1, track2} {
+		require.NoError(t, trackAPI.Storage.Set(context.Background(), track))
+	}
+
+	address, closer := babytest.TestServe[*Album](t, albumAPI)
+	defer closer()
+
+	client := babyapi.NewSubClient[*Album, *Track](albumAPI.Client(address), "/tracks")
+
+	resp, err := client.GetAll(context.Background(), "", album1.GetID())
+	require.NoError(t, err)
+	require.Equal(t, []*Track{track1}, resp.Data.Items)
+
+	resp, err = client.GetAll(context.Background(), "", album2.GetID())
+	require.NoError(t, err)
+	require.Equal(t, []*Track{track2}, resp.Data.Items)
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		requestID string
+		expected  string
+	}{
+		{"UseProvidedID", babyapi.DefaultRequestIDHeader, "trace-1234:abc/1", "trace-1234:abc/1"},
+		{"CustomHeader", "X-Correlation-ID", "correlation-id", "correlation-id"},
+		{"GenerateWhenMissing", babyapi.DefaultRequestIDHeader, "", ""},
+		{"GenerateWhenInvalid", babyapi.DefaultRequestIDHeader, "bad id\n", ""},
+		{"GenerateWhenTooLong", babyapi.DefaultRequestIDHeader, strings.Repeat("a", 129), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contextID string
+			api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+			api.AddMiddleware(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					contextID = middleware.GetReqID(r.Context())
+					next.ServeHTTP(w, r)
+				})
+			})
+			if tt.header != babyapi.DefaultRequestIDHeader {
+				api.SetRequestIDHeader(tt.header)
+			}
+
+			router, err := api.Router()
+			require.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodGet, "/albums", http.NoBody)
+			if tt.requestID != "" {
+				r.Header.Set(tt.header, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			require.Equal(t, http.StatusOK, w.Code)
+
+			responseID := w.Header().Get(tt.header)
+			require.Equal(t, responseID, contextID)
+			if tt.expected != "" {
+				require.Equal(t, tt.expected, responseID)
+				return
+			}
+
+			_, err = xid.FromString(responseID)
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("EmptyHeaderError", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		api.SetRequestIDHeader("")
+
+		_, err := api.Router()
+		require.EqualError(t, err, "encountered 1 errors constructing API:\n- SetRequestIDHeader: header must not be empty\n")
+	})
+}
+
+func TestEnablePprof(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	api.EnablePprof("/internal/pprof", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	router, err := api.Router()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		path     string
+		auth     string
+		status   int
+		contains string
+	}{
+		{"Index", "/internal/pprof/", "secret", http.StatusOK, "goroutine"},
+		{"Cmdline", "/internal/pprof/cmdline", "secret", http.StatusOK, ""},
+		{"NamedProfile", "/internal/pprof/goroutine?debug=1", "secret", http.StatusOK, "goroutine profile:"},
+		{"UnknownProfile", "/internal/pprof/missing", "secret", http.StatusNotFound, "Unknown profile"},
+		{"Unauthorized", "/internal/pprof/goroutine", "", http.StatusUnauthorized, ""},
+		{"APIRoutesUnaffected", "/albums", "", http.StatusOK, "[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, http.NoBody)
+			r.Header.Set("Authorization", tt.auth)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			require.Equal(t, tt.status, w.Code)
+			require.Contains(t, w.Body.String(), tt.contains)
+		})
+	}
+
+	t.Run("InvalidPath", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		api.EnablePprof("/")
+
+		_, err := api.Router()
+		require.EqualError(t, err, "encountered 1 errors constructing API:\n- EnablePprof: path must start with / and not be the root path\n")
+	})
+}
+
+func TestClientSetAddressAndBase(t *testing.T) {
+	client := babyapi.NewClient[*Album]("http://localhost:8080", "/albums")
+
+	url, err := client.URL("1")
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8080/albums/1", url)
+
+	client.SetAddress("https://example.com").SetBase("/v2/albums")
+
+	url, err = client.URL("1")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/v2/albums/1", url)
+
+	t.Run("SubClientKeepsParentPath", func(t *testing.T) {
+		songs := babyapi.NewSubClient[*Album, *Song](client, "/songs").SetBase("tracks")
+
+		url, err := songs.URL("2", "1")
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com/v2/albums/1/tracks/2", url)
+	})
+}
+
+func routesTestHandler(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestRoutes(t *testing.T) {
+	root := babyapi.NewRootAPI("Root", "/")
+	artists := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
+	albums := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+	root.AddNestedAPI(artists)
+	artists.AddNestedAPI(albums)
+
+	root.AddCustomRoute(http.MethodGet, "/health", http.HandlerFunc(routesTestHandler))
+	artists.AddCustomIDRoute(http.MethodPost, "/follow", http.HandlerFunc(routesTestHandler))
+	albums.DisableMethods(http.MethodPut, http.MethodPatch)
+
+	routes, err := root.Routes()
+	require.NoError(t, err)
+
+	handlerName := "github.com/calvinmclean/babyapi_test.routesTestHandler"
+	require.Equal(t, []babyapi.RouteInfo{
+		{http.MethodGet, "/artists", "Artists.GetAll"},
+		{http.MethodPost, "/artists", "Artists.Post"},
+		{http.MethodDelete, "/artists/{ArtistsID}", "Artists.Delete"},
+		{http.MethodGet, "/artists/{ArtistsID}", "Artists.Get"},
+		{http.MethodPatch, "/artists/{ArtistsID}", "Artists.Patch"},
+		{http.MethodPut, "/artists/{ArtistsID}", "Artists.Put"},
+		{http.MethodGet, "/artists/{ArtistsID}/albums", "Albums.GetAll"},
+		{http.MethodPost, "/artists/{ArtistsID}/albums", "Albums.Post"},
+		{http.MethodDelete, "/artists/{ArtistsID}/albums/{AlbumsID}", "Albums.Delete"},
+		{http.MethodGet, "/artists/{ArtistsID}/albums/{AlbumsID}", "Albums.Get"},
+		{http.MethodPost, "/artists/{ArtistsID}/follow", handlerName},
+		{http.MethodGet, "/health", handlerName},
+	}, routes)
+
+	t.Run("BuilderError", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		api.DisableMethods("TRACE")
+
+		_, err := api.Routes()
+		require.Error(t, err)
+	})
+}
+
+func TestRoutesCLI(t *testing.T) {
+	artists := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
+	albums := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	artists.AddNestedAPI(albums)
+	albums.DisableMethods(http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete)
+
+	out, err := runCommand(artists.Command(), []string{"routes"})
+	require.NoError(t, err)
+	require.Equal(t, `METHOD  PATH
+GET     /artists
+POST    /artists
+DELETE  /artists/{ArtistsID}
+GET     /artists/{ArtistsID}
+PATCH   /artists/{ArtistsID}
+PUT     /artists/{ArtistsID}
+GET     /artists/{ArtistsID}/albums
+GET     /artists/{ArtistsID}/albums/{AlbumsID}
+`, out)
+}
+
+func TestAddNestedAPICycles(t *testing.T) {
+	t.Run("Self", func(t *testing.T) {
+		artists := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
+		artists.AddNestedAPI(artists)
+
+		_, err := artists.Router()
+		require.EqualError(t, err, "encountered 1 errors constructing API:\n- AddNestedAPI: adding \"Artists\" to \"Artists\" creates a cycle\n")
+	})
+
+	t.Run("Ancestor", func(t *testing.T) {
+		artists := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
+		albums := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		songs := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
+
+		artists.AddNestedAPI(albums)
+		albums.AddNestedAPI(songs)
+		songs.AddNestedAPI(artists)
+
+		require.Nil(t, artists.Parent())
+		require.Empty(t, songs.ChildAPIs())
+
+		_, err := artists.Router()
+		require.EqualError(t, err, "error creating routes for \"Albums\": error creating routes for \"Songs\": encountered 1 errors constructing API:\n- AddNestedAPI: adding \"Artists\" to \"Songs\" creates a cycle\n")
+	})
+}
+
+func TestAddNestedAPICyclesAfterChangingParent(t *testing.T) {
+	artists := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
+	albums := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	songs := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
+
+	// Albums is still nested under Artists after its parent changes to Songs
+	artists.AddNestedAPI(albums)
+	songs.AddNestedAPI(albums)
+	albums.AddNestedAPI(artists)
+
+	require.Empty(t, albums.ChildAPIs())
+
+	_, err := artists.Router()
+	require.EqualError(t, err, "error creating routes for \"Albums\": encountered 1 errors constructing API:\n- AddNestedAPI: adding \"Artists\" to \"Albums\" creates a cycle\n")
+}
+
+func TestMaxNestingDepth(t *testing.T) {
+	newTree := func() (*babyapi.API[*Artist], *babyapi.API[*Song]) {
+		artists := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
+		albums := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		songs := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
+
+		artists.AddNestedAPI(albums)
+		albums.AddNestedAPI(songs)
+		return artists, songs
+	}
+
+	t.Run("WithinLimit", func(t *testing.T) {
+		artists, _ := newTree()
+		artists.SetMaxNestingDepth(3)
+
+		_, err := artists.Router()
+		require.NoError(t, err)
+	})
+
+	t.Run("ExceedsLimit", func(t *testing.T) {
+		artists, _ := newTree()
+		artists.SetMaxNestingDepth(2)
+
+		_, err := artists.Router()
+		require.EqualError(t, err, "encountered 1 errors constructing API:\n- nested APIs have depth 3 which exceeds the maximum of 2\n")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		artists, songs := newTree()
+		artists.SetMaxNestingDepth(0)
+
+		parent := songs
+		for i := 0; i < babyapi.DefaultMaxNestingDepth; i++ {
+			child := babyapi.NewAPI(fmt.Sprintf("Songs%d", i), "/songs", func() *Song { return &Song{} })
+			parent.AddNestedAPI(child)
+			parent = child
+		}
+
+		_, err := artists.Router()
+		require.NoError(t, err)
+	})
+
+	t.Run("DefaultLimit", func(t *testing.T) {
+		artists, songs := newTree()
+
+		parent := songs
+		for i := 0; i < babyapi.DefaultMaxNestingDepth; i++ {
+			child := babyapi.NewAPI(fmt.Sprintf("Songs%d", i), "/songs", func() *Song { return &Song{} })
+			parent.AddNestedAPI(child)
+			parent = child
+		}
+
+		_, err := artists.Router()
+		require.EqualError(t, err, fmt.Sprintf("encountered 1 errors constructing API:\n- nested APIs have depth %d which exceeds the maximum of %d\n", babyapi.DefaultMaxNestingDepth+3, babyapi.DefaultMaxNestingDepth))
+	})
+}
+
+type Playlist struct {
+	babyapi.DefaultResource
+	Name string `json:"name"`
+}
+
+type PlaylistSong struct {
+	babyapi.DefaultResource
+	AlbumID     string   `json:"album_id"`
+	PlaylistIDs []string `json:"playlist_ids"`
+	Title       string   `json:"title"`
+}
+
+func (s *PlaylistSong) ParentIDs() []string {
+	return append([]string{s.AlbumID}, s.PlaylistIDs...)
+}
+
+func TestMultipleParents(t *testing.T) {
+	root := babyapi.NewRootAPI("root", "/")
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	playlistAPI := babyapi.NewAPI("Playlists", "/playlists", func() *Playlist { return &Playlist{} })
+
+	// Each parent has its own API for songs, but they share storage
+	albumSongAPI := babyapi.NewAPI("AlbumSongs", "/songs", func() *PlaylistSong { return &PlaylistSong{} })
+	playlistSongAPI := babyapi.NewAPI("PlaylistSongs", "/songs", func() *PlaylistSong { return &PlaylistSong{} }).
+		SetStorage(albumSongAPI.Storage)
+
+	root.AddNestedAPI(albumAPI).AddNestedAPI(playlistAPI)
+	albumAPI.AddNestedAPI(albumSongAPI)
+	playlistAPI.AddNestedAPI(playlistSongAPI)
+
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+	playlist1 := &Playlist{DefaultResource: babyapi.NewDefaultResource(), Name: "Playlist1"}
+	playlist2 := &Playlist{DefaultResource: babyapi.NewDefaultResource(), Name: "Playlist2"}
+	require.NoError(t, albumAPI.Storage.Set(context.Background(), album))
+	require.NoError(t, playlistAPI.Storage.Set(context.Background(), playlist1))
+	require.NoError(t, playlistAPI.Storage.Set(context.Background(), playlist2))
+
+	song1 := &PlaylistSong{DefaultResource: babyapi.NewDefaultResource(), AlbumID: album.GetID(), PlaylistIDs: []string{playlist1.GetID(), playlist2.GetID()}, Title: "Song1"}
+	song2 := &PlaylistSong{DefaultResource: babyapi.NewDefaultResource(), AlbumID: album.GetID(), PlaylistIDs: []string{playlist2.GetID()}, Title: "Song2"}
+
+	address, closer := babytest.TestServe[*babyapi.NilResource](t, root)
+	defer closer()
+
+	albumSongClient := babyapi.NewSubClient[*Album, *PlaylistSong](babyapi.NewClient[*Album](address, "/albums"), "/songs")
+	playlistSongClient := babyapi.NewSubClient[*Playlist, *PlaylistSong](babyapi.NewClient[*Playlist](address, "/playlists"), "/songs")
+
+	_, err := albumSongClient.Put(context.Background(), song1, album.GetID())
+	require.NoError(t, err)
+	_, err = playlistSongClient.Put(context.Background(), song2, playlist2.GetID())
+	require.NoError(t, err)
+
+	t.Run("GetAllUnderEachParent", func(t *testing.T) {
+		resp, err := albumSongClient.GetAll(context.Background(), "", album.GetID())
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*PlaylistSong{song1, song2}, resp.Data.Items)
+
+		resp, err = playlistSongClient.GetAll(context.Background(), "", playlist1.GetID())
+		require.NoError(t, err)
+		require.Equal(t, []*PlaylistSong{song1}, resp.Data.Items)
+
+		resp, err = playlistSongClient.GetAll(context.Background(), "", playlist2.GetID())
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*PlaylistSong{song1, song2}, resp.Data.Items)
+	})
+
+	t.Run("UpdateUnderOtherParent", func(t *testing.T) {
+		song1.Title = "Updated"
+		_, err := playlistSongClient.Put(context.Background(), song1, playlist1.GetID())
+		require.NoError(t, err)
+
+		resp, err := albumSongClient.Get(context.Background(), song1.GetID(), album.GetID())
+		require.NoError(t, err)
+		require.Equal(t, "Updated", resp.Data.Title)
+	})
+}
+
+func TestClientAll(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	api.EnablePagination(2, 0)
+
+	albums := []*Album{}
+	for i := 0; i < 5; i++ {
+		album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: fmt.Sprintf("Album %d", i)}
+		require.NoError(t, api.Storage.Set(context.Background(), album))
+		albums = append(albums, album)
+	}
+	slices.SortFunc(albums, func(a, b *Album) int {
+		return strings.Compare(a.GetID(), b.GetID())
+	})
+
+	client, stop := babytest.NewTestClient(t, api)
+	defer stop()
+
+	collect := func(t *testing.T, c *babyapi.Client[*Album], rawQuery string, max int) ([]*Album, error) {
+		t.Helper()
+
+		result := []*Album{}
+		var resultErr error
+		c.All(context.Background(), rawQuery)(func(album *Album, err error) bool {
+			if err != nil {
+				resultErr = err
+				return false
+			}
+			result = append(result, album)
+			return max == 0 || len(result) < max
+		})
+		return result, resultErr
+	}
+
+	t.Run("AllPages", func(t *testing.T) {
+		result, err := collect(t, client, "", 0)
+		require.NoError(t, err)
+		require.Equal(t, albums, result)
+	})
+
+	t.Run("StartAtOffset", func(t *testing.T) {
+		result, err := collect(t, client, "offset=3&limit=1", 0)
+		require.NoError(t, err)
+		require.Equal(t, albums[3:], result)
+	})
+
+	t.Run("StopEarly", func(t *testing.T) {
+		result, err := collect(t, client, "", 3)
+		require.NoError(t, err)
+		require.Equal(t, albums[:3], result)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		result, err := collect(t, client, "limit=0", 0)
+		require.EqualError(t, err, "error getting all resources: unexpected response with text: Invalid request.")
+		require.Empty(t, result)
+	})
+
+	t.Run("NotPaginated", func(t *testing.T) {
+		unpaginated := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetStorage(api.Storage)
+
+		client, stop := babytest.NewTestClient(t, unpaginated)
+		defer stop()
+
+		result, err := collect(t, client, "", 0)
+		require.NoError(t, err)
+		require.ElementsMatch(t, albums, result)
+	})
+
+	t.Run("GetAllPages", func(t *testing.T) {
+		result, err := client.GetAllPages(context.Background(), "limit=2")
+		require.NoError(t, err)
+		require.Equal(t, albums, result)
+
+		result, err = client.GetAllPages(context.Background(), "limit=0")
+		require.EqualError(t, err, "error getting all resources: unexpected response with text: Invalid request.")
+		require.Nil(t, result)
+	})
+}
+
+func TestSetJSONCodec(t *testing.T) {
+	marshalCalls, unmarshalCalls := 0, 0
+	babyapi.SetJSONCodec(
+		func(v any) ([]byte, error) {
+			marshalCalls++
+			return json.MarshalIndent(v, "", "  ")
+		},
+		func(data []byte, v any) error {
+			unmarshalCalls++
+			return json.Unmarshal(data, v)
+		},
+	)
+	defer babyapi.SetJSONCodec(nil, nil)
+
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+	client, stop := babytest.NewTestClient(t, api)
+	defer stop()
+
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+
+	t.Run("ClientServerAndStorage", func(t *testing.T) {
+		resp, err := client.Put(context.Background(), album)
+		require.NoError(t, err)
+		require.Equal(t, album, resp.Data)
+		require.Equal(t, fmt.Sprintf("{\n  \"id\": %q,\n  \"title\": \"Album\"\n}\n", album.GetID()), resp.Body)
+
+		// client request, storage, and response
+		require.Equal(t, 3, marshalCalls)
+		// request body and client response
+		require.Equal(t, 2, unmarshalCalls)
+	})
+
+	t.Run("InvalidRequestBody", func(t *testing.T) {
+		_, err := client.Do(context.Background(), http.MethodPost, "", strings.NewReader("not json"))
+
+		var errResp *babyapi.ErrResponse
+		require.ErrorAs(t, err, &errResp)
+		require.Equal(t, http.StatusBadRequest, errResp.HTTPStatusCode)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		babyapi.SetJSONCodec(nil, nil)
+		marshalCalls = 0
+
+		resp, err := client.Get(context.Background(), album.GetID())
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf(`{"id":%q,"title":"Album"}`+"\n", album.GetID()), resp.Body)
+		require.Zero(t, marshalCalls)
+	})
+}
+
+type PooledAlbum struct {
+	babyapi.DefaultResource
+	Title  string `json:"title"`
+	Artist string `json:"artist,omitempty"`
+}
+
+func (a *PooledAlbum) Reset() {
+	*a = PooledAlbum{}
+}
+
+// DefaultPooledAlbum has a default Artist from the instance function, so Reset restores it
+type DefaultPooledAlbum struct {
+	babyapi.DefaultResource
+	Title  string `json:"title"`
+	Artist string `json:"artist,omitempty"`
+}
+
+func (a *DefaultPooledAlbum) Reset() {
+	*a = DefaultPooledAlbum{Artist: "Unknown"}
+}
+
+func TestEnableInstancePool(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *PooledAlbum { return &PooledAlbum{} })
+	api.EnableInstancePool()
+
+	client, stop := babytest.NewTestClient(t, api)
+	defer stop()
+
+	resp1, err := client.Post(context.Background(), &PooledAlbum{Title: "Album1", Artist: "Artist"})
+	require.NoError(t, err)
+
+	// the second request doesn't have an artist, so it must not be left over from a reused instance
+	resp2, err := client.Post(context.Background(), &PooledAlbum{Title: "Album2"})
+	require.NoError(t, err)
+	require.Empty(t, resp2.Data.Artist)
+	require.NotEqual(t, resp1.Data.GetID(), resp2.Data.GetID())
+
+	album1, err := api.Storage.Get(context.Background(), resp1.Data.GetID())
+	require.NoError(t, err)
+	require.Equal(t, "Album1", album1.Title)
+	require.Equal(t, "Artist", album1.Artist)
+
+	album2, err := api.Storage.Get(context.Background(), resp2.Data.GetID())
+	require.NoError(t, err)
+	require.Equal(t, "Album2", album2.Title)
+	require.Empty(t, album2.Artist)
+
+	t.Run("ResetRestoresDefaults", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *DefaultPooledAlbum { return &DefaultPooledAlbum{Artist: "Unknown"} })
+		api.EnableInstancePool()
+
+		client, stop := babytest.NewTestClient(t, api)
+		defer stop()
+
+		resp, err := client.Post(context.Background(), &DefaultPooledAlbum{Title: "Album1", Artist: "Artist"})
+		require.NoError(t, err)
+		require.Equal(t, "Artist", resp.Data.Artist)
+
+		// later requests can reuse the first instance, which only has the default because Reset sets it
+		for i := 0; i < 3; i++ {
+			resp, err := client.Post(context.Background(), &DefaultPooledAlbum{Title: "Album2"})
+			require.NoError(t, err)
+			require.Equal(t, "Unknown", resp.Data.Artist)
+		}
+	})
+
+	t.Run("NotResettable", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		api.EnableInstancePool()
+
+		_, err := api.Router()
+		require.EqualError(t, err, "encountered 1 errors constructing API:\n- EnableInstancePool: *babyapi_test.Album does not implement Resettable\n")
+	})
+}
+
+func BenchmarkInstancePool(b *testing.B) {
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	for _, pooled := range []bool{false, true} {
+		b.Run(fmt.Sprintf("Pooled=%t", pooled), func(b *testing.B) {
+			api := babyapi.NewAPI("Albums", "/albums", func() *PooledAlbum { return &PooledAlbum{} })
+			if pooled {
+				api.EnableInstancePool()
+			}
+
+			router := chi.NewRouter()
+			require.NoError(b, api.Route(router))
+
+			body := `{"title":"Album","artist":"Artist"}`
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader(body))
+				r.Header.Set("Content-Type", "application/json")
+				router.ServeHTTP(httptest.NewRecorder(), r)
+			}
+		})
+	}
+}
+
+type CountingBindAlbum struct {
+	babyapi.DefaultResource
+	Title string `json:"title"`
+
+	binds *atomic.Int32
+}
+
+func (a *CountingBindAlbum) Bind(r *http.Request) error {
+	a.binds.Add(1)
+	return a.DefaultResource.Bind(r)
+}
+
+func (a *CountingBindAlbum) Patch(newAlbum *CountingBindAlbum) *babyapi.ErrResponse {
+	a.Title = newAlbum.Title
+	return nil
+}
+
+func TestRequestBodyBoundOnce(t *testing.T) {
+	var binds atomic.Int32
+	api := babyapi.NewAPI("Albums", "/albums", func() *CountingBindAlbum { return &CountingBindAlbum{binds: &binds} })
+
+	// Reading the body in hooks uses the same resource from the context
+	api.SetOnCreateOrUpdate(func(r *http.Request, album *CountingBindAlbum) *babyapi.ErrResponse {
+		body, httpErr := api.GetFromRequest(r)
+		if httpErr != nil {
+			return httpErr
+		}
+		// PATCH applies the body to the existing resource, so the hook receives a different resource
+		if r.Method != http.MethodPatch && body != album {
+			return babyapi.InternalServerError(errors.New("request body was bound again"))
+		}
+		return nil
+	})
+
+	router, err := api.Router()
+	require.NoError(t, err)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(http.MethodPost, "/albums", `{"title":"Album"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.EqualValues(t, 1, binds.Swap(0))
+
+	var created CountingBindAlbum
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	id := created.GetID()
+
+	w = serve(http.MethodPut, "/albums/"+id, fmt.Sprintf(`{"id":%q,"title":"Updated"}`, id))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.EqualValues(t, 1, binds.Swap(0))
+
+	w = serve(http.MethodPatch, "/albums/"+id, `{"title":"Patched"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.EqualValues(t, 1, binds.Swap(0))
+}
+
+type DoubleBindAlbum struct {
+	babyapi.DefaultResource
+	Title string `json:"title"`
+}
+
+// Bind runs the default Bind twice to make sure binding again does not change or reject the generated ID
+func (a *DoubleBindAlbum) Bind(r *http.Request) error {
+	err := a.DefaultResource.Bind(r)
+	if err != nil {
+		return err
+	}
+
+	id := a.GetID()
+	err = a.DefaultResource.Bind(r)
+	if err != nil {
+		return err
+	}
+
+	if a.GetID() != id {
+		return fmt.Errorf("ID changed from %q to %q", id, a.GetID())
+	}
+	return nil
+}
+
+func TestBindTwiceKeepsGeneratedID(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *DoubleBindAlbum { return &DoubleBindAlbum{} })
+
+	client, stop := babytest.NewTestClient(t, api)
+	defer stop()
+
+	resp, err := client.Post(context.Background(), &DoubleBindAlbum{Title: "Album"})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Data.GetID())
+
+	stored, err := api.Storage.Get(context.Background(), resp.Data.GetID())
+	require.NoError(t, err)
+	require.Equal(t, resp.Data, stored)
+
+	t.Run("ClientIDStillRejected", func(t *testing.T) {
+		_, err := client.Post(context.Background(), &DoubleBindAlbum{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"})
+		require.EqualError(t, err, "error posting resource: unexpected response with text: Invalid request.")
+	})
+}
+
+func TestGetFromRequestKeepsRequest(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	api.AddCustomRoute(http.MethodPost, "/custom", babyapi.Handler(func(w http.ResponseWriter, r *http.Request) render.Renderer {
+		ctx := r.Context()
+
+		album, httpErr := api.GetFromRequest(r)
+		if httpErr != nil {
+			return httpErr
+		}
+
+		if r.Context() != ctx {
+			return babyapi.InternalServerError(errors.New("request context was replaced"))
+		}
+		if r.PostFormValue("title") != album.Title {
+			return babyapi.InternalServerError(errors.New("form values are not available"))
+		}
+
+		render.Status(r, http.StatusCreated)
+		return album
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/albums/custom", strings.NewReader("title=Album"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := babytest.TestRequest(t, api, r)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.Contains(t, w.Body.String(), `"title":"Album"`)
+}
+
+type DefaultsAlbum struct {
+	babyapi.DefaultResource
+	Title  string `json:"title"`
+	Genre  string `json:"genre,omitempty"`
+	Rating int    `json:"rating,omitempty"`
+}
+
+func TestSetInstance(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *DefaultsAlbum { return &DefaultsAlbum{} })
+	api.SetInstance(func() *DefaultsAlbum {
+		return &DefaultsAlbum{Genre: "Rock", Rating: 3}
+	})
+
+	client, stop := babytest.NewTestClient(t, api)
+	defer stop()
+
+	t.Run("PostKeepsDefaults", func(t *testing.T) {
+		resp, err := client.Post(context.Background(), &DefaultsAlbum{Title: "Album"})
+		require.NoError(t, err)
+		require.Equal(t, "Rock", resp.Data.Genre)
+		require.Equal(t, 3, resp.Data.Rating)
+
+		stored, err := api.Storage.Get(context.Background(), resp.Data.GetID())
+		require.NoError(t, err)
+		require.Equal(t, "Rock", stored.Genre)
+		require.Equal(t, 3, stored.Rating)
+	})
+
+	t.Run("PostOverridesDefaults", func(t *testing.T) {
+		resp, err := client.Post(context.Background(), &DefaultsAlbum{Title: "Album", Genre: "Jazz", Rating: 5})
+		require.NoError(t, err)
+		require.Equal(t, "Jazz", resp.Data.Genre)
+		require.Equal(t, 5, resp.Data.Rating)
+	})
+
+	t.Run("PutKeepsDefaults", func(t *testing.T) {
+		album := &DefaultsAlbum{DefaultResource: babyapi.NewDefaultResource(), Title: "Album", Rating: 1}
+		resp, err := client.Put(context.Background(), album)
+		require.NoError(t, err)
+		require.Equal(t, "Rock", resp.Data.Genre)
+		require.Equal(t, 1, resp.Data.Rating)
+	})
+
+	t.Run("ErrorForNilInstance", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *DefaultsAlbum { return &DefaultsAlbum{} }).
+			SetInstance(nil)
+
+		err := api.Route(chi.NewRouter())
+		require.ErrorAs(t, err, &babyapi.BuilderError{})
+		require.Equal(t, "encountered 1 errors constructing API:\n- SetInstance: instance function must not be nil\n", err.Error())
+	})
+}
+
+type PreservedAlbum struct {
+	babyapi.DefaultResource
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at" babyapi:"preserve"`
+}
+
+func TestPreserveTag(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *PreservedAlbum { return &PreservedAlbum{} })
+
+	client, stop := babytest.NewTestClient(t, api)
+	defer stop()
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	album := &PreservedAlbum{DefaultResource: babyapi.NewDefaultResource(), Title: "Album", CreatedAt: createdAt}
+
+	_, err := client.Put(context.Background(), album)
+	require.NoError(t, err)
+
+	t.Run("MissingFieldIsPreserved", func(t *testing.T) {
+		resp, err := client.Put(context.Background(), &PreservedAlbum{DefaultResource: album.DefaultResource, Title: "New Title"})
+		require.NoError(t, err)
+		require.Equal(t, "New Title", resp.Data.Title)
+		require.True(t, createdAt.Equal(resp.Data.CreatedAt))
+
+		stored, err := api.Storage.Get(context.Background(), album.GetID())
+		require.NoError(t, err)
+		require.Equal(t, "New Title", stored.Title)
+		require.True(t, createdAt.Equal(stored.CreatedAt))
+	})
+
+	t.Run("ClientValueIsIgnored", func(t *testing.T) {
+		resp, err := client.Put(context.Background(), &PreservedAlbum{DefaultResource: album.DefaultResource, Title: "Title", CreatedAt: time.Now()})
+		require.NoError(t, err)
+		require.True(t, createdAt.Equal(resp.Data.CreatedAt))
+	})
+
+	t.Run("HooksRunAfterPreserving", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *PreservedAlbum { return &PreservedAlbum{} })
+		api.SetOnCreateOrUpdate(func(r *http.Request, a *PreservedAlbum) *babyapi.ErrResponse {
+			if a.CreatedAt.IsZero() {
+				a.CreatedAt = createdAt
+			}
+			return nil
+		})
+
+		client, stop := babytest.NewTestClient(t, api)
+		defer stop()
+
+		resp, err := client.Post(context.Background(), &PreservedAlbum{Title: "Album"})
+		require.NoError(t, err)
+		require.True(t, createdAt.Equal(resp.Data.CreatedAt))
+
+		resp, err = client.Put(context.Background(), &PreservedAlbum{DefaultResource: resp.Data.DefaultResource, Title: "Updated"})
+		require.NoError(t, err)
+		require.Equal(t, "Updated", resp.Data.Title)
+		require.True(t, createdAt.Equal(resp.Data.CreatedAt))
+	})
+}
+
+// errStorage returns an error from every Get and GetAll call
+type errStorage struct {
+	babyapi.Storage[*Album]
+	err error
+}
+
+func (s errStorage) Get(context.Context, string) (*Album, error) {
+	return nil, s.err
+}
+
+func (s errStorage) GetAll(context.Context, url.Values) ([]*Album, error) {
+	return nil, fmt.Errorf("error reading: %w", s.err)
+}
+
+func TestContextErrorResponses(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		cancelRequest bool
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			"DeadlineExceeded",
+			context.DeadlineExceeded,
+			false,
+			http.StatusGatewayTimeout,
+			`{"status":"Request timed out.","error":"context deadline exceeded"}`,
+		},
+		{
+			"ClientClosedRequest",
+			context.Canceled,
+			true,
+			// nothing is written to the response, so the recorder keeps its default code
+			http.StatusOK,
+			"",
+		},
+		{
+			"CanceledFromOtherContext",
+			context.Canceled,
+			false,
+			http.StatusInternalServerError,
+			`{"status":"Server Error.","error":"context canceled"}`,
+		},
+		{
+			"OtherError",
+			errors.New("storage error"),
+			false,
+			http.StatusInternalServerError,
+			`{"status":"Server Error.","error":"storage error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+			api.SetStorage(errStorage{api.Storage, tt.err})
+
+			router, err := api.Router()
+			require.NoError(t, err)
+
+			for _, target := range []string{"/albums", "/albums/" + babyapi.NewID().String()} {
+				r := httptest.NewRequest(http.MethodGet, target, http.NoBody)
+				if tt.cancelRequest {
+					ctx, cancel := context.WithCancel(r.Context())
+					cancel()
+					r = r.WithContext(ctx)
+				}
+
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, r)
+
+				require.Equal(t, tt.expectedCode, w.Code)
+				if tt.expectedBody == "" {
+					require.Empty(t, w.Body.String())
+					continue
+				}
+				expectedBody := tt.expectedBody
+				if target == "/albums" && tt.err != nil {
+					expectedBody = strings.Replace(expectedBody, `"error":"`, `"error":"error reading: `, 1)
+				}
+				require.Equal(t, expectedBody, strings.TrimSpace(w.Body.String()))
+			}
+		})
+	}
+}
+
+type SecretAlbum struct {
+	babyapi.DefaultResource
+	Title    string `json:"title"`
+	Password string `json:"password,omitempty"`
+	secret   string
+}
+
+func (a *SecretAlbum) Bind(r *http.Request) error {
+	a.secret = a.Password
+	return a.DefaultResource.Bind(r)
+}
+
+func TestLogBodies(t *testing.T) {
+	defaultLogger := slog.Default()
+	defer slog.SetDefault(defaultLogger)
+
+	var logs bytes.Buffer
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	api := babyapi.NewAPI("Albums", "/albums", func() *SecretAlbum { return &SecretAlbum{} }).
+		SetLogBodies(true).
+		SetLogRedactFields("Password")
+
+	router, err := api.Router()
+	require.NoError(t, err)
+
+	t.Run("JSON", func(t *testing.T) {
+		logs.Reset()
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader(`{"title":"Album","password":"hunter2"}`))
+		r.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, r)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		output := logs.String()
+		require.NotContains(t, output, "hunter2")
+		require.Contains(t, output, `level=DEBUG msg="request body"`)
+		require.Contains(t, output, `level=DEBUG msg="response body"`)
+		require.Contains(t, output, `\"password\":\"[REDACTED]\"`)
+		require.Contains(t, output, `\"title\":\"Album\"`)
+	})
+
+	t.Run("Form", func(t *testing.T) {
+		logs.Reset()
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader(`title=Album&password=hunter2`))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		router.ServeHTTP(w, r)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		output := logs.String()
+		require.NotContains(t, output, "hunter2")
+		require.Contains(t, output, `body="password=%5BREDACTED%5D&title=Album"`)
+	})
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		logs.Reset()
+
+		api := babyapi.NewAPI("Albums", "/albums", func() *SecretAlbum { return &SecretAlbum{} })
+		router, err := api.Router()
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader(`{"title":"Album"}`))
+		r.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, r)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		require.NotContains(t, logs.String(), `msg="request body"`)
+		require.NotContains(t, logs.String(), `msg="response body"`)
+	})
+}
+
+type TaggedSecretAlbum struct {
+	babyapi.DefaultResource
+	Title    string `json:"title"`
+	Password string `json:"password,omitempty" babyapi:"redact"`
+}
+
+type LogValuerAlbum struct {
+	babyapi.DefaultResource
+	Title    string `json:"title"`
+	Password string `json:"password,omitempty" babyapi:"redact"`
+}
+
+func (a *LogValuerAlbum) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("id", a.GetID()), slog.String("title", a.Title))
+}
+
+func TestRedactTag(t *testing.T) {
+	defaultLogger := slog.Default()
+	defer slog.SetDefault(defaultLogger)
+
+	var logs bytes.Buffer
+
+	post := func(t *testing.T, router http.Handler) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/albums", strings.NewReader(`{"title":"Album","password":"hunter2"}`))
+		r.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, r)
+		require.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	t.Run("RequestBodyLoggedAtDebugLevel", func(t *testing.T) {
+		logs.Reset()
+		slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+		router, err := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).Router()
+		require.NoError(t, err)
+		post(t, router)
+
+		require.NotContains(t, logs.String(), "received request body")
+	})
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	t.Run("TaggedFieldsAreRedacted", func(t *testing.T) {
+		logs.Reset()
+
+		router, err := babyapi.NewAPI("Albums", "/albums", func() *TaggedSecretAlbum { return &TaggedSecretAlbum{} }).
+			SetLogBodies(true).
+			Router()
+		require.NoError(t, err)
+		post(t, router)
+
+		output := logs.String()
+		require.Contains(t, output, `msg="received request body"`)
+		require.Contains(t, output, `\"password\":\"[REDACTED]\"`)
+		require.NotContains(t, output, "hunter2")
+	})
+
+	t.Run("LogValuerIsUsed", func(t *testing.T) {
+		logs.Reset()
+
+		router, err := babyapi.NewAPI("Albums", "/albums", func() *LogValuerAlbum { return &LogValuerAlbum{} }).Router()
+		require.NoError(t, err)
+		post(t, router)
+
+		output := logs.String()
+		require.Contains(t, output, "body.title=Album")
+		require.NotContains(t, output, "hunter2")
+	})
+}
+
+func TestSetDeleteResponseMode(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+		SetDeleteResponseMode(babyapi.DeleteResponseFullBody)
+
+	client, stop := babytest.NewTestClient(t, api)
+	defer stop()
+
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+	_, err := client.Put(context.Background(), album)
+	require.NoError(t, err)
+
+	t.Run("ResponseHasDeletedResource", func(t *testing.T) {
+		resp, err := client.Delete(context.Background(), album.GetID())
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.Response.StatusCode)
+		require.Equal(t, album.GetID(), resp.Data.GetID())
+		require.Equal(t, "Album", resp.Data.Title)
+
+		_, err = api.Storage.Get(context.Background(), album.GetID())
+		require.ErrorIs(t, err, babyapi.ErrNotFound)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := client.Delete(context.Background(), album.GetID())
+		require.Error(t, err)
+
+		var httpErr *babyapi.ErrResponse
+		require.ErrorAs(t, err, &httpErr)
+		require.Equal(t, http.StatusNotFound, httpErr.HTTPStatusCode)
+	})
+
+	t.Run("NoContentByDefault", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetDeleteResponseMode(babyapi.DeleteResponseFullBody).
+			SetDeleteResponseMode(babyapi.DeleteResponseNoContent)
+
+		client, stop := babytest.NewTestClient(t, api)
+		defer stop()
+
+		_, err := client.Put(context.Background(), album)
+		require.NoError(t, err)
+
+		resp, err := client.Delete(context.Background(), album.GetID())
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNoContent, resp.Response.StatusCode)
+		require.Empty(t, resp.Body)
+	})
+
+	t.Run("CustomResponseCodeIsKept", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetCustomResponseCode(http.MethodDelete, http.StatusAccepted).
+			SetDeleteResponseMode(babyapi.DeleteResponseFullBody)
+
+		client, stop := babytest.NewTestClient(t, api)
+		defer stop()
+
+		_, err := client.Put(context.Background(), album)
+		require.NoError(t, err)
+
+		resp, err := client.Delete(context.Background(), album.GetID())
+		require.NoError(t, err)
+		require.Equal(t, http.StatusAccepted, resp.Response.StatusCode)
+		require.Equal(t, "Album", resp.Data.Title)
+	})
+}
+
+type TimestampedAlbum struct {
+	babyapi.DefaultResource
+	Title     string    `json:"title"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (a *TimestampedAlbum) LastModified() time.Time {
+	return a.UpdatedAt
+}
+
+func TestLastModified(t *testing.T) {
+	updatedAt := time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)
+
+	api := babyapi.NewAPI("Albums", "/albums", func() *TimestampedAlbum { return &TimestampedAlbum{} }).
+		EnableETag()
+
+	album := &TimestampedAlbum{DefaultResource: babyapi.NewDefaultResource(), Title: "Album", UpdatedAt: updatedAt}
+	require.NoError(t, api.Storage.Set(context.Background(), album))
+
+	router, err := api.Router()
+	require.NoError(t, err)
+
+	do := func(method string, headers map[string]string) *httptest.ResponseRecorder {
+		body := io.Reader(http.NoBody)
+		if method == http.MethodPut {
+			body = strings.NewReader(`{"id":"` + album.GetID() + `","title":"New Title"}`)
+		}
+
+		r := httptest.NewRequest(method, "/albums/"+album.GetID(), body)
+		r.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	before := updatedAt.Add(-time.Hour).Format(http.TimeFormat)
+	same := updatedAt.Format(http.TimeFormat)
+
+	tests := []struct {
+		name         string
+		method       string
+		headers      map[string]string
+		expectedCode int
+	}{
+		{"GetSetsLastModified", http.MethodGet, nil, http.StatusOK},
+		{"GetNotModified", http.MethodGet, map[string]string{"If-Modified-Since": same}, http.StatusNotModified},
+		{"GetModified", http.MethodGet, map[string]string{"If-Modified-Since": before}, http.StatusOK},
+		{"GetInvalidDateIgnored", http.MethodGet, map[string]string{"If-Modified-Since": "yesterday"}, http.StatusOK},
+		{"GetIfNoneMatchTakesPrecedence", http.MethodGet, map[string]string{"If-Modified-Since": same, "If-None-Match": `W/"other"`}, http.StatusOK},
+		{"GetUnmodifiedSinceFails", http.MethodGet, map[string]string{"If-Unmodified-Since": before}, http.StatusPreconditionFailed},
+		{"PutModifiedSinceFails", http.MethodPut, map[string]string{"If-Unmodified-Since": before}, http.StatusPreconditionFailed},
+		{"DeleteModifiedSinceFails", http.MethodDelete, map[string]string{"If-Unmodified-Since": before}, http.StatusPreconditionFailed},
+		{"PutUnmodified", http.MethodPut, map[string]string{"If-Unmodified-Since": same}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(tt.method, tt.headers)
+			require.Equal(t, tt.expectedCode, w.Code, w.Body.String())
+
+			if tt.method == http.MethodGet && tt.expectedCode != http.StatusPreconditionFailed {
+				require.Equal(t, same, w.Header().Get("Last-Modified"))
+			}
+			if tt.expectedCode == http.StatusNotModified {
+				require.Empty(t, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("DisabledWithoutETag", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *TimestampedAlbum { return &TimestampedAlbum{} })
+		require.NoError(t, api.Storage.Set(context.Background(), album))
+
+		router, err := api.Router()
+		require.NoError(t, err)
+
+		r := httptest.NewRequest(http.MethodGet, "/albums/"+album.GetID(), http.NoBody)
+		r.Header.Set("If-Modified-Since", same)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Empty(t, w.Header().Get("Last-Modified"))
+	})
+}
+
+type DatedAlbum struct {
+	babyapi.DefaultResource
+	Title       string     `json:"title"`
+	Artist      string     `json:"artist"`
+	ReleaseDate time.Time  `json:"release_date"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	Tracks      []string   `json:"tracks"`
+}
+
+func TestJSONOptions(t *testing.T) {
+	releaseDate := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	album := &DatedAlbum{DefaultResource: babyapi.NewDefaultResource(), Title: "Album", ReleaseDate: releaseDate, UpdatedAt: &releaseDate}
+
+	get := func(t *testing.T, api *babyapi.API[*DatedAlbum], target string) string {
+		require.NoError(t, api.Storage.Set(context.Background(), album))
+
+		router, err := api.Router()
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, http.NoBody))
+		require.Equal(t, http.StatusOK, w.Code)
+		return strings.TrimSpace(w.Body.String())
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *DatedAlbum { return &DatedAlbum{} })
+		body := get(t, api, "/albums/"+album.GetID())
+		require.Equal(t, `{"id":"`+album.GetID()+`","title":"Album","artist":"","release_date":"2024-01-02T03:04:05Z","updated_at":"2024-01-02T03:04:05Z","tracks":null}`, body)
+	})
+
+	t.Run("TimeFormat", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *DatedAlbum { return &DatedAlbum{} }).
+			SetTimeFormat(time.DateOnly)
+		body := get(t, api, "/albums/"+album.GetID())
+		require.Equal(t, `{"id":"`+album.GetID()+`","title":"Album","artist":"","release_date":"2024-01-02","updated_at":"2024-01-02","tracks":null}`, body)
+	})
+
+	t.Run("OmitEmpty", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *DatedAlbum { return &DatedAlbum{} }).
+			SetOmitEmpty(true)
+		body := get(t, api, "/albums/"+album.GetID())
+		require.Equal(t, `{"id":"`+album.GetID()+`","title":"Album","release_date":"2024-01-02T03:04:05Z","updated_at":"2024-01-02T03:04:05Z"}`, body)
+	})
+
+	t.Run("GetAll", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *DatedAlbum { return &DatedAlbum{} }).
+			SetTimeFormat(time.DateOnly).
+			SetOmitEmpty(true)
+		body := get(t, api, "/albums")
+		require.Equal(t, `{"items":[{"id":"`+album.GetID()+`","title":"Album","release_date":"2024-01-02","updated_at":"2024-01-02"}]}`, body)
+	})
+
+	t.Run("ErrorResponse", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *DatedAlbum { return &DatedAlbum{} }).
+			SetOmitEmpty(true)
+
+		router, err := api.Router()
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/albums/missing", http.NoBody))
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, `{"status":"Resource not found."}`, strings.TrimSpace(w.Body.String()))
+	})
+}
+
+type FullTextItem struct {
+	babyapi.DefaultResource
+	Name     string            `json:"name"`
+	Notes    *string           `json:"notes"`
+	Tags     []string          `json:"tags"`
+	Details  FullTextDetails   `json:"details"`
+	Ignored  string            `json:"-"`
+	Metadata map[string]string `json:"metadata"`
+	Password string            `json:"password,omitempty" babyapi:"redact"`
+	private  string
+}
+
+type FullTextDetails struct {
+	Location string `json:"location"`
+}
+
+func TestFullTextFilter(t *testing.T) {
+	notes := "Bring SNACKS"
+	first := &FullTextItem{DefaultResource: babyapi.NewDefaultResource(), Name: "Party", Notes: &notes, Tags: []string{"fun"}, Password: "hunter2", private: "secret"}
+	second := &FullTextItem{DefaultResource: babyapi.NewDefaultResource(), Name: "Meeting", Details: FullTextDetails{Location: "Office"}, Ignored: "party"}
+	items := []*FullTextItem{first, second}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []*FullTextItem
+	}{
+		{"EmptyQuery", "", items},
+		{"WhitespaceQuery", "  ", items},
+		{"Field", "meet", []*FullTextItem{second}},
+		{"IgnoresCase", "PARTY", []*FullTextItem{first}},
+		{"Pointer", "snacks", []*FullTextItem{first}},
+		{"Slice", "fun", []*FullTextItem{first}},
+		{"NestedStruct", "office", []*FullTextItem{second}},
+		{"UnexportedFieldIgnored", "secret", []*FullTextItem{}},
+		{"RedactedFieldIgnored", "hunter2", []*FullTextItem{}},
+		{"NoMatch", "nothing", []*FullTextItem{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, babyapi.FullTextFilter[*FullTextItem](tt.query).Filter(items))
+		})
+	}
+
+	t.Run("EnableFullTextSearch", func(t *testing.T) {
+		api := babyapi.NewAPI("Items", "/items", func() *FullTextItem { return &FullTextItem{} })
+		api.EnableFullTextSearch()
+
+		for _, item := range items {
+			err := api.Storage.Set(context.Background(), item)
+			require.NoError(t, err)
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/items?q=office", http.NoBody)
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Equal(t, `{"items":[{"id":"`+second.GetID()+`","name":"Meeting","notes":null,"tags":null,"details":{"location":"Office"},"metadata":null}]}`, strings.TrimSpace(w.Body.String()))
+	})
+}
+
+func TestNameFormatter(t *testing.T) {
+	t.Run("Singular", func(t *testing.T) {
+		tests := map[string]string{
+			"Songs":      "Song",
+			"TODOs":      "TODO",
+			"Categories": "Category",
+			"CATEGORIES": "CATEGORY",
+			"Boxes":      "Box",
+			"Addresses":  "Address",
+			"Status":     "Status",
+			"Music":      "Music",
+			"Movies":     "Movie",
+			"MOVIES":     "MOVIE",
+			"UserMovies": "UserMovie",
+			"Copies":     "Copy",
+			"Caches":     "Cache",
+			"Matches":    "Match",
+			"Statuses":   "Status",
+			"Buses":      "Bus",
+			"Houses":     "House",
+			"Excuses":    "Excuse",
+			"Sizes":      "Size",
+			"Aliases":    "Alias",
+			"Series":     "Series",
+			"TVSeries":   "TVSeries",
+			"user_pies":  "user_pie",
+		}
+		for name, expected := range tests {
+			require.Equal(t, expected, babyapi.Singular(name), name)
+		}
+	})
+
+	t.Run("Plural", func(t *testing.T) {
+		tests := map[string]string{
+			"Song":     "Songs",
+			"TODO":     "TODOs",
+			"Category": "Categories",
+			"Day":      "Days",
+			"Box":      "Boxes",
+			"Address":  "Addresses",
+			"Match":    "Matches",
+		}
+		for name, expected := range tests {
+			require.Equal(t, expected, babyapi.Plural(name), name)
+		}
+	})
+
+	t.Run("ClientMapAndParentFlags", func(t *testing.T) {
+		artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
+		artistAPI.SetNameFormatter(func(name string) string {
+			return strings.ToLower(babyapi.Singular(name))
+		})
+		albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
+		songAPI.SetNameFormatter(strings.ToUpper)
+
+		artistAPI.AddNestedAPI(albumAPI.AddNestedAPI(songAPI))
+
+		require.Equal(t, "Albums", albumAPI.Name())
+		require.Equal(t, "album", albumAPI.DisplayName())
+		require.Equal(t, "SONGS", songAPI.DisplayName())
+		require.Equal(t, babyapi.IDParamKey("Songs"), songAPI.IDParamKey())
+
+		clientMap := artistAPI.CreateClientMap(artistAPI.AnyClient("http://localhost:8080"))
+		names := []string{}
+		for name := range clientMap {
+			names = append(names, name)
+		}
+		require.ElementsMatch(t, []string{"artist", "album", "SONGS"}, names)
+
+		out, err := runCommand(artistAPI.Command(), []string{"client", "SONGS", "--help"})
+		require.NoError(t, err)
+		require.Contains(t, out, "--artist-id")
+		require.Contains(t, out, "--album-id")
+	})
+}
+
+func TestAddComputedField(t *testing.T) {
+	album := &DatedAlbum{
+		DefaultResource: babyapi.NewDefaultResource(),
+		Title:           "Album",
+		ReleaseDate:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Tracks:          []string{"One", "Two"},
+	}
+
+	newAPI := func() *babyapi.API[*DatedAlbum] {
+		api := babyapi.NewAPI("Albums", "/albums", func() *DatedAlbum { return &DatedAlbum{} })
+		api.AddComputedField("track_count", func(_ *http.Request, a *DatedAlbum) any {
+			return len(a.Tracks)
+		})
+		api.AddComputedField("requested_by", func(r *http.Request, _ *DatedAlbum) any {
+			return r.URL.Query().Get("user")
+		})
+		require.NoError(t, api.Storage.Set(context.Background(), album))
+		return api
+	}
+
+	get := func(t *testing.T, api *babyapi.API[*DatedAlbum], target string) string {
+		w := babytest.TestRequest(t, api, httptest.NewRequest(http.MethodGet, target, http.NoBody))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		return strings.TrimSpace(w.Body.String())
+	}
+
+	expected := `{"id":"` + album.GetID() + `","title":"Album","artist":"","release_date":"2024-01-02T03:04:05Z","tracks":["One","Two"],"track_count":2,"requested_by":"user1"}`
+
+	t.Run("Get", func(t *testing.T) {
+		require.Equal(t, expected, get(t, newAPI(), "/albums/"+album.GetID()+"?user=user1"))
+	})
+
+	t.Run("GetAll", func(t *testing.T) {
+		require.Equal(t, `{"items":[`+expected+`]}`, get(t, newAPI(), "/albums?user=user1"))
+	})
+
+	t.Run("ReplacesExistingField", func(t *testing.T) {
+		api := newAPI().AddComputedField("title", func(_ *http.Request, a *DatedAlbum) any {
+			return strings.ToUpper(a.Title)
+		})
+		body := get(t, api, "/albums/"+album.GetID()+"?user=user1")
+		require.Equal(t, strings.Replace(expected, `"title":"Album"`, `"title":"ALBUM"`, 1), body)
+	})
+
+	t.Run("WithJSONOptions", func(t *testing.T) {
+		api := newAPI().SetTimeFormat(time.DateOnly).SetOmitEmpty(true)
+		body := get(t, api, "/albums/"+album.GetID())
+		require.Equal(t, `{"id":"`+album.GetID()+`","title":"Album","release_date":"2024-01-02","tracks":["One","Two"],"track_count":2,"requested_by":""}`, body)
+	})
+
+	t.Run("DecodedByClient", func(t *testing.T) {
+		client, stop := babytest.NewTestClient(t, newAPI())
+		defer stop()
+
+		result, err := client.Get(context.Background(), album.GetID())
+		require.NoError(t, err)
+		require.Equal(t, album.Tracks, result.Data.Tracks)
+	})
+
+	t.Run("ErrorEmptyName", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *DatedAlbum { return &DatedAlbum{} })
+		api.AddComputedField("", func(*http.Request, *DatedAlbum) any { return nil })
+
+		_, err := api.Router()
+		require.EqualError(t, err, "encountered 1 errors constructing API:\n- AddComputedField: name must not be empty\n")
+	})
+}
+
+func TestEnableBulkPatch(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).EnableBulkPatch()
+
+	updated := []string{}
+	api.SetOnCreateOrUpdate(func(_ *http.Request, album *Album) *babyapi.ErrResponse {
+		if album.Title == "Invalid" {
+			return babyapi.ErrInvalidRequest(errors.New("invalid title"))
+		}
+		updated = append(updated, album.GetID())
+		return nil
+	})
+
+	client, stop := babytest.NewTestClient(t, api)
+	defer stop()
+
+	album1, err := client.Post(context.Background(), &Album{Title: "Album1"})
+	require.NoError(t, err)
+	album2, err := client.Post(context.Background(), &Album{Title: "Album2"})
+	require.NoError(t, err)
+	updated = []string{}
+
+	t.Run("Successful", func(t *testing.T) {
+		resp, err := client.BulkPatch(context.Background(), map[string]any{
+			album1.Data.GetID(): map[string]any{"title": "New Album1"},
+			album2.Data.GetID(): &Album{Title: "New Album2"},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Data.Items, 2)
+
+		for _, item := range resp.Data.Items {
+			require.Equal(t, http.StatusOK, item.Status)
+			require.Nil(t, item.Error)
+			require.Equal(t, item.ID, item.Resource.GetID())
+		}
+		require.ElementsMatch(t, []string{album1.Data.GetID(), album2.Data.GetID()}, updated)
+
+		stored, err := client.Get(context.Background(), album1.Data.GetID())
+		require.NoError(t, err)
+		require.Equal(t, "New Album1", stored.Data.Title)
+	})
+
+	t.Run("PerItemErrors", func(t *testing.T) {
+		body := `{"` + album1.Data.GetID() + `":{"title":"Album1"},"missing":{"title":"Missing"},"` + album2.Data.GetID() + `":{"title":"Invalid"}}`
+		r := httptest.NewRequest(http.MethodPatch, "/albums", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+
+		w := babytest.TestRequest(t, api, r)
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+		var resp babyapi.BulkPatchResponse[*Album]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Items, 3)
+
+		require.Equal(t, album1.Data.GetID(), resp.Items[0].ID)
+		require.Equal(t, http.StatusOK, resp.Items[0].Status)
+		require.Equal(t, "Album1", resp.Items[0].Resource.Title)
+
+		require.Equal(t, "missing", resp.Items[1].ID)
+		require.Equal(t, http.StatusNotFound, resp.Items[1].Status)
+		require.Nil(t, resp.Items[1].Resource)
+		require.Equal(t, "Resource not found.", resp.Items[1].Error.StatusText)
+
+		require.Equal(t, album2.Data.GetID(), resp.Items[2].ID)
+		require.Equal(t, http.StatusBadRequest, resp.Items[2].Status)
+		require.Equal(t, "invalid title", resp.Items[2].Error.ErrorText)
+	})
+
+	t.Run("ErrorBodyNotObject", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPatch, "/albums", strings.NewReader(`[]`))
+		r.Header.Set("Content-Type", "application/json")
+
+		w := babytest.TestRequest(t, api, r)
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		require.Equal(t, `{"status":"Invalid request.","error":"error decoding request body: expected a JSON object"}`, strings.TrimSpace(w.Body.String()))
+	})
+
+	t.Run("ErrorRootAPI", func(t *testing.T) {
+		_, err := babyapi.NewRootAPI("root", "/").EnableBulkPatch().Router()
+		require.EqualError(t, err, "encountered 1 errors constructing API:\n- EnableBulkPatch: bulk PATCH cannot be used with a root API\n")
+	})
+}
+
+func TestClientGeneratedIDs(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+	client, stop := babytest.NewTestClient(t, api)
+	defer stop()
+	client.UseClientGeneratedIDs()
+
+	t.Run("RetryDoesNotCreateDuplicate", func(t *testing.T) {
+		album := &Album{Title: "Album"}
+
+		resp, err := client.Post(context.Background(), album)
+		require.NoError(t, err)
+		require.NotEmpty(t, album.GetID())
+		require.Equal(t, album.GetID(), resp.Data.GetID())
+
+		resp, err = client.Post(context.Background(), album)
+		require.NoError(t, err)
+		require.Equal(t, album.GetID(), resp.Data.GetID())
+
+		albums, err := client.GetAll(context.Background(), "")
+		require.NoError(t, err)
+		require.Len(t, albums.Data.Items, 1)
+	})
+
+	t.Run("PostRawUsesIDFromBody", func(t *testing.T) {
+		id := babyapi.NewID().String()
+
+		resp, err := client.PostRaw(context.Background(), `{"id":"`+id+`","title":"Raw"}`)
+		require.NoError(t, err)
+		require.Equal(t, id, resp.Data.GetID())
+		require.Equal(t, "Raw", resp.Data.Title)
+	})
+
+	t.Run("FallbackToPostWithoutPut", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			DisableMethods(http.MethodPut)
+
+		client, stop := babytest.NewTestClient(t, api)
+		defer stop()
+		client.UseClientGeneratedIDs()
+
+		resp, err := client.PostRaw(context.Background(), `{"title":"Album"}`)
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.Data.GetID())
+		require.Equal(t, "Album", resp.Data.Title)
+	})
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	var attempts atomic.Int32
+	var bodies []string
+	var retryAfter string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		if attempts.Add(1) < 3 {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"status":"Too many requests."}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"cljcqg5o402e9s28rbp0","title":"Album"}`))
+	}))
+	defer server.Close()
+
+	reset := func(value string) {
+		attempts.Store(0)
+		bodies = nil
+		retryAfter = value
+	}
+
+	t.Run("RetriesWithBody", func(t *testing.T) {
+		reset("0")
+		client := babyapi.NewClient[*Album](server.URL, "/albums").SetRetryAfter(3, time.Second)
+
+		resp, err := client.PatchRaw(context.Background(), "cljcqg5o402e9s28rbp0", `{"title":"Album"}`)
+		require.NoError(t, err)
+		require.Equal(t, "Album", resp.Data.Title)
+		require.Equal(t, []string{`{"title":"Album"}`, `{"title":"Album"}`, `{"title":"Album"}`}, bodies)
+	})
+
+	t.Run("HTTPDate", func(t *testing.T) {
+		reset(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+		client := babyapi.NewClient[*Album](server.URL, "/albums").SetRetryAfter(3, time.Second)
+
+		_, err := client.Get(context.Background(), "cljcqg5o402e9s28rbp0")
+		require.NoError(t, err)
+		require.EqualValues(t, 3, attempts.Load())
+	})
+
+	t.Run("MaxRetries", func(t *testing.T) {
+		reset("0")
+		client := babyapi.NewClient[*Album](server.URL, "/albums").SetRetryAfter(1, time.Second)
+
+		_, err := client.Get(context.Background(), "cljcqg5o402e9s28rbp0")
+		require.EqualError(t, err, "error getting resource: unexpected response with text: Too many requests.")
+		require.EqualValues(t, 2, attempts.Load())
+	})
+
+	t.Run("WaitLongerThanMaxWait", func(t *testing.T) {
+		reset("120")
+		client := babyapi.NewClient[*Album](server.URL, "/albums").
+			SetHTTPClient(&http.Client{}).
+			SetRetryAfter(3, time.Second)
+
+		_, err := client.Get(context.Background(), "cljcqg5o402e9s28rbp0")
+		require.Error(t, err)
+		require.EqualValues(t, 1, attempts.Load())
+
+		var httpErr *babyapi.ErrResponse
+		require.ErrorAs(t, err, &httpErr)
+		require.Equal(t, http.StatusTooManyRequests, httpErr.HTTPStatusCode)
+		require.Equal(t, 120*time.Second, httpErr.RetryAfter)
+	})
+
+	t.Run("NotEnabled", func(t *testing.T) {
+		reset("0")
+		client := babyapi.NewClient[*Album](server.URL, "/albums")
+
+		_, err := client.Get(context.Background(), "cljcqg5o402e9s28rbp0")
+		require.Error(t, err)
+		require.EqualValues(t, 1, attempts.Load())
+	})
+}
+
+func TestWithSnapshot(t *testing.T) {
+	filename := t.TempDir() + "/albums.json"
+	album1 := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album1"}
+
+	t.Run("PeriodicSnapshot", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			WithSnapshot(filename, 10*time.Millisecond)
+
+		client, stop := babytest.NewTestClient(t, api)
+		defer stop()
+
+		_, err := client.Put(context.Background(), album1)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			data, err := os.ReadFile(filename)
+			return err == nil && len(data) > 2
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("LoadedOnStartup", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			WithSnapshot(filename, 0)
+
+		album, err := api.Storage.Get(context.Background(), album1.GetID())
+		require.NoError(t, err)
+		require.Equal(t, album1, album)
+
+		require.NoError(t, api.Storage.Set(context.Background(), &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album2"}))
+
+		go func() {
+			_ = api.Serve("localhost:0")
+		}()
+		api.Stop()
+
+		api = babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			WithSnapshot(filename, 0)
+		albums, err := api.Storage.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.Len(t, albums, 2)
+	})
+
+	t.Run("ErrorInvalidFile", func(t *testing.T) {
+		invalid := t.TempDir() + "/invalid.json"
+		require.NoError(t, os.WriteFile(invalid, []byte("not json"), 0o600))
+
+		_, err := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			WithSnapshot(invalid, 0).
+			Router()
+		require.ErrorContains(t, err, "WithSnapshot: error decoding snapshot file")
+	})
+}
+
+func TestSeedStorage(t *testing.T) {
+	album1 := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album1"}
+	album2 := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album2"}
+
+	t.Run("SeedStorage", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+		err := babyapi.SeedStorage(context.Background(), api.Storage, album1, album2)
+		require.NoError(t, err)
+
+		albums, err := api.Storage.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*Album{album1, album2}, albums)
+	})
+
+	t.Run("SeedArray", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+		count, err := api.Seed(context.Background(), []byte(`[{"id":"cljcqg5o402e9s28rbp0","title":"Album1"}]`), false)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		album, err := api.Storage.Get(context.Background(), "cljcqg5o402e9s28rbp0")
+		require.NoError(t, err)
+		require.Equal(t, "Album1", album.Title)
+	})
+
+	t.Run("SeedNestedAPIs", func(t *testing.T) {
+		artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
+		albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		artistAPI.AddNestedAPI(albumAPI)
+
+		count, err := artistAPI.Seed(context.Background(), []byte(`{
+			"Artists": [{"id":"cljcqg5o402e9s28rbp0","name":"Artist1"}],
+			"Albums": [{"id":"cljcqg5o402e9s28rbpg","title":"Album1"},{"id":"cljcqg5o402e9s28rbq0","title":"Album2"}]
+		}`), false)
+		require.NoError(t, err)
+		require.Equal(t, 3, count)
+
+		artist, err := artistAPI.Storage.Get(context.Background(), "cljcqg5o402e9s28rbp0")
+		require.NoError(t, err)
+		require.Equal(t, "Artist1", artist.Name)
+
+		albums, err := albumAPI.Storage.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.Len(t, albums, 2)
+	})
+
+	t.Run("BindGeneratesID", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+		count, err := api.Seed(context.Background(), []byte(`[{"title":"Album1"}]`), true)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		albums, err := api.Storage.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.Len(t, albums, 1)
+		require.NotEmpty(t, albums[0].GetID())
+		require.Equal(t, "Album1", albums[0].Title)
+	})
+
+	t.Run("ErrorMissingIDWithoutBind", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+		_, err := api.Seed(context.Background(), []byte(`[{"title":"Album1"}]`), false)
+		require.EqualError(t, err, "error decoding resource 0: missing required id field")
+
+		albums, err := api.Storage.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.Empty(t, albums)
+	})
+
+	t.Run("ErrorUnknownAPI", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+		_, err := api.Seed(context.Background(), []byte(`{"Songs":[]}`), false)
+		require.EqualError(t, err, `unknown API "Songs" in seed data`)
+	})
+
+	t.Run("CLI", func(t *testing.T) {
+		dir := t.TempDir()
+		seedFile := dir + "/data.json"
+		snapshotFile := dir + "/albums.json"
+		require.NoError(t, os.WriteFile(seedFile, []byte(`[{"id":"cljcqg5o402e9s28rbp0","title":"Album1"},{"title":"Album2"}]`), 0o600))
+
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			WithSnapshot(snapshotFile, 0)
+
+		out, err := runCommand(api.Command(), []string{"seed", "--file", seedFile, "--bind"})
+		require.NoError(t, err)
+		require.Equal(t, "seeded 2 resources\n", out)
+
+		// Storage is closed after seeding, so the snapshot has the resources
+		api = babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			WithSnapshot(snapshotFile, 0)
+		albums, err := api.Storage.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.Len(t, albums, 2)
+	})
+}
+
+func TestDryRun(t *testing.T) {
+	var afterCalls atomic.Int32
+	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+		EnableDryRun().
+		EnableBulkPatch().
+		SetOnCreateOrUpdate(func(_ *http.Request, album *Album) *babyapi.ErrResponse {
+			if album.Title == "bad" {
+				return babyapi.ErrInvalidRequest(fmt.Errorf("invalid title"))
+			}
+			return nil
+		}).
+		SetAfterCreateOrUpdate(func(*http.Request, *Album) *babyapi.ErrResponse {
+			afterCalls.Add(1)
+			return nil
+		})
+
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+	require.NoError(t, api.Storage.Set(context.Background(), album))
+
+	client, stop := babytest.NewTestClient(t, api)
+	defer stop()
+	dryRunClient := client.DryRun()
+
+	requireUnchanged := func(t *testing.T) {
+		t.Helper()
+
+		albums, err := api.Storage.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.Equal(t, []*Album{album}, albums)
+		require.Zero(t, afterCalls.Load())
+	}
+
+	t.Run("Post", func(t *testing.T) {
+		resp, err := dryRunClient.Post(context.Background(), &Album{Title: "New Album"})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.Response.StatusCode)
+		require.Equal(t, "true", resp.Response.Header.Get(babyapi.DryRunHeader))
+		require.NotEmpty(t, resp.Data.GetID())
+		require.Equal(t, "New Album", resp.Data.Title)
+
+		requireUnchanged(t)
+	})
+
+	t.Run("PostValidationError", func(t *testing.T) {
+		_, err := dryRunClient.Post(context.Background(), &Album{Title: "bad"})
+		require.Error(t, err)
+
+		var errResp *babyapi.ErrResponse
+		require.ErrorAs(t, err, &errResp)
+		require.Equal(t, http.StatusBadRequest, errResp.HTTPStatusCode)
+
+		requireUnchanged(t)
+	})
+
+	t.Run("Put", func(t *testing.T) {
+		resp, err := dryRunClient.Put(context.Background(), &Album{DefaultResource: album.DefaultResource, Title: "Updated"})
+		require.NoError(t, err)
+		require.Equal(t, "Updated", resp.Data.Title)
+
+		requireUnchanged(t)
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+		resp, err := dryRunClient.Patch(context.Background(), album.GetID(), &Album{Title: "Patched"})
+		require.NoError(t, err)
+		require.Equal(t, "Patched", resp.Data.Title)
+
+		requireUnchanged(t)
+	})
+
+	t.Run("BulkPatch", func(t *testing.T) {
+		resp, err := dryRunClient.BulkPatch(context.Background(), map[string]any{album.GetID(): map[string]any{"title": "Patched"}})
+		require.NoError(t, err)
+		require.Equal(t, "true", resp.Response.Header.Get(babyapi.DryRunHeader))
+		require.Len(t, resp.Data.Items, 1)
+		require.Equal(t, "Patched", resp.Data.Items[0].Resource.Title)
+
+		requireUnchanged(t)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		_, err := dryRunClient.Delete(context.Background(), album.GetID())
+		require.NoError(t, err)
+
+		requireUnchanged(t)
+	})
+
+	t.Run("OriginalClientIsNotDryRun", func(t *testing.T) {
+		resp, err := client.Post(context.Background(), &Album{Title: "Stored"})
+		require.NoError(t, err)
+		require.Empty(t, resp.Response.Header.Get(babyapi.DryRunHeader))
+
+		_, err = api.Storage.Get(context.Background(), resp.Data.GetID())
+		require.NoError(t, err)
+		require.EqualValues(t, 1, afterCalls.Load())
+	})
+
+	t.Run("ErrorWhenNotEnabled", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+		client, stop := babytest.NewTestClient(t, api)
+		defer stop()
+
+		_, err := client.DryRun().Post(context.Background(), &Album{Title: "New Album"})
+		require.ErrorIs(t, err, babyapi.ErrDryRunNotConfirmed)
+	})
+}
+
+func TestSetStrictBinding(t *testing.T) {
+	newRequest := func(method, target, body string) *http.Request {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		return r
+	}
+
+	t.Run("UnknownFieldRejected", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetStrictBinding(true)
+
+		w := babytest.TestRequest(t, api, newRequest(http.MethodPost, "/albums", `{"titel":"New Album"}`))
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		require.Equal(t, `{"status":"Invalid request.","error":"json: unknown field \"titel\""}`, strings.TrimSpace(w.Body.String()))
+
+		albums, err := api.Storage.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.Empty(t, albums)
+	})
+
+	t.Run("KnownFieldsAllowed", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetStrictBinding(true)
+
+		w := babytest.TestRequest(t, api, newRequest(http.MethodPost, "/albums", `{"title":"New Album"}`))
+		require.Equal(t, http.StatusCreated, w.Result().StatusCode)
+	})
+
+	t.Run("PatchUnknownFieldRejected", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetStrictBinding(true)
+
+		album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+		require.NoError(t, api.Storage.Set(context.Background(), album))
+
+		w := babytest.TestRequest(t, api, newRequest(http.MethodPatch, "/albums/"+album.GetID(), `{"titel":"Patched"}`))
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+	})
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+
+		w := babytest.TestRequest(t, api, newRequest(http.MethodPost, "/albums", `{"titel":"New Album"}`))
+		require.Equal(t, http.StatusCreated, w.Result().StatusCode)
+	})
+
+	t.Run("NestedAPIInheritsAndOverrides", func(t *testing.T) {
+		artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} }).
+			SetStrictBinding(true)
+		albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} }).
+			SetStrictBinding(false)
+		artistAPI.AddNestedAPI(albumAPI).AddNestedAPI(songAPI)
+
+		artist := &Artist{DefaultResource: babyapi.NewDefaultResource(), Name: "Artist"}
+		require.NoError(t, artistAPI.Storage.Set(context.Background(), artist))
+
+		w := babytest.TestRequest(t, artistAPI, newRequest(http.MethodPost, "/artists/"+artist.GetID()+"/albums", `{"titel":"New Album"}`))
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+
+		w = babytest.TestRequest(t, artistAPI, newRequest(http.MethodPost, "/artists/"+artist.GetID()+"/songs", `{"titel":"New Song"}`))
+		require.Equal(t, http.StatusCreated, w.Result().StatusCode)
+	})
+}
+
+type Lyric struct {
+	babyapi.DefaultResource
+	TrackID string `json:"track_id"`
+	Line    string `json:"line"`
+}
+
+func (l *Lyric) ParentID() string {
+	return l.TrackID
+}
+
+func TestSetMaxExpandDepth(t *testing.T) {
+	newAPIs := func(depth int) *babyapi.API[*Album] {
+		albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetMaxExpandDepth(depth)
+		trackAPI := babyapi.NewAPI("Tracks", "/tracks", func() *Track { return &Track{} })
+		lyricAPI := babyapi.NewAPI("Lyrics", "/lyrics", func() *Lyric { return &Lyric{} })
+		songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
+		albumAPI.AddNestedAPI(trackAPI.AddNestedAPI(lyricAPI)).AddNestedAPI(songAPI)
+
+		album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+		require.NoError(t, albumAPI.Storage.Set(context.Background(), album))
+
+		track := &Track{DefaultResource: babyapi.NewDefaultResource(), AlbumID: album.GetID(), Title: "Track"}
+		otherTrack := &Track{DefaultResource: babyapi.NewDefaultResource(), AlbumID: "other", Title: "Other"}
+		require.NoError(t, trackAPI.Storage.Set(context.Background(), track))
+		require.NoError(t, trackAPI.Storage.Set(context.Background(), otherTrack))
+
+		lyric := &Lyric{DefaultResource: babyapi.NewDefaultResource(), TrackID: track.GetID(), Line: "La"}
+		require.NoError(t, lyricAPI.Storage.Set(context.Background(), lyric))
+
+		return albumAPI
+	}
+
+	getAlbum := func(t *testing.T, api *babyapi.API[*Album], query string) *httptest.ResponseRecorder {
+		albums, err := api.Storage.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.Len(t, albums, 1)
+
+		r := httptest.NewRequest(http.MethodGet, "/albums/"+albums[0].GetID()+"?"+query, http.NoBody)
+		return babytest.TestRequest(t, api, r)
+	}
+
+	t.Run("ExpandChildren", func(t *testing.T) {
+		api := newAPIs(1)
+
+		w := getAlbum(t, api, "expand=Tracks")
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+		var resp struct {
+			Title  string   `json:"title"`
+			Tracks []*Track `json:"Tracks"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Equal(t, "Album", resp.Title)
+		require.Len(t, resp.Tracks, 1)
+		require.Equal(t, "Track", resp.Tracks[0].Title)
+	})
+
+	t.Run("ExpandNestedChildren", func(t *testing.T) {
+		api := newAPIs(2)
+
+		w := getAlbum(t, api, "expand=Tracks.Lyrics")
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+		var resp struct {
+			Tracks []struct {
+				Title  string   `json:"title"`
+				Lyrics []*Lyric `json:"Lyrics"`
+			} `json:"Tracks"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Tracks, 1)
+		require.Len(t, resp.Tracks[0].Lyrics, 1)
+		require.Equal(t, "La", resp.Tracks[0].Lyrics[0].Line)
+	})
+
+	t.Run("ErrorDepthExceeded", func(t *testing.T) {
+		api := newAPIs(1)
+
+		w := getAlbum(t, api, "expand=Tracks,Tracks.Lyrics")
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		require.Equal(t, `{"status":"Invalid request.","error":"expand depth 2 exceeds the maximum of 1"}`, strings.TrimSpace(w.Body.String()))
+	})
+
+	t.Run("IgnoredByDefault", func(t *testing.T) {
+		api := newAPIs(0)
+
+		w := getAlbum(t, api, "expand=Tracks")
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.NotContains(t, w.Body.String(), "Tracks")
+		require.Contains(t, w.Body.String(), `"title":"Album"`)
+	})
+
+	t.Run("ErrorUnknownAPI", func(t *testing.T) {
+		api := newAPIs(1)
+
+		w := getAlbum(t, api, "expand=Concerts")
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		require.Equal(t, `{"status":"Invalid request.","error":"unknown nested API \"Concerts\" in expand"}`, strings.TrimSpace(w.Body.String()))
+	})
+
+	t.Run("ErrorInvalidPath", func(t *testing.T) {
+		api := newAPIs(2)
+
+		w := getAlbum(t, api, "expand=Tracks..Lyrics")
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+	})
+
+	t.Run("ErrorChildWithoutParentID", func(t *testing.T) {
+		api := newAPIs(1)
+
+		w := getAlbum(t, api, "expand=Songs")
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+	})
+
+	t.Run("ErrorNegativeDepth", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetMaxExpandDepth(-1)
+
+		_, err := api.Router()
+		require.EqualError(t, err, "encountered 1 errors constructing API:\n- SetMaxExpandDepth: depth must not be negative\n")
+	})
+}
+
+func TestEnablePartialPut(t *testing.T) {
+	api := babyapi.NewAPI("Albums", "/albums", func() *DatedAlbum { return &DatedAlbum{} }).
+		EnablePartialPut()
+
+	album := &DatedAlbum{DefaultResource: babyapi.NewDefaultResource(), Title: "Album", Artist: "Artist", Tracks: []string{"One"}}
+	require.NoError(t, api.Storage.Set(context.Background(), album))
+
+	getETag := func(t *testing.T) string {
+		t.Helper()
+
+		w := babytest.TestRequest(t, api, httptest.NewRequest(http.MethodGet, "/albums/"+album.GetID(), http.NoBody))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+		etag := w.Result().Header.Get("ETag")
+		require.NotEmpty(t, etag)
+		return etag
+	}
+
+	put := func(t *testing.T, ifMatch, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		r := httptest.NewRequest(http.MethodPut, "/albums/"+album.GetID(), strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			r.Header.Set("If-Match", ifMatch)
+		}
+		return babytest.TestRequest(t, api, r)
+	}
+
+	getStored := func(t *testing.T) *DatedAlbum {
+		t.Helper()
+
+		stored, err := api.Storage.Get(context.Background(), album.GetID())
+		require.NoError(t, err)
+		return stored
+	}
+
+	t.Run("MergeWithMatchingETag", func(t *testing.T) {
+		etag := getETag(t)
+
+		w := put(t, etag, fmt.Sprintf(`{"id":%q,"title":"New Title"}`, album.GetID()))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+		stored := getStored(t)
+		require.Equal(t, "New Title", stored.Title)
+		require.Equal(t, "Artist", stored.Artist)
+		require.Equal(t, []string{"One"}, stored.Tracks)
+	})
+
+	t.Run("ErrorWithOldETag", func(t *testing.T) {
+		etag := getETag(t)
+
+		w := put(t, etag, fmt.Sprintf(`{"id":%q,"artist":"Other Artist"}`, album.GetID()))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+		w = put(t, etag, fmt.Sprintf(`{"id":%q,"title":"Stale"}`, album.GetID()))
+		require.Equal(t, http.StatusPreconditionFailed, w.Result().StatusCode)
+
+		stored := getStored(t)
+		require.Equal(t, "New Title", stored.Title)
+		require.Equal(t, "Other Artist", stored.Artist)
+	})
+
+	t.Run("ErrorWhenResourceDoesNotExist", func(t *testing.T) {
+		id := babyapi.NewID().String()
+
+		r := httptest.NewRequest(http.MethodPut, "/albums/"+id, strings.NewReader(fmt.Sprintf(`{"id":%q,"title":"New"}`, id)))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("If-Match", "*")
+		w := babytest.TestRequest(t, api, r)
+		require.Equal(t, http.StatusPreconditionFailed, w.Result().StatusCode)
+
+		_, err := api.Storage.Get(context.Background(), id)
+		require.ErrorIs(t, err, babyapi.ErrNotFound)
+	})
+
+	t.Run("WithoutIfMatchReplaces", func(t *testing.T) {
+		w := put(t, "", fmt.Sprintf(`{"id":%q,"title":"Replaced"}`, album.GetID()))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+		stored := getStored(t)
+		require.Equal(t, "Replaced", stored.Title)
+		require.Empty(t, stored.Artist)
+		require.Empty(t, stored.Tracks)
+	})
+}
+
+func TestSetNotFoundResponse(t *testing.T) {
+	t.Run("CustomResponse", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetNotFoundResponse(babyapi.ErrForbidden)
+
+		for _, method := range []string{http.MethodGet, http.MethodPatch, http.MethodDelete} {
+			t.Run(method, func(t *testing.T) {
+				r := httptest.NewRequest(method, "/albums/"+babyapi.NewID().String(), strings.NewReader(`{"title":"New"}`))
+				r.Header.Set("Content-Type", "application/json")
+
+				w := babytest.TestRequest(t, api, r)
+				require.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+				require.Equal(t, `{"status":"Forbidden"}`, strings.TrimSpace(w.Body.String()))
+			})
+		}
+	})
+
+	t.Run("PutStillCreates", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetNotFoundResponse(babyapi.ErrForbidden)
+
+		id := babyapi.NewID().String()
+		r := httptest.NewRequest(http.MethodPut, "/albums/"+id, strings.NewReader(fmt.Sprintf(`{"id":%q,"title":"New"}`, id)))
+		r.Header.Set("Content-Type", "application/json")
+
+		w := babytest.TestRequest(t, api, r)
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+
+	t.Run("NestedAPIParent", func(t *testing.T) {
+		artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} }).
+			SetNotFoundResponse(&babyapi.ErrResponse{HTTPStatusCode: http.StatusNotFound, StatusText: "Artist not found."})
+		albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		artistAPI.AddNestedAPI(albumAPI)
+
+		r := httptest.NewRequest(http.MethodGet, "/artists/"+babyapi.NewID().String()+"/albums", http.NoBody)
+		w := babytest.TestRequest(t, artistAPI, r)
+		require.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+		require.Equal(t, `{"status":"Artist not found."}`, strings.TrimSpace(w.Body.String()))
+	})
+
+	t.Run("FileField", func(t *testing.T) {
+		api := babyapi.NewAPI("Attachments", "/attachments", func() *Attachment { return &Attachment{} }).
+			EnableFileField("file", babyapi.NewKVBlobStore(kv.NewDefaultDB(), "Attachments")).
+			SetNotFoundResponse(babyapi.ErrForbidden)
+
+		attachment := &Attachment{DefaultResource: babyapi.NewDefaultResource(), Name: "No File"}
+		require.NoError(t, api.Storage.Set(context.Background(), attachment))
+
+		w := babytest.TestRequest(t, api, httptest.NewRequest(http.MethodGet, "/attachments/"+attachment.GetID()+"/file", http.NoBody))
+		require.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+		require.Equal(t, `{"status":"Forbidden"}`, strings.TrimSpace(w.Body.String()))
+	})
+
+	t.Run("ErrorNil", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetNotFoundResponse(nil)
+
+		_, err := api.Router()
+		require.EqualError(t, err, "encountered 1 errors constructing API:\n- SetNotFoundResponse: response must not be nil\n")
+	})
+}
+
+func TestSetHideUnauthorized(t *testing.T) {
+	newAPI := func(hide bool) (*babyapi.API[*Album], *Album) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetHideUnauthorized(hide)
+		api.AddIDMiddleware(api.GetRequestedResourceAndDoMiddleware(func(r *http.Request, album *Album) (*http.Request, *babyapi.ErrResponse) {
+			if r.URL.Query().Get("password") != "secret" {
+				return r, babyapi.ErrForbidden
+			}
+			return r, nil
+		}))
+
+		album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+		require.NoError(t, api.Storage.Set(context.Background(), album))
+
+		return api, album
+	}
+
+	t.Run("ForbiddenIsHidden", func(t *testing.T) {
+		api, album := newAPI(true)
+
+		w := babytest.TestRequest(t, api, httptest.NewRequest(http.MethodGet, "/albums/"+album.GetID(), http.NoBody))
+		require.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+		require.Equal(t, `{"status":"Resource not found."}`, strings.TrimSpace(w.Body.String()))
+
+		// A missing resource has the same response
+		w = babytest.TestRequest(t, api, httptest.NewRequest(http.MethodGet, "/albums/"+babyapi.NewID().String(), http.NoBody))
+		require.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+		require.Equal(t, `{"status":"Resource not found."}`, strings.TrimSpace(w.Body.String()))
+	})
+
+	t.Run("AllowedRequest", func(t *testing.T) {
+		api, album := newAPI(true)
+
+		w := babytest.TestRequest(t, api, httptest.NewRequest(http.MethodGet, "/albums/"+album.GetID()+"?password=secret", http.NoBody))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+
+	t.Run("CustomNotFoundResponse", func(t *testing.T) {
+		api, album := newAPI(true)
+		api.SetNotFoundResponse(&babyapi.ErrResponse{HTTPStatusCode: http.StatusNotFound, StatusText: "Album not found."})
+
+		w := babytest.TestRequest(t, api, httptest.NewRequest(http.MethodGet, "/albums/"+album.GetID(), http.NoBody))
+		require.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+		require.Equal(t, `{"status":"Album not found."}`, strings.TrimSpace(w.Body.String()))
+	})
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		api, album := newAPI(false)
+
+		w := babytest.TestRequest(t, api, httptest.NewRequest(http.MethodGet, "/albums/"+album.GetID(), http.NoBody))
+		require.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+		require.Equal(t, `{"status":"Forbidden"}`, strings.TrimSpace(w.Body.String()))
+	})
+}
+
+func TestSetCORS(t *testing.T) {
+	artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} }).
+		SetCORS(babyapi.CORSOptions{
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{http.MethodGet},
+			ExposedHeaders: []string{"ETag"},
+			MaxAge:         10 * time.Minute,
+		}).
+		AddMiddleware(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") == "" {
+					_ = render.Render(w, r, babyapi.ErrForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+		})
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+		SetCORS(babyapi.CORSOptions{
+			AllowedOrigins:   []string{"https://example.com"},
+			AllowedHeaders:   []string{"Authorization", "Content-Type"},
+			AllowCredentials: true,
+		})
+	songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
+	artistAPI.AddNestedAPI(albumAPI.AddNestedAPI(songAPI))
+
+	artist := &Artist{DefaultResource: babyapi.NewDefaultResource(), Name: "Artist"}
+	require.NoError(t, artistAPI.Storage.Set(context.Background(), artist))
+
+	preflight := func(t *testing.T, path, origin, method string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		r := httptest.NewRequest(http.MethodOptions, path, http.NoBody)
+		r.Header.Set("Origin", origin)
+		r.Header.Set("Access-Control-Request-Method", method)
+		r.Header.Set("Access-Control-Request-Headers", "X-Custom")
+		return babytest.TestRequest(t, artistAPI, r)
+	}
+
+	t.Run("Preflight", func(t *testing.T) {
+		w := preflight(t, "/artists/"+artist.GetID(), "https://other.com", http.MethodGet)
+		require.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+		require.Equal(t, "*", w.Result().Header.Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "GET", w.Result().Header.Get("Access-Control-Allow-Methods"))
+		require.Equal(t, "X-Custom", w.Result().Header.Get("Access-Control-Allow-Headers"))
+		require.Equal(t, "600", w.Result().Header.Get("Access-Control-Max-Age"))
+		require.Empty(t, w.Result().Header.Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("PreflightMethodNotAllowed", func(t *testing.T) {
+		w := preflight(t, "/artists", "https://other.com", http.MethodDelete)
+		require.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+		require.Empty(t, w.Result().Header.Get("Access-Control-Allow-Methods"))
+	})
+
+	t.Run("ActualRequest", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/artists", http.NoBody)
+		r.Header.Set("Origin", "https://other.com")
+		r.Header.Set("Authorization", "token")
+
+		w := babytest.TestRequest(t, artistAPI, r)
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Equal(t, "*", w.Result().Header.Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "ETag", w.Result().Header.Get("Access-Control-Expose-Headers"))
+	})
+
+	t.Run("NestedAPIOverride", func(t *testing.T) {
+		w := preflight(t, "/artists/"+artist.GetID()+"/albums", "https://example.com", http.MethodPost)
+		require.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+		require.Equal(t, "https://example.com", w.Result().Header.Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "true", w.Result().Header.Get("Access-Control-Allow-Credentials"))
+		require.Equal(t, "GET, HEAD, POST, PUT, PATCH, DELETE", w.Result().Header.Get("Access-Control-Allow-Methods"))
+		require.Equal(t, "Authorization, Content-Type", w.Result().Header.Get("Access-Control-Allow-Headers"))
+		require.Empty(t, w.Result().Header.Get("Access-Control-Max-Age"))
+
+		w = preflight(t, "/artists/"+artist.GetID()+"/albums", "https://other.com", http.MethodGet)
+		require.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+		require.Empty(t, w.Result().Header.Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("NestedAPIInherits", func(t *testing.T) {
+		w := preflight(t, "/artists/"+artist.GetID()+"/albums/"+babyapi.NewID().String()+"/songs", "https://example.com", http.MethodPut)
+		require.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+		require.Equal(t, "https://example.com", w.Result().Header.Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "true", w.Result().Header.Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("ErrorNoOrigins", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetCORS(babyapi.CORSOptions{})
+
+		_, err := api.Router()
+		require.EqualError(t, err, "encountered 1 errors constructing API:\n- SetCORS: at least one allowed origin is required\n")
+	})
+}
+
+func TestSetResponseHeaders(t *testing.T) {
+	artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} }).
+		SetResponseHeaders(map[string]string{
+			"X-Content-Type-Options": "nosniff",
+			"cache-control":          "no-store",
+		})
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+		SetResponseHeaders(map[string]string{"Cache-Control": "max-age=60"})
+	artistAPI.AddNestedAPI(albumAPI)
+
+	artistAPI.AddCustomRoute(http.MethodGet, "/private", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "private")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	artist := &Artist{DefaultResource: babyapi.NewDefaultResource(), Name: "Artist"}
+	require.NoError(t, artistAPI.Storage.Set(context.Background(), artist))
+
+	t.Run("AllResponses", func(t *testing.T) {
+		w := babytest.TestRequest(t, artistAPI, httptest.NewRequest(http.MethodGet, "/artists/"+artist.GetID(), http.NoBody))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Equal(t, "nosniff", w.Result().Header.Get("X-Content-Type-Options"))
+		require.Equal(t, "no-store", w.Result().Header.Get("Cache-Control"))
+
+		w = babytest.TestRequest(t, artistAPI, httptest.NewRequest(http.MethodGet, "/artists/"+babyapi.NewID().String(), http.NoBody))
+		require.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+		require.Equal(t, "nosniff", w.Result().Header.Get("X-Content-Type-Options"))
+	})
+
+	t.Run("NestedAPIOverride", func(t *testing.T) {
+		w := babytest.TestRequest(t, artistAPI, httptest.NewRequest(http.MethodGet, "/artists/"+artist.GetID()+"/albums", http.NoBody))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Equal(t, "nosniff", w.Result().Header.Get("X-Content-Type-Options"))
+		require.Equal(t, []string{"max-age=60"}, w.Result().Header.Values("Cache-Control"))
+	})
+
+	t.Run("HandlerOverride", func(t *testing.T) {
+		w := babytest.TestRequest(t, artistAPI, httptest.NewRequest(http.MethodGet, "/artists/private", http.NoBody))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Equal(t, "private", w.Result().Header.Get("Cache-Control"))
+	})
+
+	t.Run("ErrorEmptyName", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetResponseHeaders(map[string]string{"": "value"})
+
+		_, err := api.Router()
+		require.EqualError(t, err, "encountered 1 errors constructing API:\n- SetResponseHeaders: header name must not be empty\n")
+	})
+}
+
+func TestGetAllStreaming(t *testing.T) {
+	newAPI := func(count int) (*babyapi.API[*Album], []*Album) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			EnablePagination(count, 0)
+
+		albums := []*Album{}
+		for i := 0; i < count; i++ {
+			album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: strings.Repeat("a", 100)}
+			require.NoError(t, api.Storage.Set(context.Background(), album))
+			albums = append(albums, album)
+		}
+		slices.SortFunc(albums, func(a, b *Album) int {
+			return strings.Compare(a.GetID(), b.GetID())
 		})
+
+		return api, albums
 	}
-}
 
-func TestReadOnlyPanicAfterStart(t *testing.T) {
-	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	expectedBody := func(t *testing.T, albums []*Album) string {
+		data, err := json.Marshal(struct {
+			Items []*Album `json:"items"`
+			Total int      `json:"total"`
+			Limit int      `json:"limit"`
+		}{albums, len(albums), len(albums)})
+		require.NoError(t, err)
+		return string(data) + "\n"
+	}
 
-	_, err := api.Router()
-	require.NoError(t, err)
+	t.Run("SmallListHasContentLength", func(t *testing.T) {
+		api, albums := newAPI(3)
 
-	require.PanicsWithError(t, "API cannot be modified after starting", func() {
-		api.SetOnCreateOrUpdate(func(_ *http.Request, _ *Album) *babyapi.ErrResponse {
-			return nil
-		})
+		w := babytest.TestRequest(t, api, httptest.NewRequest(http.MethodGet, "/albums", http.NoBody))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Equal(t, "application/json", w.Result().Header.Get("Content-Type"))
+		require.Equal(t, expectedBody(t, albums), w.Body.String())
+		require.Equal(t, fmt.Sprint(w.Body.Len()), w.Result().Header.Get("Content-Length"))
 	})
-}
 
-func TestAddRouteWorksWithMultipleMethodSamePath(t *testing.T) {
-	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	t.Run("LargeListIsStreamed", func(t *testing.T) {
+		api, albums := newAPI(1000)
 
-	api.AddCustomRoute(http.MethodGet, "/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	}))
-	api.AddCustomRoute(http.MethodPost, "/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	}))
+		w := babytest.TestRequest(t, api, httptest.NewRequest(http.MethodGet, "/albums", http.NoBody))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Equal(t, "application/json", w.Result().Header.Get("Content-Type"))
+		require.Equal(t, expectedBody(t, albums), w.Body.String())
+		require.Empty(t, w.Result().Header.Get("Content-Length"))
+	})
 
-	babytest.RunTableTest(t, api, []babytest.TestCase[*babyapi.AnyResource]{
-		{
-			Name: "GetSuccess",
-			Test: babytest.RequestFuncTest[*babyapi.AnyResource](func(getResponse babytest.PreviousResponseGetter, address string) *http.Request {
-				req, err := http.NewRequest(http.MethodGet, address+"/albums/test", http.NoBody)
-				require.NoError(t, err)
-				return req
-			}),
-			ExpectedResponse: babytest.ExpectedResponse{
-				Status: http.StatusOK,
-			},
-		},
-		{
-			Name: "PostSuccess",
-			Test: babytest.RequestFuncTest[*babyapi.AnyResource](func(getResponse babytest.PreviousResponseGetter, address string) *http.Request {
-				req, err := http.NewRequest(http.MethodPost, address+"/albums/test", http.NoBody)
-				require.NoError(t, err)
-				return req
-			}),
+	t.Run("EmptyListWithOmitEmpty", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetOmitEmpty(true)
 
-			ExpectedResponse: babytest.ExpectedResponse{
-				Status: http.StatusOK,
-			},
-		},
+		w := babytest.TestRequest(t, api, httptest.NewRequest(http.MethodGet, "/albums", http.NoBody))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Equal(t, "{}\n", w.Body.String())
+	})
+
+	t.Run("WrapperEmbeddingResourceListIsNotStreamed", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetGetAllResponseWrapper(func(albums []*Album) render.Renderer {
+				return &EmbeddedListResponse{ResourceList: &babyapi.ResourceList[*Album]{Items: albums}, Extra: "hello"}
+			})
+
+		w := babytest.TestRequest(t, api, httptest.NewRequest(http.MethodGet, "/albums", http.NoBody))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Equal(t, `{"items":[],"extra":"hello"}`, strings.TrimSpace(w.Body.String()))
 	})
 }
 
-func TestWithContextShutdown(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).WithContext(ctx)
+type EmbeddedListResponse struct {
+	*babyapi.ResourceList[*Album]
+	Extra string `json:"extra"`
+}
 
-	go func() {
-		err := api.Serve(":8080")
-		require.NoError(t, err)
-	}()
+func TestSetStreamingResponses(t *testing.T) {
+	artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} }).
+		SetStreamingResponses(true)
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} }).
+		SetStreamingResponses(false)
+	artistAPI.AddNestedAPI(albumAPI)
+	albumAPI.AddNestedAPI(songAPI)
 
-	// Stop the API via context after 1s
-	time.AfterFunc(1*time.Second, cancel)
+	artistAPI.AddCustomRoute(http.MethodGet, "/custom", babyapi.Handler(func(w http.ResponseWriter, r *http.Request) render.Renderer {
+		return &babyapi.ResourceList[*Artist]{Items: []*Artist{{DefaultResource: babyapi.NewDefaultResource(), Name: "Custom"}}}
+	}))
 
-	timeout := time.After(2 * time.Second)
-	select {
-	case <-api.Done():
-		require.True(t, true)
-	case <-timeout:
-		require.Fail(t, "API failed to stop after context is cancelled")
+	artist := &Artist{DefaultResource: babyapi.NewDefaultResource(), Name: "Artist"}
+	require.NoError(t, artistAPI.Storage.Set(context.Background(), artist))
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+	require.NoError(t, albumAPI.Storage.Set(context.Background(), album))
+	song := &Song{DefaultResource: babyapi.NewDefaultResource(), Title: "Song"}
+	require.NoError(t, songAPI.Storage.Set(context.Background(), song))
+
+	albumsPath := "/artists/" + artist.GetID() + "/albums"
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+		streamed bool
+	}{
+		{"GetAll", "/artists", `{"items":[{"id":"` + artist.GetID() + `","name":"Artist"}]}`, true},
+		{"CustomRoute", "/artists/custom", `{"items":[{"id":"`, true},
+		{"NestedAPIInherits", albumsPath, `{"items":[{"id":"` + album.GetID() + `","title":"Album"}]}`, true},
+		{"NestedAPIOverride", albumsPath + "/" + album.GetID() + "/songs", `{"items":[{"id":"` + song.GetID() + `","title":"Song"}]}`, false},
 	}
-}
 
-type AllAlbumsWrapper []*Album
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := babytest.TestRequest(t, artistAPI, httptest.NewRequest(http.MethodGet, tt.path, http.NoBody))
+			require.Equal(t, http.StatusOK, w.Result().StatusCode)
+			require.Contains(t, w.Body.String(), tt.expected)
+			require.Equal(t, tt.streamed, w.Flushed)
 
-func (AllAlbumsWrapper) Render(http.ResponseWriter, *http.Request) error {
-	return nil
+			if tt.streamed {
+				require.Empty(t, w.Result().Header.Get("Content-Length"))
+			} else {
+				require.Equal(t, fmt.Sprint(w.Body.Len()), w.Result().Header.Get("Content-Length"))
+			}
+		})
+	}
+
+	t.Run("DefaultIsBuffered", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+		require.NoError(t, api.Storage.Set(context.Background(), album))
+
+		w := babytest.TestRequest(t, api, httptest.NewRequest(http.MethodGet, "/albums", http.NoBody))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.False(t, w.Flushed)
+		require.Equal(t, fmt.Sprint(w.Body.Len()), w.Result().Header.Get("Content-Length"))
+	})
 }
 
-func TestGetAllResponseWrapperWithClient(t *testing.T) {
-	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
-	api.SetGetAllResponseWrapper(func(a []*Album) render.Renderer {
-		return AllAlbumsWrapper(a)
+type ValidatedAlbum struct {
+	babyapi.DefaultResource
+	Title  string   `json:"title,omitempty" jsonschema:"required,minLength=1"`
+	Year   int      `json:"year,omitempty" jsonschema:"minimum=1900"`
+	Format string   `json:"format,omitempty" jsonschema:"enum=vinyl,enum=cd"`
+	Tracks []string `json:"tracks"`
+}
+
+func TestClientValidatePayload(t *testing.T) {
+	var schemaRequests atomic.Int32
+	api := babyapi.NewAPI("Albums", "/albums", func() *ValidatedAlbum { return &ValidatedAlbum{} }).
+		EnableSchemaEndpoint()
+	api.AddMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/schema") {
+				schemaRequests.Add(1)
+			}
+			next.ServeHTTP(w, r)
+		})
 	})
 
 	client, stop := babytest.NewTestClient(t, api)
 	defer stop()
 
-	t.Run("CreateAlbum", func(t *testing.T) {
-		_, err := client.Post(context.Background(), &Album{Title: "Album"})
-		require.NoError(t, err)
-	})
+	t.Run("Valid", func(t *testing.T) {
+		album := &ValidatedAlbum{DefaultResource: babyapi.NewDefaultResource(), Title: "Album", Year: 2024, Format: "cd"}
+		require.NoError(t, client.ValidatePayload(context.Background(), album))
 
-	t.Run("RegularGetAllRequestErrors", func(t *testing.T) {
-		_, err := client.GetAll(context.Background(), "")
-		require.Error(t, err)
+		// A nil slice is encoded as null
+		album.Tracks = nil
+		require.NoError(t, client.ValidatePayload(context.Background(), album))
 	})
 
-	t.Run("MakeRequestCanBeUsed", func(t *testing.T) {
-		req, err := client.GetAllRequest(context.Background(), "")
-		require.NoError(t, err)
-
-		resp, err := babyapi.MakeRequest[AllAlbumsWrapper](req, http.DefaultClient, http.StatusOK, nil)
-		require.NoError(t, err)
-		require.Equal(t, "Album", resp.Data[0].Title)
+	t.Run("Invalid", func(t *testing.T) {
+		album := &ValidatedAlbum{DefaultResource: babyapi.NewDefaultResource(), Year: 1800, Format: "tape", Tracks: []string{"Track"}}
+		err := client.ValidatePayload(context.Background(), album)
+
+		var validationErr *babyapi.SchemaValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Equal(t, []babyapi.SchemaError{
+			{Path: "/title", Message: "is required"},
+			{Path: "/year", Message: "must be at least 1900"},
+			{Path: "/format", Message: "must be one of [vinyl cd]"},
+		}, validationErr.Errors)
+		require.Equal(t, "encountered 3 errors validating payload:\n- /title: is required\n- /year: must be at least 1900\n- /format: must be one of [vinyl cd]\n", err.Error())
 	})
 
-	t.Run("MakeGenericRequestCanBeUsed", func(t *testing.T) {
-		req, err := client.GetAllRequest(context.Background(), "")
-		require.NoError(t, err)
+	t.Run("SchemaIsCached", func(t *testing.T) {
+		require.Equal(t, int32(1), schemaRequests.Load())
 
-		var albums AllAlbumsWrapper
-		resp, err := client.MakeGenericRequest(req, &albums)
-		require.NoError(t, err)
-		require.Equal(t, "Album", albums[0].Title)
-		require.NotNil(t, resp.Data)
-		require.Equal(t, http.StatusOK, resp.Response.StatusCode)
+		// Copies of the Client use the same schema
+		album := &ValidatedAlbum{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+		require.NoError(t, client.DryRun().ValidatePayload(context.Background(), album))
+		require.Equal(t, int32(1), schemaRequests.Load())
 	})
 
-	t.Run("GetAllCLI", func(t *testing.T) {
-		out, err := runCommand(api.Command(), []string{"client", "--pretty=false", "--address", client.Address, "Albums", "list"})
-		require.NoError(t, err)
-		require.Regexp(t, `[{"id":"[0-9a-v]{20}","title":"Album"}]`, strings.TrimSpace(out))
+	t.Run("ErrorNoSchemaEndpoint", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *ValidatedAlbum { return &ValidatedAlbum{} })
+		client, stop := babytest.NewTestClient(t, api)
+		defer stop()
+
+		err := client.ValidatePayload(context.Background(), &ValidatedAlbum{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error getting schema")
 	})
 }
 
-func TestClient(t *testing.T) {
-	api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
-	api.SetGetAllResponseWrapper(func(a []*Album) render.Renderer {
-		return AllAlbumsWrapper(a)
-	})
+func TestSetMaxURILength(t *testing.T) {
+	artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} }).
+		SetMaxURILength(100)
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+		SetMaxURILength(60)
+	artistAPI.AddNestedAPI(albumAPI)
 
-	client, stop := babytest.NewTestClient(t, api)
-	defer stop()
+	artist := &Artist{DefaultResource: babyapi.NewDefaultResource(), Name: "Artist"}
+	require.NoError(t, artistAPI.Storage.Set(context.Background(), artist))
 
-	t.Run("CustomResponseCodeSuccess", func(t *testing.T) {
-		client.SetCustomResponseCode(babyapi.MethodGetAll, http.StatusCreated)
-		resp, err := client.GetAllAny(context.Background(), "")
-		require.NoError(t, err)
-		require.Equal(t, http.StatusCreated, resp.Response.StatusCode)
-	})
+	albumsPath := "/artists/" + artist.GetID() + "/albums"
 
-	t.Run("SetHTTPClient", func(t *testing.T) {
-		client.SetHTTPClient(http.DefaultClient)
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{"ShortURI", "/artists?name=Artist", http.StatusOK},
+		{"LongQuery", "/artists?name=" + strings.Repeat("a", 100), http.StatusRequestURITooLong},
+		{"LongPath", "/artists/" + strings.Repeat("a", 100), http.StatusRequestURITooLong},
+		{"NestedAPIShortURI", albumsPath, http.StatusOK},
+		{"NestedAPILowerLength", albumsPath + "?title=" + strings.Repeat("a", 20), http.StatusRequestURITooLong},
+	}
 
-		_, err := client.GetAllAny(context.Background(), "")
-		require.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := babytest.TestRequest(t, artistAPI, httptest.NewRequest(http.MethodGet, tt.path, http.NoBody))
+			require.Equal(t, tt.expectedStatus, w.Result().StatusCode)
+			if tt.expectedStatus == http.StatusRequestURITooLong {
+				require.Equal(t, `{"status":"URI too long."}`, strings.TrimSpace(w.Body.String()))
+			}
+		})
+	}
+
+	t.Run("ErrorNotPositive", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetMaxURILength(0)
+
+		_, err := api.Router()
+		require.EqualError(t, err, "encountered 1 errors constructing API:\n- SetMaxURILength: length must be positive\n")
 	})
 }
 
-// Test that a root API can have children with their own middleware operating independently
-func TestChildAPIWithMiddleware(t *testing.T) {
-	api := babyapi.NewRootAPI("root", "/")
-
-	api.Get = func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(200)
+func TestAddMethodMiddleware(t *testing.T) {
+	addHeader := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Middleware", name)
+				next.ServeHTTP(w, r)
+			})
+		}
 	}
 
-	api.AddCustomRoute(http.MethodGet, "/teapot", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusTeapot)
-	}))
+	artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} })
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	artistAPI.AddNestedAPI(albumAPI)
 
-	rootMiddlewareHits := 0
-	api.AddMiddleware(func(next http.Handler) http.Handler {
+	artistAPI.AddMethodMiddleware(http.MethodGet, addHeader("get"))
+	artistAPI.AddMethodMiddleware(babyapi.MethodGetAll, addHeader("get-all"))
+	artistAPI.AddMethodMiddleware(http.MethodPost, func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			rootMiddlewareHits++
+			// The request body is already bound
+			artist, ok := babyapi.GetRequestBodyFromContext[*Artist](r.Context())
+			if ok && artist.Name == "invalid" {
+				_ = render.Render(w, r, babyapi.ErrInvalidRequest(fmt.Errorf("invalid name")))
+				return
+			}
 			next.ServeHTTP(w, r)
 		})
 	})
+	artistAPI.AddCustomRoute(http.MethodGet, "/custom", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
 
-	songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
-	api.AddNestedAPI(songAPI)
-
-	songMiddlewareHits := 0
-	songAPI.AddMiddleware(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			songMiddlewareHits++
-			next.ServeHTTP(w, r)
-		})
-	})
+	artist := &Artist{DefaultResource: babyapi.NewDefaultResource(), Name: "Artist"}
+	require.NoError(t, artistAPI.Storage.Set(context.Background(), artist))
 
-	musicVideoAPI := babyapi.NewAPI("MusicVideos", "/music_videos", func() *MusicVideo { return &MusicVideo{} })
-	api.AddNestedAPI(musicVideoAPI)
-	musicVideoMiddlewareHits := 0
-	musicVideoAPI.AddMiddleware(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			musicVideoMiddlewareHits++
-			next.ServeHTTP(w, r)
-		})
-	})
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		status   int
+		expected []string
+	}{
+		{"GetAll", http.MethodGet, "/artists", "", http.StatusOK, []string{"get", "get-all"}},
+		{"Get", http.MethodGet, "/artists/" + artist.GetID(), "", http.StatusOK, []string{"get"}},
+		{"CustomRoute", http.MethodGet, "/artists/custom", "", http.StatusOK, []string{"get"}},
+		{"NestedAPI", http.MethodGet, "/artists/" + artist.GetID() + "/albums", "", http.StatusOK, nil},
+		{"PostValid", http.MethodPost, "/artists", `{"name":"New Artist"}`, http.StatusCreated, nil},
+		{"PostInvalid", http.MethodPost, "/artists", `{"name":"invalid"}`, http.StatusBadRequest, nil},
+		{"OtherMethod", http.MethodDelete, "/artists/" + artist.GetID(), "", http.StatusNoContent, nil},
+	}
 
-	t.Run("GetRootAPI", func(t *testing.T) {
-		r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
-		w := babytest.TestRequest(t, api, r)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
 
-		require.Equal(t, http.StatusOK, w.Result().StatusCode)
-		require.Equal(t, 1, rootMiddlewareHits)
-	})
+			w := babytest.TestRequest(t, artistAPI, r)
+			require.Equal(t, tt.status, w.Result().StatusCode)
+			require.Equal(t, tt.expected, w.Result().Header.Values("X-Middleware"))
+		})
+	}
 
-	t.Run("GetRootAPICustomRoute", func(t *testing.T) {
-		r := httptest.NewRequest(http.MethodGet, "/teapot", http.NoBody)
-		w := babytest.TestRequest(t, api, r)
+	t.Run("ErrorUnsupportedMethod", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			AddMethodMiddleware("FETCH", addHeader("fetch"))
 
-		require.Equal(t, http.StatusTeapot, w.Result().StatusCode)
-		require.Equal(t, 2, rootMiddlewareHits)
+		_, err := api.Router()
+		require.EqualError(t, err, "encountered 1 errors constructing API:\n- AddMethodMiddleware: unsupported method \"FETCH\"\n")
 	})
+}
 
-	t.Run("GetSongAPI", func(t *testing.T) {
-		r := httptest.NewRequest(http.MethodGet, "/songs", http.NoBody)
-		w := babytest.TestRequest(t, api, r)
+func TestSetResponseTransformer(t *testing.T) {
+	envelope := func(r *http.Request, data []byte) ([]byte, error) {
+		if r.URL.Query().Has("fail") {
+			return nil, errors.New("transformer error")
+		}
+		return []byte(`{"data":` + string(data) + `}`), nil
+	}
 
-		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	artistAPI := babyapi.NewAPI("Artists", "/artists", func() *Artist { return &Artist{} }).
+		SetResponseTransformer(envelope)
+	albumAPI := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} })
+	artistAPI.AddNestedAPI(albumAPI)
 
-		require.Equal(t, 3, rootMiddlewareHits)
-		require.Equal(t, 1, songMiddlewareHits)
-		require.Equal(t, 0, musicVideoMiddlewareHits)
-	})
+	artist := &Artist{DefaultResource: babyapi.NewDefaultResource(), Name: "Artist"}
+	require.NoError(t, artistAPI.Storage.Set(context.Background(), artist))
+	album := &Album{DefaultResource: babyapi.NewDefaultResource(), Title: "Album"}
+	require.NoError(t, albumAPI.Storage.Set(context.Background(), album))
 
-	t.Run("GetMusicVideoAPI", func(t *testing.T) {
-		r := httptest.NewRequest(http.MethodGet, "/music_videos", http.NoBody)
-		w := babytest.TestRequest(t, api, r)
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Get", "/artists/" + artist.GetID(), http.StatusOK, `{"data":{"id":"` + artist.GetID() + `","name":"Artist"}}`},
+		{"GetAll", "/artists", http.StatusOK, `{"data":{"items":[{"id":"` + artist.GetID() + `","name":"Artist"}]}}`},
+		{"Error", "/artists/" + babyapi.NewID().String(), http.StatusNotFound, `{"data":{"status":"Resource not found."}}`},
+		{"NestedAPIInherits", "/artists/" + artist.GetID() + "/albums", http.StatusOK, `{"data":{"items":[{"id":"` + album.GetID() + `","title":"Album"}]}}`},
+		{"TransformerError", "/artists/" + artist.GetID() + "?fail", http.StatusInternalServerError, "transformer error"},
+	}
 
-		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := babytest.TestRequest(t, artistAPI, httptest.NewRequest(http.MethodGet, tt.path, http.NoBody))
+			require.Equal(t, tt.expectedStatus, w.Result().StatusCode)
+			require.Equal(t, tt.expectedBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
 
-		require.Equal(t, 4, rootMiddlewareHits)
-		require.Equal(t, 1, songMiddlewareHits)
-		require.Equal(t, 1, musicVideoMiddlewareHits)
-	})
+	t.Run("ErrorNil", func(t *testing.T) {
+		api := babyapi.NewAPI("Albums", "/albums", func() *Album { return &Album{} }).
+			SetResponseTransformer(nil)
 
-	t.Run("AssertMiddlewareHits", func(t *testing.T) {
-		require.Equal(t, 4, rootMiddlewareHits)
-		require.Equal(t, 1, songMiddlewareHits)
-		require.Equal(t, 1, musicVideoMiddlewareHits)
+		_, err := api.Router()
+		require.EqualError(t, err, "encountered 1 errors constructing API:\n- SetResponseTransformer: transformer must not be nil\n")
 	})
 }
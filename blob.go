@@ -0,0 +1,120 @@
+package babyapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/madflojo/hord"
+)
+
+// BlobStore stores binary file contents, like images or attachments, that are too large or unstructured to
+// store directly on a resource. Get should return ErrNotFound if the key does not exist. If the content returned by
+// Get implements io.Seeker, ServeBlob supports range requests
+type BlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// KVBlobStore implements the BlobStore interface using hord.Database for the storage backend. Contents are read into
+// memory, so it is best for small files
+type KVBlobStore struct {
+	prefix string
+	db     hord.Database
+}
+
+var _ BlobStore = &KVBlobStore{}
+
+// NewKVBlobStore creates a new BlobStore that stores contents with keys prefixed by 'prefix'
+func NewKVBlobStore(db hord.Database, prefix string) BlobStore {
+	return &KVBlobStore{prefix, db}
+}
+
+func (s *KVBlobStore) key(key string) string {
+	return fmt.Sprintf("%s_%s", s.prefix, key)
+}
+
+// Put reads all of the content and writes it to the database
+func (s *KVBlobStore) Put(_ context.Context, key string, content io.Reader) error {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return fmt.Errorf("error reading content: %w", err)
+	}
+
+	err = s.db.Set(s.key(key), data)
+	if err != nil {
+		return fmt.Errorf("error writing data to database: %w", err)
+	}
+
+	return nil
+}
+
+// Get reads the content from the database
+func (s *KVBlobStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	data, err := s.db.Get(s.key(key))
+	if err != nil {
+		if errors.Is(hord.ErrNil, err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error getting data: %w", err)
+	}
+
+	return readSeekNopCloser{bytes.NewReader(data)}, nil
+}
+
+// Delete deletes the content from the database
+func (s *KVBlobStore) Delete(_ context.Context, key string) error {
+	return s.db.Delete(s.key(key))
+}
+
+// readSeekNopCloser is like io.NopCloser, but keeps the io.Seeker implementation
+type readSeekNopCloser struct {
+	io.ReadSeeker
+}
+
+func (readSeekNopCloser) Close() error {
+	return nil
+}
+
+// contentTyper is implemented by content that knows its Content-Type, like objects from S3Blob, so ServeBlob does
+// not need to read the beginning of the content to detect it
+type contentTyper interface {
+	ContentType() string
+}
+
+// ServeBlob writes the content from a BlobStore to the response. If the content implements io.Seeker, it uses
+// http.ServeContent to support range requests, so clients can resume downloads or stream media. Otherwise, the full
+// content is always written. If the Content-Type is not already set, it is detected from the name's extension, then
+// from a ContentType() string method on the content, and finally from the beginning of the content
+func ServeBlob(w http.ResponseWriter, r *http.Request, name string, content io.ReadCloser) {
+	defer content.Close()
+
+	typed, ok := content.(contentTyper)
+	if ok && w.Header().Get("Content-Type") == "" && mime.TypeByExtension(filepath.Ext(name)) == "" && typed.ContentType() != "" {
+		w.Header().Set("Content-Type", typed.ContentType())
+	}
+
+	readSeeker, ok := content.(io.ReadSeeker)
+	if ok {
+		http.ServeContent(w, r, name, time.Time{}, readSeeker)
+		return
+	}
+
+	w.Header().Set("Accept-Ranges", "none")
+	_, err := io.Copy(w, content)
+	if err != nil {
+		logger := GetLoggerFromContext(r.Context())
+		if logger == nil {
+			logger = slog.Default()
+		}
+		logger.Error("error writing blob content", "error", err)
+	}
+}
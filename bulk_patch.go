@@ -0,0 +1,159 @@
+package babyapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// BulkPatchResponse is the response from the bulk PATCH endpoint added by EnableBulkPatch. Items are in the same
+// order as the IDs in the request body
+type BulkPatchResponse[T any] struct {
+	Items []BulkPatchResult[T] `json:"items"`
+}
+
+func (bpr *BulkPatchResponse[T]) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+// BulkPatchResult is the result of patching one resource in a bulk PATCH request. Status is the response code that a
+// PATCH request for only this resource would have. Resource is set for successful responses and Error is set otherwise
+type BulkPatchResult[T any] struct {
+	ID       string       `json:"id"`
+	Status   int          `json:"status"`
+	Resource T            `json:"resource,omitempty"`
+	Error    *ErrResponse `json:"error,omitempty"`
+}
+
+// EnableBulkPatch adds a PATCH /base route to patch multiple resources in one request. The request body is a JSON
+// object of resource IDs to the patch for that resource, like {"id1": {"completed": true}}. Each patch is used for a
+// separate request to the Patch handler, so the ID middlewares and update hooks run for every resource. Resources are
+// patched separately, so an error for one resource does not prevent patching the others. The response is
+// BulkPatchResponse with the result for each ID
+func (a *API[T]) EnableBulkPatch() *API[T] {
+	a.panicIfReadOnly()
+
+	if a.rootAPI {
+		a.errors = append(a.errors, fmt.Errorf("EnableBulkPatch: bulk PATCH cannot be used with a root API"))
+		return a
+	}
+
+	a.BulkPatch = a.defaultBulkPatch()
+	return a
+}
+
+func (a *API[T]) defaultBulkPatch() http.HandlerFunc {
+	return Handler(func(w http.ResponseWriter, r *http.Request) render.Renderer {
+		logger := GetLoggerFromContext(r.Context())
+
+		if a.Patch == nil {
+			return ErrMethodNotAllowedResponse
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return ErrInvalidRequest(fmt.Errorf("error reading request body: %w", err))
+		}
+
+		patches, err := decodeOrderedObject(data)
+		if err != nil {
+			return ErrInvalidRequest(fmt.Errorf("error decoding request body: %w", err))
+		}
+
+		// The Patch handler is wrapped with the same middlewares as the ID route so each item is handled like a
+		// separate PATCH request
+		var handler http.Handler = a.requestBodyMiddleware(a.Patch)
+		for i := len(a.idMiddlewares) - 1; i >= 0; i-- {
+			handler = a.idMiddlewares[i](handler)
+		}
+		handler = a.resourceExistsMiddleware(handler)
+
+		logger.Info("patching resources", "count", len(patches))
+
+		resp := &BulkPatchResponse[json.RawMessage]{Items: []BulkPatchResult[json.RawMessage]{}}
+		for _, patch := range patches {
+			resp.Items = append(resp.Items, a.bulkPatchItem(r, handler, patch.name, patch.value.(json.RawMessage)))
+		}
+
+		// Each item's headers are separate, so the dry run header is set for the whole response
+		if IsDryRun(r.Context()) {
+			w.Header().Set(DryRunHeader, "true")
+		}
+
+		render.Status(r, http.StatusOK)
+
+		return resp
+	})
+}
+
+// bulkPatchItem uses the handler with a copy of the request that has the ID URL param and the patch as the body
+func (a *API[T]) bulkPatchItem(r *http.Request, handler http.Handler, id string, patch json.RawMessage) BulkPatchResult[json.RawMessage] {
+	rctx := chi.NewRouteContext()
+	if parent := chi.RouteContext(r.Context()); parent != nil {
+		rctx.URLParams.Keys = append(rctx.URLParams.Keys, parent.URLParams.Keys...)
+		rctx.URLParams.Values = append(rctx.URLParams.Values, parent.URLParams.Values...)
+	}
+	rctx.URLParams.Add(a.IDParamKey(), id)
+
+	// Responses for each item are always JSON so they can be included in the bulk response
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, render.ContentTypeCtxKey, render.ContentTypeJSON)
+
+	itemReq := r.Clone(ctx)
+	itemReq.Body = io.NopCloser(bytes.NewReader(patch))
+	itemReq.ContentLength = int64(len(patch))
+	itemReq.Header.Set("Content-Type", "application/json")
+
+	iw := &bulkItemResponseWriter{header: http.Header{}}
+	handler.ServeHTTP(iw, itemReq)
+	if iw.status == 0 {
+		iw.status = http.StatusOK
+	}
+
+	result := BulkPatchResult[json.RawMessage]{ID: id, Status: iw.status}
+	body := bytes.TrimSpace(iw.body.Bytes())
+	if iw.status < 200 || iw.status >= 300 {
+		result.Error = &ErrResponse{HTTPStatusCode: iw.status}
+		err := unmarshalJSON(body, result.Error)
+		if err != nil || result.Error.StatusText == "" {
+			result.Error.StatusText = http.StatusText(iw.status)
+		}
+		return result
+	}
+
+	if len(body) > 0 {
+		result.Resource = json.RawMessage(body)
+	}
+	return result
+}
+
+// bulkItemResponseWriter stores the response for one item in a bulk PATCH request. Unlike bufferedResponseWriter,
+// it has separate headers so the item's headers are not added to the bulk response
+type bulkItemResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (bw *bulkItemResponseWriter) Header() http.Header {
+	return bw.header
+}
+
+func (bw *bulkItemResponseWriter) WriteHeader(statusCode int) {
+	if bw.status == 0 {
+		bw.status = statusCode
+	}
+}
+
+func (bw *bulkItemResponseWriter) Write(b []byte) (int, error) {
+	if bw.status == 0 {
+		bw.status = http.StatusOK
+	}
+	return bw.body.Write(b)
+}
@@ -11,6 +11,7 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 )
@@ -37,7 +38,7 @@ type cliArgs struct {
 
 func (a *API[T]) Command() *cobra.Command {
 	rootCmd := &cobra.Command{
-		Use:   a.name,
+		Use:   a.DisplayName(),
 		Short: "automatic CLI for babyapi server",
 		RunE:  a.serveCmd,
 	}
@@ -62,12 +63,29 @@ func (a *API[T]) Command() *cobra.Command {
 	clientCmd.PersistentFlags().StringSliceVar(&a.cliArgs.headers, "headers", []string{}, "add headers to request")
 	clientCmd.PersistentFlags().StringVarP(&a.cliArgs.query, "query", "q", "", "add query parameters to request")
 
+	routesCmd := &cobra.Command{
+		Use:   "routes",
+		Short: "print the API's routes without starting the server",
+		RunE:  a.routesCmd,
+	}
+
+	seedCmd := &cobra.Command{
+		Use:   "seed",
+		Short: "load resources from a JSON file into the API's storage",
+		RunE:  a.seedCmd,
+	}
+	seedCmd.Flags().String("file", "", "JSON file with an array of resources or an object of API names to arrays of resources")
+	seedCmd.Flags().Bool("bind", false, "bind each resource like a request body to validate it and generate missing IDs")
+	_ = seedCmd.MarkFlagRequired("file")
+
 	for name, client := range a.CreateClientMap(a.AnyClient(a.cliArgs.address)) {
 		clientCmd.AddCommand(client.Command(name, &a.cliArgs))
 	}
 
 	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(clientCmd)
+	rootCmd.AddCommand(routesCmd)
+	rootCmd.AddCommand(seedCmd)
 
 	return rootCmd
 }
@@ -83,12 +101,27 @@ func (a *API[T]) serveCmd(_ *cobra.Command, _ []string) error {
 	return a.Serve(a.cliArgs.address)
 }
 
-// CreateClientMap returns a map of API names to the corresponding Client for that child API. This makes it easy to use
+func (a *API[T]) routesCmd(cmd *cobra.Command, _ []string) error {
+	routes, err := a.Routes()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH")
+	for _, route := range routes {
+		fmt.Fprintf(w, "%s\t%s\n", route.Method, route.Pattern)
+	}
+
+	return w.Flush()
+}
+
+// CreateClientMap returns a map of API display names to the corresponding Client for that child API. This makes it easy to use
 // child APIs dynamically. The initial parent/base client must be provided so child APIs can use NewSubClient
 func (a *API[T]) CreateClientMap(parent *Client[*AnyResource]) map[string]*Client[*AnyResource] {
 	clientMap := map[string]*Client[*AnyResource]{}
 	if !a.rootAPI {
-		clientMap[a.name] = parent
+		clientMap[a.DisplayName()] = parent
 	}
 
 	for _, child := range a.subAPIs {
@@ -101,7 +134,7 @@ func (a *API[T]) CreateClientMap(parent *Client[*AnyResource]) map[string]*Clien
 		} else {
 			childClient = NewSubClient[*AnyResource, *AnyResource](parent, base)
 		}
-		childClient.name = child.Name()
+		childClient.name = child.DisplayName()
 
 		childClient.SetCustomResponseCodeMap(child.getCustomResponseCodeMap())
 
@@ -119,6 +152,26 @@ type PrintableResponse interface {
 	Fprint(out io.Writer, pretty bool) error
 }
 
+// seedCmd is the CLI command for Seed. Storage is closed afterward so snapshots from WithSnapshot are written
+func (a *API[T]) seedCmd(cmd *cobra.Command, _ []string) error {
+	filename, _ := cmd.Flags().GetString("file")
+	bind, _ := cmd.Flags().GetBool("bind")
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("error reading seed file: %w", err)
+	}
+
+	count, err := a.Seed(cmd.Context(), data, bind)
+	if err != nil {
+		return err
+	}
+	a.closeStorage()
+
+	fmt.Fprintf(cmd.OutOrStdout(), "seeded %d resources\n", count)
+	return nil
+}
+
 func (c *Client[T]) Command(name string, input *cliArgs) *cobra.Command {
 	reqEditor := func(r *http.Request) error {
 		for _, header := range input.headers {
@@ -180,7 +233,7 @@ func (c *Client[T]) Command(name string, input *cliArgs) *cobra.Command {
 		Use:   "get",
 		Short: "make a GET request to get a resource by ID",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			c.Address = input.address
+			c.SetAddress(input.address)
 
 			var err error
 			req, err = c.cliGetRequest(parentIDs, args)
@@ -191,7 +244,7 @@ func (c *Client[T]) Command(name string, input *cliArgs) *cobra.Command {
 		Use:   "list",
 		Short: "make a GET request to list resources",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			c.Address = input.address
+			c.SetAddress(input.address)
 
 			var err error
 			req, err = c.cliGetAllRequest(parentIDs)
@@ -202,7 +255,7 @@ func (c *Client[T]) Command(name string, input *cliArgs) *cobra.Command {
 		Use:   "delete",
 		Short: "make a DELETE request to delete a resource by ID",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			c.Address = input.address
+			c.SetAddress(input.address)
 
 			var err error
 			req, err = c.cliDeleteRequest(parentIDs, args)
@@ -213,7 +266,7 @@ func (c *Client[T]) Command(name string, input *cliArgs) *cobra.Command {
 		Use:   "post",
 		Short: "make a POST request to create a new resource",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			c.Address = input.address
+			c.SetAddress(input.address)
 
 			var err error
 			req, err = c.cliPostRequest(parentIDs, body)
@@ -224,7 +277,7 @@ func (c *Client[T]) Command(name string, input *cliArgs) *cobra.Command {
 		Use:   "put",
 		Short: "make a PUT request to create or modify a resource by ID",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			c.Address = input.address
+			c.SetAddress(input.address)
 
 			var err error
 			req, err = c.cliPutRequest(parentIDs, body, args)
@@ -235,7 +288,7 @@ func (c *Client[T]) Command(name string, input *cliArgs) *cobra.Command {
 		Use:   "patch",
 		Short: "make a PATCH request to modify a resource by ID",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			c.Address = input.address
+			c.SetAddress(input.address)
 
 			var err error
 			req, err = c.cliPatchRequest(parentIDs, body, args)
@@ -1,14 +1,20 @@
 package babyapi
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"path"
+	"strconv"
 	"strings"
+
+	"github.com/rs/xid"
 )
 
 // Response wraps an HTTP response from the API and allows easy access to the decoded response type (if JSON),
@@ -40,16 +46,17 @@ func newResponse[T any](resp *http.Response, expectedStatusCode int) (*Response[
 		}
 
 		var httpErr *ErrResponse
-		err := json.Unmarshal([]byte(result.Body), &httpErr)
+		err := unmarshalJSON([]byte(result.Body), &httpErr)
 		if err != nil {
 			return nil, fmt.Errorf("error decoding error response %q: %w", result.Body, err)
 		}
 		httpErr.HTTPStatusCode = resp.StatusCode
+		httpErr.RetryAfter, _ = parseRetryAfter(resp)
 		return nil, httpErr
 	}
 
 	if strings.Contains(result.ContentType, "application/json") {
-		err := json.Unmarshal([]byte(result.Body), &result.Data)
+		err := unmarshalJSON([]byte(result.Body), &result.Data)
 		if err != nil {
 			return nil, fmt.Errorf("error decoding response body %q: %w", result.Body, err)
 		}
@@ -101,6 +108,11 @@ type Client[T Resource] struct {
 	requestEditor       RequestEditor
 	parents             []clientParent
 	customResponseCodes map[string]int
+	actions             map[string]clientAction
+	clientGeneratedIDs  bool
+	retryAfter          *retryAfterPolicy
+	dryRun              bool
+	schema              *clientSchema
 }
 
 // NewClient initializes a Client for interacting with the Resource API
@@ -113,6 +125,11 @@ func NewClient[T Resource](addr, base string) *Client[T] {
 		DefaultRequestEditor,
 		[]clientParent{},
 		defaultResponseCodes(),
+		map[string]clientAction{},
+		false,
+		nil,
+		false,
+		&clientSchema{},
 	}
 }
 
@@ -129,6 +146,18 @@ func NewSubClient[T, R Resource](parent *Client[T], path string) *Client[R] {
 	return newClient
 }
 
+// SetAddress changes the address of the API server, like when switching environments after the Client is created
+func (c *Client[T]) SetAddress(addr string) *Client[T] {
+	c.Address = addr
+	return c
+}
+
+// SetBase changes the base path of the resource. Parent paths from NewSubClient are not changed
+func (c *Client[T]) SetBase(base string) *Client[T] {
+	c.base = strings.TrimLeft(base, "/")
+	return c
+}
+
 // SetCustomResponseCode will override the default expected response codes for the specified HTTP verb
 func (c *Client[T]) SetCustomResponseCode(verb string, code int) *Client[T] {
 	c.customResponseCodes[verb] = code
@@ -141,8 +170,31 @@ func (c *Client[T]) SetCustomResponseCodeMap(customResponseCodes map[string]int)
 	return c
 }
 
-// SetHTTPClient allows overriding the Clients HTTP client with a custom one
+// UseClientGeneratedIDs makes Post generate an ID for new resources and create them with a PUT request to
+// /base/{ID}, so retrying a request that failed after the server received it does not create a duplicate resource.
+// Bodies that already have an "id" use that ID instead. Post also sets the generated ID on the resource, so calling
+// Post again with the same resource uses the same ID. PostRaw cannot change the caller's body, so it only reuses an ID
+// that is in the body. If the API responds with 405 Method Not Allowed for the PUT, the original body is sent with a
+// POST request instead.
+//
+// This is only safe for APIs where PUT creates resources the same way as POST. Since the request is a PUT, any
+// server-side handling that is specific to POST, like SetCreateResponseMode and the Location header, is not used, and
+// a resource that already has the ID is replaced instead of causing an error. IDs must also be valid for the API,
+// since they are generated with xid instead of by the server
+func (c *Client[T]) UseClientGeneratedIDs() *Client[T] {
+	c.clientGeneratedIDs = true
+	return c
+}
+
+// SetHTTPClient allows overriding the Clients HTTP client with a custom one. If SetRetryAfter or DryRun is used, a copy
+// of the client is used to retry requests or set the dry run header
 func (c *Client[T]) SetHTTPClient(client *http.Client) *Client[T] {
+	if c.retryAfter != nil {
+		client = c.retryAfter.wrap(client)
+	}
+	if c.dryRun {
+		client = wrapDryRun(client)
+	}
 	c.client = client
 	return c
 }
@@ -179,6 +231,37 @@ func (c *Client[T]) GetRequest(ctx context.Context, id string, parentIDs ...stri
 	return c.NewRequestWithParentIDs(ctx, http.MethodGet, http.NoBody, id, parentIDs...)
 }
 
+// Exists checks if a resource exists by ID. It returns true if the resource is found and false if the API responds
+// with 404 Not Found. Any other response results in an error
+func (c *Client[T]) Exists(ctx context.Context, id string, parentIDs ...string) (bool, error) {
+	return c.ExistsWithEditor(ctx, id, c.requestEditor, parentIDs...)
+}
+
+// ExistsWithEditor checks if a resource exists by ID after modifying the request with requestEditor
+func (c *Client[T]) ExistsWithEditor(ctx context.Context, id string, requestEditor RequestEditor, parentIDs ...string) (bool, error) {
+	req, err := c.GetRequest(ctx, id, parentIDs...)
+	if err != nil {
+		return false, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := makeRequest(req, c.client, requestEditor)
+	if err != nil {
+		return false, fmt.Errorf("error checking if resource exists: %w", err)
+	}
+	defer resp.Body.Close()
+
+	expectedStatusCode := c.customResponseCodes[http.MethodGet]
+	switch resp.StatusCode {
+	case expectedStatusCode:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+
+	_, err = newResponse[any](resp, expectedStatusCode)
+	return false, fmt.Errorf("error checking if resource exists: %w", err)
+}
+
 // GetAll gets all resources from the API
 func (c *Client[T]) GetAll(ctx context.Context, rawQuery string, parentIDs ...string) (*Response[*ResourceList[T]], error) {
 	return c.GetAllWithEditor(ctx, rawQuery, c.requestEditor, parentIDs...)
@@ -199,6 +282,75 @@ func (c *Client[T]) GetAllWithEditor(ctx context.Context, rawQuery string, reque
 	return result, nil
 }
 
+// All gets every resource by following pages from an API using EnablePagination. It starts at the offset in
+// rawQuery, or the first page, and requests the next offset until Total resources are read. If the response is not
+// paginated, it yields the items from the first response. Errors are yielded with the zero value of T and stop the
+// iteration. It has the same signature as iter.Seq2[T, error], so it can be used with range in Go 1.23 or later:
+//
+//	for album, err := range client.All(ctx, "limit=100") { ... }
+func (c *Client[T]) All(ctx context.Context, rawQuery string, parentIDs ...string) func(yield func(T, error) bool) {
+	return func(yield func(T, error) bool) {
+		query, err := url.ParseQuery(rawQuery)
+		if err != nil {
+			yield(*new(T), fmt.Errorf("error parsing query: %w", err))
+			return
+		}
+
+		offset := 0
+		if rawOffset := query.Get(offsetParam); rawOffset != "" {
+			offset, err = strconv.Atoi(rawOffset)
+			if err != nil {
+				yield(*new(T), fmt.Errorf("error parsing offset: %w", err))
+				return
+			}
+		}
+
+		pageQuery := rawQuery
+		for {
+			resp, err := c.GetAll(ctx, pageQuery, parentIDs...)
+			if err != nil {
+				yield(*new(T), err)
+				return
+			}
+
+			for _, item := range resp.Data.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			// Responses without a limit are not paginated, so all items are in the first response
+			offset += len(resp.Data.Items)
+			if resp.Data.Limit == 0 || len(resp.Data.Items) == 0 || offset >= resp.Data.Total {
+				return
+			}
+
+			query.Set(offsetParam, strconv.Itoa(offset))
+			pageQuery = query.Encode()
+		}
+	}
+}
+
+// GetAllPages gets every resource by following pages from an API using EnablePagination and returns them in one
+// slice. It is the same as collecting the resources from All, so it works like GetAll for APIs without pagination
+func (c *Client[T]) GetAllPages(ctx context.Context, rawQuery string, parentIDs ...string) ([]T, error) {
+	items := []T{}
+	var err error
+	c.All(ctx, rawQuery, parentIDs...)(func(item T, itemErr error) bool {
+		if itemErr != nil {
+			err = itemErr
+			return false
+		}
+		items = append(items, item)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
 // GetAllRequest creates a request that can be used to get all resources
 func (c *Client[T]) GetAllRequest(ctx context.Context, rawQuery string, parentIDs ...string) (*http.Request, error) {
 	req, err := c.NewRequestWithParentIDs(ctx, http.MethodGet, http.NoBody, "", parentIDs...)
@@ -239,7 +391,7 @@ func (c *Client[T]) Put(ctx context.Context, resource T, parentIDs ...string) (*
 // PutWithEditor makes a PUT request to create/modify a resource by ID after modifying the request with requestEditor
 func (c *Client[T]) PutWithEditor(ctx context.Context, resource T, requestEditor RequestEditor, parentIDs ...string) (*Response[T], error) {
 	var body bytes.Buffer
-	err := json.NewEncoder(&body).Encode(resource)
+	err := encodeJSON(&body, resource)
 	if err != nil {
 		return nil, fmt.Errorf("error encoding request body: %w", err)
 	}
@@ -275,7 +427,18 @@ func (c *Client[T]) put(ctx context.Context, id string, body io.Reader, requestE
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
 
-	result, err := c.MakeRequestWithEditor(req, c.customResponseCodes[http.MethodPut], requestEditor)
+	resp, err := makeRequest(req, c.client, requestEditor)
+	if err != nil {
+		return nil, fmt.Errorf("error putting resource: %w", err)
+	}
+
+	// PUT can respond with a different status code when it creates a new resource
+	expectedStatusCode := c.customResponseCodes[http.MethodPut]
+	if createStatusCode, ok := c.customResponseCodes[MethodPutCreate]; ok && resp.StatusCode == createStatusCode {
+		expectedStatusCode = createStatusCode
+	}
+
+	result, err := newResponse[T](resp, expectedStatusCode)
 	if err != nil {
 		return nil, fmt.Errorf("error putting resource: %w", err)
 	}
@@ -291,12 +454,27 @@ func (c *Client[T]) Post(ctx context.Context, resource T, parentIDs ...string) (
 // PostWithEditor makes a POST request to create a new resource after modifying the request with requestEditor
 func (c *Client[T]) PostWithEditor(ctx context.Context, resource T, requestEditor RequestEditor, parentIDs ...string) (*Response[T], error) {
 	var body bytes.Buffer
-	err := json.NewEncoder(&body).Encode(resource)
+	err := encodeJSON(&body, resource)
 	if err != nil {
 		return nil, fmt.Errorf("error encoding request body: %w", err)
 	}
 
-	return c.post(ctx, &body, requestEditor, parentIDs...)
+	if !c.clientGeneratedIDs {
+		return c.post(ctx, &body, requestEditor, parentIDs...)
+	}
+
+	id, bodyWithID, err := withGeneratedID(body.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error adding ID to request body: %w", err)
+	}
+
+	// The ID is set on the resource so it is used again if the caller retries with the same resource
+	err = unmarshalJSON(bodyWithID, resource)
+	if err != nil {
+		return nil, fmt.Errorf("error setting ID on resource: %w", err)
+	}
+
+	return c.putWithGeneratedID(ctx, id, body.Bytes(), bodyWithID, requestEditor, parentIDs...)
 }
 
 // PostRequest creates a request that can be used to POST a resource
@@ -322,6 +500,20 @@ func (c *Client[T]) PostRawWithEditor(ctx context.Context, body string, requestE
 }
 
 func (c *Client[T]) post(ctx context.Context, body io.Reader, requestEditor RequestEditor, parentIDs ...string) (*Response[T], error) {
+	if c.clientGeneratedIDs {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading request body: %w", err)
+		}
+
+		id, bodyWithID, err := withGeneratedID(data)
+		if err != nil {
+			return nil, fmt.Errorf("error adding ID to request body: %w", err)
+		}
+
+		return c.putWithGeneratedID(ctx, id, data, bodyWithID, requestEditor, parentIDs...)
+	}
+
 	req, err := c.PostRequest(ctx, body, parentIDs...)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
@@ -335,6 +527,62 @@ func (c *Client[T]) post(ctx context.Context, body io.Reader, requestEditor Requ
 	return result, nil
 }
 
+// putWithGeneratedID creates the resource with a PUT request for UseClientGeneratedIDs. The original body is sent with
+// a POST request if the API does not allow PUT
+func (c *Client[T]) putWithGeneratedID(ctx context.Context, id string, original, bodyWithID []byte, requestEditor RequestEditor, parentIDs ...string) (*Response[T], error) {
+	result, err := c.put(ctx, id, bytes.NewReader(bodyWithID), requestEditor, parentIDs...)
+
+	var httpErr *ErrResponse
+	if errors.As(err, &httpErr) && httpErr.HTTPStatusCode == http.StatusMethodNotAllowed {
+		req, err := c.PostRequest(ctx, bytes.NewReader(original), parentIDs...)
+		if err != nil {
+			return nil, fmt.Errorf("error creating request: %w", err)
+		}
+
+		result, err := c.MakeRequestWithEditor(req, c.customResponseCodes[http.MethodPost], requestEditor)
+		if err != nil {
+			return result, fmt.Errorf("error posting resource: %w", err)
+		}
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error posting resource: %w", err)
+	}
+
+	return result, nil
+}
+
+// withGeneratedID returns the JSON object with a new xid in the "id" field. If the object already has a non-empty
+// ID, it is not changed
+func withGeneratedID(body []byte) (string, []byte, error) {
+	obj, err := decodeOrderedObject(body)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, field := range obj {
+		if field.name != "id" {
+			continue
+		}
+
+		var id string
+		err = unmarshalJSON(field.value.(json.RawMessage), &id)
+		if err == nil && id != "" {
+			return id, body, nil
+		}
+	}
+
+	id := xid.New().String()
+	obj = obj.merge(orderedObject{{"id", id}})
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return id, data, nil
+}
+
 // Patch makes a PATCH request to modify a resource by ID
 func (c *Client[T]) Patch(ctx context.Context, id string, resource T, parentIDs ...string) (*Response[T], error) {
 	return c.PatchWithEditor(ctx, id, resource, c.requestEditor, parentIDs...)
@@ -343,7 +591,25 @@ func (c *Client[T]) Patch(ctx context.Context, id string, resource T, parentIDs
 // PatchWithEditor makes a PATCH request to modify a resource by ID after modifying the request with requestEditor
 func (c *Client[T]) PatchWithEditor(ctx context.Context, id string, resource T, requestEditor RequestEditor, parentIDs ...string) (*Response[T], error) {
 	var body bytes.Buffer
-	err := json.NewEncoder(&body).Encode(resource)
+	err := encodeJSON(&body, resource)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding request body: %w", err)
+	}
+
+	return c.patch(ctx, id, &body, requestEditor, parentIDs...)
+}
+
+// PatchFields makes a PATCH request to modify a resource by ID. The fields are encoded as the request body so only
+// the provided fields are sent, instead of the zero values of every field in a full resource
+func (c *Client[T]) PatchFields(ctx context.Context, id string, fields map[string]any, parentIDs ...string) (*Response[T], error) {
+	return c.PatchFieldsWithEditor(ctx, id, fields, c.requestEditor, parentIDs...)
+}
+
+// PatchFieldsWithEditor makes a PATCH request to modify a resource by ID after modifying the request with requestEditor.
+// The fields are encoded as the request body
+func (c *Client[T]) PatchFieldsWithEditor(ctx context.Context, id string, fields map[string]any, requestEditor RequestEditor, parentIDs ...string) (*Response[T], error) {
+	var body bytes.Buffer
+	err := encodeJSON(&body, fields)
 	if err != nil {
 		return nil, fmt.Errorf("error encoding request body: %w", err)
 	}
@@ -387,6 +653,34 @@ func (c *Client[T]) patch(ctx context.Context, id string, body io.Reader, reques
 	return resp, nil
 }
 
+// BulkPatch makes a PATCH request to modify multiple resources on an API using EnableBulkPatch. The patches map
+// resource IDs to the patch for that resource, which can be a resource or a map of fields. The response has the
+// result for each resource, so errors for individual resources are not returned as an error
+func (c *Client[T]) BulkPatch(ctx context.Context, patches map[string]any, parentIDs ...string) (*Response[*BulkPatchResponse[T]], error) {
+	return c.BulkPatchWithEditor(ctx, patches, c.requestEditor, parentIDs...)
+}
+
+// BulkPatchWithEditor makes a PATCH request to modify multiple resources after modifying the request with requestEditor
+func (c *Client[T]) BulkPatchWithEditor(ctx context.Context, patches map[string]any, requestEditor RequestEditor, parentIDs ...string) (*Response[*BulkPatchResponse[T]], error) {
+	var body bytes.Buffer
+	err := encodeJSON(&body, patches)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding request body: %w", err)
+	}
+
+	req, err := c.PatchRequest(ctx, &body, "", parentIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := MakeRequest[*BulkPatchResponse[T]](req, c.client, http.StatusOK, requestEditor)
+	if err != nil {
+		return nil, fmt.Errorf("error patching resources: %w", err)
+	}
+
+	return resp, nil
+}
+
 // Delete makes a DELETE request to delete a resource by ID
 func (c *Client[T]) Delete(ctx context.Context, id string, parentIDs ...string) (*Response[T], error) {
 	return c.DeleteWithEditor(ctx, id, c.requestEditor, parentIDs...)
@@ -412,6 +706,94 @@ func (c *Client[T]) DeleteRequest(ctx context.Context, id string, parentIDs ...s
 	return c.NewRequestWithParentIDs(ctx, http.MethodDelete, http.NoBody, id, parentIDs...)
 }
 
+// Subscribe connects to a server-sent events endpoint at the provided path, relative to the Client's base URL, and
+// delivers parsed events on the returned channel until the context is cancelled or the server closes the connection.
+// Any error encountered is sent on the error channel. Both channels are closed when the subscription ends
+func (c *Client[T]) Subscribe(ctx context.Context, path string, parentIDs ...string) (<-chan ServerSentEvent, <-chan error) {
+	return c.SubscribeWithEditor(ctx, path, c.requestEditor, parentIDs...)
+}
+
+// SubscribeWithEditor is the same as Subscribe, but modifies the request with requestEditor before connecting
+func (c *Client[T]) SubscribeWithEditor(ctx context.Context, path string, requestEditor RequestEditor, parentIDs ...string) (<-chan ServerSentEvent, <-chan error) {
+	events := make(chan ServerSentEvent)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(events)
+		defer close(errs)
+
+		err := c.subscribe(ctx, path, requestEditor, events, parentIDs...)
+		if err != nil && ctx.Err() == nil {
+			errs <- err
+		}
+	}()
+
+	return events, errs
+}
+
+func (c *Client[T]) subscribe(ctx context.Context, path string, requestEditor RequestEditor, events chan<- ServerSentEvent, parentIDs ...string) error {
+	address, err := c.URL("", parentIDs...)
+	if err != nil {
+		return fmt.Errorf("error creating target URL: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address+path, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := makeRequest(req, c.client, requestEditor)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, err = newResponse[any](resp, http.StatusOK)
+		return fmt.Errorf("error subscribing to events: %w", err)
+	}
+
+	var event ServerSentEvent
+	var data []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "":
+			// An empty line dispatches the event. Lines starting with a colon are comments and are ignored
+			if line != "" || (event.Event == "" && len(data) == 0) {
+				continue
+			}
+
+			event.Data = strings.Join(data, "\n")
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return nil
+			}
+
+			event = ServerSentEvent{}
+			data = nil
+		case "event":
+			event.Event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return fmt.Errorf("error reading events: %w", err)
+	}
+
+	return nil
+}
+
 // NewRequestWithParentIDs uses http.NewRequestWithContext to create a new request using the URL created from the provided ID and parent IDs
 func (c *Client[T]) NewRequestWithParentIDs(ctx context.Context, method string, body io.Reader, id string, parentIDs ...string) (*http.Request, error) {
 	address, err := c.URL(id, parentIDs...)
@@ -442,6 +824,83 @@ func (c *Client[T]) URL(id string, parentIDs ...string) (string, error) {
 	return path, nil
 }
 
+// Do makes a request to a path relative to the resource base, like "/export" or "/{ID}/rsvp", which is useful for
+// calling custom routes. Any response below 400 is accepted and JSON responses are decoded into the resource type.
+// Error responses are returned as *ErrResponse
+func (c *Client[T]) Do(ctx context.Context, method, subpath string, body io.Reader, parentIDs ...string) (*Response[T], error) {
+	return c.DoWithEditor(ctx, method, subpath, body, c.requestEditor, parentIDs...)
+}
+
+// DoWithEditor is like Do, but modifies the request with requestEditor
+func (c *Client[T]) DoWithEditor(ctx context.Context, method, subpath string, body io.Reader, requestEditor RequestEditor, parentIDs ...string) (*Response[T], error) {
+	req, err := c.DoRequest(ctx, method, subpath, body, parentIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := makeRequest(req, c.client, requestEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	// newResponse only decodes errors when the status code is unexpected
+	expectedStatusCode := resp.StatusCode
+	if resp.StatusCode >= http.StatusBadRequest {
+		expectedStatusCode = http.StatusOK
+	}
+
+	return newResponse[T](resp, expectedStatusCode)
+}
+
+// ClientAction makes a request to a custom route registered with RegisterClientAction. If id is set, the request
+// uses the resource's path, like a route created with AddCustomIDRoute. Otherwise, it uses the base path, like a
+// route created with AddCustomRoute
+type ClientAction[T Resource] func(ctx context.Context, id string, body io.Reader, parentIDs ...string) (*Response[T], error)
+
+type clientAction struct {
+	method  string
+	subpath string
+}
+
+// RegisterClientAction adds a named helper for calling a custom route, which can be retrieved with Action. The
+// subpath is relative to the resource base, or the resource's path if an ID is provided when calling the action
+func (c *Client[T]) RegisterClientAction(name, method, subpath string) *Client[T] {
+	c.actions[name] = clientAction{method, subpath}
+	return c
+}
+
+// Action returns the ClientAction registered with the name. The returned action returns an error when it is called
+// if the name is not registered
+func (c *Client[T]) Action(name string) ClientAction[T] {
+	return func(ctx context.Context, id string, body io.Reader, parentIDs ...string) (*Response[T], error) {
+		action, ok := c.actions[name]
+		if !ok {
+			return nil, fmt.Errorf("client action %q is not registered", name)
+		}
+
+		if body == nil {
+			body = http.NoBody
+		}
+
+		return c.Do(ctx, action.method, path.Join(id, action.subpath), body, parentIDs...)
+	}
+}
+
+// DoRequest creates a request for a path relative to the resource base
+func (c *Client[T]) DoRequest(ctx context.Context, method, subpath string, body io.Reader, parentIDs ...string) (*http.Request, error) {
+	address, err := c.URL("", parentIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating target URL: %w", err)
+	}
+
+	subpath = strings.TrimLeft(subpath, "/")
+	if subpath != "" {
+		address += "/" + subpath
+	}
+
+	return http.NewRequestWithContext(ctx, method, address, body)
+}
+
 // MakeRequest generically sends an HTTP request after calling the request editor and checks the response code
 // It returns a babyapi.Response which contains the http.Response after extracting the body to Body string and
 // JSON decoding the resource type into Data if the response is JSON
@@ -482,7 +941,7 @@ func (c *Client[T]) MakeGenericRequest(req *http.Request, target any) (*Response
 		return result, nil
 	}
 
-	err = json.Unmarshal(body, target)
+	err = unmarshalJSON(body, target)
 	if err != nil {
 		return nil, fmt.Errorf("error decoding response body %q: %w", string(body), err)
 	}
@@ -0,0 +1,207 @@
+package babyapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+
+	"github.com/go-chi/render"
+)
+
+// computedField is a field added to JSON responses by AddComputedField
+type computedField[T Resource] struct {
+	name    string
+	compute func(*http.Request, T) any
+}
+
+// AddComputedField adds a field to the JSON responses for each resource, like a value derived from other fields, without
+// storing it or creating a response wrapper type. The function is called with the request when the response is
+// rendered. A computed field replaces a field with the same name in the response, and adding a field with an existing
+// name replaces it. The response from SetResponseWrapper must encode to a JSON object, and responses from
+// SetGetAllResponseWrapper do not include computed fields
+func (a *API[T]) AddComputedField(name string, compute func(*http.Request, T) any) *API[T] {
+	a.panicIfReadOnly()
+
+	if name == "" {
+		a.errors = append(a.errors, fmt.Errorf("AddComputedField: name must not be empty"))
+		return a
+	}
+	if compute == nil {
+		a.errors = append(a.errors, fmt.Errorf("AddComputedField: compute function for %q must not be nil", name))
+		return a
+	}
+
+	for i, field := range a.computedFields {
+		if field.name == name {
+			a.computedFields[i].compute = compute
+			return a
+		}
+	}
+
+	a.computedFields = append(a.computedFields, computedField[T]{name, compute})
+	return a
+}
+
+// wrapResponse uses the response wrapper for the resource and adds the API's computed fields
+func (a *API[T]) wrapResponse(resource T) render.Renderer {
+	return a.wrapResponseWithFields(resource, nil)
+}
+
+// wrapResponseWithFields is like wrapResponse, but also adds the fields, like expanded nested resources, after the
+// computed fields
+func (a *API[T]) wrapResponseWithFields(resource T, extra orderedObject) render.Renderer {
+	resp := a.responseWrapper(resource)
+	if len(a.computedFields) == 0 && len(extra) == 0 {
+		return resp
+	}
+
+	computed := &computedResponse{
+		Renderer: resp,
+		compute: func(r *http.Request) orderedObject {
+			fields := orderedObject{}
+			for _, field := range a.computedFields {
+				fields = append(fields, orderedField{field.name, field.compute(r, resource)})
+			}
+			return fields.merge(extra)
+		},
+	}
+
+	if _, ok := resp.(HTMLer); ok {
+		return &computedHTMLResponse{computed}
+	}
+	return computed
+}
+
+// computedResponse adds computed fields to the JSON encoding of a response. The fields are computed when the
+// response is rendered since that is when the request is available
+type computedResponse struct {
+	render.Renderer
+
+	compute func(*http.Request) orderedObject
+	fields  orderedObject
+}
+
+func (cr *computedResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	err := cr.Renderer.Render(w, r)
+	if err != nil {
+		return err
+	}
+
+	cr.fields = cr.compute(r)
+	return nil
+}
+
+func (cr *computedResponse) MarshalJSON() ([]byte, error) {
+	data, err := marshalJSON(cr.Renderer)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := decodeOrderedObject(data)
+	if err != nil {
+		return nil, fmt.Errorf("error adding computed fields: %w", err)
+	}
+
+	return obj.merge(cr.fields).MarshalJSON()
+}
+
+func (cr *computedResponse) unwrapComputed() *computedResponse {
+	return cr
+}
+
+// computedHTMLResponse is used when the wrapped response implements HTMLer so HTML responses still work. Computed
+// fields are only added to JSON responses
+type computedHTMLResponse struct {
+	*computedResponse
+}
+
+func (chr *computedHTMLResponse) HTML(r *http.Request) string {
+	return chr.Renderer.(HTMLer).HTML(r)
+}
+
+// convertComputed is used by jsonOptions so the options also apply to the wrapped response and computed fields
+func (opts *jsonOptions) convertComputed(cr *computedResponse) (any, error) {
+	value, err := opts.convert(reflect.ValueOf(cr.Renderer))
+	if err != nil {
+		return nil, err
+	}
+
+	obj, ok := value.(orderedObject)
+	if !ok {
+		// The response implements json.Marshaler, so options cannot be applied to its fields
+		raw, ok := value.(json.RawMessage)
+		if !ok {
+			return nil, fmt.Errorf("error adding computed fields: %w", errNotJSONObject)
+		}
+
+		obj, err = decodeOrderedObject(raw)
+		if err != nil {
+			return nil, fmt.Errorf("error adding computed fields: %w", err)
+		}
+	}
+
+	fields := orderedObject{}
+	for _, field := range cr.fields {
+		value, err := opts.convert(reflect.ValueOf(field.value))
+		if err != nil {
+			return nil, fmt.Errorf("error encoding field %q: %w", field.name, err)
+		}
+		fields = append(fields, orderedField{field.name, value})
+	}
+
+	return obj.merge(fields), nil
+}
+
+var errNotJSONObject = errors.New("expected a JSON object")
+
+// decodeOrderedObject decodes a JSON object into an orderedObject of raw values so the fields can be used or encoded
+// again in the same order
+func decodeOrderedObject(data []byte) (orderedObject, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token != json.Delim('{') {
+		return nil, errNotJSONObject
+	}
+
+	obj := orderedObject{}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		var value json.RawMessage
+		err = dec.Decode(&value)
+		if err != nil {
+			return nil, err
+		}
+
+		obj = append(obj, orderedField{token.(string), value})
+	}
+
+	return obj, nil
+}
+
+// merge returns the object with the fields added, replacing existing fields with the same name
+func (o orderedObject) merge(fields orderedObject) orderedObject {
+	result := append(orderedObject{}, o...)
+	for _, field := range fields {
+		exists := slices.ContainsFunc(result, func(f orderedField) bool {
+			return f.name == field.name
+		})
+		if exists {
+			result.set(field.name, field.value)
+			continue
+		}
+		result = append(result, field)
+	}
+	return result
+}
@@ -0,0 +1,129 @@
+package babyapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// SetContentNegotiation sets the preferred order of response content types, like "application/json" and "text/html".
+// The request's Accept header is parsed with q-values and the acceptable type with the highest q-value is used. When
+// multiple types have the same q-value, including wildcards like */*, the earliest type in the order is preferred.
+// If the request does not have an Accept header, the first type is used
+func (a *API[T]) SetContentNegotiation(order []string) *API[T] {
+	a.panicIfReadOnly()
+
+	for _, contentType := range order {
+		if render.GetContentType(contentType) == render.ContentTypeUnknown {
+			a.errors = append(a.errors, fmt.Errorf("SetContentNegotiation: unsupported content type %q", contentType))
+			return a
+		}
+	}
+
+	a.contentNegotiation = order
+	return a
+}
+
+// contentNegotiationMiddleware sets the negotiated content type in the request context so it is used by
+// render.GetAcceptedContentType. If none of the types are acceptable, the default behavior is used
+func (a *API[T]) contentNegotiationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType, ok := negotiateContentType(r.Header.Get("Accept"), a.contentNegotiation)
+		if ok {
+			r = r.WithContext(context.WithValue(r.Context(), render.ContentTypeCtxKey, render.GetContentType(contentType)))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// negotiateContentType chooses the content type from the order with the highest q-value in the Accept header
+func negotiateContentType(accept string, order []string) (string, bool) {
+	if len(order) == 0 {
+		return "", false
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return order[0], true
+	}
+
+	acceptedRanges := parseAccept(accept)
+
+	var result string
+	bestQ := 0.0
+	for _, contentType := range order {
+		q := acceptedQuality(acceptedRanges, contentType)
+		if q > bestQ {
+			result = contentType
+			bestQ = q
+		}
+	}
+
+	return result, result != ""
+}
+
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	var result []mediaRange
+	for _, field := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(field, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if mediaType == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(param, "=")
+			if strings.TrimSpace(key) != "q" {
+				continue
+			}
+
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err == nil {
+				q = parsed
+			}
+		}
+
+		result = append(result, mediaRange{mediaType, q})
+	}
+
+	return result
+}
+
+// acceptedQuality gets the q-value for a content type using the most specific matching media range
+func acceptedQuality(acceptedRanges []mediaRange, contentType string) float64 {
+	contentType = strings.ToLower(contentType)
+	mainType, _, _ := strings.Cut(contentType, "/")
+
+	q := 0.0
+	specificity := -1
+	for _, mr := range acceptedRanges {
+		var s int
+		switch mr.mediaType {
+		case contentType:
+			s = 2
+		case mainType + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+
+		if s > specificity {
+			q = mr.q
+			specificity = s
+		}
+	}
+
+	return q
+}
@@ -4,6 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/xid"
 )
 
 // ContextKey is used to store API resources in the request context
@@ -14,8 +19,42 @@ type ctxKey int
 const (
 	loggerCtxKey ctxKey = iota
 	requestBodyCtxKey
+	parentChainCtxKey
+	generatedIDsCtxKey
+	jsonOptionsCtxKey
+	dryRunCtxKey
+	strictBindingCtxKey
+	streamingResponsesCtxKey
+	responseTransformerCtxKey
+	fileUploadsCtxKey
 )
 
+// generatedIDs records the IDs created by ID.Bind while binding a POST request body. If Bind runs again for the same
+// resource, it keeps the ID instead of treating it as an ID set by the client
+type generatedIDs struct {
+	ids []xid.ID
+}
+
+func newContextWithGeneratedIDs(ctx context.Context) context.Context {
+	return context.WithValue(ctx, generatedIDsCtxKey, &generatedIDs{})
+}
+
+// fileUploads records the files stored by EnableFileField while binding a request body for the default handlers. The
+// files are deleted after the request unless the resource referencing them is stored
+type fileUploads struct {
+	files  []storedFile
+	stored bool
+}
+
+type storedFile struct {
+	store BlobStore
+	key   string
+}
+
+func newContextWithFileUploads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fileUploadsCtxKey, &fileUploads{})
+}
+
 // GetLoggerFromContext returns the structured logger from the context. It expects to use an HTTP
 // request context to get a logger with details from middleware
 func GetLoggerFromContext(ctx context.Context) *slog.Logger {
@@ -32,8 +71,21 @@ func NewContextWithLogger(ctx context.Context, logger *slog.Logger) context.Cont
 	return context.WithValue(ctx, loggerCtxKey, logger)
 }
 
-// GetRequestBodyFromContext gets an API resource from the request context. It can only be used in
-// URL paths that include the resource ID
+// GetRoutePattern returns the route pattern that matched the request, like "/albums/{AlbumsID}", instead of the
+// request path. This is useful for metrics and logs since it does not include IDs. The pattern is built while the
+// request is routed, so it is only complete in handlers or in middlewares after calling the next handler. It returns
+// an empty string if the request was not routed by an API
+func GetRoutePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return ""
+	}
+	return rctx.RoutePattern()
+}
+
+// GetRequestBodyFromContext gets an API resource from the request context. It is only set for the default POST, PUT,
+// and PATCH routes, so it can be used by middlewares and hooks like SetOnCreateOrUpdate to read the body that was
+// already bound
 func GetRequestBodyFromContext[T any](ctx context.Context) (T, bool) {
 	value, ok := ctx.Value(requestBodyCtxKey).(T)
 	if !ok {
@@ -72,6 +124,22 @@ func GetResourceFromContext[T Resource](ctx context.Context, key ContextKey) (T,
 	return val, nil
 }
 
+// GetParentChain returns the parent resources from the request path, ordered from the root API to the direct parent.
+// A resource is added to the chain when the request is for a nested API or custom ID route under it, so the chain
+// does not include the resource requested by a default ID route like GET /base/{ID}
+func GetParentChain(ctx context.Context) []any {
+	chain, _ := ctx.Value(parentChainCtxKey).([]any)
+	return chain
+}
+
+// newContextWithParent adds the resource to the end of the parent chain
+func newContextWithParent(ctx context.Context, resource any) context.Context {
+	chain := GetParentChain(ctx)
+	// Clip so appending never modifies the chain used by other contexts
+	chain = append(slices.Clip(chain), resource)
+	return context.WithValue(ctx, parentChainCtxKey, chain)
+}
+
 func (a *API[T]) newContextWithResource(ctx context.Context, value T) context.Context {
 	return context.WithValue(ctx, a.contextKey(), value)
 }
@@ -0,0 +1,179 @@
+package babyapi
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CORSOptions configures Cross-Origin Resource Sharing for an API with SetCORS
+type CORSOptions struct {
+	// AllowedOrigins are the origins that can make cross-origin requests, like "https://example.com". Use "*" to
+	// allow any origin
+	AllowedOrigins []string
+
+	// AllowedMethods are the methods allowed by preflight requests. The default is GET, HEAD, POST, PUT, PATCH, and
+	// DELETE
+	AllowedMethods []string
+
+	// AllowedHeaders are the request headers allowed by preflight requests. If it is empty, the headers requested by
+	// the preflight request are allowed
+	AllowedHeaders []string
+
+	// ExposedHeaders are response headers that browsers allow clients to read, like ETag
+	ExposedHeaders []string
+
+	// AllowCredentials allows requests with cookies or authorization. The request's origin is used in the
+	// Access-Control-Allow-Origin header instead of "*" since browsers do not allow a wildcard with credentials
+	AllowCredentials bool
+
+	// MaxAge is used for the Access-Control-Max-Age header so browsers can cache the results of preflight requests.
+	// It is rounded down to seconds and the header is not set if it is 0
+	MaxAge time.Duration
+}
+
+var defaultCORSMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// SetCORS enables Cross-Origin Resource Sharing headers for the API's routes. Nested APIs use the same options
+// unless they use SetCORS to set their own, so a child API can have a different policy than its parent. Preflight
+// requests respond with 204 No Content before any other middlewares, like authentication, since browsers do not send
+// credentials with them
+func (a *API[T]) SetCORS(opts CORSOptions) *API[T] {
+	a.panicIfReadOnly()
+
+	if len(opts.AllowedOrigins) == 0 {
+		a.errors = append(a.errors, fmt.Errorf("SetCORS: at least one allowed origin is required"))
+		return a
+	}
+	if opts.MaxAge < 0 {
+		a.errors = append(a.errors, fmt.Errorf("SetCORS: max age must not be negative"))
+		return a
+	}
+	if len(opts.AllowedMethods) == 0 {
+		opts.AllowedMethods = defaultCORSMethods
+	}
+
+	a.cors = &opts
+	return a
+}
+
+// hasCORS returns true if this API or any nested API uses SetCORS
+func (a *API[T]) hasCORS() bool {
+	if a.cors != nil {
+		return true
+	}
+	for _, child := range a.subAPIs {
+		if child.hasCORS() {
+			return true
+		}
+	}
+	return false
+}
+
+// corsOptionsForPath finds the API for the path relative to this API's base and returns the options from the closest
+// API that uses SetCORS
+func (a *API[T]) corsOptionsForPath(path string) *CORSOptions {
+	rest := strings.Trim(path, "/")
+
+	// Root APIs do not have IDs, so nested APIs are directly after the base
+	if !a.isRoot() {
+		_, rest, _ = strings.Cut(rest, "/")
+	}
+
+	for _, child := range a.subAPIs {
+		childBase := strings.Trim(child.Base(), "/")
+		if rest != childBase && !strings.HasPrefix(rest, childBase+"/") {
+			continue
+		}
+
+		if opts := child.corsOptionsForPath(strings.TrimPrefix(rest, childBase)); opts != nil {
+			return opts
+		}
+		break
+	}
+
+	return a.cors
+}
+
+// corsMiddleware is used by the top-level API to set CORS headers using the options for the API that handles the
+// request. This is done in one place because middlewares from parent APIs also run for requests to nested APIs, so
+// the parent would respond to preflight requests before the nested API's middleware
+func (a *API[T]) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		w.Header().Add("Vary", "Origin")
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		// The wildcard URL param is the path after the base path since the API's routes are mounted there
+		opts := a.corsOptionsForPath(chi.URLParam(r, "*"))
+		if origin == "" || opts == nil || !opts.allowsOrigin(origin) {
+			if preflight && opts != nil {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if opts.AllowCredentials || !slices.Contains(opts.AllowedOrigins, "*") {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+		if opts.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if len(opts.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(opts.ExposedHeaders, ", "))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		method := r.Header.Get("Access-Control-Request-Method")
+		if slices.ContainsFunc(opts.AllowedMethods, func(allowed string) bool {
+			return strings.EqualFold(allowed, method)
+		}) {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(opts.AllowedMethods, ", "))
+
+			allowedHeaders := strings.Join(opts.AllowedHeaders, ", ")
+			if len(opts.AllowedHeaders) == 0 {
+				allowedHeaders = r.Header.Get("Access-Control-Request-Headers")
+			}
+			if allowedHeaders != "" {
+				w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+			}
+
+			if opts.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (opts *CORSOptions) allowsOrigin(origin string) bool {
+	return slices.ContainsFunc(opts.AllowedOrigins, func(allowed string) bool {
+		return allowed == "*" || strings.EqualFold(allowed, origin)
+	})
+}
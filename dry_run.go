@@ -0,0 +1,102 @@
+package babyapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// DryRunHeader is the request header used to validate a request without changing storage when EnableDryRun is used.
+// The API responds with the same header when the resource was not stored
+const DryRunHeader = "X-Dry-Run"
+
+// ErrDryRunNotConfirmed is returned by a Client from DryRun when the response to a request that can modify resources
+// does not have the X-Dry-Run header. This means the server does not support dry runs, so the request might have
+// changed resources
+var ErrDryRunNotConfirmed = errors.New("server did not confirm the dry run, so the request might have changed resources")
+
+// EnableDryRun allows requests with the header "X-Dry-Run: true" to validate creating, updating, or deleting resources
+// without changing storage. The default POST, PUT, PATCH, and DELETE handlers still bind the request and run
+// SetOnCreateOrUpdate or SetBeforeDelete, but skip Storage and the after hooks, then respond with the same status and
+// body as a real request. These responses have the X-Dry-Run header so clients know that nothing was stored. This
+// also applies to nested APIs. Custom routes can use IsDryRun to support the header
+func (a *API[T]) EnableDryRun() *API[T] {
+	a.panicIfReadOnly()
+
+	a.dryRun = true
+	return a
+}
+
+// IsDryRun returns true if the request context is for a dry run request to an API using EnableDryRun
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunCtxKey).(bool)
+	return dryRun
+}
+
+// dryRunMiddleware adds the dry run flag to the request context if the X-Dry-Run header is true
+func (a *API[T]) dryRunMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dryRun, _ := strconv.ParseBool(r.Header.Get(DryRunHeader))
+		if dryRun {
+			r = r.WithContext(context.WithValue(r.Context(), dryRunCtxKey, true))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// DryRun returns a copy of the Client that sends the "X-Dry-Run: true" header with every request, so an API using
+// EnableDryRun validates creating or updating resources without storing them. Responses have the resource that would
+// be stored. Successful responses to requests other than GET, HEAD, and OPTIONS must have the X-Dry-Run header, or
+// ErrDryRunNotConfirmed is returned. Since the request was already handled, this only detects that the server ignored
+// the header and cannot undo the changes
+func (c *Client[T]) DryRun() *Client[T] {
+	dryRun := *c
+	if !dryRun.dryRun {
+		dryRun.dryRun = true
+		dryRun.client = wrapDryRun(c.client)
+	}
+	return &dryRun
+}
+
+// wrapDryRun returns a copy of the http.Client that uses dryRunTransport
+func wrapDryRun(client *http.Client) *http.Client {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	wrapped := *client
+	wrapped.Transport = &dryRunTransport{next}
+	return &wrapped
+}
+
+// dryRunTransport sets the X-Dry-Run header on requests and makes sure the server confirmed the dry run
+type dryRunTransport struct {
+	next http.RoundTripper
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the original request
+	req = req.Clone(req.Context())
+	req.Header.Set(DryRunHeader, "true")
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return resp, nil
+	}
+
+	confirmed, _ := strconv.ParseBool(resp.Header.Get(DryRunHeader))
+	if resp.StatusCode >= 400 || confirmed {
+		return resp, nil
+	}
+
+	resp.Body.Close()
+	return nil, ErrDryRunNotConfirmed
+}
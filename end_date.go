@@ -1,6 +1,7 @@
 package babyapi
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"time"
@@ -12,6 +13,37 @@ type EndDateable interface {
 	SetEndDate(time.Time)
 }
 
+const endDatedParam = "end_dated"
+
+// EndDatedQueryParam creates query parameters used with GetAll to include or exclude end-dated resources
 func EndDatedQueryParam(value bool) url.Values {
-	return url.Values{"end_dated": []string{fmt.Sprint(value)}}
+	return url.Values{endDatedParam: []string{fmt.Sprint(value)}}
+}
+
+// EndDatedFilter creates a filter that excludes end-dated resources unless the 'end_dated' query param is "true".
+// Resources that do not implement EndDateable are always included. Storage implementations should use this in GetAll
+// so soft-deletes behave the same regardless of the backend
+func EndDatedFilter[T any](query url.Values) FilterFunc[T] {
+	if query.Get(endDatedParam) == "true" {
+		return nil
+	}
+
+	return func(item T) bool {
+		endDateable, ok := any(item).(EndDateable)
+		return !ok || !endDateable.EndDated()
+	}
+}
+
+// SoftDelete sets the end-date to time.Now() and saves the resource using set if it implements EndDateable and is
+// not already end-dated. It returns false if the resource was not soft-deleted and should be hard-deleted instead.
+// Storage implementations should use this in Delete so soft-deletes behave the same regardless of the backend
+func SoftDelete[T Resource](ctx context.Context, resource T, set func(context.Context, T) error) (bool, error) {
+	endDateable, ok := any(resource).(EndDateable)
+	if !ok || endDateable.EndDated() {
+		return false, nil
+	}
+
+	endDateable.SetEndDate(time.Now())
+
+	return true, set(ctx, resource)
 }
@@ -1,15 +1,26 @@
 package babyapi
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/render"
 )
 
+// StatusClientClosedRequest is the non-standard status code used when the client closes the connection before the
+// server responds
+const StatusClientClosedRequest = 499
+
 var ErrNotFoundResponse = &ErrResponse{HTTPStatusCode: http.StatusNotFound, StatusText: "Resource not found."}
 var ErrMethodNotAllowedResponse = &ErrResponse{HTTPStatusCode: http.StatusMethodNotAllowed, StatusText: "Method not allowed."}
 var ErrForbidden = &ErrResponse{HTTPStatusCode: http.StatusForbidden, StatusText: "Forbidden"}
+var ErrPreconditionFailedResponse = &ErrResponse{HTTPStatusCode: http.StatusPreconditionFailed, StatusText: "Precondition failed."}
+var ErrURITooLongResponse = &ErrResponse{HTTPStatusCode: http.StatusRequestURITooLong, StatusText: "URI too long."}
+var ErrTooManyConnectionsResponse = &ErrResponse{HTTPStatusCode: http.StatusServiceUnavailable, StatusText: "Too many connections."}
 
 // ErrResponse is an error that implements Renderer to be used in HTTP response
 type ErrResponse struct {
@@ -19,6 +30,9 @@ type ErrResponse struct {
 	StatusText string `json:"status"`          // user-level status message
 	AppCode    int64  `json:"code,omitempty"`  // application-specific error code
 	ErrorText  string `json:"error,omitempty"` // application-level error message, for debugging
+
+	// RetryAfter is set by the Client from the Retry-After header of 429 and 503 responses
+	RetryAfter time.Duration `json:"-"`
 }
 
 func (e *ErrResponse) Error() string {
@@ -48,7 +62,26 @@ func ErrInvalidRequest(err error) *ErrResponse {
 	}
 }
 
+func ConflictError(err error) *ErrResponse {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: http.StatusConflict,
+		StatusText:     "Conflict.",
+		ErrorText:      err.Error(),
+	}
+}
+
+// InternalServerError creates a 500 Server Error response for unexpected errors. Errors caused by the request context
+// use ClientClosedRequestError for context.Canceled or GatewayTimeoutError for context.DeadlineExceeded instead, so
+// storage errors from client disconnects and timeouts are not reported as server errors
 func InternalServerError(err error) *ErrResponse {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ClientClosedRequestError(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return GatewayTimeoutError(err)
+	}
+
 	return &ErrResponse{
 		Err:            err,
 		HTTPStatusCode: 500,
@@ -56,3 +89,85 @@ func InternalServerError(err error) *ErrResponse {
 		ErrorText:      err.Error(),
 	}
 }
+
+// ClientClosedRequestError creates a 499 response for requests that are cancelled by the client. Handler does not
+// write this response since the client is no longer waiting for it
+func ClientClosedRequestError(err error) *ErrResponse {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: StatusClientClosedRequest,
+		StatusText:     "Client closed request.",
+		ErrorText:      err.Error(),
+	}
+}
+
+// GatewayTimeoutError creates a 504 Gateway Timeout response for requests that exceed their deadline
+func GatewayTimeoutError(err error) *ErrResponse {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: http.StatusGatewayTimeout,
+		StatusText:     "Request timed out.",
+		ErrorText:      err.Error(),
+	}
+}
+
+// isClientClosed returns true if the error is caused by the client closing the request. The request's context must be
+// done since context.Canceled can also come from other contexts
+func isClientClosed(ctx context.Context, err error) bool {
+	if ctx.Err() == nil {
+		return false
+	}
+
+	var httpErr *ErrResponse
+	if errors.As(err, &httpErr) {
+		return httpErr.HTTPStatusCode == StatusClientClosedRequest
+	}
+	return errors.Is(err, context.Canceled)
+}
+
+// logError logs the error at the error level unless it is caused by the client closing the request. This is a normal
+// part of serving requests, so it is logged at the debug level to keep it out of the error logs
+func logError(ctx context.Context, logger *slog.Logger, msg string, err error) {
+	if isClientClosed(ctx, err) {
+		logger.Debug(msg, "error", err)
+		return
+	}
+	logger.Error(msg, "error", err)
+}
+
+// renderErrResponse renders the error response unless the client already closed the request
+func renderErrResponse(w http.ResponseWriter, r *http.Request, httpErr *ErrResponse) {
+	httpErr, closed := checkClientClosed(r, httpErr)
+	if closed {
+		return
+	}
+	_ = render.Render(w, r, httpErr)
+}
+
+// checkClientClosed returns true if the response is 499 and the request's context is done, so the client is no
+// longer waiting for it. Otherwise, a 499 response is caused by context.Canceled from a different context, like a
+// shutdown context, so it is replaced with 500 Internal Server Error. Not responding would result in an empty 200 OK
+func checkClientClosed(r *http.Request, httpErr *ErrResponse) (*ErrResponse, bool) {
+	if httpErr.HTTPStatusCode != StatusClientClosedRequest {
+		return httpErr, false
+	}
+	if r.Context().Err() != nil {
+		return httpErr, true
+	}
+
+	return &ErrResponse{
+		Err:            httpErr.Err,
+		HTTPStatusCode: http.StatusInternalServerError,
+		StatusText:     "Server Error.",
+		ErrorText:      httpErr.ErrorText,
+	}, false
+}
+
+// storageSetError creates an error response for errors from Storage.Set. ErrConflict results in 409 Conflict and
+// other errors are internal server errors
+func storageSetError(err error) *ErrResponse {
+	if errors.Is(err, ErrConflict) {
+		return ConflictError(err)
+	}
+	return InternalServerError(err)
+}
@@ -0,0 +1,103 @@
+package babyapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// EnableETag adds a weak ETag header to successful responses from the Get and GetAll routes. The ETag is a hash of
+// the response body and Content-Type, so it changes whenever any resource in a collection changes, including fields
+// added by a response wrapper. Requests with a matching If-None-Match header receive a 304 response without a body,
+// which allows polling clients to avoid downloading unchanged resources and lists. Resources that implement
+// Timestamped also get a Last-Modified header and support the If-Modified-Since and If-Unmodified-Since headers
+func (a *API[T]) EnableETag() *API[T] {
+	a.panicIfReadOnly()
+
+	a.etag = true
+	return a
+}
+
+// etagMiddleware buffers the response to calculate the ETag and respond with 304 Not Modified if the request's
+// If-None-Match header matches. It does nothing unless EnableETag is used
+func (a *API[T]) etagMiddleware(next http.Handler) http.Handler {
+	if !a.etag {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bw := &bufferedResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(bw, r)
+
+		if bw.status == 0 {
+			bw.status = http.StatusOK
+		}
+
+		if bw.status < 200 || bw.status >= 300 {
+			w.WriteHeader(bw.status)
+			_, _ = w.Write(bw.body.Bytes())
+			return
+		}
+
+		etag := weakETag(w.Header().Get("Content-Type"), bw.body.Bytes())
+		w.Header().Set("ETag", etag)
+
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(bw.status)
+		_, _ = w.Write(bw.body.Bytes())
+	})
+}
+
+// weakETag creates a weak ETag using a hash of the Content-Type and body
+func weakETag(contentType string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(contentType))
+	hash.Write([]byte{0})
+	hash.Write(body)
+
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches uses weak comparison to check if the ETag matches any of the values in the If-None-Match header
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, value := range strings.Split(ifNoneMatch, ",") {
+		value = strings.TrimSpace(value)
+		if value == "*" || strings.TrimPrefix(value, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bufferedResponseWriter stores the status code and body instead of writing them so the response can be inspected
+// before it is sent. Headers are still set directly on the wrapped http.ResponseWriter
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (bw *bufferedResponseWriter) WriteHeader(statusCode int) {
+	if bw.status == 0 {
+		bw.status = statusCode
+	}
+}
+
+func (bw *bufferedResponseWriter) Write(b []byte) (int, error) {
+	if bw.status == 0 {
+		bw.status = http.StatusOK
+	}
+	return bw.body.Write(b)
+}
@@ -215,13 +215,13 @@ type Event struct {
 	Details  string
 
 	// Password should only be used in POST requests to create new Events and then is removed
-	Password string `json:",omitempty"`
+	Password string `json:",omitempty" babyapi:"redact"`
 	// this unexported password allows using it internally without exporting to storage or responses
 	password string
 
-	// These fields are excluded from responses
-	Salt string `json:",omitempty"`
-	Key  string `json:",omitempty"`
+	// These fields are excluded from responses and logs
+	Salt string `json:",omitempty" babyapi:"redact"`
+	Key  string `json:",omitempty" babyapi:"redact"`
 }
 
 func (e *Event) Render(w http.ResponseWriter, r *http.Request) error {
@@ -238,13 +238,9 @@ func (e *Event) Render(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
-// Disable PUT requests for Events because it complicates things with passwords
 // When creating a new resource with POST, salt and hash the password for storing
 func (e *Event) Bind(r *http.Request) error {
 	switch r.Method {
-	case http.MethodPut:
-		render.Status(r, http.StatusMethodNotAllowed)
-		return fmt.Errorf("PUT not allowed")
 	case http.MethodPost:
 		if e.Password == "" {
 			return errors.New("missing required 'password' field")
@@ -368,6 +364,9 @@ func createAPI() *API {
 		return createEventPage.Renderer(map[string]any{})
 	})
 
+	// Disable PUT requests for Events because it complicates things with passwords
+	api.Events.DisableMethods(http.MethodPut)
+
 	api.Events.
 		AddIDMiddleware(api.Events.GetRequestedResourceAndDoMiddleware(api.authenticationMiddleware)).
 		AddIDMiddleware(api.Events.GetRequestedResourceAndDoMiddleware(api.getAllInvitesMiddleware))
@@ -124,9 +124,9 @@ func TestAPI(t *testing.T) {
 				},
 			},
 			ExpectedResponse: babytest.ExpectedResponse{
-				Status: http.StatusBadRequest,
-				Body:   `{"status":"Invalid request.","error":"PUT not allowed"}`,
-				Error:  "error putting resource: unexpected response with text: Invalid request.",
+				Status: http.StatusMethodNotAllowed,
+				Body:   `{"status":"Method not allowed."}`,
+				Error:  "error putting resource: unexpected response with text: Method not allowed.",
 			},
 		},
 		{
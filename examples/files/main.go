@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/calvinmclean/babyapi"
+	"github.com/calvinmclean/babyapi/extensions/blob"
+	"github.com/calvinmclean/babyapi/storage/kv"
+)
+
+func createAPI() *babyapi.API[*blob.File] {
+	api := babyapi.NewAPI("Files", "/files", func() *blob.File { return &blob.File{} })
+
+	api.ApplyExtension(blob.FileResource[*blob.File]{Store: createStore()})
+
+	return api
+}
+
+// createStore uses S3 if a bucket is configured. Otherwise, content is stored in memory
+func createStore() babyapi.BlobStore {
+	bucket := os.Getenv("S3_BUCKET")
+	if bucket == "" {
+		return babyapi.NewKVBlobStore(kv.NewDefaultDB(), "Files")
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	return blob.NewS3Blob(s3.NewFromConfig(cfg), bucket, "files")
+}
+
+func main() {
+	api := createAPI()
+	api.RunCLI()
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/calvinmclean/babyapi"
@@ -36,22 +35,17 @@ type SongResponse struct {
 	*Song
 	AlbumTitle string `json:"album_title"`
 	ArtistName string `json:"artist_name"`
-
-	api *babyapi.API[*Song] `json:"-"`
 }
 
 func (sr *SongResponse) Render(w http.ResponseWriter, r *http.Request) error {
-	album, err := babyapi.GetResourceFromContext[*Album](r.Context(), sr.api.ParentContextKey())
-	if err != nil {
-		return fmt.Errorf("error getting album: %w", err)
-	}
-	sr.AlbumTitle = album.Title
-
-	artist, err := babyapi.GetResourceFromContext[*Artist](r.Context(), babyapi.ContextKey(sr.api.Parent().Parent().Name()))
-	if err != nil {
-		return fmt.Errorf("error getting artist: %w", err)
+	for _, parent := range babyapi.GetParentChain(r.Context()) {
+		switch p := parent.(type) {
+		case *Album:
+			sr.AlbumTitle = p.Title
+		case *Artist:
+			sr.ArtistName = p.Name
+		}
 	}
-	sr.ArtistName = artist.Name
 
 	return nil
 }
@@ -75,7 +69,7 @@ func createAPI() API {
 	songAPI := babyapi.NewAPI("Songs", "/songs", func() *Song { return &Song{} })
 
 	songAPI.SetResponseWrapper(func(s *Song) render.Renderer {
-		return &SongResponse{Song: s, api: songAPI}
+		return &SongResponse{Song: s}
 	})
 
 	artistAPI.AddNestedAPI(albumAPI)
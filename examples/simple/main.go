@@ -15,5 +15,6 @@ func main() {
 		"TODOs", "/todos",
 		func() *TODO { return &TODO{} },
 	)
+	api.EnableQueryFilter()
 	api.RunCLI()
 }
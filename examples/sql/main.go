@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 
 	"github.com/calvinmclean/babyapi"
 	"github.com/calvinmclean/babyapi/examples/sql/db"
@@ -41,12 +42,41 @@ func (a Author) Render(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
-// Storage implements the babyapi.Storage interface with the sqlc-generated queries
+// Storage implements the babyapi.Storage and babyapi.Transactional interfaces with the sqlc-generated queries
 type Storage struct {
 	dbMode string
+	db     *sql.DB
 	*db.Queries
 }
 
+var _ babyapi.Transactional[*Author] = Storage{}
+
+type txCtxKey struct{}
+
+// WithTx runs the function in a database transaction. If the context already has a transaction from another
+// Storage using the same database, it is used instead of starting a new one so multiple storages can be atomic
+func (s Storage) WithTx(ctx context.Context, fn func(context.Context, babyapi.Storage[*Author]) error) error {
+	tx, ok := ctx.Value(txCtxKey{}).(*sql.Tx)
+	if ok {
+		return fn(ctx, Storage{s.dbMode, s.db, s.Queries.WithTx(tx)})
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	err = fn(context.WithValue(ctx, txCtxKey{}, tx), Storage{s.dbMode, s.db, s.Queries.WithTx(tx)})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (s Storage) Get(ctx context.Context, id string) (*Author, error) {
 	a, err := s.Queries.GetAuthor(ctx, id)
 	if err != nil {
@@ -79,12 +109,17 @@ func (s Storage) GetAll(ctx context.Context, query url.Values) ([]*Author, error
 }
 
 func (s Storage) Set(ctx context.Context, a *Author) error {
-	return s.Queries.UpsertAuthor(ctx, db.UpsertAuthorParams{
+	err := s.Queries.UpsertAuthor(ctx, db.UpsertAuthorParams{
 		ID:    a.ID,
 		Name:  a.Name,
 		Bio:   a.Bio,
 		Genre: a.Genre,
 	})
+	// Wrap constraint violations with babyapi.ErrConflict so the API responds with 409 Conflict
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return fmt.Errorf("%w: %w", babyapi.ErrConflict, err)
+	}
+	return err
 }
 
 func (s Storage) Delete(ctx context.Context, id string) error {
@@ -108,6 +143,7 @@ func (s *Storage) Apply(api *babyapi.API[*Author]) error {
 		return fmt.Errorf("error creating table: %w", err)
 	}
 
+	s.db = database
 	s.Queries = db.New(database)
 	api.SetStorage(s)
 
@@ -12,8 +12,8 @@ import (
 )
 
 const (
-	allTODOs         html.Template = "allTODOs"
-	allTODOsTemplate string        = `<!doctype html>
+	layout         html.Template = "layout"
+	layoutTemplate string        = `<!doctype html>
 <html>
 	<head>
 		<meta charset="UTF-8">
@@ -32,42 +32,45 @@ const (
 	</style>
 
 	<body>
-		<table class="uk-table uk-table-divider uk-margin-left uk-margin-right">
-			<colgroup>
-				<col>
-				<col>
-				<col style="width: 300px;">
-			</colgroup>
-
-			<thead>
-				<tr>
-					<th>Title</th>
-					<th>Description</th>
-					<th></th>
-				</tr>
-			</thead>
-
-			<tbody hx-ext="sse" sse-connect="/todos/listen" sse-swap="newTODO" hx-swap="beforeend">
-				<form hx-post="/todos" hx-swap="none" hx-on::after-request="this.reset()">
-					<td>
-						<input class="uk-input" name="Title" type="text">
-					</td>
-					<td>
-						<input class="uk-input" name="Description" type="text">
-					</td>
-					<td>
-						<button type="submit" class="uk-button uk-button-primary">Add TODO</button>
-					</td>
-				</form>
-
-				{{ range . }}
-				{{ template "todoRow" . }}
-				{{ end }}
-			</tbody>
-		</table>
+		{{ template "content" . }}
 	</body>
 </html>`
 
+	allTODOs         html.Template = "allTODOs"
+	allTODOsTemplate string        = `<table class="uk-table uk-table-divider uk-margin-left uk-margin-right">
+	<colgroup>
+		<col>
+		<col>
+		<col style="width: 300px;">
+	</colgroup>
+
+	<thead>
+		<tr>
+			<th>Title</th>
+			<th>Description</th>
+			<th></th>
+		</tr>
+	</thead>
+
+	<tbody hx-ext="sse" sse-connect="/todos/listen" sse-swap="newTODO" hx-swap="beforeend">
+		<form hx-post="/todos" hx-swap="none" hx-on::after-request="this.reset()">
+			<td>
+				<input class="uk-input" name="Title" type="text">
+			</td>
+			<td>
+				<input class="uk-input" name="Description" type="text">
+			</td>
+			<td>
+				<button type="submit" class="uk-button uk-button-primary">Add TODO</button>
+			</td>
+		</form>
+
+		{{ range . }}
+		{{ template "todoRow" . }}
+		{{ end }}
+	</tbody>
+</table>`
+
 	todoRow         html.Template = "todoRow"
 	todoRowTemplate string        = `<tr hx-target="this" hx-swap="outerHTML">
 	<td>{{ .Title }}</td>
@@ -121,7 +124,7 @@ func (at AllTODOs) Render(w http.ResponseWriter, r *http.Request) error {
 }
 
 func (at AllTODOs) HTML(r *http.Request) string {
-	return allTODOs.Render(r, at.Items)
+	return allTODOs.RenderPage(r, layout, at.Items)
 }
 
 func createAPI() *babyapi.API[*TODO] {
@@ -131,7 +134,7 @@ func createAPI() *babyapi.API[*TODO] {
 
 	// Use AllTODOs in the GetAll response since it implements HTMLer
 	api.SetGetAllResponseWrapper(func(todos []*TODO) render.Renderer {
-		return AllTODOs{ResourceList: babyapi.ResourceList[*TODO]{todos}}
+		return AllTODOs{ResourceList: babyapi.ResourceList[*TODO]{Items: todos}}
 	})
 
 	api.ApplyExtension(extensions.HTMX[*TODO]{})
@@ -145,11 +148,16 @@ func createAPI() *babyapi.API[*TODO] {
 			return nil
 		}
 
-		select {
-		case todoChan <- &babyapi.ServerSentEvent{Event: "newTODO", Data: t.HTML(r)}:
-		default:
+		// Skip rendering the event if nobody is listening
+		if api.ServerSentEventListeners("/listen") == 0 {
 			logger := babyapi.GetLoggerFromContext(r.Context())
 			logger.Info("no listeners for server-sent event")
+			return nil
+		}
+
+		select {
+		case todoChan <- &babyapi.ServerSentEvent{Event: "newTODO", Data: t.HTML(r)}:
+		case <-api.Done():
 		}
 		return nil
 	})
@@ -165,6 +173,7 @@ func createAPI() *babyapi.API[*TODO] {
 	})
 
 	html.SetMap(map[string]string{
+		string(layout):   layoutTemplate,
 		string(allTODOs): allTODOsTemplate,
 		string(todoRow):  todoRowTemplate,
 	})
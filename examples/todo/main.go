@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/calvinmclean/babyapi"
@@ -52,14 +53,14 @@ func (t *TODO) Bind(r *http.Request) error {
 
 func main() {
 	api := babyapi.NewAPI("TODOs", "/todos", func() *TODO { return &TODO{} })
-	api.SetGetAllFilter(func(r *http.Request) babyapi.FilterFunc[*TODO] {
-		return func(t *TODO) bool {
-			getCompletedParam := r.URL.Query().Get("completed")
-			// No filtering if param is not provided
-			if getCompletedParam == "" {
-				return true
-			}
+	api.AddGetAllFilter("completed", func(r *http.Request) babyapi.FilterFunc[*TODO] {
+		getCompletedParam := r.URL.Query().Get("completed")
+		// No filtering if param is not provided
+		if getCompletedParam == "" {
+			return nil
+		}
 
+		return func(t *TODO) bool {
 			if getCompletedParam == "true" {
 				return t.Completed != nil && *t.Completed
 			}
@@ -67,6 +68,17 @@ func main() {
 			return t.Completed == nil || !*t.Completed
 		}
 	})
+	api.AddGetAllFilter("title", func(r *http.Request) babyapi.FilterFunc[*TODO] {
+		titleParam := r.URL.Query().Get("title")
+		// No filtering if param is not provided
+		if titleParam == "" {
+			return nil
+		}
+
+		return func(t *TODO) bool {
+			return strings.Contains(strings.ToLower(t.Title), strings.ToLower(titleParam))
+		}
+	})
 
 	api.RunCLI()
 }
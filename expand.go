@@ -0,0 +1,153 @@
+package babyapi
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// expandQueryParam is the query parameter used to add nested API resources to a response
+const expandQueryParam = "expand"
+
+// SetMaxExpandDepth enables the "expand" query parameter for GET requests to /base/{ID}, which adds the resources
+// from nested APIs to the response. The parameter is a comma-separated list of nested API names, using dots for deeper
+// levels, like "?expand=Albums.Songs,Concerts". Each name is added as a field with an array of the child resources
+// that have the resource as a parent, so the child resources must implement ChildResource or MultiParentResource.
+// Child resources are read directly from the nested API's Storage and encoded without its response wrappers or
+// computed fields. The nested API's middlewares, ID middlewares, and handlers are not used, so expand should not be
+// enabled if they authorize requests or remove fields from responses.
+//
+// The depth is the number of levels in the longest path, so each level reads another API's storage for every resource
+// in the previous level. Requests with a deeper path respond with 400 Bad Request. The default is 0, which disables
+// expand so the query parameter is ignored
+func (a *API[T]) SetMaxExpandDepth(depth int) *API[T] {
+	a.panicIfReadOnly()
+
+	if depth < 0 {
+		a.errors = append(a.errors, fmt.Errorf("SetMaxExpandDepth: depth must not be negative"))
+		return a
+	}
+
+	a.maxExpandDepth = depth
+	return a
+}
+
+// expandPath is a nested API to expand and the paths to expand for each of its resources
+type expandPath struct {
+	name     string
+	children []expandPath
+}
+
+// parseExpand parses the comma-separated paths from the expand query parameter into a tree and returns the depth of
+// the longest path
+func parseExpand(value string) ([]expandPath, int, error) {
+	paths := []expandPath{}
+	depth := 0
+	for _, path := range strings.Split(value, ",") {
+		names := strings.Split(strings.TrimSpace(path), ".")
+		depth = max(depth, len(names))
+
+		level := &paths
+		for _, name := range names {
+			if name == "" {
+				return nil, 0, fmt.Errorf("invalid expand path %q", path)
+			}
+
+			i := slices.IndexFunc(*level, func(p expandPath) bool {
+				return p.name == name
+			})
+			if i < 0 {
+				*level = append(*level, expandPath{name: name})
+				i = len(*level) - 1
+			}
+			level = &(*level)[i].children
+		}
+	}
+
+	return paths, depth, nil
+}
+
+// expand reads the expand query parameter and gets the fields with nested resources for the resource
+func (a *API[T]) expand(r *http.Request, id string) (orderedObject, *ErrResponse) {
+	paths, depth, err := parseExpand(r.URL.Query().Get(expandQueryParam))
+	if err != nil {
+		return nil, ErrInvalidRequest(err)
+	}
+	if depth > a.maxExpandDepth {
+		return nil, ErrInvalidRequest(fmt.Errorf("expand depth %d exceeds the maximum of %d", depth, a.maxExpandDepth))
+	}
+
+	return a.expandFields(r, id, paths)
+}
+
+// expandFields gets the resources from each nested API in the paths that belong to the resource with the ID
+func (a *API[T]) expandFields(r *http.Request, id string, paths []expandPath) (orderedObject, *ErrResponse) {
+	fields := orderedObject{}
+	for _, path := range paths {
+		child, ok := a.subAPIs[path.name]
+		if !ok {
+			return nil, ErrInvalidRequest(fmt.Errorf("unknown nested API %q in expand", path.name))
+		}
+
+		items, httpErr := child.expandResources(r, id, path.children)
+		if httpErr != nil {
+			return nil, httpErr
+		}
+		fields = append(fields, orderedField{path.name, items})
+	}
+
+	return fields, nil
+}
+
+// expandResources gets this API's resources with the parent ID, sorted by ID, and expands the paths for each of them
+func (a *API[T]) expandResources(r *http.Request, parentID string, paths []expandPath) ([]any, *ErrResponse) {
+	if !hasParents[T]() {
+		return nil, ErrInvalidRequest(fmt.Errorf("nested API %q cannot be expanded because its resources do not have parent IDs", a.name))
+	}
+
+	var resources []T
+	var err error
+	parentIndexed, ok := a.Storage.(ParentIndexed[T])
+	if ok {
+		resources, err = parentIndexed.GetAllByParent(r.Context(), parentID, nil)
+	} else {
+		resources, err = a.Storage.GetAll(r.Context(), nil)
+		resources = slices.DeleteFunc(resources, func(item T) bool {
+			return !slices.Contains(parentIDsOf(item), parentID)
+		})
+	}
+	if err != nil {
+		return nil, InternalServerError(fmt.Errorf("error getting resources to expand %q: %w", a.name, err))
+	}
+
+	slices.SortStableFunc(resources, func(a, b T) int {
+		return strings.Compare(a.GetID(), b.GetID())
+	})
+
+	items := make([]any, 0, len(resources))
+	for _, resource := range resources {
+		if len(paths) == 0 {
+			items = append(items, resource)
+			continue
+		}
+
+		data, err := marshalJSON(resource)
+		if err != nil {
+			return nil, InternalServerError(fmt.Errorf("error encoding resource to expand %q: %w", a.name, err))
+		}
+
+		obj, err := decodeOrderedObject(data)
+		if err != nil {
+			return nil, InternalServerError(fmt.Errorf("error encoding resource to expand %q: %w", a.name, err))
+		}
+
+		fields, httpErr := a.expandFields(r, resource.GetID(), paths)
+		if httpErr != nil {
+			return nil, httpErr
+		}
+		items = append(items, obj.merge(fields))
+	}
+
+	return items, nil
+}
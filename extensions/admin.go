@@ -0,0 +1,301 @@
+package extensions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/calvinmclean/babyapi"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// Admin is an Extension that adds a basic HTML interface for managing resources without writing templates. The
+// form fields are created from the resource's JSON schema. It adds these routes to the root of a top-level API:
+//   - GET /admin/base lists all resources with a form to create a new one
+//   - GET /admin/base/{ID} shows a form to update or delete the resource
+//
+// APIs with parents, including APIs nested under a NewRootAPI, can't add routes to the root, so the routes are added
+// to the API's base path instead, like /parents/{ParentsID}/base/admin. Admin must be applied after the API is added
+// to its parent with AddNestedAPI. For nested APIs with resources that implement babyapi.ChildResource, the pages
+// only include resources that belong to the parent from the request path.
+//
+// The pages use HTMX to send requests to the API's default routes, so the resource must support form inputs for
+// POST and PUT. Only string, number, integer, and boolean fields are shown in forms. The admin pages do not add any
+// authentication, so use Middleware or the API's middlewares to protect them
+type Admin[T babyapi.Resource] struct {
+	// Middleware is optional middleware for the admin pages, like authentication. It does not apply to the API's
+	// routes used by the pages, so they must be protected separately
+	Middleware func(http.Handler) http.Handler
+}
+
+func (a Admin[T]) Apply(api *babyapi.API[T]) error {
+	wrap := func(h http.HandlerFunc) http.Handler {
+		if a.Middleware == nil {
+			return h
+		}
+		return a.Middleware(h)
+	}
+
+	fields := adminFields(api)
+
+	// newPage gets the base paths for the request. Nested APIs use the path from the request since it has the
+	// parent IDs. The suffix is the part of the path after the API's base path
+	newPage := func(r *http.Request, suffix string, rows []adminRow) adminPage {
+		if api.Parent() == nil {
+			return adminPage{Name: api.Name(), Base: api.Base(), AdminBase: "/admin" + api.Base(), Fields: fields, Rows: rows}
+		}
+
+		base := strings.TrimSuffix(r.URL.Path, suffix)
+		return adminPage{Name: api.Name(), Base: base, AdminBase: base + "/admin", Fields: fields, Rows: rows}
+	}
+
+	// belongsToParent checks that the resource is for the parent from the request path
+	belongsToParent := func(r *http.Request, resource T) bool {
+		if api.Parent() == nil {
+			return true
+		}
+
+		parentID := api.GetParentIDParam(r)
+		child, ok := any(resource).(babyapi.ChildResource)
+		return parentID == "" || !ok || child.ParentID() == parentID
+	}
+
+	listPage := wrap(func(w http.ResponseWriter, r *http.Request) {
+		resources, err := api.Storage.GetAll(r.Context(), nil)
+		if err != nil {
+			_ = render.Render(w, r, babyapi.InternalServerError(err))
+			return
+		}
+
+		rows := []adminRow{}
+		for _, resource := range resources {
+			if !belongsToParent(r, resource) {
+				continue
+			}
+
+			row, err := newAdminRow(resource)
+			if err != nil {
+				_ = render.Render(w, r, babyapi.InternalServerError(err))
+				return
+			}
+			rows = append(rows, row)
+		}
+
+		renderAdminPage(w, r, adminListTemplate, newPage(r, "/admin", rows))
+	})
+
+	editPage := wrap(func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		resource, err := api.Storage.Get(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, babyapi.ErrNotFound) {
+				_ = render.Render(w, r, api.NotFoundResponse())
+				return
+			}
+			_ = render.Render(w, r, babyapi.InternalServerError(err))
+			return
+		}
+		if !belongsToParent(r, resource) {
+			_ = render.Render(w, r, api.NotFoundResponse())
+			return
+		}
+
+		row, err := newAdminRow(resource)
+		if err != nil {
+			_ = render.Render(w, r, babyapi.InternalServerError(err))
+			return
+		}
+
+		renderAdminPage(w, r, adminEditTemplate, newPage(r, "/admin/"+id, []adminRow{row}))
+	})
+
+	if api.Parent() != nil {
+		api.AddCustomRoute(http.MethodGet, "/admin", listPage)
+		api.AddCustomRoute(http.MethodGet, "/admin/{id}", editPage)
+		return nil
+	}
+
+	api.AddCustomRootRoute(http.MethodGet, "/admin"+api.Base(), listPage)
+	api.AddCustomRootRoute(http.MethodGet, "/admin"+api.Base()+"/{id}", editPage)
+
+	return nil
+}
+
+// adminField is a form field for the admin pages
+type adminField struct {
+	Name  string
+	Input string
+}
+
+// adminFields creates form fields for the properties in the resource's JSON schema. The ID is excluded because it is
+// set by the API
+func adminFields[T babyapi.Resource](api *babyapi.API[T]) []adminField {
+	schema := api.Schema()
+	if schema.Properties == nil {
+		return nil
+	}
+
+	fields := []adminField{}
+	for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		if pair.Key == "id" {
+			continue
+		}
+
+		var input string
+		switch pair.Value.Type {
+		case "string":
+			input = "text"
+		case "integer", "number":
+			input = "number"
+		case "boolean":
+			input = "checkbox"
+		default:
+			continue
+		}
+
+		fields = append(fields, adminField{pair.Key, input})
+	}
+
+	return fields
+}
+
+// adminRow is a resource's ID and JSON fields formatted for display
+type adminRow struct {
+	ID     string
+	Values map[string]string
+}
+
+func newAdminRow[T babyapi.Resource](resource T) (adminRow, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return adminRow{}, fmt.Errorf("error encoding resource: %w", err)
+	}
+
+	var fields map[string]any
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return adminRow{}, fmt.Errorf("error decoding resource: %w", err)
+	}
+
+	values := map[string]string{}
+	for name, value := range fields {
+		if s, ok := value.(string); ok {
+			values[name] = s
+			continue
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return adminRow{}, fmt.Errorf("error encoding field %q: %w", name, err)
+		}
+		values[name] = string(encoded)
+	}
+
+	return adminRow{resource.GetID(), values}, nil
+}
+
+// adminForm is the data used to render form fields with existing values
+type adminForm struct {
+	Fields []adminField
+	Values map[string]string
+}
+
+// adminPage is the data used to render the admin pages. Base is the path of the API's routes and AdminBase is the
+// path of the list page
+type adminPage struct {
+	Name      string
+	Base      string
+	AdminBase string
+	Fields    []adminField
+	Rows      []adminRow
+}
+
+func renderAdminPage(w http.ResponseWriter, r *http.Request, tmpl *template.Template, page adminPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	err := tmpl.Execute(w, page)
+	if err != nil {
+		babyapi.GetLoggerFromContext(r.Context()).Error("error rendering admin page", "error", err)
+	}
+}
+
+var adminFuncs = template.FuncMap{
+	"form": func(fields []adminField, values map[string]string) adminForm {
+		return adminForm{fields, values}
+	},
+}
+
+const adminLayout = `<!doctype html>
+<html>
+<head>
+	<meta charset="UTF-8">
+	<title>{{ .Name }} Admin</title>
+	<script src="https://unpkg.com/htmx.org@1.9.8"></script>
+</head>
+<body>
+	<h1><a href="{{ .AdminBase }}">{{ .Name }}</a></h1>
+	{{ template "content" . }}
+</body>
+</html>`
+
+const adminFormFields = `{{ define "fields" }}
+{{- $values := .Values }}
+{{- range .Fields }}
+	<label>{{ .Name }}
+	{{- if eq .Input "checkbox" }}
+		<input type="checkbox" name="{{ .Name }}" value="true"{{ if eq (index $values .Name) "true" }} checked{{ end }}>
+	{{- else }}
+		<input type="{{ .Input }}" name="{{ .Name }}" value="{{ index $values .Name }}"{{ if eq .Input "number" }} step="any"{{ end }}>
+	{{- end }}
+	</label>
+{{- end }}
+{{ end }}`
+
+var adminListTemplate = template.Must(template.Must(template.New("layout").Funcs(adminFuncs).Parse(adminLayout)).Parse(adminFormFields + `
+{{ define "content" }}
+	<table>
+		<thead>
+			<tr>
+				<th>id</th>
+				{{- range .Fields }}
+				<th>{{ .Name }}</th>
+				{{- end }}
+			</tr>
+		</thead>
+		<tbody>
+			{{- $fields := .Fields }}
+			{{- $adminBase := .AdminBase }}
+			{{- range .Rows }}
+			{{- $row := . }}
+			<tr>
+				<td><a href="{{ $adminBase }}/{{ .ID }}">{{ .ID }}</a></td>
+				{{- range $fields }}
+				<td>{{ index $row.Values .Name }}</td>
+				{{- end }}
+			</tr>
+			{{- end }}
+		</tbody>
+	</table>
+
+	<h2>Create</h2>
+	<form hx-post="{{ .Base }}" hx-swap="none" hx-on::after-request="if (event.detail.successful) window.location.reload()">
+		{{- template "fields" (form .Fields nil) }}
+		<button type="submit">Create</button>
+	</form>
+{{ end }}`))
+
+var adminEditTemplate = template.Must(template.Must(template.New("layout").Funcs(adminFuncs).Parse(adminLayout)).Parse(adminFormFields + `
+{{ define "content" }}
+	{{- $row := index .Rows 0 }}
+	<h2>{{ $row.ID }}</h2>
+	<form hx-put="{{ .Base }}/{{ $row.ID }}" hx-swap="none" hx-on::after-request="if (event.detail.successful) window.location.reload()">
+		<input type="hidden" name="id" value="{{ $row.ID }}">
+		{{- template "fields" (form .Fields $row.Values) }}
+		<button type="submit">Update</button>
+	</form>
+	<button hx-delete="{{ .Base }}/{{ $row.ID }}" hx-confirm="Delete {{ $row.ID }}?" hx-swap="none" hx-on::after-request="if (event.detail.successful) window.location.href = '{{ .AdminBase }}'">Delete</button>
+{{ end }}`))
@@ -0,0 +1,217 @@
+package extensions
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/calvinmclean/babyapi"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type AdminItem struct {
+	babyapi.DefaultResource
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	Done  bool   `json:"done"`
+}
+
+func TestAdmin(t *testing.T) {
+	api := babyapi.NewAPI("Items", "/items", func() *AdminItem { return &AdminItem{} })
+	api.ApplyExtension(Admin[*AdminItem]{
+		Middleware: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "admin" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+		},
+	})
+
+	router := chi.NewRouter()
+	require.NoError(t, api.Route(router))
+
+	serve := func(method, target string, body url.Values) *httptest.ResponseRecorder {
+		var r *http.Request
+		if body == nil {
+			r = httptest.NewRequest(method, target, http.NoBody)
+		} else {
+			r = httptest.NewRequest(method, target, strings.NewReader(body.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		r.Header.Set("Authorization", "admin")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(http.MethodPost, "/items", url.Values{"name": {"Item One"}, "count": {"3"}, "done": {"true"}})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	items, err := api.Storage.GetAll(context.Background(), nil)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	id := items[0].GetID()
+
+	t.Run("ListPage", func(t *testing.T) {
+		w := serve(http.MethodGet, "/admin/items", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+
+		body := w.Body.String()
+		require.Contains(t, body, `<a href="/admin/items/`+id+`">`+id+`</a>`)
+		require.Contains(t, body, `<td>Item One</td>`)
+		require.Contains(t, body, `<td>3</td>`)
+		require.Contains(t, body, `<form hx-post="/items"`)
+		require.Contains(t, body, `<input type="text" name="name" value="">`)
+		require.Contains(t, body, `<input type="number" name="count" value="" step="any">`)
+		require.Contains(t, body, `<input type="checkbox" name="done" value="true">`)
+	})
+
+	t.Run("EditPage", func(t *testing.T) {
+		w := serve(http.MethodGet, "/admin/items/"+id, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		body := w.Body.String()
+		require.Contains(t, body, `<form hx-put="/items/`+id+`"`)
+		require.Contains(t, body, `<input type="hidden" name="id" value="`+id+`">`)
+		require.Contains(t, body, `<input type="text" name="name" value="Item One">`)
+		require.Contains(t, body, `<input type="number" name="count" value="3" step="any">`)
+		require.Contains(t, body, `<input type="checkbox" name="done" value="true" checked>`)
+		require.Contains(t, body, `hx-delete="/items/`+id+`"`)
+	})
+
+	t.Run("EditFormUpdatesResource", func(t *testing.T) {
+		w := serve(http.MethodPut, "/items/"+id, url.Values{"id": {id}, "name": {"Updated"}, "count": {"4"}})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		item, err := api.Storage.Get(context.Background(), id)
+		require.NoError(t, err)
+		require.Equal(t, "Updated", item.Name)
+		require.Equal(t, 4, item.Count)
+		require.False(t, item.Done)
+	})
+
+	t.Run("EditPageNotFound", func(t *testing.T) {
+		w := serve(http.MethodGet, "/admin/items/missing", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Middleware", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/admin/items", http.NoBody)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+type AdminChildItem struct {
+	babyapi.DefaultResource
+	Name      string `json:"name"`
+	ParentsID string `json:"parents_id"`
+}
+
+func (i *AdminChildItem) ParentID() string {
+	return i.ParentsID
+}
+
+func TestAdminNestedAPI(t *testing.T) {
+	parent := babyapi.NewAPI("Parents", "/parents", func() *TestType { return &TestType{} })
+	child := babyapi.NewAPI("Items", "/items", func() *AdminChildItem { return &AdminChildItem{} })
+	parent.AddNestedAPI(child)
+	child.ApplyExtension(Admin[*AdminChildItem]{})
+
+	router, err := parent.Router()
+	require.NoError(t, err)
+
+	parent1 := &TestType{DefaultResource: babyapi.NewDefaultResource()}
+	parent2 := &TestType{DefaultResource: babyapi.NewDefaultResource()}
+	for _, p := range []*TestType{parent1, parent2} {
+		require.NoError(t, parent.Storage.Set(context.Background(), p))
+	}
+
+	item1 := &AdminChildItem{DefaultResource: babyapi.NewDefaultResource(), Name: "Item One", ParentsID: parent1.GetID()}
+	item2 := &AdminChildItem{DefaultResource: babyapi.NewDefaultResource(), Name: "Item Two", ParentsID: parent2.GetID()}
+	for _, item := range []*AdminChildItem{item1, item2} {
+		require.NoError(t, child.Storage.Set(context.Background(), item))
+	}
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, http.NoBody))
+		return w
+	}
+
+	base := "/parents/" + parent1.GetID() + "/items"
+
+	t.Run("ListPage", func(t *testing.T) {
+		w := serve(base + "/admin")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		body := w.Body.String()
+		require.Contains(t, body, `<h1><a href="`+base+`/admin">Items</a></h1>`)
+		require.Contains(t, body, `<a href="`+base+`/admin/`+item1.GetID()+`">`+item1.GetID()+`</a>`)
+		require.Contains(t, body, `<form hx-post="`+base+`"`)
+		require.NotContains(t, body, item2.GetID())
+	})
+
+	t.Run("EditPage", func(t *testing.T) {
+		w := serve(base + "/admin/" + item1.GetID())
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		body := w.Body.String()
+		require.Contains(t, body, `<form hx-put="`+base+`/`+item1.GetID()+`"`)
+		require.Contains(t, body, `hx-delete="`+base+`/`+item1.GetID()+`"`)
+		require.Contains(t, body, `window.location.href = '`+base+`/admin'`)
+	})
+
+	t.Run("EditPageOtherParent", func(t *testing.T) {
+		w := serve(base + "/admin/" + item2.GetID())
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("ParentNotFound", func(t *testing.T) {
+		w := serve("/parents/missing/items/admin")
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAdminRootAPI(t *testing.T) {
+	root := babyapi.NewRootAPI("Root", "/api")
+	items := babyapi.NewAPI("Items", "/items", func() *AdminItem { return &AdminItem{} })
+	root.AddNestedAPI(items)
+	items.ApplyExtension(Admin[*AdminItem]{})
+
+	router, err := root.Router()
+	require.NoError(t, err)
+
+	item := &AdminItem{DefaultResource: babyapi.NewDefaultResource(), Name: "Item One"}
+	require.NoError(t, items.Storage.Set(context.Background(), item))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/items/admin", http.NoBody))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	body := w.Body.String()
+	require.Contains(t, body, `<a href="/api/items/admin/`+item.GetID()+`">`+item.GetID()+`</a>`)
+	require.Contains(t, body, `<form hx-post="/api/items"`)
+}
+
+func TestAdminNotFoundResponse(t *testing.T) {
+	api := babyapi.NewAPI("Items", "/items", func() *AdminItem { return &AdminItem{} }).
+		SetNotFoundResponse(babyapi.ErrForbidden)
+	api.ApplyExtension(Admin[*AdminItem]{})
+
+	router := chi.NewRouter()
+	require.NoError(t, api.Route(router))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/items/missing", http.NoBody))
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
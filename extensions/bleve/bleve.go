@@ -0,0 +1,70 @@
+// Package bleve provides an extensions.SearchIndex implementation using Bleve. It is a separate module so Bleve is
+// not required by babyapi
+package bleve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/calvinmclean/babyapi/extensions"
+)
+
+// Index implements extensions.SearchIndex using a Bleve index. Resources are indexed with the index's mapping, which
+// uses the JSON names of fields by default. Queries use Bleve's query string syntax, like "name:apples"
+type Index struct {
+	index bleve.Index
+}
+
+var _ extensions.SearchIndex = &Index{}
+
+// New creates an Index from an existing Bleve index, like one created with bleve.New or bleve.Open
+func New(index bleve.Index) *Index {
+	return &Index{index}
+}
+
+// NewMemOnly creates an Index that is only stored in memory and uses the default mapping. It does not keep resources
+// between restarts, so it should be used with in-memory storage or re-indexed when starting
+func NewMemOnly() (*Index, error) {
+	index, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		return nil, fmt.Errorf("error creating index: %w", err)
+	}
+
+	return New(index), nil
+}
+
+// Index adds or replaces the resource in the index
+func (i *Index) Index(id string, data any) error {
+	return i.index.Index(id, data)
+}
+
+// Delete removes the resource from the index
+func (i *Index) Delete(id string) error {
+	return i.index.Delete(id)
+}
+
+// Search returns the IDs of every resource matching the query string, ordered by score
+func (i *Index) Search(ctx context.Context, query string) ([]string, error) {
+	count, err := i.index.DocCount()
+	if err != nil {
+		return nil, fmt.Errorf("error counting documents: %w", err)
+	}
+
+	req := bleve.NewSearchRequestOptions(bleve.NewQueryStringQuery(query), int(count), 0, false)
+	result, err := i.index.SearchInContext(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("error searching: %w", err)
+	}
+
+	ids := make([]string, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids, nil
+}
+
+// Close closes the Bleve index
+func (i *Index) Close() error {
+	return i.index.Close()
+}
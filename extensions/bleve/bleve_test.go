@@ -0,0 +1,111 @@
+package bleve
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/calvinmclean/babyapi"
+	"github.com/calvinmclean/babyapi/extensions"
+	babytest "github.com/calvinmclean/babyapi/test"
+	"github.com/stretchr/testify/require"
+)
+
+type Item struct {
+	babyapi.DefaultResource
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func TestIndex(t *testing.T) {
+	index, err := NewMemOnly()
+	require.NoError(t, err)
+	defer index.Close()
+
+	ctx := context.Background()
+	require.NoError(t, index.Index("1", &Item{Name: "Apples", Description: "Red fruit"}))
+	require.NoError(t, index.Index("2", &Item{Name: "Bananas", Description: "Yellow fruit"}))
+
+	t.Run("Match", func(t *testing.T) {
+		ids, err := index.Search(ctx, "apples")
+		require.NoError(t, err)
+		require.Equal(t, []string{"1"}, ids)
+	})
+
+	t.Run("AllMatches", func(t *testing.T) {
+		ids, err := index.Search(ctx, "fruit")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"1", "2"}, ids)
+	})
+
+	t.Run("FieldQuery", func(t *testing.T) {
+		ids, err := index.Search(ctx, "description:yellow")
+		require.NoError(t, err)
+		require.Equal(t, []string{"2"}, ids)
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		ids, err := index.Search(ctx, "cherries")
+		require.NoError(t, err)
+		require.Empty(t, ids)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, index.Delete("1"))
+
+		ids, err := index.Search(ctx, "fruit")
+		require.NoError(t, err)
+		require.Equal(t, []string{"2"}, ids)
+	})
+
+	t.Run("InvalidQuery", func(t *testing.T) {
+		_, err := index.Search(ctx, `"unterminated`)
+		require.Error(t, err)
+	})
+}
+
+func TestSearchExtension(t *testing.T) {
+	index, err := NewMemOnly()
+	require.NoError(t, err)
+	defer index.Close()
+
+	api := babyapi.NewAPI("Items", "/items", func() *Item { return &Item{} })
+	api.ApplyExtension(extensions.Search[*Item]{Index: index})
+
+	client, stop := babytest.NewTestClient(t, api)
+	defer stop()
+
+	search := func(t *testing.T, query string) []*Item {
+		req, err := client.NewRequestWithParentIDs(context.Background(), http.MethodGet, http.NoBody, "search")
+		require.NoError(t, err)
+		req.URL.RawQuery = "q=" + query
+
+		resp, err := babyapi.MakeRequest[*babyapi.ResourceList[*Item]](req, http.DefaultClient, http.StatusOK, nil)
+		require.NoError(t, err)
+		return resp.Data.Items
+	}
+
+	apples, err := client.Post(context.Background(), &Item{Name: "Apples", Description: "Red fruit"})
+	require.NoError(t, err)
+	bananas, err := client.Post(context.Background(), &Item{Name: "Bananas", Description: "Yellow fruit"})
+	require.NoError(t, err)
+
+	t.Run("ResourcesAreIndexedWhenCreated", func(t *testing.T) {
+		require.Equal(t, []*Item{apples.Data}, search(t, "apples"))
+	})
+
+	t.Run("ResourcesAreReindexedWhenUpdated", func(t *testing.T) {
+		bananas.Data.Description = "Ripe yellow fruit"
+		_, err := client.Put(context.Background(), bananas.Data)
+		require.NoError(t, err)
+
+		require.Equal(t, []*Item{bananas.Data}, search(t, "ripe"))
+	})
+
+	t.Run("ResourcesAreRemovedWhenDeleted", func(t *testing.T) {
+		_, err := client.Delete(context.Background(), apples.Data.GetID())
+		require.NoError(t, err)
+
+		require.Equal(t, []*Item{bananas.Data}, search(t, "fruit"))
+	})
+}
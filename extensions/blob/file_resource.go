@@ -0,0 +1,119 @@
+package blob
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/calvinmclean/babyapi"
+	"github.com/go-chi/render"
+)
+
+// FileMetadata is implemented by resources that store metadata for content in a BlobStore
+type FileMetadata interface {
+	babyapi.Resource
+
+	SetContentInfo(contentType string, size int64)
+	GetContentType() string
+}
+
+// File is a resource that stores metadata for content in a BlobStore. It can be embedded in other types to add
+// more fields
+type File struct {
+	babyapi.DefaultResource
+
+	Name        string `json:"name"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`
+}
+
+// SetContentInfo sets the content type and size after uploading new content
+func (f *File) SetContentInfo(contentType string, size int64) {
+	f.ContentType = contentType
+	f.Size = size
+}
+
+// GetContentType returns the content type to use when downloading the content
+func (f *File) GetContentType() string {
+	return f.ContentType
+}
+
+// FileResource is an Extension that adds routes to upload and download the content for a resource. The resource
+// stores metadata in the API's Storage like any other resource, and the content is stored in the BlobStore using the
+// resource's ID as the key:
+//   - PUT /base/{ID}/content uploads the request body as the content and updates the resource's content type and size
+//   - GET /base/{ID}/content responds with the content and supports range requests if the BlobStore's content
+//     implements io.Seeker
+//
+// Content is not deleted from the BlobStore when the resource is deleted
+type FileResource[T FileMetadata] struct {
+	Store babyapi.BlobStore
+}
+
+func (fr FileResource[T]) Apply(api *babyapi.API[T]) error {
+	if fr.Store == nil {
+		return errors.New("missing required Store")
+	}
+
+	api.AddCustomIDRoute(http.MethodPut, "/content", api.GetRequestedResourceAndDo(fr.upload(api)))
+	api.AddCustomIDRoute(http.MethodGet, "/content", fr.download(api))
+
+	return nil
+}
+
+func (fr FileResource[T]) upload(api *babyapi.API[T]) func(*http.Request, T) (render.Renderer, *babyapi.ErrResponse) {
+	return func(r *http.Request, resource T) (render.Renderer, *babyapi.ErrResponse) {
+		body := &countingReader{r: r.Body}
+		err := fr.Store.Put(r.Context(), resource.GetID(), body)
+		if err != nil {
+			return nil, babyapi.InternalServerError(fmt.Errorf("error storing content: %w", err))
+		}
+
+		resource.SetContentInfo(r.Header.Get("Content-Type"), body.n)
+
+		err = api.Storage.Set(r.Context(), resource)
+		if err != nil {
+			return nil, babyapi.InternalServerError(fmt.Errorf("error storing resource: %w", err))
+		}
+
+		return resource, nil
+	}
+}
+
+func (fr FileResource[T]) download(api *babyapi.API[T]) http.HandlerFunc {
+	return babyapi.Handler(func(w http.ResponseWriter, r *http.Request) render.Renderer {
+		resource, err := api.GetResourceFromContext(r.Context())
+		if err != nil {
+			return babyapi.InternalServerError(err)
+		}
+
+		content, err := fr.Store.Get(r.Context(), resource.GetID())
+		if err != nil {
+			if errors.Is(err, babyapi.ErrNotFound) {
+				return api.NotFoundResponse()
+			}
+			return babyapi.InternalServerError(fmt.Errorf("error getting content: %w", err))
+		}
+
+		if contentType := resource.GetContentType(); contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+
+		babyapi.ServeBlob(w, r, "", content)
+
+		return nil
+	})
+}
+
+// countingReader counts the bytes read so the size of uploaded content can be saved
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,115 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/calvinmclean/babyapi"
+	"github.com/calvinmclean/babyapi/storage/kv"
+	babytest "github.com/calvinmclean/babyapi/test"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileResource(t *testing.T) {
+	api := babyapi.NewAPI("Files", "/files", func() *File { return &File{} }).
+		ApplyExtension(FileResource[*File]{Store: babyapi.NewKVBlobStore(kv.NewDefaultDB(), "Files")})
+
+	address, closer := babytest.TestServe[*File](t, api)
+	defer closer()
+
+	client := api.Client(address)
+
+	file, err := client.Post(context.Background(), &File{Name: "hello.txt"})
+	require.NoError(t, err)
+
+	contentURL := address + "/files/" + file.Data.GetID() + "/content"
+
+	t.Run("DownloadNotFound", func(t *testing.T) {
+		resp, err := http.Get(contentURL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("DownloadCustomNotFoundResponse", func(t *testing.T) {
+		api := babyapi.NewAPI("Files", "/files", func() *File { return &File{} }).
+			SetNotFoundResponse(babyapi.ErrForbidden).
+			ApplyExtension(FileResource[*File]{Store: babyapi.NewKVBlobStore(kv.NewDefaultDB(), "Files")})
+
+		file := &File{DefaultResource: babyapi.NewDefaultResource(), Name: "hello.txt"}
+		require.NoError(t, api.Storage.Set(context.Background(), file))
+
+		w := babytest.TestRequest(t, api, httptest.NewRequest(http.MethodGet, "/files/"+file.GetID()+"/content", http.NoBody))
+		require.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+	})
+
+	t.Run("Upload", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPut, contentURL, strings.NewReader("hello world"))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "text/plain")
+
+		resp, err := client.MakeRequest(req, http.StatusOK)
+		require.NoError(t, err)
+		require.Equal(t, "hello.txt", resp.Data.Name)
+		require.Equal(t, "text/plain", resp.Data.ContentType)
+		require.Equal(t, int64(11), resp.Data.Size)
+
+		stored, err := client.Get(context.Background(), file.Data.GetID())
+		require.NoError(t, err)
+		require.Equal(t, int64(11), stored.Data.Size)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		resp, err := http.Get(contentURL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(data))
+	})
+
+	t.Run("DownloadRange", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, contentURL, http.NoBody)
+		require.NoError(t, err)
+		req.Header.Set("Range", "bytes=6-")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+		require.Equal(t, "bytes 6-10/11", resp.Header.Get("Content-Range"))
+
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "world", string(data))
+	})
+
+	t.Run("UploadResourceNotFound", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPut, address+"/files/cljcqg5o402e9s28rbp0/content", strings.NewReader("hello"))
+		require.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("MissingStore", func(t *testing.T) {
+		api := babyapi.NewAPI("Files", "/files", func() *File { return &File{} }).
+			ApplyExtension(FileResource[*File]{})
+
+		err := api.Route(chi.NewRouter())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "ApplyExtension: error applying extension: missing required Store")
+	})
+}
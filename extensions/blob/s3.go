@@ -0,0 +1,205 @@
+// Package blob provides babyapi.BlobStore implementations for external storage services and an extension for
+// resources that store file contents
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/calvinmclean/babyapi"
+)
+
+// S3API is the subset of methods from *s3.Client that are used by S3Blob
+type S3API interface {
+	PutObject(context.Context, *s3.PutObjectInput, ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(context.Context, *s3.GetObjectInput, ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	HeadObject(context.Context, *s3.HeadObjectInput, ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	DeleteObject(context.Context, *s3.DeleteObjectInput, ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// S3Blob implements babyapi.BlobStore using an S3 bucket. Objects are stored with keys prefixed by Prefix
+type S3Blob struct {
+	Client S3API
+	Bucket string
+	Prefix string
+}
+
+var _ babyapi.BlobStore = &S3Blob{}
+
+// NewS3Blob creates a new BlobStore using the S3 client and bucket
+func NewS3Blob(client S3API, bucket, prefix string) *S3Blob {
+	return &S3Blob{client, bucket, prefix}
+}
+
+func (s *S3Blob) key(key string) string {
+	return path.Join(s.Prefix, key)
+}
+
+// Put uploads the content to the bucket. S3 requires a seekable body to sign the request, so content that does not
+// implement io.ReadSeeker is read into memory first
+func (s *S3Blob) Put(ctx context.Context, key string, content io.Reader) error {
+	body, ok := content.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return fmt.Errorf("error reading content: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	_, err := s.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(key)),
+		Body:   body,
+	})
+	if err != nil {
+		return fmt.Errorf("error putting object: %w", err)
+	}
+
+	return nil
+}
+
+// Get gets the content from the bucket. It returns babyapi.ErrNotFound if the object does not exist. Only the
+// object's metadata is requested until the content is read. The content implements io.Seeker by requesting a new
+// byte range from the bucket, so babyapi.ServeBlob can respond to range requests without downloading the whole
+// object. It also has the object's Content-Type so babyapi.ServeBlob does not read the content to detect it
+func (s *S3Blob) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, babyapi.ErrNotFound
+		}
+		return nil, fmt.Errorf("error getting object metadata: %w", err)
+	}
+
+	return &s3Object{
+		s:           s,
+		ctx:         ctx,
+		key:         key,
+		size:        aws.ToInt64(out.ContentLength),
+		contentType: aws.ToString(out.ContentType),
+	}, nil
+}
+
+func (s *S3Blob) getObject(ctx context.Context, key string, offset int64) (*s3.GetObjectOutput, error) {
+	in := &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(key)),
+	}
+	if offset > 0 {
+		in.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	out, err := s.Client.GetObject(ctx, in)
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, babyapi.ErrNotFound
+		}
+		return nil, fmt.Errorf("error getting object: %w", err)
+	}
+
+	return out, nil
+}
+
+// isS3NotFound checks for the errors returned by GetObject and HeadObject when the object does not exist
+func isS3NotFound(err error) bool {
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+	return errors.As(err, &noSuchKey) || errors.As(err, &notFound)
+}
+
+// Delete deletes the content from the bucket
+func (s *S3Blob) Delete(ctx context.Context, key string) error {
+	_, err := s.Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting object: %w", err)
+	}
+
+	return nil
+}
+
+// s3Object reads an object from the bucket. The object is requested on the first Read. Seeking only changes the
+// offset, and the object is requested again starting at the new offset on the next Read
+type s3Object struct {
+	s           *S3Blob
+	ctx         context.Context
+	key         string
+	size        int64
+	contentType string
+
+	offset     int64
+	body       io.ReadCloser
+	bodyOffset int64
+}
+
+func (o *s3Object) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+
+	if o.body == nil || o.bodyOffset != o.offset {
+		err := o.Close()
+		if err != nil {
+			return 0, err
+		}
+
+		out, err := o.s.getObject(o.ctx, o.key, o.offset)
+		if err != nil {
+			return 0, err
+		}
+		o.body = out.Body
+		o.bodyOffset = o.offset
+	}
+
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	o.bodyOffset += int64(n)
+	return n, err
+}
+
+// ContentType returns the object's Content-Type from the bucket
+func (o *s3Object) ContentType() string {
+	return o.contentType
+}
+
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += o.offset
+	case io.SeekEnd:
+		offset += o.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	o.offset = offset
+	return offset, nil
+}
+
+func (o *s3Object) Close() error {
+	if o.body == nil {
+		return nil
+	}
+
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
@@ -0,0 +1,166 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/calvinmclean/babyapi"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 stores objects in memory so S3Blob can be tested without a bucket
+type fakeS3 struct {
+	objects map[string]string
+
+	// getObjectCalls counts requests for object content
+	getObjectCalls int
+}
+
+func (f *fakeS3) PutObject(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.objects[*in.Bucket+"/"+*in.Key] = string(data)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) GetObject(_ context.Context, in *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.getObjectCalls++
+
+	data, ok := f.objects[*in.Bucket+"/"+*in.Key]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	if in.Range != nil {
+		var start int
+		_, err := fmt.Sscanf(*in.Range, "bytes=%d-", &start)
+		if err != nil {
+			return nil, err
+		}
+		data = data[start:]
+	}
+	return &s3.GetObjectOutput{
+		Body:          io.NopCloser(strings.NewReader(data)),
+		ContentLength: aws.Int64(int64(len(data))),
+	}, nil
+}
+
+// HeadObject uses the same default Content-Type as S3 for objects uploaded without one
+func (f *fakeS3) HeadObject(_ context.Context, in *s3.HeadObjectInput, _ ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	data, ok := f.objects[*in.Bucket+"/"+*in.Key]
+	if !ok {
+		return nil, &types.NotFound{}
+	}
+	return &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len(data))),
+		ContentType:   aws.String("binary/octet-stream"),
+	}, nil
+}
+
+func (f *fakeS3) DeleteObject(_ context.Context, in *s3.DeleteObjectInput, _ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	delete(f.objects, *in.Bucket+"/"+*in.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func TestS3Blob(t *testing.T) {
+	client := &fakeS3{objects: map[string]string{}}
+	store := NewS3Blob(client, "bucket", "files")
+
+	t.Run("Put", func(t *testing.T) {
+		err := store.Put(context.Background(), "key", strings.NewReader("hello"))
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"bucket/files/key": "hello"}, client.objects)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		content, err := store.Get(context.Background(), "key")
+		require.NoError(t, err)
+		defer content.Close()
+
+		data, err := io.ReadAll(content)
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(data))
+	})
+
+	t.Run("GetSeek", func(t *testing.T) {
+		content, err := store.Get(context.Background(), "key")
+		require.NoError(t, err)
+		defer content.Close()
+
+		seeker, ok := content.(io.ReadSeeker)
+		require.True(t, ok)
+
+		size, err := seeker.Seek(0, io.SeekEnd)
+		require.NoError(t, err)
+		require.Equal(t, int64(5), size)
+
+		_, err = seeker.Seek(2, io.SeekStart)
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(seeker)
+		require.NoError(t, err)
+		require.Equal(t, "llo", string(data))
+	})
+
+	t.Run("GetDoesNotReadContent", func(t *testing.T) {
+		client.getObjectCalls = 0
+
+		content, err := store.Get(context.Background(), "key")
+		require.NoError(t, err)
+		defer content.Close()
+		require.Zero(t, client.getObjectCalls)
+	})
+
+	t.Run("ServeBlob", func(t *testing.T) {
+		client.getObjectCalls = 0
+
+		content, err := store.Get(context.Background(), "key")
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		babyapi.ServeBlob(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody), "", content)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "binary/octet-stream", w.Header().Get("Content-Type"))
+		require.Equal(t, "hello", w.Body.String())
+		// the content is not read to detect the Content-Type, so the object is only requested once
+		require.Equal(t, 1, client.getObjectCalls)
+	})
+
+	t.Run("ServeBlobRange", func(t *testing.T) {
+		client.getObjectCalls = 0
+
+		content, err := store.Get(context.Background(), "key")
+		require.NoError(t, err)
+
+		r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		r.Header.Set("Range", "bytes=3-")
+		w := httptest.NewRecorder()
+		babyapi.ServeBlob(w, r, "hello.txt", content)
+
+		require.Equal(t, http.StatusPartialContent, w.Code)
+		require.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Equal(t, "lo", w.Body.String())
+		require.Equal(t, 1, client.getObjectCalls)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		err := store.Delete(context.Background(), "key")
+		require.NoError(t, err)
+		require.Empty(t, client.objects)
+	})
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		_, err := store.Get(context.Background(), "key")
+		require.ErrorIs(t, err, babyapi.ErrNotFound)
+	})
+}
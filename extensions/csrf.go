@@ -0,0 +1,170 @@
+package extensions
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/calvinmclean/babyapi"
+	"github.com/go-chi/render"
+)
+
+type csrfCtxKey struct{}
+
+// CSRF is a babyapi Extension that protects HTML/HTMX applications from cross-site request forgery using a
+// double-submit cookie. A random token is stored in a cookie and must also be submitted with each POST, PUT, PATCH,
+// or DELETE request using a header or form field. Use TemplateFuncs to inject the token into HTML templates.
+// Requests with JSON bodies or bearer token authorization are skipped since they cannot be forged by cross-site forms
+type CSRF[T babyapi.Resource] struct {
+	// CookieName is the name of the cookie storing the token. Default is "csrf_token"
+	CookieName string
+	// HeaderName is the request header that can be used to submit the token. Default is "X-CSRF-Token"
+	HeaderName string
+	// FormField is the form field that can be used to submit the token. Default is "csrf_token"
+	FormField string
+	// Skip allows overriding the default logic for skipping API clients that do not need CSRF protection
+	Skip func(*http.Request) bool
+}
+
+// Apply adds the CSRF middleware to the API
+func (c CSRF[T]) Apply(api *babyapi.API[T]) error {
+	api.AddMiddleware(c.Middleware)
+	return nil
+}
+
+func (c CSRF[T]) cookieName() string {
+	if c.CookieName == "" {
+		return "csrf_token"
+	}
+	return c.CookieName
+}
+
+func (c CSRF[T]) headerName() string {
+	if c.HeaderName == "" {
+		return "X-CSRF-Token"
+	}
+	return c.HeaderName
+}
+
+func (c CSRF[T]) formField() string {
+	if c.FormField == "" {
+		return "csrf_token"
+	}
+	return c.FormField
+}
+
+// Middleware makes sure every request has a CSRF token cookie and validates the submitted token for requests that
+// modify resources
+func (c CSRF[T]) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var token string
+		cookie, err := r.Cookie(c.cookieName())
+		if err == nil && cookie.Value != "" {
+			token = cookie.Value
+		} else {
+			token, err = newCSRFToken()
+			if err != nil {
+				_ = render.Render(w, r, babyapi.InternalServerError(err))
+				return
+			}
+
+			http.SetCookie(w, &http.Cookie{
+				Name:     c.cookieName(),
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), csrfCtxKey{}, token))
+
+		if c.requiresValidation(r) && !c.validToken(r, cookie) {
+			logger := babyapi.GetLoggerFromContext(r.Context())
+			if logger != nil {
+				logger.Warn("invalid or missing CSRF token")
+			}
+			_ = render.Render(w, r, babyapi.ErrForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c CSRF[T]) requiresValidation(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+
+	if c.Skip != nil {
+		return !c.Skip(r)
+	}
+
+	// Cross-site forms cannot send JSON bodies or set the Authorization header
+	if render.GetRequestContentType(r) == render.ContentTypeJSON {
+		return false
+	}
+	if strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		return false
+	}
+
+	return true
+}
+
+// validToken checks that the token submitted by header or form matches the cookie. If the cookie was not already
+// set by a previous request, the token is always invalid
+func (c CSRF[T]) validToken(r *http.Request, cookie *http.Cookie) bool {
+	if cookie == nil || cookie.Value == "" {
+		return false
+	}
+
+	submitted := r.Header.Get(c.headerName())
+	if submitted == "" {
+		submitted = r.PostFormValue(c.formField())
+	}
+	if submitted == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(submitted), []byte(cookie.Value)) == 1
+}
+
+// TemplateFuncs returns template functions to use with html.SetFuncs. "csrfToken" returns the token string and
+// "csrfField" returns a hidden form input containing the token
+func (c CSRF[T]) TemplateFuncs(r *http.Request) map[string]any {
+	return map[string]any{
+		"csrfToken": func() string {
+			return CSRFToken(r)
+		},
+		"csrfField": func() template.HTML {
+			return template.HTML(fmt.Sprintf(
+				`<input type="hidden" name="%s" value="%s">`,
+				template.HTMLEscapeString(c.formField()),
+				template.HTMLEscapeString(CSRFToken(r)),
+			))
+		},
+	}
+}
+
+// CSRFToken gets the current request's CSRF token. It is only available when the CSRF extension is used
+func CSRFToken(r *http.Request) string {
+	token, _ := r.Context().Value(csrfCtxKey{}).(string)
+	return token
+}
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("error generating CSRF token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,103 @@
+package extensions
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/calvinmclean/babyapi"
+	babytest "github.com/calvinmclean/babyapi/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSRF(t *testing.T) {
+	api := babyapi.NewAPI("Test", "/item", func() *TestType { return &TestType{} })
+	api.ApplyExtension(CSRF[*TestType]{})
+
+	var token string
+	t.Run("GetSetsCookie", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/item", http.NoBody)
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		require.Equal(t, "csrf_token", cookies[0].Name)
+		require.True(t, cookies[0].HttpOnly)
+		token = cookies[0].Value
+		require.NotEmpty(t, token)
+	})
+
+	t.Run("FormPostWithoutTokenForbidden", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/item", strings.NewReader("FieldOne=value"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.AddCookie(&http.Cookie{Name: "csrf_token", Value: token})
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+	})
+
+	t.Run("FormPostWithoutCookieForbidden", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/item", strings.NewReader("FieldOne=value&csrf_token="+token))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+	})
+
+	t.Run("FormPostWithWrongTokenForbidden", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/item", strings.NewReader("FieldOne=value&csrf_token=wrong"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.AddCookie(&http.Cookie{Name: "csrf_token", Value: token})
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+	})
+
+	t.Run("FormPostWithFormToken", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/item", strings.NewReader("FieldOne=value&csrf_token="+token))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.AddCookie(&http.Cookie{Name: "csrf_token", Value: token})
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusCreated, w.Result().StatusCode)
+		require.Regexp(t, `{"id":"[0-9a-v]{20}","FieldOne":"value"}`, w.Body.String())
+	})
+
+	t.Run("FormPostWithHeaderToken", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/item", strings.NewReader("FieldOne=value"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("X-CSRF-Token", token)
+		r.AddCookie(&http.Cookie{Name: "csrf_token", Value: token})
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusCreated, w.Result().StatusCode)
+	})
+
+	t.Run("JSONPostSkipped", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/item", strings.NewReader(`{"FieldOne":"value"}`))
+		r.Header.Set("Content-Type", "application/json")
+		w := babytest.TestRequest(t, api, r)
+
+		require.Equal(t, http.StatusCreated, w.Result().StatusCode)
+	})
+}
+
+func TestCSRFTemplateFuncs(t *testing.T) {
+	csrf := CSRF[*TestType]{FormField: "token"}
+
+	var rendered strings.Builder
+	handler := csrf.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tmpl := template.Must(template.New("form").Funcs(csrf.TemplateFuncs(r)).Parse(`{{ csrfField }}|{{ csrfToken }}`))
+		require.NoError(t, tmpl.Execute(&rendered, nil))
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	r.AddCookie(&http.Cookie{Name: "csrf_token", Value: "abc123"})
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	require.Equal(t, `<input type="hidden" name="token" value="abc123">|abc123`, rendered.String())
+}
@@ -170,6 +170,9 @@ func TestAPIWithExtension(t *testing.T) {
 			ExpectedResponse: babytest.ExpectedResponse{
 				Status:     http.StatusOK,
 				BodyRegexp: `{"items":\[{"id":"[0-9a-v]{20}","FieldOne":"ValueOne","links":{"Child":"/item/[0-9a-v]{20}/child","self":"/item/[0-9a-v]{20}"}}\]}`,
+				JSONMatch: map[string]any{
+					"items.0.FieldOne": "ValueOne",
+				},
 			},
 		},
 		{
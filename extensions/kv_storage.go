@@ -2,6 +2,7 @@ package extensions
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/calvinmclean/babyapi"
 	"github.com/calvinmclean/babyapi/storage/kv"
@@ -23,6 +24,9 @@ type KeyValueStorage[T babyapi.Resource] struct {
 	// DB is the database connection. It is created if not provided. This is useful if multiple APIs share
 	// a storage backend
 	DB hord.Database
+
+	// Options configure optional KVStorage features, like indexes from babyapi.WithIndex
+	Options []babyapi.KVStorageOption
 }
 
 type KVConnectionConfig struct {
@@ -34,6 +38,26 @@ type KVConnectionConfig struct {
 	// Password for Redis instance
 	RedisPassword string
 
+	// RedisSentinelServers are the addresses of Redis Sentinel servers to use instead of RedisHost. The
+	// RedisSentinelMaster is required when these are set. Redis Cluster is not supported by the hord Redis driver,
+	// so use DB with a custom hord.Database for clusters
+	RedisSentinelServers []string
+	// RedisSentinelMaster is the name of the master monitored by the Sentinel servers
+	RedisSentinelMaster string
+
+	// RedisMaxActive is the maximum number of connections in the Redis connection pool. Zero means no limit
+	RedisMaxActive int
+	// RedisMaxIdle is the maximum number of idle connections in the Redis connection pool
+	RedisMaxIdle int
+	// RedisIdleTimeout closes connections that are idle for longer than this duration
+	RedisIdleTimeout time.Duration
+	// RedisConnectTimeout is the timeout for connecting to Redis
+	RedisConnectTimeout time.Duration
+	// RedisReadTimeout is the timeout for reading the response to each Redis command
+	RedisReadTimeout time.Duration
+	// RedisWriteTimeout is the timeout for writing each Redis command
+	RedisWriteTimeout time.Duration
+
 	// If other configurations are empty, this will not return an error and skips setting api Storage.
 	// This is useful if using env vars as the values for configs
 	Optional bool
@@ -57,18 +81,20 @@ func (h KeyValueStorage[T]) Apply(api *babyapi.API[T]) error {
 		storageKeyPrefix = api.Name()
 	}
 
-	api.SetStorage(babyapi.NewKVStorage[T](db, storageKeyPrefix))
+	api.SetStorage(babyapi.NewKVStorage[T](db, storageKeyPrefix, h.Options...))
 
 	return nil
 }
 
 func (h KVConnectionConfig) CreateDB() (hord.Database, error) {
 	switch {
+	case len(h.RedisSentinelServers) > 0:
+		if h.RedisSentinelMaster == "" {
+			return nil, fmt.Errorf("redis sentinel master is required when using sentinel servers")
+		}
+		return kv.NewRedisDB(h.redisConfig())
 	case h.RedisHost != "" && h.RedisPassword != "":
-		return kv.NewRedisDB(redis.Config{
-			Server:   h.RedisHost + ":6379",
-			Password: h.RedisPassword,
-		})
+		return kv.NewRedisDB(h.redisConfig())
 	case h.Filename != "":
 		return kv.NewFileDB(hashmap.Config{
 			Filename: h.Filename,
@@ -79,3 +105,27 @@ func (h KVConnectionConfig) CreateDB() (hord.Database, error) {
 		return nil, fmt.Errorf("filename or redis configuration is required")
 	}
 }
+
+// redisConfig creates the configuration for a Redis server or Sentinel servers with the pool and timeout settings
+func (h KVConnectionConfig) redisConfig() redis.Config {
+	cfg := redis.Config{
+		Password:       h.RedisPassword,
+		MaxActive:      h.RedisMaxActive,
+		MaxIdle:        h.RedisMaxIdle,
+		IdleTimeout:    h.RedisIdleTimeout,
+		ConnectTimeout: h.RedisConnectTimeout,
+		ReadTimeout:    h.RedisReadTimeout,
+		WriteTimeout:   h.RedisWriteTimeout,
+	}
+
+	if len(h.RedisSentinelServers) > 0 {
+		cfg.SentinelConfig = redis.SentinelConfig{
+			Servers: h.RedisSentinelServers,
+			Master:  h.RedisSentinelMaster,
+		}
+		return cfg
+	}
+
+	cfg.Server = h.RedisHost + ":6379"
+	return cfg
+}
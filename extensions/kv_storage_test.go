@@ -0,0 +1,98 @@
+package extensions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/madflojo/hord/drivers/redis"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateDB(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      KVConnectionConfig
+		expectedErr string
+		expectNil   bool
+	}{
+		{
+			"SentinelMissingMaster",
+			KVConnectionConfig{RedisSentinelServers: []string{"localhost:26379"}},
+			"redis sentinel master is required when using sentinel servers",
+			true,
+		},
+		{
+			"MissingConfig",
+			KVConnectionConfig{},
+			"filename or redis configuration is required",
+			true,
+		},
+		{
+			"Optional",
+			KVConnectionConfig{Optional: true},
+			"",
+			true,
+		},
+		{
+			"File",
+			KVConnectionConfig{Filename: t.TempDir() + "/db.json"},
+			"",
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := tt.config.CreateDB()
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				require.Equal(t, tt.expectedErr, err.Error())
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.expectNil, db == nil)
+		})
+	}
+}
+
+func TestRedisConfig(t *testing.T) {
+	base := KVConnectionConfig{
+		RedisPassword:       "password",
+		RedisMaxActive:      10,
+		RedisMaxIdle:        5,
+		RedisIdleTimeout:    time.Minute,
+		RedisConnectTimeout: time.Second,
+		RedisReadTimeout:    2 * time.Second,
+		RedisWriteTimeout:   3 * time.Second,
+	}
+	expected := redis.Config{
+		Password:       "password",
+		MaxActive:      10,
+		MaxIdle:        5,
+		IdleTimeout:    time.Minute,
+		ConnectTimeout: time.Second,
+		ReadTimeout:    2 * time.Second,
+		WriteTimeout:   3 * time.Second,
+	}
+
+	t.Run("Server", func(t *testing.T) {
+		config := base
+		config.RedisHost = "localhost"
+
+		expected := expected
+		expected.Server = "localhost:6379"
+
+		require.Equal(t, expected, config.redisConfig())
+	})
+
+	t.Run("Sentinel", func(t *testing.T) {
+		config := base
+		config.RedisSentinelServers = []string{"localhost:26379"}
+		config.RedisSentinelMaster = "master"
+
+		expected := expected
+		expected.SentinelConfig = redis.SentinelConfig{Servers: []string{"localhost:26379"}, Master: "master"}
+
+		require.Equal(t, expected, config.redisConfig())
+	})
+}
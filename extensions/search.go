@@ -0,0 +1,149 @@
+package extensions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/calvinmclean/babyapi"
+	"github.com/go-chi/render"
+)
+
+// SearchIndex is a full-text index of resources by ID. The babyapi/extensions/bleve module implements it with a
+// Bleve index:
+//
+//	index, err := bleve.NewMemOnly()
+//	if err != nil {
+//		return err
+//	}
+//	api.ApplyExtension(extensions.Search[*TODO]{Index: index})
+type SearchIndex interface {
+	Index(id string, data any) error
+	Delete(id string) error
+	Search(ctx context.Context, query string) ([]string, error)
+}
+
+// Search is an Extension that keeps resources in a SearchIndex, like Bleve, and adds a GET /base/search route that
+// responds with the resources matching the 'q' query parameter. It wraps the API's Storage so resources are indexed
+// when they are stored or deleted by any handler, so it must be applied after any extension that sets the Storage,
+// like KeyValueStorage. Resources are indexed when they are stored, even if a transaction is later rolled back, so
+// results are always read from Storage. For nested APIs that implement babyapi.ChildResource, results only include
+// resources that belong to the parent from the request path
+type Search[T babyapi.Resource] struct {
+	Index SearchIndex
+}
+
+func (s Search[T]) Apply(api *babyapi.API[T]) error {
+	if s.Index == nil {
+		return errors.New("search index is required")
+	}
+
+	storage := &searchStorage[T]{api.Storage, s.Index}
+	if _, ok := api.Storage.(babyapi.Transactional[T]); ok {
+		api.SetStorage(&transactionalSearchStorage[T]{storage})
+	} else {
+		api.SetStorage(storage)
+	}
+
+	api.AddCustomRoute(http.MethodGet, "/search", babyapi.Handler(func(w http.ResponseWriter, r *http.Request) render.Renderer {
+		logger := babyapi.GetLoggerFromContext(r.Context())
+
+		query := r.URL.Query().Get(babyapi.FullTextSearchQueryParam)
+		if query == "" {
+			return babyapi.ErrInvalidRequest(fmt.Errorf("missing %q query parameter", babyapi.FullTextSearchQueryParam))
+		}
+
+		ids, err := s.Index.Search(r.Context(), query)
+		if err != nil {
+			logger.Error("error searching index", "error", err)
+			return babyapi.InternalServerError(err)
+		}
+
+		var parentID string
+		if api.Parent() != nil {
+			parentID = api.GetParentIDParam(r)
+		}
+
+		results := &babyapi.ResourceList[T]{Items: []T{}}
+		for _, id := range ids {
+			resource, err := api.Storage.Get(r.Context(), id)
+			if errors.Is(err, babyapi.ErrNotFound) {
+				// The index can have resources that were not stored because the transaction was rolled back
+				continue
+			}
+			if err != nil {
+				return babyapi.InternalServerError(err)
+			}
+
+			child, ok := any(resource).(babyapi.ChildResource)
+			if parentID != "" && ok && child.ParentID() != parentID {
+				continue
+			}
+
+			results.Items = append(results.Items, resource)
+		}
+
+		return results
+	}))
+
+	return nil
+}
+
+// searchStorage updates the index when resources are stored or deleted
+type searchStorage[T babyapi.Resource] struct {
+	babyapi.Storage[T]
+	index SearchIndex
+}
+
+func (s *searchStorage[T]) Set(ctx context.Context, resource T) error {
+	err := s.Storage.Set(ctx, resource)
+	if err != nil {
+		return err
+	}
+
+	err = s.index.Index(resource.GetID(), resource)
+	if err != nil {
+		return fmt.Errorf("error indexing resource: %w", err)
+	}
+
+	return nil
+}
+
+func (s *searchStorage[T]) Delete(ctx context.Context, id string) error {
+	err := s.Storage.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.index.Delete(id)
+	if err != nil {
+		return fmt.Errorf("error removing resource from index: %w", err)
+	}
+
+	return nil
+}
+
+// GetAllByParent uses the wrapped Storage's GetAllByParent if it implements babyapi.ParentIndexed so wrapping does
+// not change how nested APIs get their resources
+func (s *searchStorage[T]) GetAllByParent(ctx context.Context, parentID string, query url.Values) ([]T, error) {
+	parentIndexed, ok := s.Storage.(babyapi.ParentIndexed[T])
+	if !ok {
+		return s.Storage.GetAll(ctx, query)
+	}
+
+	return parentIndexed.GetAllByParent(ctx, parentID, query)
+}
+
+// transactionalSearchStorage is used when the wrapped Storage implements babyapi.Transactional so storage used in
+// transactions also updates the index
+type transactionalSearchStorage[T babyapi.Resource] struct {
+	*searchStorage[T]
+}
+
+func (s *transactionalSearchStorage[T]) WithTx(ctx context.Context, fn func(context.Context, babyapi.Storage[T]) error) error {
+	return s.Storage.(babyapi.Transactional[T]).WithTx(ctx, func(ctx context.Context, storage babyapi.Storage[T]) error {
+		return fn(ctx, &searchStorage[T]{storage, s.index})
+	})
+}
@@ -0,0 +1,111 @@
+package extensions
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/calvinmclean/babyapi"
+	babytest "github.com/calvinmclean/babyapi/test"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryIndex is a simple SearchIndex that matches resource names containing the query
+type memoryIndex struct {
+	names map[string]string
+	lock  sync.Mutex
+}
+
+func (i *memoryIndex) Index(id string, data any) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.names[id] = strings.ToLower(data.(*AdminItem).Name)
+	return nil
+}
+
+func (i *memoryIndex) Delete(id string) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	delete(i.names, id)
+	return nil
+}
+
+func (i *memoryIndex) Search(_ context.Context, query string) ([]string, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	ids := []string{}
+	for id, name := range i.names {
+		if strings.Contains(name, strings.ToLower(query)) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func TestSearch(t *testing.T) {
+	index := &memoryIndex{names: map[string]string{}}
+
+	api := babyapi.NewAPI("Items", "/items", func() *AdminItem { return &AdminItem{} })
+	api.ApplyExtension(Search[*AdminItem]{Index: index})
+
+	client, stop := babytest.NewTestClient(t, api)
+	defer stop()
+
+	search := func(t *testing.T, query string) []*AdminItem {
+		req, err := client.NewRequestWithParentIDs(context.Background(), http.MethodGet, http.NoBody, "search")
+		require.NoError(t, err)
+		req.URL.RawQuery = "q=" + query
+
+		resp, err := babyapi.MakeRequest[*babyapi.ResourceList[*AdminItem]](req, http.DefaultClient, http.StatusOK, nil)
+		require.NoError(t, err)
+		return resp.Data.Items
+	}
+
+	apples, err := client.Post(context.Background(), &AdminItem{Name: "Apples"})
+	require.NoError(t, err)
+	_, err = client.Post(context.Background(), &AdminItem{Name: "Bananas"})
+	require.NoError(t, err)
+
+	t.Run("ResourcesAreIndexedWhenCreated", func(t *testing.T) {
+		results := search(t, "apple")
+		require.Len(t, results, 1)
+		require.Equal(t, apples.Data.GetID(), results[0].GetID())
+		require.Equal(t, "Apples", results[0].Name)
+	})
+
+	t.Run("ResourcesAreIndexedWhenUpdated", func(t *testing.T) {
+		_, err := client.Put(context.Background(), &AdminItem{DefaultResource: apples.Data.DefaultResource, Name: "Green Apples"})
+		require.NoError(t, err)
+
+		results := search(t, "green")
+		require.Len(t, results, 1)
+		require.Equal(t, "Green Apples", results[0].Name)
+	})
+
+	t.Run("ResourcesAreRemovedWhenDeleted", func(t *testing.T) {
+		_, err := client.Delete(context.Background(), apples.Data.GetID())
+		require.NoError(t, err)
+
+		require.Empty(t, search(t, "apple"))
+	})
+
+	t.Run("MissingQuery", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/items/search", http.NoBody)
+		w := babytest.TestRequest(t, api, r)
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+	})
+
+	t.Run("ErrorWithoutIndex", func(t *testing.T) {
+		api := babyapi.NewAPI("Items", "/items", func() *AdminItem { return &AdminItem{} })
+		api.ApplyExtension(Search[*AdminItem]{})
+
+		_, err := api.Router()
+		require.EqualError(t, err, "encountered 1 errors constructing API:\n- ApplyExtension: error applying extension: search index is required\n")
+	})
+}
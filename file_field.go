@@ -0,0 +1,258 @@
+package babyapi
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// FileReference is stored on a resource to reference a file uploaded with EnableFileField. The file's contents are
+// stored in a BlobStore using the Key
+type FileReference struct {
+	Key         string `json:"key"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`
+}
+
+// fileField is a multipart form file that is stored in a BlobStore and referenced by a resource field
+type fileField struct {
+	index []int
+	store BlobStore
+}
+
+// EnableFileField allows uploading a file using the fieldName in multipart form requests to create or update
+// resources. The file is stored using the BlobStore and a FileReference is saved on the resource field with a
+// matching JSON or field name. The field must have type FileReference or *FileReference. Each upload is stored with
+// a new key. The default handlers delete uploaded files if the resource is not stored, and delete the previous file
+// when an update replaces or removes it. Custom routes using GetFromRequest are responsible for cleaning up files.
+//
+// It also adds a GET /base/{ID}/{fieldName} route to download the file, which supports range requests if the
+// BlobStore's content implements io.Seeker
+func (a *API[T]) EnableFileField(fieldName string, store BlobStore) *API[T] {
+	a.panicIfReadOnly()
+
+	index, ok := fileReferenceFieldIndex(reflect.TypeOf(*new(T)), fieldName)
+	if !ok {
+		a.errors = append(a.errors, fmt.Errorf("EnableFileField: %T does not have a FileReference field for %q", *new(T), fieldName))
+		return a
+	}
+
+	field := fileField{index, store}
+	a.fileFields[fieldName] = field
+
+	return a.AddCustomIDRoute(http.MethodGet, "/"+fieldName, a.fileDownloadHandler(field))
+}
+
+// storeFileFields stores uploaded files from a multipart form request and sets the FileReference on the resource.
+// Files are not stored for dry run requests. If a file can't be stored, the files already stored by the request are
+// deleted
+func (a *API[T]) storeFileFields(r *http.Request, resource T) *ErrResponse {
+	if len(a.fileFields) == 0 || r.MultipartForm == nil {
+		return nil
+	}
+
+	stored := []storedFile{}
+	for name, field := range a.fileFields {
+		file, header, err := r.FormFile(name)
+		if errors.Is(err, http.ErrMissingFile) {
+			continue
+		}
+		if err != nil {
+			deleteFiles(r, stored)
+			return ErrInvalidRequest(fmt.Errorf("error reading file %q: %w", name, err))
+		}
+
+		ref := FileReference{
+			Key:         NewID().String(),
+			Filename:    header.Filename,
+			ContentType: header.Header.Get("Content-Type"),
+			Size:        header.Size,
+		}
+
+		// Dry runs still set the FileReference so the response shows the result, but the file is not stored
+		if !IsDryRun(r.Context()) {
+			err = field.store.Put(r.Context(), ref.Key, file)
+		}
+		_ = file.Close()
+		if err != nil {
+			deleteFiles(r, stored)
+			return InternalServerError(fmt.Errorf("error storing file %q: %w", name, err))
+		}
+
+		if !IsDryRun(r.Context()) {
+			stored = append(stored, storedFile{field.store, ref.Key})
+		}
+		field.set(resource, ref)
+	}
+
+	uploads, ok := r.Context().Value(fileUploadsCtxKey).(*fileUploads)
+	if ok {
+		uploads.files = append(uploads.files, stored...)
+	}
+
+	return nil
+}
+
+// fileUploadsStored is used after the default handlers store a resource. Files uploaded by the request or referenced
+// by the previous resource are deleted if the stored resource does not reference them, like when an upload replaces
+// a file or a Patcher does not copy the uploaded FileReference
+func (a *API[T]) fileUploadsStored(r *http.Request, resource, previous T) {
+	unused := []storedFile{}
+
+	uploads, ok := r.Context().Value(fileUploadsCtxKey).(*fileUploads)
+	if ok {
+		uploads.stored = true
+		unused = append(unused, uploads.files...)
+	}
+
+	for _, field := range a.fileFields {
+		previousRef, ok := field.get(previous)
+		if ok {
+			unused = append(unused, storedFile{field.store, previousRef.Key})
+		}
+	}
+
+	for _, field := range a.fileFields {
+		ref, ok := field.get(resource)
+		if !ok {
+			continue
+		}
+		unused = slices.DeleteFunc(unused, func(file storedFile) bool {
+			return file.key == ref.Key
+		})
+	}
+
+	deleteFiles(r, unused)
+}
+
+// deleteUnstoredFileUploads deletes the files uploaded by the request if the resource referencing them was not stored
+func deleteUnstoredFileUploads(r *http.Request) {
+	uploads, ok := r.Context().Value(fileUploadsCtxKey).(*fileUploads)
+	if !ok || uploads.stored {
+		return
+	}
+
+	deleteFiles(r, uploads.files)
+}
+
+// deleteFiles deletes the files and logs errors since the files are no longer used by the response
+func deleteFiles(r *http.Request, files []storedFile) {
+	logger := GetLoggerFromContext(r.Context())
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	for _, file := range files {
+		err := file.store.Delete(r.Context(), file.key)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			logger.Error("error deleting unused file", "key", file.key, "error", err)
+		}
+	}
+}
+
+// fileDownloadHandler responds with the contents of the file referenced by the requested resource
+func (a *API[T]) fileDownloadHandler(field fileField) http.HandlerFunc {
+	return Handler(func(w http.ResponseWriter, r *http.Request) render.Renderer {
+		resource, err := a.GetResourceFromContext(r.Context())
+		if err != nil {
+			return InternalServerError(err)
+		}
+
+		ref, ok := field.get(resource)
+		if !ok {
+			return a.notFoundResponse
+		}
+
+		content, err := field.store.Get(r.Context(), ref.Key)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return a.notFoundResponse
+			}
+			return InternalServerError(fmt.Errorf("error getting file: %w", err))
+		}
+
+		if ref.ContentType != "" {
+			w.Header().Set("Content-Type", ref.ContentType)
+		}
+		if ref.Filename != "" {
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", ref.Filename))
+		}
+
+		ServeBlob(w, r, ref.Filename, content)
+
+		return nil
+	})
+}
+
+// get returns the FileReference from the resource if it is set
+func (f fileField) get(resource any) (FileReference, bool) {
+	rv := reflect.ValueOf(resource)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return FileReference{}, false
+	}
+
+	switch v := rv.Elem().FieldByIndex(f.index).Interface().(type) {
+	case FileReference:
+		return v, v.Key != ""
+	case *FileReference:
+		if v == nil {
+			return FileReference{}, false
+		}
+		return *v, v.Key != ""
+	}
+
+	return FileReference{}, false
+}
+
+// set sets the FileReference on the resource
+func (f fileField) set(resource any, ref FileReference) {
+	fieldValue := reflect.ValueOf(resource).Elem().FieldByIndex(f.index)
+	if fieldValue.Kind() == reflect.Pointer {
+		fieldValue.Set(reflect.ValueOf(&ref))
+		return
+	}
+	fieldValue.Set(reflect.ValueOf(ref))
+}
+
+// fileReferenceFieldIndex finds the index of the FileReference or *FileReference field matching the name. Embedded
+// structs are treated as part of the parent struct. The type must be a pointer to a struct
+func fileReferenceFieldIndex(t reflect.Type, name string) ([]int, bool) {
+	if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	return findFileReferenceField(t.Elem(), name)
+}
+
+func findFileReferenceField(t reflect.Type, name string) ([]int, bool) {
+	fileReferenceType := reflect.TypeOf(FileReference{})
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Type != fileReferenceType {
+			index, ok := findFileReferenceField(field.Type, name)
+			if ok {
+				return append([]int{i}, index...), true
+			}
+			continue
+		}
+
+		if !field.IsExported() || !strings.EqualFold(formFieldName(field), name) {
+			continue
+		}
+
+		if field.Type == fileReferenceType || field.Type == reflect.PointerTo(fileReferenceType) {
+			return []int{i}, true
+		}
+	}
+
+	return nil, false
+}
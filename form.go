@@ -0,0 +1,247 @@
+package babyapi
+
+import (
+	"encoding"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// FormBinder can be implemented by resources to customize how form-encoded request bodies are decoded. When it is
+// not implemented, form fields are automatically mapped to the resource's fields by the JSON tag name or field name
+type FormBinder interface {
+	BindForm(url.Values) error
+}
+
+// multipartMaxMemory is the maximum number of bytes of a multipart form stored in memory. The rest is stored in
+// temporary files
+const multipartMaxMemory = 32 << 20
+
+// decode is used as render.Decode so form-encoded and multipart form requests are handled by decodeForm
+func decode(r *http.Request, v any) error {
+	if render.GetRequestContentType(r) == render.ContentTypeForm || isMultipartForm(r) {
+		return decodeForm(r, v)
+	}
+
+	if render.GetRequestContentType(r) == render.ContentTypeJSON {
+		return decodeJSON(r, v)
+	}
+
+	return render.DefaultDecoder(r, v)
+}
+
+// decodeForm parses the request's form and uses it to set fields on the provided value. Values that are not structs
+// and do not implement FormBinder use the default form decoder
+func decodeForm(r *http.Request, v any) error {
+	formBinder, isFormBinder := v.(FormBinder)
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() && rv.Elem().Kind() == reflect.Pointer {
+		if rv.Elem().IsNil() {
+			rv.Elem().Set(reflect.New(rv.Elem().Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+
+	isStruct := rv.Kind() == reflect.Pointer && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct
+	if !isFormBinder && !isStruct && !isMultipartForm(r) {
+		return render.DecodeForm(r.Body, v)
+	}
+
+	var err error
+	if isMultipartForm(r) {
+		err = r.ParseMultipartForm(multipartMaxMemory)
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		return fmt.Errorf("error parsing form: %w", err)
+	}
+
+	if !isFormBinder && !isStruct {
+		return fmt.Errorf("unable to decode multipart form into %T", v)
+	}
+
+	if isFormBinder {
+		return formBinder.BindForm(r.PostForm)
+	}
+
+	return setFormFields(rv.Elem(), r.PostForm)
+}
+
+func isMultipartForm(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "multipart/form-data"
+}
+
+// setFormFields sets each struct field that has a matching form key. Form keys are matched case-insensitively against
+// the JSON tag name, or field name if there is no tag. Embedded structs are treated as part of the parent struct
+func setFormFields(rv reflect.Value, form url.Values) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fieldValue := rv.Field(i)
+
+		if field.Anonymous && !isTextUnmarshaler(fieldValue) {
+			if field.Type.Kind() == reflect.Pointer {
+				// nil embedded pointers are skipped because they are commonly used for methods only, like DefaultRenderer
+				if fieldValue.IsNil() || field.Type.Elem().Kind() != reflect.Struct {
+					continue
+				}
+				fieldValue = fieldValue.Elem()
+			}
+
+			if fieldValue.Kind() == reflect.Struct {
+				err := setFormFields(fieldValue, form)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		name := formFieldName(field)
+		if name == "" {
+			continue
+		}
+
+		values, ok := getFormValues(form, name)
+		if !ok {
+			continue
+		}
+
+		err := setFormValue(fieldValue, values)
+		if err != nil {
+			return fmt.Errorf("invalid value for form field %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// formFieldName gets the name used for a field from the JSON tag or uses the field name. An empty string
+// is returned if the field is ignored by JSON
+func formFieldName(field reflect.StructField) string {
+	tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch tag {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return tag
+}
+
+func getFormValues(form url.Values, name string) ([]string, bool) {
+	values, ok := form[name]
+	if ok {
+		return values, true
+	}
+
+	for key, values := range form {
+		if strings.EqualFold(key, name) {
+			return values, true
+		}
+	}
+
+	return nil, false
+}
+
+// isTextUnmarshaler returns false for values that can't be used with Interface, like unexported embedded structs, so
+// they are handled through their exported promoted fields instead
+func isTextUnmarshaler(v reflect.Value) bool {
+	if !v.CanAddr() || !v.CanInterface() {
+		return false
+	}
+	_, ok := v.Addr().Interface().(encoding.TextUnmarshaler)
+	return ok
+}
+
+// setFormValue sets the value using the provided form values. Empty values are ignored for non-string types so
+// the zero value is used
+func setFormValue(v reflect.Value, values []string) error {
+	if isTextUnmarshaler(v) {
+		if values[0] == "" {
+			return nil
+		}
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(values[0]))
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if values[0] == "" && v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		newValue := reflect.New(v.Type().Elem())
+		err := setFormValue(newValue.Elem(), values)
+		if err != nil {
+			return err
+		}
+		v.Set(newValue)
+		return nil
+	case reflect.Slice:
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			err := setFormValue(slice.Index(i), []string{value})
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case reflect.String:
+		v.SetString(values[0])
+		return nil
+	}
+
+	value := values[0]
+	if value == "" {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		// HTML checkboxes use "on" as the default value when checked
+		if value == "on" {
+			v.SetBool(true)
+			return nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
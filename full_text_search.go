@@ -0,0 +1,81 @@
+package babyapi
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// FullTextSearchQueryParam is the query parameter used for the search query by EnableFullTextSearch
+const FullTextSearchQueryParam = "q"
+
+// EnableFullTextSearch adds a GetAll filter that uses FullTextFilter with the 'q' query parameter, like
+// /todos?q=groceries, to search resources without a dedicated search engine. Since it checks every resource returned
+// by storage, it is intended for small datasets
+func (a *API[T]) EnableFullTextSearch() *API[T] {
+	a.panicIfReadOnly()
+
+	return a.AddGetAllFilter("fullTextSearch", func(r *http.Request) FilterFunc[T] {
+		return FullTextFilter[T](r.URL.Query().Get(FullTextSearchQueryParam))
+	})
+}
+
+// FullTextFilter creates a filter that only includes resources with a string field containing the search query,
+// ignoring case. Exported string fields, pointers to strings, and slices and arrays of strings are searched, including
+// fields of embedded and nested structs. Fields tagged with RedactTag are not searched. An empty query does not filter
+// any resources. It can also be used with SetGlobalSearchFilter to search all string fields
+func FullTextFilter[T any](query string) FilterFunc[T] {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+	query = strings.ToLower(query)
+
+	return func(item T) bool {
+		return containsText(reflect.ValueOf(item), query)
+	}
+}
+
+// containsText checks the value and its exported fields and elements for a string that contains the lowercase query
+func containsText(v reflect.Value, query string) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return false
+		}
+		return containsText(v.Elem(), query)
+	case reflect.String:
+		return strings.Contains(strings.ToLower(v.String()), query)
+	case reflect.Slice, reflect.Array:
+		// byte slices are binary data, so they are not searched
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return false
+		}
+		for i := 0; i < v.Len(); i++ {
+			if containsText(v.Index(i), query) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		rt := v.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			field := rt.Field(i)
+			if !field.IsExported() && !field.Anonymous {
+				continue
+			}
+			if formFieldName(field) == "" {
+				continue
+			}
+			// Sensitive fields are not searched so results can't reveal their values
+			if hasBabyapiTag(field, RedactTag) {
+				continue
+			}
+
+			if containsText(v.Field(i), query) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -77,7 +77,7 @@ func (a *API[T]) GetRequestedResourceAndDo(do func(*http.Request, T) (render.Ren
 
 		resource, httpErr := a.GetRequestedResource(r)
 		if httpErr != nil {
-			logger.Error("error getting requested resource", "error", httpErr.Error())
+			logError(r.Context(), logger, "error getting requested resource", httpErr)
 			return httpErr
 		}
 
@@ -107,14 +107,14 @@ func (a *API[T]) GetRequestedResourceAndDoMiddleware(do func(*http.Request, T) (
 			resource, httpErr := a.GetRequestedResource(r)
 			if httpErr != nil {
 				// Skip for PUT because it can be used to create new resources
-				if errors.Is(httpErr, ErrNotFoundResponse) && r.Method == http.MethodPut {
+				if httpErr == a.notFoundResponse && r.Method == http.MethodPut {
 					logger.Warn("resource not found but continuing to next handler")
 					next.ServeHTTP(w, r)
 					return
 				}
 
-				logger.Error("error getting requested resource", "error", httpErr.Error())
-				_ = render.Render(w, r, httpErr)
+				logError(r.Context(), logger, "error getting requested resource", httpErr)
+				renderErrResponse(w, r, httpErr)
 				return
 			}
 
@@ -147,7 +147,7 @@ func (a *API[T]) ReadRequestBodyAndDo(do func(*http.Request, T) (T, *ErrResponse
 			return nil
 		}
 
-		return a.responseWrapper(resp)
+		return a.wrapResponse(resp)
 	})
 }
 
@@ -168,9 +168,30 @@ func ReadRequestBodyAndDo[T RendererBinder](do func(*http.Request, T) (render.Re
 	})
 }
 
-// GetFromRequest will read the API's resource type from the request body or request context
+// GetFromRequest will read the API's resource type from the request body or request context.
+//
+// The default POST, PUT, and PATCH routes use requestBodyMiddleware, which calls this once to bind the body and then
+// stores the result in the request context. The default handlers and any other calls during the same request get
+// the resource from the context, so the body is only read once and Bind side effects, like generating IDs, only run
+// once. Custom routes do not use the middleware, so the body is bound by the first call to this or
+// ReadRequestBodyAndDo and the result is not stored in the context
 func (a *API[T]) GetFromRequest(r *http.Request) (T, *ErrResponse) {
-	return GetFromRequest(r, a.instance)
+	resource, ok := GetRequestBodyFromContext[T](r.Context())
+	if ok {
+		return resource, nil
+	}
+
+	resource, httpErr := bindRequest(r, a.newInstance)
+	if httpErr != nil {
+		return *new(T), httpErr
+	}
+
+	httpErr = a.storeFileFields(r, resource)
+	if httpErr != nil {
+		return *new(T), httpErr
+	}
+
+	return resource, nil
 }
 
 // GetFromRequest will read a resource type from the request body or request context
@@ -180,8 +201,19 @@ func GetFromRequest[T RendererBinder](r *http.Request, instance func() T) (T, *E
 		return resource, nil
 	}
 
-	resource = instance()
-	err := render.Bind(r, resource)
+	return bindRequest(r, instance)
+}
+
+// bindRequest decodes the request body into a new instance and runs its Bind method
+func bindRequest[T RendererBinder](r *http.Request, instance func() T) (T, *ErrResponse) {
+	bindReq := r.WithContext(newContextWithGeneratedIDs(r.Context()))
+
+	resource := instance()
+	err := render.Bind(bindReq, resource)
+
+	// Form values parsed while binding are copied so they are still available to the caller, but its context is kept
+	r.Form, r.PostForm, r.MultipartForm = bindReq.Form, bindReq.PostForm, bindReq.MultipartForm
+
 	if err != nil {
 		return *new(T), ErrInvalidRequest(err)
 	}
@@ -189,14 +221,15 @@ func GetFromRequest[T RendererBinder](r *http.Request, instance func() T) (T, *E
 	return resource, nil
 }
 
-// GetRequestedResource reads the API's resource from storage based on the ID in the request URL
+// GetRequestedResource reads the API's resource from storage based on the ID in the request URL. If it does not exist,
+// the error is the response from SetNotFoundResponse
 func (a *API[T]) GetRequestedResource(r *http.Request) (T, *ErrResponse) {
 	id := a.GetIDParam(r)
 
 	resource, err := a.Storage.Get(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
-			return *new(T), ErrNotFoundResponse
+			return *new(T), a.notFoundResponse
 		}
 
 		return *new(T), InternalServerError(err)
@@ -216,7 +249,15 @@ func Handler(do func(http.ResponseWriter, *http.Request) render.Renderer) http.H
 
 		httpErr, ok := response.(*ErrResponse)
 		if ok {
+			// There is no reason to respond if the client already closed the request
+			var closed bool
+			httpErr, closed = checkClientClosed(r, httpErr)
+			if closed {
+				logger.Debug("client closed request", "error", httpErr.Err)
+				return
+			}
 			logger.Error("error returned from handler", "error", httpErr.Err)
+			response = httpErr
 		}
 
 		err := render.Render(w, r, response)
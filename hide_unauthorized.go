@@ -0,0 +1,68 @@
+package babyapi
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+// SetHideUnauthorized enables or disables responding with the not-found response instead of 403 Forbidden, so clients
+// cannot tell if a resource exists when they are not allowed to access it. It applies to every 403 response from this
+// API and its nested APIs, including responses from middlewares and custom routes, like when ErrForbidden is returned
+// by GetRequestedResourceAndDoMiddleware. The response is ErrNotFoundResponse unless SetNotFoundResponse is used
+func (a *API[T]) SetHideUnauthorized(enabled bool) *API[T] {
+	a.panicIfReadOnly()
+
+	a.hideUnauthorized = enabled
+	return a
+}
+
+// hideUnauthorizedMiddleware replaces 403 Forbidden responses with the API's not-found response
+func (a *API[T]) hideUnauthorizedMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&hideForbiddenResponseWriter{ResponseWriter: w, r: r, notFound: a.notFoundResponse}, r)
+	})
+}
+
+// hideForbiddenResponseWriter writes the not-found response instead of a 403 Forbidden response and discards the
+// original body
+type hideForbiddenResponseWriter struct {
+	http.ResponseWriter
+	r        *http.Request
+	notFound *ErrResponse
+
+	wroteHeader bool
+	hidden      bool
+}
+
+func (hw *hideForbiddenResponseWriter) WriteHeader(statusCode int) {
+	if hw.wroteHeader {
+		return
+	}
+	hw.wroteHeader = true
+
+	if statusCode != http.StatusForbidden {
+		hw.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+
+	hw.hidden = true
+	hw.Header().Del("Content-Length")
+	_ = render.Render(hw.ResponseWriter, hw.r, hw.notFound)
+}
+
+func (hw *hideForbiddenResponseWriter) Write(b []byte) (int, error) {
+	if !hw.wroteHeader {
+		hw.WriteHeader(http.StatusOK)
+	}
+	if hw.hidden {
+		return len(b), nil
+	}
+	return hw.ResponseWriter.Write(b)
+}
+
+func (hw *hideForbiddenResponseWriter) Flush() {
+	if flusher, ok := hw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -3,6 +3,7 @@ package html
 import (
 	"bytes"
 	"embed"
+	"fmt"
 	"html/template"
 	"net/http"
 	"os"
@@ -28,9 +29,46 @@ func SetFuncs(funcs func(r *http.Request) map[string]any) {
 	templateFuncs = funcs
 }
 
+// ContentBlock is the name of the template that a layout uses to include the page content when rendered with Page
+const ContentBlock = "content"
+
 type Template string
 
 func (t Template) Render(r *http.Request, data any) string {
+	return execute(parseTemplates(r), string(t), data)
+}
+
+// RenderPage renders the layout template with this template as the content. The layout includes the content using
+// {{ template "content" . }} or {{ block "content" . }}{{ end }} to provide a default
+func (t Template) RenderPage(r *http.Request, layout Template, data any) string {
+	templates := parseTemplates(r)
+
+	content := templates.Lookup(string(t))
+	if content == nil {
+		panic(fmt.Errorf("html/template: %q is undefined", t))
+	}
+
+	templates = template.Must(templates.AddParseTree(ContentBlock, content.Tree))
+
+	return execute(templates, string(layout), data)
+}
+
+// Page creates a renderer that composes the layout and content templates. This allows multiple pages to share a
+// base layout without duplicating the full HTML document in each template
+func Page(layout, content Template, data any) render.Renderer {
+	return pageRenderer{layout: layout, htmlRenderer: htmlRenderer{t: content, data: data}}
+}
+
+type pageRenderer struct {
+	htmlRenderer
+	layout Template
+}
+
+func (p pageRenderer) HTML(r *http.Request) string {
+	return p.t.RenderPage(r, p.layout, p.data)
+}
+
+func parseTemplates(r *http.Request) *template.Template {
 	templates := template.New("base")
 	if templateFuncs != nil {
 		templates = templates.Funcs(templateFuncs(r))
@@ -46,8 +84,12 @@ func (t Template) Render(r *http.Request, data any) string {
 		templates = template.Must(templates.ParseFS(templateFS, templateGlob))
 	}
 
+	return templates
+}
+
+func execute(templates *template.Template, name string, data any) string {
 	var renderedOutput bytes.Buffer
-	err := templates.ExecuteTemplate(&renderedOutput, string(t), data)
+	err := templates.ExecuteTemplate(&renderedOutput, name, data)
 	if err != nil {
 		panic(err)
 	}
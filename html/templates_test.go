@@ -0,0 +1,41 @@
+package html
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPage(t *testing.T) {
+	SetMap(map[string]string{
+		"layout":  `<html><body>{{ template "content" . }}</body></html>`,
+		"default": `<html><body>{{ block "content" . }}default{{ end }}</body></html>`,
+		"item":    `<p>{{ . }}</p>`,
+	})
+	defer SetMap(nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+	t.Run("RenderPage", func(t *testing.T) {
+		require.Equal(t, "<html><body><p>hello</p></body></html>", Template("item").RenderPage(r, "layout", "hello"))
+	})
+
+	t.Run("RenderPageWithBlock", func(t *testing.T) {
+		require.Equal(t, "<html><body><p>hello</p></body></html>", Template("item").RenderPage(r, "default", "hello"))
+		require.Equal(t, "<html><body>default</body></html>", Template("default").Render(r, "hello"))
+	})
+
+	t.Run("Page", func(t *testing.T) {
+		page, ok := Page("layout", "item", "hello").(interface{ HTML(*http.Request) string })
+		require.True(t, ok)
+		require.Equal(t, "<html><body><p>hello</p></body></html>", page.HTML(r))
+	})
+
+	t.Run("UndefinedContent", func(t *testing.T) {
+		require.Panics(t, func() {
+			Template("missing").RenderPage(r, "layout", nil)
+		})
+	})
+}
@@ -0,0 +1,48 @@
+package babyapi
+
+import (
+	"fmt"
+	"sync"
+)
+
+// EnableInstancePool reuses resource instances for request bodies with a sync.Pool to reduce allocations for very
+// busy APIs. The resource type must implement Resettable so instances are cleared before they are reused. Reset must
+// restore any defaults from the instance function since they are only set for instances created by it. The
+// instance is returned to the pool after the handler responds, so Storage, hooks, and handlers must not keep a
+// reference to the request body after the request, like storing the pointer in an in-memory map
+func (a *API[T]) EnableInstancePool() *API[T] {
+	a.panicIfReadOnly()
+
+	if _, ok := any(a.instance()).(Resettable); !ok {
+		a.errors = append(a.errors, fmt.Errorf("EnableInstancePool: %T does not implement Resettable", *new(T)))
+		return a
+	}
+
+	a.instancePool = &sync.Pool{
+		New: func() any {
+			return a.instance()
+		},
+	}
+
+	return a
+}
+
+// newInstance creates a new instance of the resource or gets a reset instance from the pool
+func (a *API[T]) newInstance() T {
+	if a.instancePool == nil {
+		return a.instance()
+	}
+
+	resource := a.instancePool.Get().(T)
+	any(resource).(Resettable).Reset()
+	return resource
+}
+
+// releaseInstance returns the instance to the pool if it is enabled
+func (a *API[T]) releaseInstance(resource T) {
+	if a.instancePool == nil {
+		return
+	}
+
+	a.instancePool.Put(resource)
+}
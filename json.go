@@ -0,0 +1,190 @@
+package babyapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/go-chi/render"
+)
+
+// jsonCodec has the functions set by SetJSONCodec. When it is nil, encoding/json is used
+var jsonCodec *struct {
+	marshal   func(any) ([]byte, error)
+	unmarshal func([]byte, any) error
+}
+
+// SetJSONCodec replaces encoding/json with other functions, like from a faster JSON library, to encode and decode
+// request bodies, responses, Client requests and responses, and KVStorage data. Passing nil for either function
+// restores the default encoding/json implementation. It applies to all APIs and Clients, so it should be called
+// before any are used
+func SetJSONCodec(marshal func(any) ([]byte, error), unmarshal func([]byte, any) error) {
+	if marshal == nil || unmarshal == nil {
+		jsonCodec = nil
+		return
+	}
+
+	jsonCodec = &struct {
+		marshal   func(any) ([]byte, error)
+		unmarshal func([]byte, any) error
+	}{marshal, unmarshal}
+}
+
+func marshalJSON(v any) ([]byte, error) {
+	if jsonCodec == nil {
+		return json.Marshal(v)
+	}
+	return jsonCodec.marshal(v)
+}
+
+func unmarshalJSON(data []byte, v any) error {
+	if jsonCodec == nil {
+		return json.Unmarshal(data, v)
+	}
+	return jsonCodec.unmarshal(data, v)
+}
+
+// encodeJSON writes the JSON encoding of v followed by a newline, like json.Encoder
+func encodeJSON(w io.Writer, v any) error {
+	if jsonCodec == nil {
+		return json.NewEncoder(w).Encode(v)
+	}
+
+	data, err := jsonCodec.marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// SetStrictBinding enables or disables rejecting JSON request bodies that have fields which are not in the resource,
+// so a typo in a field name results in 400 Bad Request instead of being ignored. It applies to request bodies bound by
+// the default handlers and GetFromRequest, and is inherited by nested APIs unless they set it. Since unknown fields are
+// detected by encoding/json, strict request bodies are not decoded with the functions from SetJSONCodec
+func (a *API[T]) SetStrictBinding(enabled bool) *API[T] {
+	a.panicIfReadOnly()
+
+	a.strictBinding = &enabled
+	return a
+}
+
+func (a *API[T]) strictBindingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), strictBindingCtxKey, *a.strictBinding)))
+	})
+}
+
+// decodeJSON is used by decode for JSON request bodies
+func decodeJSON(r *http.Request, v any) error {
+	if strict, _ := r.Context().Value(strictBindingCtxKey).(bool); strict {
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(v)
+		_, _ = io.Copy(io.Discard, r.Body)
+		return err
+	}
+
+	if jsonCodec == nil {
+		return render.DefaultDecoder(r, v)
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("error reading request body: %w", err)
+	}
+
+	return jsonCodec.unmarshal(data, v)
+}
+
+// SetResponseTransformer sets a function that modifies the encoded body of JSON responses from this API right before
+// it is written, like to add an envelope or remove null fields. This is useful for response formats that cannot be
+// created with response wrappers. It is used for every JSON response that is rendered, including errors, but not for
+// HTML, XML, or server-sent events. Since the function needs the whole body, responses are buffered even if
+// SetStreamingResponses is used. Nested APIs use the same function unless they set their own
+func (a *API[T]) SetResponseTransformer(transform func(*http.Request, []byte) ([]byte, error)) *API[T] {
+	a.panicIfReadOnly()
+
+	if transform == nil {
+		a.errors = append(a.errors, fmt.Errorf("SetResponseTransformer: transformer must not be nil"))
+		return a
+	}
+
+	a.responseTransformer = transform
+	return a
+}
+
+func (a *API[T]) responseTransformerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), responseTransformerCtxKey, a.responseTransformer)))
+	})
+}
+
+// respondJSON is used by render.Respond for responses that are not HTML. It uses render.DefaultResponder unless the
+// response is JSON and is a ResourceList, or a custom codec, the API's JSON options, or a response transformer is set
+func respondJSON(w http.ResponseWriter, r *http.Request, v any) {
+	isChan := v != nil && reflect.TypeOf(v).Kind() == reflect.Chan
+	if isChan || render.GetAcceptedContentType(r) == render.ContentTypeXML {
+		render.DefaultResponder(w, r, v)
+		return
+	}
+
+	opts := getJSONOptionsFromContext(r.Context())
+	transform, _ := r.Context().Value(responseTransformerCtxKey).(func(*http.Request, []byte) ([]byte, error))
+
+	// Lists are encoded one item at a time instead of encoding the whole response in memory, unless the transformer
+	// needs the whole response
+	if streamer, ok := getJSONStreamer(v); ok && transform == nil {
+		respondStreamJSON(w, r, streamer, opts)
+		return
+	}
+
+	if jsonCodec == nil && opts == nil && transform == nil {
+		render.DefaultResponder(w, r, v)
+		return
+	}
+
+	data, err := encodeResponseJSON(v, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if transform != nil {
+		data, err = transform(r, data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if status, ok := r.Context().Value(render.StatusCtxKey).(int); ok {
+		w.WriteHeader(status)
+	}
+	_, _ = w.Write(append(data, '\n'))
+}
+
+// encodeResponseJSON encodes the response with the JSON options, without a trailing newline
+func encodeResponseJSON(v any, opts *jsonOptions) ([]byte, error) {
+	if streamer, ok := getJSONStreamer(v); ok {
+		var buf bytes.Buffer
+		err := streamer.streamJSON(&buf, opts)
+		return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), err
+	}
+
+	if opts != nil {
+		var err error
+		v, err = opts.value(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return marshalJSON(v)
+}
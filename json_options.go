@@ -0,0 +1,314 @@
+package babyapi
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// jsonOptions change how JSON responses are encoded for an API
+type jsonOptions struct {
+	timeFormat string
+	omitEmpty  bool
+}
+
+// SetTimeFormat sets the layout, like time.DateOnly, used to format time.Time fields in JSON responses from this API
+// instead of RFC 3339. Request bodies are still decoded with the resource's UnmarshalJSON or encoding/json, so Clients
+// and any other code that decodes the responses must expect this format. Like AddMiddleware, the JSON options also
+// apply to nested APIs unless they set their own options
+func (a *API[T]) SetTimeFormat(layout string) *API[T] {
+	a.panicIfReadOnly()
+
+	if a.jsonOptions == nil {
+		a.jsonOptions = &jsonOptions{}
+	}
+	a.jsonOptions.timeFormat = layout
+	return a
+}
+
+// SetOmitEmpty enables omitting fields with empty values from JSON responses from this API, the same as using the
+// omitempty option in every field's JSON tag. Zero time.Time values are also omitted. Like AddMiddleware, the JSON
+// options also apply to nested APIs unless they set their own options
+func (a *API[T]) SetOmitEmpty(enabled bool) *API[T] {
+	a.panicIfReadOnly()
+
+	if a.jsonOptions == nil {
+		a.jsonOptions = &jsonOptions{}
+	}
+	a.jsonOptions.omitEmpty = enabled
+	return a
+}
+
+// jsonOptionsMiddleware adds the API's JSON options to the request context so they are used by respondJSON
+func (a *API[T]) jsonOptionsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), jsonOptionsCtxKey, a.jsonOptions)))
+	})
+}
+
+func getJSONOptionsFromContext(ctx context.Context) *jsonOptions {
+	opts, _ := ctx.Value(jsonOptionsCtxKey).(*jsonOptions)
+	return opts
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// value converts v into a value that uses the options when it is encoded. It follows the same rules as
+// encoding/json for field names, embedded structs, and types that implement json.Marshaler or encoding.TextMarshaler
+func (opts *jsonOptions) value(v any) (any, error) {
+	return opts.convert(reflect.ValueOf(v))
+}
+
+func (opts *jsonOptions) convert(v reflect.Value) (any, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	if v.CanInterface() {
+		if computed, ok := v.Interface().(interface{ unwrapComputed() *computedResponse }); ok {
+			return opts.convertComputed(computed.unwrapComputed())
+		}
+	}
+
+	if v.Type() == timeType && opts.timeFormat != "" {
+		return v.Interface().(time.Time).Format(opts.timeFormat), nil
+	}
+
+	if v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		isTime := v.Elem().Type() == timeType && opts.timeFormat != ""
+		if v.Kind() == reflect.Interface || isTime || !implementsMarshaler(v.Type()) {
+			return opts.convert(v.Elem())
+		}
+	}
+
+	if implementsMarshaler(v.Type()) || (v.CanAddr() && implementsMarshaler(reflect.PointerTo(v.Type()))) {
+		if v.CanAddr() {
+			v = v.Addr()
+		}
+		data, err := marshalJSON(v.Interface())
+		if err != nil {
+			return nil, err
+		}
+		return json.RawMessage(data), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return opts.convertStruct(v)
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		result := map[string]any{}
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := mapKey(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := opts.convert(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			result[key] = value
+		}
+		return result, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		// byte slices are base64 encoded by encoding/json
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface(), nil
+		}
+
+		result := make([]any, v.Len())
+		for i := range result {
+			value, err := opts.convert(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = value
+		}
+		return result, nil
+	default:
+		return v.Interface(), nil
+	}
+}
+
+// convertStruct converts a struct into an orderedObject. Fields from embedded structs are included unless a field
+// with the same name is less deeply nested, like encoding/json
+func (opts *jsonOptions) convertStruct(v reflect.Value) (orderedObject, error) {
+	fields := orderedObject{}
+	depths := map[string]int{}
+
+	var walk func(v reflect.Value, depth int) error
+	walk = func(v reflect.Value, depth int) error {
+		rt := v.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			field := rt.Field(i)
+			fieldValue := v.Field(i)
+
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, tagOptions, _ := strings.Cut(tag, ",")
+
+			if field.Anonymous && name == "" {
+				fieldType := field.Type
+				if fieldType.Kind() == reflect.Pointer {
+					fieldType = fieldType.Elem()
+				}
+
+				if fieldType.Kind() == reflect.Struct && !implementsMarshaler(field.Type) {
+					if fieldValue.Kind() == reflect.Pointer {
+						if fieldValue.IsNil() {
+							continue
+						}
+						fieldValue = fieldValue.Elem()
+					}
+
+					err := walk(fieldValue, depth+1)
+					if err != nil {
+						return err
+					}
+					continue
+				}
+			}
+
+			if !field.IsExported() {
+				continue
+			}
+
+			if name == "" {
+				name = field.Name
+			}
+
+			omitEmpty := opts.omitEmpty || slices.Contains(strings.Split(tagOptions, ","), "omitempty")
+			if omitEmpty && isEmptyJSONValue(fieldValue) {
+				continue
+			}
+
+			value, err := opts.convert(fieldValue)
+			if err != nil {
+				return fmt.Errorf("error encoding field %q: %w", name, err)
+			}
+
+			existingDepth, ok := depths[name]
+			switch {
+			case !ok:
+				depths[name] = depth
+				fields = append(fields, orderedField{name, value})
+			case depth < existingDepth:
+				depths[name] = depth
+				fields.set(name, value)
+			}
+		}
+		return nil
+	}
+
+	err := walk(v, 0)
+	return fields, err
+}
+
+func implementsMarshaler(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType)
+}
+
+// isEmptyJSONValue uses the same rules as the omitempty option in encoding/json, but also treats zero time.Time
+// values as empty
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	case reflect.Struct:
+		return v.Type() == timeType && v.IsZero()
+	}
+	return false
+}
+
+// mapKey converts a map key to a string like encoding/json
+func mapKey(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+
+	if tm, ok := key.Interface().(encoding.TextMarshaler); ok {
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return fmt.Sprint(key.Interface()), nil
+	}
+
+	return "", fmt.Errorf("unsupported map key type %s", key.Type())
+}
+
+// orderedObject is a JSON object that keeps the order of its fields
+type orderedObject []orderedField
+
+type orderedField struct {
+	name  string
+	value any
+}
+
+func (o orderedObject) set(name string, value any) {
+	for i := range o {
+		if o[i].name == name {
+			o[i].value = value
+			return
+		}
+	}
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		name, err := json.Marshal(field.name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+
+		value, err := marshalJSON(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,331 @@
+package babyapi
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/madflojo/hord"
+)
+
+// kvFieldIndex is a field from WithIndex
+type kvFieldIndex struct {
+	name      string
+	index     []int
+	fieldType reflect.Type
+}
+
+// newKVFieldIndex finds the field matching the name in the pointer to struct type
+func newKVFieldIndex(t reflect.Type, name string) (kvFieldIndex, bool) {
+	if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return kvFieldIndex{}, false
+	}
+
+	index, fieldType, ok := findIndexField(t.Elem(), name)
+	if !ok {
+		return kvFieldIndex{}, false
+	}
+
+	return kvFieldIndex{strings.ToLower(name), index, fieldType}, true
+}
+
+// findIndexField finds a field supported by QueryFilter with a matching name. Embedded structs are treated as part
+// of the parent struct
+func findIndexField(t reflect.Type, name string) ([]int, reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && !reflect.PointerTo(field.Type).Implements(textUnmarshalerType) {
+			index, fieldType, ok := findIndexField(field.Type, name)
+			if ok {
+				return append([]int{i}, index...), fieldType, true
+			}
+			continue
+		}
+
+		if !field.IsExported() || !strings.EqualFold(formFieldName(field), name) || !queryFilterSupported(field.Type) {
+			continue
+		}
+
+		return []int{i}, field.Type, true
+	}
+
+	return nil, nil, false
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// value returns the indexed string for the field's value. Nil pointers are not indexed
+func (i kvFieldIndex) value(item any) (string, bool) {
+	rv := reflect.ValueOf(item)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return "", false
+	}
+
+	return indexValueString(rv.Elem().FieldByIndex(i.index))
+}
+
+// parseValue parses the query value into the field's type so it matches the indexed string
+func (i kvFieldIndex) parseValue(value string) (string, bool) {
+	fieldType := i.fieldType
+	for fieldType.Kind() == reflect.Pointer && !reflect.PointerTo(fieldType).Implements(textUnmarshalerType) {
+		fieldType = fieldType.Elem()
+	}
+
+	v := reflect.New(fieldType).Elem()
+	err := setFormValue(v, []string{value})
+	if err != nil {
+		return "", false
+	}
+
+	return indexValueString(v)
+}
+
+// indexValueString uses encoding.TextMarshaler if it is implemented. Otherwise, it uses the default format. Values
+// from unexported fields are not indexed
+func indexValueString(v reflect.Value) (string, bool) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+
+	if !v.CanInterface() {
+		return "", false
+	}
+
+	marshaler, ok := addressable(v).Addr().Interface().(encoding.TextMarshaler)
+	if ok {
+		text, err := marshaler.MarshalText()
+		if err != nil {
+			return "", false
+		}
+		return string(text), true
+	}
+
+	return fmt.Sprint(v.Interface()), true
+}
+
+// parentIDsOf returns the ParentIDs if the resource implements MultiParentResource or the ParentID if it implements
+// ChildResource. Empty IDs are excluded
+func parentIDsOf(item any) []string {
+	var parentIDs []string
+	switch child := item.(type) {
+	case MultiParentResource:
+		parentIDs = child.ParentIDs()
+	case ChildResource:
+		parentIDs = []string{child.ParentID()}
+	}
+
+	return slices.DeleteFunc(slices.Clone(parentIDs), func(parentID string) bool {
+		return parentID == ""
+	})
+}
+
+// hasParents checks if the resource type implements ChildResource or MultiParentResource
+func hasParents[T Resource]() bool {
+	switch any(*new(T)).(type) {
+	case MultiParentResource, ChildResource:
+		return true
+	}
+	return false
+}
+
+func (c *KVStorage[T]) hasIndexes() bool {
+	return c.parentIndex || len(c.indexes) > 0
+}
+
+func (c *KVStorage[T]) fieldIndex(name string) (kvFieldIndex, bool) {
+	for _, index := range c.indexes {
+		if strings.EqualFold(index.name, name) {
+			return index, true
+		}
+	}
+	return kvFieldIndex{}, false
+}
+
+// indexKey joins the escaped parts with the separator after "%index". Escaped prefixes can't start with "%index", so
+// GetAll does not read index keys as resources. Prefixes are not escaped with the default separator, so they should
+// not start with "%index" when indexes are used
+func (c *KVStorage[T]) indexKey(parts ...string) string {
+	key := "%index"
+	for _, part := range parts {
+		key += c.separator + c.escapeKeyPart(part)
+	}
+	return key
+}
+
+// parentIndexKey is the key for the list of resource IDs that belong to the parent
+func (c *KVStorage[T]) parentIndexKey(parentID string) string {
+	return c.indexKey(c.prefix, "parent", parentID)
+}
+
+// fieldIndexKey is the key for the list of resource IDs with the value for a field. The value is parsed for the
+// field's type first, so a value that can't be parsed uses a key that has no resources
+func (c *KVStorage[T]) fieldIndexKey(index kvFieldIndex, value string) string {
+	parsed, ok := index.parseValue(value)
+	if !ok {
+		return c.indexKey(c.prefix, "invalid")
+	}
+	return c.indexValueKey(index, parsed)
+}
+
+func (c *KVStorage[T]) indexValueKey(index kvFieldIndex, value string) string {
+	return c.indexKey(c.prefix, "field", index.name, value)
+}
+
+// indexKeys returns the keys of all indexes that include the resource
+func (c *KVStorage[T]) indexKeys(item T) []string {
+	keys := []string{}
+
+	if c.parentIndex {
+		for _, parentID := range parentIDsOf(item) {
+			keys = append(keys, c.parentIndexKey(parentID))
+		}
+	}
+
+	for _, index := range c.indexes {
+		value, ok := index.value(item)
+		if ok {
+			keys = append(keys, c.indexValueKey(index, value))
+		}
+	}
+
+	return keys
+}
+
+// queryIndexes returns the IDs of resources matching query parameters for indexed fields. Like QueryFilter, a field
+// can match any of the parameter's values and empty values are ignored. It returns false if no parameters use an index
+func (c *KVStorage[T]) queryIndexes(query url.Values) ([]string, bool, error) {
+	var result []string
+	indexed := false
+
+	for _, index := range c.indexes {
+		values, ok := getFormValues(query, index.name)
+		if !ok {
+			continue
+		}
+
+		values = slices.DeleteFunc(slices.Clone(values), func(value string) bool {
+			return value == ""
+		})
+		if len(values) == 0 {
+			continue
+		}
+
+		matches := []string{}
+		for _, value := range values {
+			ids, err := c.readIndex(c.fieldIndexKey(index, value))
+			if err != nil {
+				return nil, false, err
+			}
+			for _, id := range ids {
+				if !slices.Contains(matches, id) {
+					matches = append(matches, id)
+				}
+			}
+		}
+
+		if !indexed {
+			result = matches
+			indexed = true
+			continue
+		}
+
+		result = slices.DeleteFunc(result, func(id string) bool {
+			return !slices.Contains(matches, id)
+		})
+	}
+
+	return result, indexed, nil
+}
+
+// updateIndexes removes the ID from indexes that are only in oldKeys and adds it to indexes that are only in newKeys.
+// indexLock must be held because each index is read and written separately
+func (c *KVStorage[T]) updateIndexes(id string, oldKeys, newKeys []string) error {
+	for _, key := range oldKeys {
+		if slices.Contains(newKeys, key) {
+			continue
+		}
+
+		err := c.updateIndex(key, func(ids []string) []string {
+			return slices.DeleteFunc(ids, func(existing string) bool {
+				return existing == id
+			})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, key := range newKeys {
+		if slices.Contains(oldKeys, key) {
+			continue
+		}
+
+		err := c.updateIndex(key, func(ids []string) []string {
+			if slices.Contains(ids, id) {
+				return ids
+			}
+			return append(ids, id)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readIndex reads the list of IDs stored at the key. A missing key is an empty index
+func (c *KVStorage[T]) readIndex(key string) ([]string, error) {
+	data, err := c.db.Get(key)
+	if err != nil {
+		if errors.Is(hord.ErrNil, err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("error getting index: %w", err)
+	}
+
+	var ids []string
+	err = unmarshalJSON(data, &ids)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing index: %w", err)
+	}
+
+	return ids, nil
+}
+
+// updateIndex reads the index, modifies it with update, and writes the result. Empty indexes are deleted
+func (c *KVStorage[T]) updateIndex(key string, update func([]string) []string) error {
+	ids, err := c.readIndex(key)
+	if err != nil {
+		return err
+	}
+
+	ids = update(ids)
+	if len(ids) == 0 {
+		err = c.db.Delete(key)
+		if err != nil {
+			return fmt.Errorf("error deleting index: %w", err)
+		}
+		return nil
+	}
+
+	data, err := marshalJSON(ids)
+	if err != nil {
+		return fmt.Errorf("error marshalling index: %w", err)
+	}
+
+	err = c.db.Set(key, data)
+	if err != nil {
+		return fmt.Errorf("error writing index: %w", err)
+	}
+
+	return nil
+}
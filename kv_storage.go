@@ -2,12 +2,13 @@ package babyapi
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
+	"reflect"
+	"slices"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/madflojo/hord"
 )
@@ -16,20 +17,121 @@ import (
 //
 // It allows soft-deleting if your type implements the kv.EndDateable interface. This means Delete will set the end-date
 // to now and update in storage instead of deleting. If something is already end-dated, then it is hard-deleted. Also,
-// the GetAll method will automatically read the 'end_dated' query param to determine if end-dated resources should
+// the GetAll method uses EndDatedFilter to read the 'end_dated' query param and determine if end-dated resources should
 // be filtered out
+//
+// It implements ParentIndexed for resources that implement ChildResource or MultiParentResource. By default, GetAllByParent reads every
+// resource and filters by ParentID, which is O(n) for the number of resources. Use WithParentIndex to maintain an
+// index of resource IDs for each parent instead. Use WithIndex to maintain indexes for other fields. Index updates are
+// serialized for each KVStorage, so multiple KVStorages with the same prefix and database should not be used with indexes
 type KVStorage[T Resource] struct {
-	prefix string
-	db     hord.Database
+	prefix      string
+	separator   string
+	db          hord.Database
+	parentIndex bool
+	indexes     []kvFieldIndex
+
+	// indexLock is held while a resource and its indexes are updated so concurrent writes do not lose index updates
+	indexLock sync.Mutex
+}
+
+var (
+	_ Storage[*NilResource]       = &KVStorage[*NilResource]{}
+	_ ParentIndexed[*NilResource] = &KVStorage[*NilResource]{}
+)
+
+// KVStorageOption configures optional KVStorage features
+type KVStorageOption func(*kvStorageOptions)
+
+type kvStorageOptions struct {
+	separator   string
+	parentIndex bool
+	indexes     []string
+}
+
+// defaultKeySeparator is used between the prefix and ID unless WithKeySeparator is used
+const defaultKeySeparator = "_"
+
+// WithKeySeparator sets the separator used between the prefix and ID in keys. The default is an underscore, which
+// can't be used in prefixes. When a different separator is used, any percent signs or separators in the prefix are percent-encoded, so keys are unique
+// for each prefix and ID even if a prefix contains the separator. A separator that does not appear in prefixes, like
+// ":", keeps keys readable. The separator can't contain a percent sign
+func WithKeySeparator(separator string) KVStorageOption {
+	return func(o *kvStorageOptions) {
+		o.separator = separator
+	}
+}
+
+// WithParentIndex stores an index of resource IDs for each parent ID so GetAllByParent does not read every resource.
+// The index is updated by Set and Delete, so it should be enabled before any resources are stored. It does nothing
+// for resources that do not implement ChildResource or MultiParentResource
+func WithParentIndex() KVStorageOption {
+	return func(o *kvStorageOptions) {
+		o.parentIndex = true
+	}
+}
+
+// WithIndex stores an index of resource IDs for each value of the fields. Fields are matched case-insensitively
+// against the JSON tag name, or field name if there is no tag, and support the same types as QueryFilter. GetAll
+// uses the index when a query parameter has an indexed field's name, and GetByIndex can be used directly. Like
+// WithParentIndex, it should be enabled before any resources are stored
+func WithIndex(fieldNames ...string) KVStorageOption {
+	return func(o *kvStorageOptions) {
+		o.indexes = append(o.indexes, fieldNames...)
+	}
 }
 
-// NewKVStorage creates a new storage client for the specified type. It stores resources with keys prefixed by 'prefix'
-func NewKVStorage[T Resource](db hord.Database, prefix string) Storage[T] {
-	return &KVStorage[T]{prefix, db}
+// NewKVStorage creates a new storage client for the specified type. It stores resources with keys prefixed by 'prefix'.
+// Panics if an index from WithIndex does not match a supported field, the separator from WithKeySeparator is invalid,
+// or the prefix contains an underscore while using the default separator. Prefixes are not escaped with the default
+// separator, so "User" would read keys for a "User_Profile" prefix
+func NewKVStorage[T Resource](db hord.Database, prefix string, opts ...KVStorageOption) Storage[T] {
+	options := kvStorageOptions{separator: defaultKeySeparator}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.separator == "" {
+		options.separator = defaultKeySeparator
+	}
+	if strings.Contains(options.separator, "%") {
+		panic(fmt.Sprintf("NewKVStorage: key separator %q can't contain a percent sign", options.separator))
+	}
+	if options.separator == defaultKeySeparator && strings.Contains(prefix, defaultKeySeparator) {
+		panic(fmt.Sprintf("NewKVStorage: prefix %q can't contain an underscore unless WithKeySeparator is used", prefix))
+	}
+
+	indexes := []kvFieldIndex{}
+	for _, name := range options.indexes {
+		index, ok := newKVFieldIndex(reflect.TypeOf(*new(T)), name)
+		if !ok {
+			panic(fmt.Sprintf("NewKVStorage: %T does not have a supported field for index %q", *new(T), name))
+		}
+		indexes = append(indexes, index)
+	}
+
+	return &KVStorage[T]{prefix: prefix, separator: options.separator, db: db, parentIndex: options.parentIndex, indexes: indexes}
 }
 
+// key is the escaped prefix and the ID joined by the separator. IDs are not escaped because the first separator in the
+// key always ends the prefix. Prefixes are not escaped with the default separator so existing keys, like
+// "TODO_<id>", do not change. NewKVStorage rejects prefixes containing the default separator instead
 func (c *KVStorage[T]) key(id string) string {
-	return fmt.Sprintf("%s_%s", c.prefix, id)
+	prefix := c.prefix
+	if c.separator != defaultKeySeparator {
+		prefix = c.escapeKeyPart(prefix)
+	}
+
+	return prefix + c.separator + id
+}
+
+// escapeKeyPart percent-encodes percent signs and the separator so the value can't contain the separator
+func (c *KVStorage[T]) escapeKeyPart(value string) string {
+	encodedSeparator := ""
+	for _, b := range []byte(c.separator) {
+		encodedSeparator += fmt.Sprintf("%%%02X", b)
+	}
+
+	return strings.NewReplacer("%", "%25", c.separator, encodedSeparator).Replace(value)
 }
 
 // Delete will delete a resource by the key. If the resource implements EndDateable, it will first soft-delete by
@@ -42,18 +144,22 @@ func (c *KVStorage[T]) Delete(ctx context.Context, id string) error {
 		return fmt.Errorf("error getting resource before deleting: %w", err)
 	}
 
-	endDateable, ok := any(result).(EndDateable)
-	if !ok {
-		return c.db.Delete(key)
+	softDeleted, err := SoftDelete[T](ctx, result, c.Set)
+	if softDeleted || err != nil {
+		return err
 	}
 
-	if endDateable.EndDated() {
-		return c.db.Delete(key)
+	if c.hasIndexes() {
+		c.indexLock.Lock()
+		defer c.indexLock.Unlock()
 	}
 
-	endDateable.SetEndDate(time.Now())
+	err = c.db.Delete(key)
+	if err != nil {
+		return err
+	}
 
-	return c.Set(ctx, result)
+	return c.updateIndexes(id, c.indexKeys(result), nil)
 }
 
 // Get will use the provided key to read data from the data source. Then, it will Unmarshal
@@ -76,7 +182,7 @@ func (c *KVStorage[T]) get(key string) (T, error) {
 	}
 
 	var result T
-	err = json.Unmarshal(dataBytes, &result)
+	err = unmarshalJSON(dataBytes, &result)
 	if err != nil {
 		return *new(T), fmt.Errorf("error parsing data: %w", err)
 	}
@@ -85,16 +191,98 @@ func (c *KVStorage[T]) get(key string) (T, error) {
 }
 
 // GetAll will use the provided prefix to read data from the data source. Then, it will use Get
-// to read each element into the correct type
+// to read each element into the correct type. If query parameters match fields from WithIndex, only the resources
+// with matching values are read
 func (c *KVStorage[T]) GetAll(_ context.Context, query url.Values) ([]T, error) {
+	ids, indexed, err := c.queryIndexes(query)
+	if err != nil {
+		return nil, err
+	}
+	if indexed {
+		return c.getIDs(ids, query)
+	}
+
+	return c.getAll(query, nil)
+}
+
+// GetByIndex gets the resources with the value for a field from WithIndex. The value is parsed like a query parameter
+// for the field's type. It returns an error if the field is not indexed
+func (c *KVStorage[T]) GetByIndex(_ context.Context, fieldName, value string) ([]T, error) {
+	index, ok := c.fieldIndex(fieldName)
+	if !ok {
+		return nil, fmt.Errorf("field %q is not indexed", fieldName)
+	}
+
+	ids, err := c.readIndex(c.fieldIndexKey(index, value))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.getIDs(ids, nil)
+}
+
+// GetAllByParent gets all resources that implement ChildResource or MultiParentResource and have the parent ID. If
+// WithParentIndex is not used, this reads all resources. If the type does not implement either interface, it is the
+// same as GetAll because the parent is unknown
+func (c *KVStorage[T]) GetAllByParent(_ context.Context, parentID string, query url.Values) ([]T, error) {
+	if !hasParents[T]() {
+		return c.getAll(query, nil)
+	}
+
+	if !c.parentIndex {
+		return c.getAll(query, func(item T) bool {
+			return slices.Contains(parentIDsOf(item), parentID)
+		})
+	}
+
+	ids, err := c.readIndex(c.parentIndexKey(parentID))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.getIDs(ids, query)
+}
+
+// getIDs reads the resources by ID and returns the ones that match the end-dated filter. IDs for resources that no
+// longer exist are skipped so a stale index does not cause errors
+func (c *KVStorage[T]) getIDs(ids []string, query url.Values) ([]T, error) {
+	filter := EndDatedFilter[T](query)
+
+	results := []T{}
+	for _, id := range ids {
+		result, err := c.get(c.key(id))
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error getting data: %w", err)
+		}
+
+		if filter != nil && !filter(result) {
+			continue
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// getAll reads all resources with the prefix and returns the ones that match the end-dated filter and optional filter
+func (c *KVStorage[T]) getAll(query url.Values, include FilterFunc[T]) ([]T, error) {
 	keys, err := c.db.Keys()
 	if err != nil {
 		return nil, fmt.Errorf("error getting keys: %w", err)
 	}
 
+	filter := FilterAnd(EndDatedFilter[T](query), include)
+
+	// The separator is included so a prefix like "Invite" does not match keys for "InviteLog"
+	keyPrefix := c.key("")
+
 	results := []T{}
 	for _, key := range keys {
-		if !strings.HasPrefix(key, c.prefix) {
+		if !strings.HasPrefix(key, keyPrefix) {
 			continue
 		}
 
@@ -103,9 +291,7 @@ func (c *KVStorage[T]) GetAll(_ context.Context, query url.Values) ([]T, error)
 			return nil, fmt.Errorf("error getting data: %w", err)
 		}
 
-		getEndDated := query.Get("end_dated") == "true"
-		endDateable, ok := any(result).(EndDateable)
-		if ok && !getEndDated && endDateable.EndDated() {
+		if filter != nil && !filter(result) {
 			continue
 		}
 
@@ -117,15 +303,29 @@ func (c *KVStorage[T]) GetAll(_ context.Context, query url.Values) ([]T, error)
 
 // Set marshals the provided item and writes it to the database
 func (c *KVStorage[T]) Set(_ context.Context, item T) error {
-	asBytes, err := json.Marshal(item)
+	asBytes, err := marshalJSON(item)
 	if err != nil {
 		return fmt.Errorf("error marshalling data: %w", err)
 	}
 
+	var oldIndexKeys []string
+	if c.hasIndexes() {
+		c.indexLock.Lock()
+		defer c.indexLock.Unlock()
+
+		existing, err := c.get(c.key(item.GetID()))
+		switch {
+		case err == nil:
+			oldIndexKeys = c.indexKeys(existing)
+		case !errors.Is(err, ErrNotFound):
+			return fmt.Errorf("error getting existing resource: %w", err)
+		}
+	}
+
 	err = c.db.Set(c.key(item.GetID()), asBytes)
 	if err != nil {
 		return fmt.Errorf("error writing data to database: %w", err)
 	}
 
-	return nil
+	return c.updateIndexes(item.GetID(), oldIndexKeys, c.indexKeys(item))
 }
@@ -2,6 +2,9 @@ package babyapi
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"sync"
 	"testing"
 	"time"
 
@@ -139,3 +142,440 @@ func TestEndDateable(t *testing.T) {
 		require.ErrorIs(t, err, ErrNotFound)
 	})
 }
+
+func TestEndDatedFilterAndSoftDelete(t *testing.T) {
+	active := &EndDateableTODO{DefaultResource: NewDefaultResource(), Title: "Active"}
+	deleted := &EndDateableTODO{DefaultResource: NewDefaultResource(), Title: "Deleted"}
+
+	var saved []*EndDateableTODO
+	set := func(_ context.Context, todo *EndDateableTODO) error {
+		saved = append(saved, todo)
+		return nil
+	}
+
+	t.Run("SoftDeleteSetsEndDate", func(t *testing.T) {
+		softDeleted, err := SoftDelete(context.Background(), deleted, set)
+		require.NoError(t, err)
+		require.True(t, softDeleted)
+		require.True(t, deleted.EndDated())
+		require.Equal(t, []*EndDateableTODO{deleted}, saved)
+	})
+
+	t.Run("SoftDeleteAlreadyEndDated", func(t *testing.T) {
+		softDeleted, err := SoftDelete(context.Background(), deleted, set)
+		require.NoError(t, err)
+		require.False(t, softDeleted)
+		require.Len(t, saved, 1)
+	})
+
+	t.Run("SoftDeleteNotEndDateable", func(t *testing.T) {
+		softDeleted, err := SoftDelete(context.Background(), &NilResource{}, func(context.Context, *NilResource) error {
+			return nil
+		})
+		require.NoError(t, err)
+		require.False(t, softDeleted)
+	})
+
+	todos := []*EndDateableTODO{active, deleted}
+
+	t.Run("FilterExcludesEndDated", func(t *testing.T) {
+		require.Equal(t, []*EndDateableTODO{active}, EndDatedFilter[*EndDateableTODO](nil).Filter(todos))
+		require.Equal(t, []*EndDateableTODO{active}, EndDatedFilter[*EndDateableTODO](EndDatedQueryParam(false)).Filter(todos))
+	})
+
+	t.Run("FilterIncludesEndDated", func(t *testing.T) {
+		require.Equal(t, todos, EndDatedFilter[*EndDateableTODO](EndDatedQueryParam(true)).Filter(todos))
+	})
+}
+
+type ChildTODO struct {
+	DefaultResource
+
+	ListID string
+	Title  string
+}
+
+func (c *ChildTODO) ParentID() string {
+	return c.ListID
+}
+
+func TestKVStorageGetAllByParent(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []KVStorageOption
+	}{
+		{"Scan", nil},
+		{"Index", []KVStorageOption{WithParentIndex()}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := kv.NewFileDB(hashmap.Config{})
+			require.NoError(t, err)
+			c := NewKVStorage[*ChildTODO](db, "TODO", tt.opts...).(*KVStorage[*ChildTODO])
+
+			todo1 := &ChildTODO{DefaultResource: NewDefaultResource(), ListID: "list1", Title: "TODO 1"}
+			todo2 := &ChildTODO{DefaultResource: NewDefaultResource(), ListID: "list1", Title: "TODO 2"}
+			todo3 := &ChildTODO{DefaultResource: NewDefaultResource(), ListID: "list2", Title: "TODO 3"}
+			for _, todo := range []*ChildTODO{todo1, todo2, todo3} {
+				require.NoError(t, c.Set(context.Background(), todo))
+			}
+
+			t.Run("GetAllByParent", func(t *testing.T) {
+				result, err := c.GetAllByParent(context.Background(), "list1", nil)
+				require.NoError(t, err)
+				require.ElementsMatch(t, []*ChildTODO{todo1, todo2}, result)
+			})
+
+			t.Run("MoveToNewParent", func(t *testing.T) {
+				todo2.ListID = "list2"
+				require.NoError(t, c.Set(context.Background(), todo2))
+
+				result, err := c.GetAllByParent(context.Background(), "list1", nil)
+				require.NoError(t, err)
+				require.ElementsMatch(t, []*ChildTODO{todo1}, result)
+
+				result, err = c.GetAllByParent(context.Background(), "list2", nil)
+				require.NoError(t, err)
+				require.ElementsMatch(t, []*ChildTODO{todo2, todo3}, result)
+			})
+
+			t.Run("Delete", func(t *testing.T) {
+				require.NoError(t, c.Delete(context.Background(), todo1.GetID()))
+
+				result, err := c.GetAllByParent(context.Background(), "list1", nil)
+				require.NoError(t, err)
+				require.Empty(t, result)
+			})
+
+			t.Run("GetAllIgnoresIndex", func(t *testing.T) {
+				result, err := c.GetAll(context.Background(), nil)
+				require.NoError(t, err)
+				require.ElementsMatch(t, []*ChildTODO{todo2, todo3}, result)
+			})
+		})
+	}
+
+	t.Run("NotChildResource", func(t *testing.T) {
+		db, err := kv.NewFileDB(hashmap.Config{})
+		require.NoError(t, err)
+		c := NewKVStorage[*TODO](db, "TODO", WithParentIndex()).(*KVStorage[*TODO])
+
+		todo := &TODO{DefaultResource: NewDefaultResource(), Title: "TODO"}
+		require.NoError(t, c.Set(context.Background(), todo))
+
+		result, err := c.GetAllByParent(context.Background(), "list1", nil)
+		require.NoError(t, err)
+		require.Equal(t, []*TODO{todo}, result)
+	})
+}
+
+type IndexedTODO struct {
+	DefaultResource
+
+	Title    string `json:"title"`
+	Priority int    `json:"priority"`
+	Owner    *string
+}
+
+type todoDetails struct {
+	Category string `json:"category"`
+	notes    string
+}
+
+type EmbeddedIndexedTODO struct {
+	DefaultResource
+	todoDetails
+}
+
+func TestKVStorageIndexes(t *testing.T) {
+	db, err := kv.NewFileDB(hashmap.Config{})
+	require.NoError(t, err)
+	c := NewKVStorage[*IndexedTODO](db, "TODO", WithIndex("priority", "owner")).(*KVStorage[*IndexedTODO])
+
+	owner := "me"
+	todo1 := &IndexedTODO{DefaultResource: NewDefaultResource(), Title: "TODO 1", Priority: 1, Owner: &owner}
+	todo2 := &IndexedTODO{DefaultResource: NewDefaultResource(), Title: "TODO 2", Priority: 2, Owner: &owner}
+	todo3 := &IndexedTODO{DefaultResource: NewDefaultResource(), Title: "TODO 3", Priority: 1}
+	for _, todo := range []*IndexedTODO{todo1, todo2, todo3} {
+		require.NoError(t, c.Set(context.Background(), todo))
+	}
+
+	t.Run("GetByIndex", func(t *testing.T) {
+		result, err := c.GetByIndex(context.Background(), "priority", "1")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*IndexedTODO{todo1, todo3}, result)
+
+		result, err = c.GetByIndex(context.Background(), "Owner", "me")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*IndexedTODO{todo1, todo2}, result)
+	})
+
+	t.Run("GetByIndexParsesValue", func(t *testing.T) {
+		result, err := c.GetByIndex(context.Background(), "priority", "01")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*IndexedTODO{todo1, todo3}, result)
+
+		result, err = c.GetByIndex(context.Background(), "priority", "abc")
+		require.NoError(t, err)
+		require.Empty(t, result)
+	})
+
+	t.Run("GetByIndexNotIndexed", func(t *testing.T) {
+		_, err := c.GetByIndex(context.Background(), "title", "TODO 1")
+		require.Error(t, err)
+		require.Equal(t, `field "title" is not indexed`, err.Error())
+	})
+
+	t.Run("GetAllQuery", func(t *testing.T) {
+		result, err := c.GetAll(context.Background(), url.Values{"priority": []string{"1"}, "owner": []string{"me"}})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*IndexedTODO{todo1}, result)
+
+		result, err = c.GetAll(context.Background(), url.Values{"priority": []string{"1", "2"}})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*IndexedTODO{todo1, todo2, todo3}, result)
+
+		result, err = c.GetAll(context.Background(), url.Values{"priority": []string{""}})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*IndexedTODO{todo1, todo2, todo3}, result)
+	})
+
+	t.Run("UpdateMovesIndex", func(t *testing.T) {
+		todo1.Priority = 2
+		todo1.Owner = nil
+		require.NoError(t, c.Set(context.Background(), todo1))
+
+		result, err := c.GetByIndex(context.Background(), "priority", "2")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*IndexedTODO{todo1, todo2}, result)
+
+		result, err = c.GetByIndex(context.Background(), "owner", "me")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*IndexedTODO{todo2}, result)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, c.Delete(context.Background(), todo2.GetID()))
+
+		result, err := c.GetByIndex(context.Background(), "priority", "2")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*IndexedTODO{todo1}, result)
+	})
+
+	t.Run("StaleIndexEntry", func(t *testing.T) {
+		stale := &IndexedTODO{DefaultResource: NewDefaultResource(), Title: "Stale", Priority: 3}
+		require.NoError(t, c.Set(context.Background(), stale))
+		require.NoError(t, db.Delete(c.key(stale.GetID())))
+
+		result, err := c.GetByIndex(context.Background(), "priority", "3")
+		require.NoError(t, err)
+		require.Empty(t, result)
+	})
+
+	t.Run("ConcurrentSet", func(t *testing.T) {
+		todos := []*IndexedTODO{}
+		for i := 0; i < 20; i++ {
+			todos = append(todos, &IndexedTODO{DefaultResource: NewDefaultResource(), Title: fmt.Sprintf("TODO %d", i), Priority: 4})
+		}
+
+		var wg sync.WaitGroup
+		for _, todo := range todos {
+			wg.Add(1)
+			go func(todo *IndexedTODO) {
+				defer wg.Done()
+				assert.NoError(t, c.Set(context.Background(), todo))
+			}(todo)
+		}
+		wg.Wait()
+
+		result, err := c.GetByIndex(context.Background(), "priority", "4")
+		require.NoError(t, err)
+		require.ElementsMatch(t, todos, result)
+	})
+
+	t.Run("UnexportedEmbeddedStruct", func(t *testing.T) {
+		c := NewKVStorage[*EmbeddedIndexedTODO](db, "EmbeddedTODO", WithIndex("category")).(*KVStorage[*EmbeddedIndexedTODO])
+
+		todo := &EmbeddedIndexedTODO{DefaultResource: NewDefaultResource(), todoDetails: todoDetails{Category: "home"}}
+		require.NoError(t, c.Set(context.Background(), todo))
+
+		result, err := c.GetByIndex(context.Background(), "category", "home")
+		require.NoError(t, err)
+		require.Equal(t, []*EmbeddedIndexedTODO{todo}, result)
+	})
+
+	t.Run("InvalidIndex", func(t *testing.T) {
+		require.PanicsWithValue(t, `NewKVStorage: *babyapi.IndexedTODO does not have a supported field for index "missing"`, func() {
+			NewKVStorage[*IndexedTODO](db, "TODO", WithIndex("missing"))
+		})
+	})
+}
+
+func TestKVStorageKeys(t *testing.T) {
+	t.Run("DefaultSeparatorNotEscaped", func(t *testing.T) {
+		db, err := kv.NewFileDB(hashmap.Config{})
+		require.NoError(t, err)
+		c := NewKVStorage[*TODO](db, "todo%items")
+
+		todo := &TODO{DefaultResource: NewDefaultResource(), Title: "TODO"}
+		require.NoError(t, c.Set(context.Background(), todo))
+
+		keys, err := db.Keys()
+		require.NoError(t, err)
+		require.Equal(t, []string{"todo%items_" + todo.GetID()}, keys)
+
+		result, err := c.Get(context.Background(), todo.GetID())
+		require.NoError(t, err)
+		require.Equal(t, todo, result)
+	})
+
+	t.Run("PrefixContainsSeparator", func(t *testing.T) {
+		db, err := kv.NewFileDB(hashmap.Config{})
+		require.NoError(t, err)
+
+		c1 := NewKVStorage[*TODO](db, "a:b", WithKeySeparator(":"))
+		c2 := NewKVStorage[*TODO](db, "a", WithKeySeparator(":"))
+
+		todo := &TODO{DefaultResource: NewDefaultResource(), Title: "TODO"}
+		require.NoError(t, c1.Set(context.Background(), todo))
+
+		_, err = c2.Get(context.Background(), "b:"+todo.GetID())
+		require.ErrorIs(t, err, ErrNotFound)
+
+		keys, err := db.Keys()
+		require.NoError(t, err)
+		require.Equal(t, []string{"a%3Ab:" + todo.GetID()}, keys)
+	})
+
+	t.Run("WithKeySeparator", func(t *testing.T) {
+		db, err := kv.NewFileDB(hashmap.Config{})
+		require.NoError(t, err)
+		c := NewKVStorage[*ChildTODO](db, "TODO:List", WithKeySeparator(":"), WithParentIndex())
+
+		todo := &ChildTODO{DefaultResource: NewDefaultResource(), ListID: "list1", Title: "TODO"}
+		require.NoError(t, c.Set(context.Background(), todo))
+
+		keys, err := db.Keys()
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"TODO%3AList:" + todo.GetID(), "%index:TODO%3AList:parent:list1"}, keys)
+
+		result, err := c.Get(context.Background(), todo.GetID())
+		require.NoError(t, err)
+		require.Equal(t, todo, result)
+
+		all, err := c.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.Equal(t, []*ChildTODO{todo}, all)
+	})
+
+	t.Run("InvalidSeparator", func(t *testing.T) {
+		require.PanicsWithValue(t, `NewKVStorage: key separator "%" can't contain a percent sign`, func() {
+			NewKVStorage[*TODO](kv.NewDefaultDB(), "TODO", WithKeySeparator("%"))
+		})
+	})
+}
+
+func TestKVStorageOverlappingPrefixes(t *testing.T) {
+	db, err := kv.NewFileDB(hashmap.Config{})
+	require.NoError(t, err)
+
+	invites := NewKVStorage[*TODO](db, "Invite")
+	inviteLogs := NewKVStorage[*TODO](db, "InviteLog")
+
+	invite := &TODO{DefaultResource: NewDefaultResource(), Title: "Invite"}
+	require.NoError(t, invites.Set(context.Background(), invite))
+	inviteLog := &TODO{DefaultResource: NewDefaultResource(), Title: "InviteLog"}
+	require.NoError(t, inviteLogs.Set(context.Background(), inviteLog))
+
+	result, err := invites.GetAll(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, []*TODO{invite}, result)
+
+	result, err = inviteLogs.GetAll(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, []*TODO{inviteLog}, result)
+
+	t.Run("PrefixContainsDefaultSeparator", func(t *testing.T) {
+		// GetAll for "User" would read "User_Profile_<id>" keys, so the prefix is rejected
+		require.PanicsWithValue(t, `NewKVStorage: prefix "User_Profile" can't contain an underscore unless WithKeySeparator is used`, func() {
+			NewKVStorage[*TODO](db, "User_Profile")
+		})
+	})
+
+	t.Run("PrefixContainsCustomSeparator", func(t *testing.T) {
+		users := NewKVStorage[*TODO](db, "User", WithKeySeparator(":"))
+		profiles := NewKVStorage[*TODO](db, "User:Profile", WithKeySeparator(":"))
+
+		user := &TODO{DefaultResource: NewDefaultResource(), Title: "User"}
+		require.NoError(t, users.Set(context.Background(), user))
+		profile := &TODO{DefaultResource: NewDefaultResource(), Title: "Profile"}
+		require.NoError(t, profiles.Set(context.Background(), profile))
+
+		result, err := users.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.Equal(t, []*TODO{user}, result)
+
+		result, err = profiles.GetAll(context.Background(), nil)
+		require.NoError(t, err)
+		require.Equal(t, []*TODO{profile}, result)
+	})
+}
+
+type MultiParentTODO struct {
+	DefaultResource
+
+	ListIDs []string
+	Title   string
+}
+
+func (c *MultiParentTODO) ParentIDs() []string {
+	return c.ListIDs
+}
+
+func TestKVStorageGetAllByMultipleParents(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []KVStorageOption
+	}{
+		{"Scan", nil},
+		{"Index", []KVStorageOption{WithParentIndex()}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := kv.NewFileDB(hashmap.Config{})
+			require.NoError(t, err)
+			c := NewKVStorage[*MultiParentTODO](db, "TODO", tt.opts...).(*KVStorage[*MultiParentTODO])
+
+			todo1 := &MultiParentTODO{DefaultResource: NewDefaultResource(), ListIDs: []string{"list1", "list2"}, Title: "TODO 1"}
+			todo2 := &MultiParentTODO{DefaultResource: NewDefaultResource(), ListIDs: []string{"list2"}, Title: "TODO 2"}
+			for _, todo := range []*MultiParentTODO{todo1, todo2} {
+				require.NoError(t, c.Set(context.Background(), todo))
+			}
+
+			t.Run("GetAllByEachParent", func(t *testing.T) {
+				result, err := c.GetAllByParent(context.Background(), "list1", nil)
+				require.NoError(t, err)
+				require.ElementsMatch(t, []*MultiParentTODO{todo1}, result)
+
+				result, err = c.GetAllByParent(context.Background(), "list2", nil)
+				require.NoError(t, err)
+				require.ElementsMatch(t, []*MultiParentTODO{todo1, todo2}, result)
+			})
+
+			t.Run("RemoveParent", func(t *testing.T) {
+				todo1.ListIDs = []string{"list1"}
+				require.NoError(t, c.Set(context.Background(), todo1))
+
+				result, err := c.GetAllByParent(context.Background(), "list2", nil)
+				require.NoError(t, err)
+				require.ElementsMatch(t, []*MultiParentTODO{todo2}, result)
+			})
+
+			t.Run("Delete", func(t *testing.T) {
+				require.NoError(t, c.Delete(context.Background(), todo1.GetID()))
+
+				result, err := c.GetAllByParent(context.Background(), "list1", nil)
+				require.NoError(t, err)
+				require.Empty(t, result)
+			})
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package babyapi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// lastModifiedMiddleware handles time-based conditional requests for resources that implement Timestamped. It sets
+// the Last-Modified header and checks the request's If-Modified-Since and If-Unmodified-Since headers:
+//   - GET requests respond with 304 Not Modified if the resource was not modified since If-Modified-Since
+//   - All requests respond with 412 Precondition Failed if the resource was modified since If-Unmodified-Since, so
+//     PUT, PATCH, and DELETE requests do not overwrite changes made by other clients
+//
+// Like HTTP, If-Modified-Since is ignored when the request has If-None-Match. It does nothing unless EnableETag is
+// used, and it must be used after resourceExistsMiddleware adds the resource to the context
+func (a *API[T]) lastModifiedMiddleware(next http.Handler) http.Handler {
+	if !a.etag {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resource, err := a.GetResourceFromContext(r.Context())
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		timestamped, ok := any(resource).(Timestamped)
+		if !ok || timestamped.LastModified().IsZero() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// HTTP dates do not include fractional seconds, so they are removed before comparing
+		lastModified := timestamped.LastModified().UTC().Truncate(time.Second)
+
+		if ifUnmodifiedSince, ok := parseHTTPDate(r.Header.Get("If-Unmodified-Since")); ok && lastModified.After(ifUnmodifiedSince) {
+			_ = render.Render(w, r, ErrPreconditionFailedResponse)
+			return
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+
+		ifModifiedSince, ok := parseHTTPDate(r.Header.Get("If-Modified-Since"))
+		if ok && r.Header.Get("If-None-Match") == "" && !lastModified.After(ifModifiedSince) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// parseHTTPDate parses a date from a conditional request header. It returns false if the header is empty or invalid,
+// so the header is ignored
+func parseHTTPDate(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
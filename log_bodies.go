@@ -0,0 +1,234 @@
+package babyapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// maxLoggedBodyBytes limits how much of each request and response body is logged
+const maxLoggedBodyBytes = 64 << 10
+
+// redactedValue replaces the values of redacted fields in logs
+const redactedValue = "[REDACTED]"
+
+// RedactTag is the value of the `babyapi` struct tag used to mark sensitive fields, like passwords, that are
+// replaced with "[REDACTED]" in logs. It works the same as using SetLogRedactFields with the field's JSON name:
+//
+//	type User struct {
+//		babyapi.DefaultResource
+//		Name     string `json:"name"`
+//		Password string `json:"password" babyapi:"redact"`
+//	}
+//
+// Resources can also implement slog.LogValuer to fully control how they are logged
+const RedactTag = "redact"
+
+// SetLogBodies enables logging request and response bodies at the debug level, which is useful for debugging. Bodies
+// are logged with the values of fields set by SetLogRedactFields replaced. Like AddMiddleware, this also applies to
+// the routes of nested APIs. Bodies longer than 64KiB are truncated and multipart form bodies are not logged
+func (a *API[T]) SetLogBodies(enabled bool) *API[T] {
+	a.panicIfReadOnly()
+
+	a.logBodies = enabled
+	return a
+}
+
+// SetLogRedactFields sets the names of fields, like "password", with values that are replaced with "[REDACTED]"
+// when request bodies and responses are logged. Names are matched without case to JSON keys at any level and to
+// form-encoded field names. When fields are set, resources logged by the default handlers are encoded as JSON
+// so only exported fields are logged. Bodies that are not JSON or forms are not logged since they cannot be redacted
+func (a *API[T]) SetLogRedactFields(fields ...string) *API[T] {
+	a.panicIfReadOnly()
+
+	a.logRedactFields = append(a.logRedactFields, fields...)
+	return a
+}
+
+// logBodiesMiddleware logs the request and response bodies at the debug level
+func (a *API[T]) logBodiesMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := GetLoggerFromContext(r.Context())
+
+		if isMultipartForm(r) {
+			logger.Debug("request body", "body", "multipart form body not logged")
+		} else if r.Body != nil && r.Body != http.NoBody {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				_ = render.Render(w, r, ErrInvalidRequest(fmt.Errorf("error reading request body: %w", err)))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			logger.Debug("request body", "body", a.redactBody(r.Header.Get("Content-Type"), truncateBody(body)))
+		}
+
+		var response limitedBuffer
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(&response)
+
+		next.ServeHTTP(ww, r)
+
+		if response.Len() > 0 {
+			logger.Debug("response body", "body", a.redactBody(ww.Header().Get("Content-Type"), response.Bytes()))
+		}
+	})
+}
+
+// loggableResource returns the value used to log a resource. Resources implementing slog.LogValuer are logged as-is
+// so they can control their own output. Otherwise, it is encoded as redacted JSON when there are redacted fields
+func (a *API[T]) loggableResource(resource T) any {
+	if _, ok := any(resource).(slog.LogValuer); ok {
+		return resource
+	}
+
+	if len(a.redactFields()) == 0 {
+		return resource
+	}
+
+	data, err := marshalJSON(resource)
+	if err != nil {
+		return "body not logged because it could not be encoded"
+	}
+
+	return a.redactBody("application/json", data)
+}
+
+// redactBody replaces the values of redacted fields in a JSON or form-encoded body
+func (a *API[T]) redactBody(contentType string, body []byte) string {
+	fields := a.redactFields()
+	if len(fields) == 0 {
+		return string(body)
+	}
+
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(mediaType)) {
+	case "application/json", "":
+		var data any
+		err := unmarshalJSON(body, &data)
+		if err != nil {
+			return "body not logged because it could not be redacted"
+		}
+
+		redacted, err := marshalJSON(redactJSON(data, fields))
+		if err != nil {
+			return "body not logged because it could not be redacted"
+		}
+		return string(redacted)
+	case "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "body not logged because it could not be redacted"
+		}
+
+		for key, vals := range values {
+			if isRedactedField(key, fields) {
+				for i := range vals {
+					vals[i] = redactedValue
+				}
+			}
+		}
+		return values.Encode()
+	default:
+		return fmt.Sprintf("body with content type %q not logged because it cannot be redacted", mediaType)
+	}
+}
+
+// redactFields returns the fields set by SetLogRedactFields and the JSON names of fields tagged with RedactTag
+func (a *API[T]) redactFields() []string {
+	return append(slices.Clone(a.logRedactFields), taggedRedactFields(reflect.TypeOf(*new(T)))...)
+}
+
+// taggedRedactFields gets the JSON names of fields with RedactTag, including fields from embedded structs
+func taggedRedactFields(rt reflect.Type) []string {
+	for rt != nil && rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := []string{}
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.Anonymous {
+			fields = append(fields, taggedRedactFields(field.Type)...)
+			continue
+		}
+
+		if !field.IsExported() || !hasBabyapiTag(field, RedactTag) {
+			continue
+		}
+
+		name := formFieldName(field)
+		if name != "" {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
+}
+
+// redactJSON replaces the values of redacted fields in decoded JSON
+func redactJSON(data any, fields []string) any {
+	switch v := data.(type) {
+	case map[string]any:
+		for key, val := range v {
+			if isRedactedField(key, fields) {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redactJSON(val, fields)
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = redactJSON(val, fields)
+		}
+	}
+
+	return data
+}
+
+func isRedactedField(name string, fields []string) bool {
+	for _, field := range fields {
+		if strings.EqualFold(name, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateBody shortens a body to the maximum length for logs
+func truncateBody(body []byte) []byte {
+	if len(body) > maxLoggedBodyBytes {
+		return body[:maxLoggedBodyBytes]
+	}
+	return body
+}
+
+// limitedBuffer keeps the first maxLoggedBodyBytes written to it and discards the rest, so long responses and
+// server-sent events do not use unlimited memory
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	remaining := maxLoggedBodyBytes - b.Len()
+	if remaining > 0 {
+		if len(p) > remaining {
+			b.Buffer.Write(p[:remaining])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
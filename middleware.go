@@ -1,8 +1,11 @@
 package babyapi
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -11,12 +14,77 @@ import (
 )
 
 func (a *API[T]) DefaultMiddleware(r chi.Router) {
-	r.Use(middleware.RequestID)
+	r.Use(a.requestIDMiddleware)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Recoverer)
 	r.Use(a.logMiddleware)
 }
 
+// maxRequestIDLength limits the length of request IDs provided by clients
+const maxRequestIDLength = 128
+
+// requestIDMiddleware adds a request ID to the context so it can be used by logMiddleware and middleware.GetReqID.
+// It uses the ID from the request header if it is valid. Otherwise, a new ID is generated
+func (a *API[T]) requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(a.requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = NewID().String()
+		}
+
+		w.Header().Set(a.requestIDHeader, requestID)
+
+		ctx := context.WithValue(r.Context(), middleware.RequestIDKey, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID checks that a client-provided request ID is not too long and only uses characters that are safe to
+// log and return in headers
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range requestID {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("-_.:/", c):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// SetMaxURILength responds with 414 URI Too Long to requests with a path and query longer than the length in bytes.
+// This protects the API from very long requests, like complex query filters, that are expensive to parse. It also
+// applies to nested APIs, which can set a lower length for their own routes
+func (a *API[T]) SetMaxURILength(length int) *API[T] {
+	a.panicIfReadOnly()
+
+	if length <= 0 {
+		a.errors = append(a.errors, fmt.Errorf("SetMaxURILength: length must be positive"))
+		return a
+	}
+
+	a.maxURILength = length
+	return a
+}
+
+// maxURILengthMiddleware rejects requests with an escaped path and query longer than the API's maximum length
+func (a *API[T]) maxURILengthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.URL.RequestURI()) > a.maxURILength {
+			_ = render.Render(w, r, ErrURITooLongResponse)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (a *API[T]) logMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		logger := slog.Default()
@@ -39,6 +107,7 @@ func (a *API[T]) logMiddleware(next http.Handler) http.Handler {
 				"status", ww.Status(),
 				"bytes_written", ww.BytesWritten(),
 				"time_elapsed", time.Since(t1),
+				"route", GetRoutePattern(r),
 			).Info("response completed")
 		}()
 
@@ -46,16 +115,24 @@ func (a *API[T]) logMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// requestBodyMiddleware binds the request body and stores it in the context so handlers use the same resource
+// instead of binding the body again
 func (a *API[T]) requestBodyMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(a.fileFields) > 0 {
+			r = r.WithContext(newContextWithFileUploads(r.Context()))
+			defer deleteUnstoredFileUploads(r)
+		}
+
 		body, httpErr := a.GetFromRequest(r)
 		if httpErr != nil {
 			_ = render.Render(w, r, httpErr)
 			return
 		}
+		defer a.releaseInstance(body)
 
 		logger := GetLoggerFromContext(r.Context())
-		logger.Info("received request body", "body", body)
+		logger.Debug("received request body", "body", a.loggableResource(body))
 
 		next.ServeHTTP(w, r.WithContext(a.NewContextWithRequestBody(r.Context(), body)))
 	})
@@ -63,14 +140,28 @@ func (a *API[T]) requestBodyMiddleware(next http.Handler) http.Handler {
 
 func (a *API[T]) resourceExistsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.idValidator != nil {
+			err := a.idValidator(a.GetIDParam(r))
+			if err != nil {
+				_ = render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid ID: %w", err)))
+				return
+			}
+		}
+
+		if a.skipLookupForNestedAPIs && a.isNestedAPIRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		resource, httpErr := a.GetRequestedResource(r)
 		if httpErr != nil {
-			// Skip for PUT because it can be used to create new resources
-			if r.Method == http.MethodPut {
+			// Skip for PUT because it can be used to create new resources. Parent resources must still exist when
+			// the PUT request is for a nested resource, which is identified by the remaining path after the ID
+			if r.Method == http.MethodPut && chi.URLParam(r, "*") == "" {
 				next.ServeHTTP(w, r)
 				return
 			}
-			_ = render.Render(w, r, httpErr)
+			renderErrResponse(w, r, httpErr)
 			return
 		}
 
@@ -80,6 +171,9 @@ func (a *API[T]) resourceExistsMiddleware(next http.Handler) http.Handler {
 
 		ctx := a.newContextWithResource(r.Context(), resource)
 		ctx = NewContextWithLogger(ctx, logger)
+		if chi.URLParam(r, "*") != "" {
+			ctx = newContextWithParent(ctx, resource)
+		}
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -0,0 +1,130 @@
+package babyapi
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SetNameFormatter sets a function that changes how the API's name is displayed, like using Singular to name CLI
+// commands and parent ID flags "song" instead of "Songs". It does not change the name used for URL params, context
+// keys, or storage. Nested APIs use their parent's formatter unless they set their own
+func (a *API[T]) SetNameFormatter(formatter func(name string) string) *API[T] {
+	a.panicIfReadOnly()
+
+	a.nameFormatter = formatter
+	return a
+}
+
+// DisplayName returns the API's name after applying the formatter from SetNameFormatter
+func (a *API[T]) DisplayName() string {
+	formatter := a.getNameFormatter()
+	if formatter == nil {
+		return a.name
+	}
+	return formatter(a.name)
+}
+
+func (a *API[T]) getNameFormatter() func(string) string {
+	if a.nameFormatter == nil && a.parent != nil {
+		return a.parent.getNameFormatter()
+	}
+	return a.nameFormatter
+}
+
+// irregularSingulars are plurals that do not follow the suffix rules used by Singular. Each singular is a prefix of
+// its plural, so the case of the name is kept
+var irregularSingulars = map[string]string{
+	// -ie words, which would otherwise become -y
+	"movies": "movie", "cookies": "cookie", "zombies": "zombie", "calories": "calorie", "pies": "pie",
+	"ties": "tie", "lies": "lie", "rookies": "rookie", "selfies": "selfie", "brownies": "brownie",
+	"goalies": "goalie", "genies": "genie", "smoothies": "smoothie", "sorties": "sortie", "prairies": "prairie",
+
+	// -che words, which would otherwise become -ch
+	"caches": "cache", "niches": "niche", "avalanches": "avalanche", "aches": "ache", "headaches": "headache",
+	"moustaches": "moustache", "mustaches": "mustache", "quiches": "quiche", "psyches": "psyche",
+
+	// -use words, which would otherwise become -us
+	"uses": "use", "abuses": "abuse", "misuses": "misuse", "excuses": "excuse", "fuses": "fuse", "muses": "muse",
+	"refuses": "refuse", "accuses": "accuse", "ruses": "ruse",
+
+	// -s words, which would otherwise become -se
+	"aliases": "alias", "biases": "bias", "canvases": "canvas", "gases": "gas", "atlases": "atlas",
+	"quizzes": "quiz",
+
+	// words that are the same as the plural
+	"series": "series", "species": "species", "news": "news",
+}
+
+// Singular returns the singular form of an English name, like "Song" for "Songs", "Category" for "Categories", or
+// "Status" for "Statuses". Regular plurals and common exceptions, like "Movies" and "Caches", are handled using the
+// last word of names like "UserMovies", so names that are already singular, like "Music", are not changed
+func Singular(name string) string {
+	start := lastWordIndex(name)
+	lower := strings.ToLower(name)
+
+	if singular, ok := irregularSingulars[lower[start:]]; ok {
+		return name[:start+len(singular)]
+	}
+
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(name) > 3:
+		return name[:len(name)-3] + matchCase("y", name[len(name)-3:])
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "shes"), strings.HasSuffix(lower, "ches"),
+		strings.HasSuffix(lower, "xes"), strings.HasSuffix(lower, "zzes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(lower, "uses") && len(name) > 4 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-5])):
+		// Like "Statuses", but not "Houses" or "Causes"
+		return name[:len(name)-2]
+	case strings.HasSuffix(lower, "ss"), strings.HasSuffix(lower, "us"), strings.HasSuffix(lower, "is"):
+		return name
+	case strings.HasSuffix(lower, "s") && len(name) > 1:
+		return name[:len(name)-1]
+	}
+	return name
+}
+
+// lastWordIndex returns the index where the last word of a camel case name or a name with separators starts
+func lastWordIndex(name string) int {
+	for i := len(name) - 1; i > 0; i-- {
+		switch c, prev := name[i], name[i-1]; {
+		case prev == '_' || prev == '-' || prev == ' ':
+			return i
+		case isUpperASCII(c) && !isUpperASCII(prev):
+			return i
+		case isUpperASCII(c) && i+1 < len(name) && 'a' <= name[i+1] && name[i+1] <= 'z':
+			// The last uppercase letter in a run starts the next word, like "Series" in "TVSeries"
+			return i
+		}
+	}
+	return 0
+}
+
+func isUpperASCII(c byte) bool {
+	return 'A' <= c && c <= 'Z'
+}
+
+// Plural returns the plural form of an English name, like "Songs" for "Song" or "Categories" for "Category". The
+// suffix is lowercase unless the name ends with an uppercase "Y", so acronyms keep their case, like "TODOs"
+func Plural(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case name == "":
+		return name
+	case strings.HasSuffix(lower, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return name[:len(name)-1] + matchCase("ies", name[len(name)-1:])
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return name + "es"
+	}
+	return name + "s"
+}
+
+// matchCase makes the suffix uppercase if the text it replaces is uppercase
+func matchCase(suffix, replaced string) string {
+	for _, r := range replaced {
+		if !unicode.IsUpper(r) {
+			return suffix
+		}
+	}
+	return strings.ToUpper(suffix)
+}
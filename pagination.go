@@ -0,0 +1,88 @@
+package babyapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	limitParam  = "limit"
+	offsetParam = "offset"
+)
+
+var errInvalidLimit = errors.New("limit must be a positive integer")
+var errInvalidOffset = errors.New("offset must be a non-negative integer")
+
+// pagination configures limit/offset pagination for GetAll
+type pagination struct {
+	defaultLimit int
+	maxLimit     int
+}
+
+// EnablePagination enables limit/offset pagination for the default GetAll handler using the 'limit' and 'offset'
+// query parameters. If 'limit' is not provided, defaultLimit is used. A positive maxLimit is the largest allowed
+// 'limit' and larger values are reduced to it. Pagination is applied after filtering and resources are sorted by ID,
+// which is ordered by creation time when using the default ID type. The response includes the total number of
+// filtered resources along with the limit and offset. If SetGetAllResponseWrapper is used, the wrapper receives the
+// page of resources and is responsible for any metadata
+func (a *API[T]) EnablePagination(defaultLimit, maxLimit int) *API[T] {
+	a.panicIfReadOnly()
+
+	if defaultLimit <= 0 {
+		a.errors = append(a.errors, fmt.Errorf("EnablePagination: defaultLimit must be positive: %d", defaultLimit))
+		return a
+	}
+
+	if maxLimit > 0 && maxLimit < defaultLimit {
+		a.errors = append(a.errors, fmt.Errorf("EnablePagination: maxLimit %d is less than defaultLimit %d", maxLimit, defaultLimit))
+		return a
+	}
+
+	a.pagination = &pagination{defaultLimit, maxLimit}
+	return a
+}
+
+// parse reads the limit and offset query parameters from the request
+func (p *pagination) parse(r *http.Request) (int, int, error) {
+	query := r.URL.Query()
+
+	limit := p.defaultLimit
+	if rawLimit := query.Get(limitParam); rawLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil || limit <= 0 {
+			return 0, 0, errInvalidLimit
+		}
+	}
+
+	if p.maxLimit > 0 && limit > p.maxLimit {
+		limit = p.maxLimit
+	}
+
+	offset := 0
+	if rawOffset := query.Get(offsetParam); rawOffset != "" {
+		var err error
+		offset, err = strconv.Atoi(rawOffset)
+		if err != nil || offset < 0 {
+			return 0, 0, errInvalidOffset
+		}
+	}
+
+	return limit, offset, nil
+}
+
+// paginate returns the items in the page described by limit and offset
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+
+	end := len(items)
+	if limit < end-offset {
+		end = offset + limit
+	}
+
+	return items[offset:end]
+}
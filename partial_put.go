@@ -0,0 +1,113 @@
+package babyapi
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// EnablePartialPut makes PUT requests with an If-Match header update only some fields of an existing resource, if it
+// did not change since the client read it. The If-Match header must match the ETag from a GET request for the
+// resource with the same Accept header, or the API responds with 412 Precondition Failed. This includes requests for
+// resources that do not exist. Then, each field in the request body that is not the zero value replaces the field in
+// the stored resource, except fields with the preserve tag. This means a partial PUT cannot clear fields.
+//
+// The request body is still bound like a normal PUT, so it must have the ID and its Bind method should allow missing
+// fields. PUT requests without If-Match replace the whole resource. This also uses EnableETag so clients can get the
+// ETag of the resource
+func (a *API[T]) EnablePartialPut() *API[T] {
+	a.panicIfReadOnly()
+
+	a.etag = true
+	a.partialPut = true
+	return a
+}
+
+// mergePartialPut checks the request's If-Match header and returns the stored resource with the non-zero fields from
+// the update
+func (a *API[T]) mergePartialPut(r *http.Request, update T, exists bool) (T, *ErrResponse) {
+	if !exists {
+		return *new(T), ErrPreconditionFailedResponse
+	}
+
+	etag, ok := a.currentETag(r)
+	if !ok || !etagMatches(r.Header.Get("If-Match"), etag) {
+		return *new(T), ErrPreconditionFailedResponse
+	}
+
+	// The resource is read again so the previous resource from the context is not modified
+	resource, httpErr := a.GetRequestedResource(r)
+	if httpErr != nil {
+		if httpErr == a.notFoundResponse {
+			return *new(T), ErrPreconditionFailedResponse
+		}
+		return *new(T), httpErr
+	}
+
+	rv := reflect.ValueOf(resource)
+	uv := reflect.ValueOf(update)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() && uv.Kind() == reflect.Pointer && !uv.IsNil() && rv.Elem().Kind() == reflect.Struct {
+		mergeNonZeroFields(rv.Elem(), uv.Elem())
+	}
+
+	return resource, nil
+}
+
+// mergeNonZeroFields copies exported fields from the update that are not the zero value. Embedded structs are merged
+// recursively and fields with the preserve tag are not copied
+func mergeNonZeroFields(rv, update reflect.Value) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fieldValue := rv.Field(i)
+		updateValue := update.Field(i)
+
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		if hasBabyapiTag(field, PreserveTag) {
+			continue
+		}
+
+		if field.Anonymous {
+			if field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct {
+				if fieldValue.IsNil() || updateValue.IsNil() {
+					continue
+				}
+				mergeNonZeroFields(fieldValue.Elem(), updateValue.Elem())
+				continue
+			}
+
+			if field.Type.Kind() == reflect.Struct {
+				mergeNonZeroFields(fieldValue, updateValue)
+				continue
+			}
+		}
+
+		if !updateValue.IsZero() && fieldValue.CanSet() {
+			fieldValue.Set(updateValue)
+		}
+	}
+}
+
+// currentETag gets the ETag of the resource from the request URL by using the Get handler with a copy of the request.
+// It returns false if the API does not have a Get handler or the response is not successful
+func (a *API[T]) currentETag(r *http.Request) (string, bool) {
+	if a.Get == nil {
+		return "", false
+	}
+
+	getReq := r.Clone(r.Context())
+	getReq.Method = http.MethodGet
+	getReq.Body = http.NoBody
+	getReq.ContentLength = 0
+	getReq.Header.Del("Content-Type")
+	getReq.Header.Del("If-Match")
+
+	w := &bulkItemResponseWriter{header: http.Header{}}
+	a.Get.ServeHTTP(w, getReq)
+	if w.status != 0 && (w.status < 200 || w.status >= 300) {
+		return "", false
+	}
+
+	return weakETag(w.header.Get("Content-Type"), w.body.Bytes()), true
+}
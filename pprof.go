@@ -0,0 +1,40 @@
+package babyapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// EnablePprof adds the net/http/pprof handlers to the root of the API at the path, like /debug/pprof, for profiling
+// memory, goroutines, and CPU usage. Profiles expose details about the running program, so middlewares should be
+// used to add authentication. The middlewares only apply to the pprof routes. It can only be used on an API without
+// a parent
+func (a *API[T]) EnablePprof(path string, middlewares ...func(http.Handler) http.Handler) *API[T] {
+	a.panicIfReadOnly()
+
+	path = strings.TrimSuffix(path, "/")
+	if path == "" || !strings.HasPrefix(path, "/") {
+		a.errors = append(a.errors, fmt.Errorf("EnablePprof: path must start with / and not be the root path"))
+		return a
+	}
+
+	protect := func(h http.HandlerFunc) http.Handler {
+		return chi.Chain(middlewares...).Handler(h)
+	}
+
+	a.AddCustomRootRoute(http.MethodGet, path+"/", protect(pprof.Index))
+	a.AddCustomRootRoute(http.MethodGet, path+"/cmdline", protect(pprof.Cmdline))
+	a.AddCustomRootRoute(http.MethodGet, path+"/profile", protect(pprof.Profile))
+	a.AddCustomRootRoute(http.MethodGet, path+"/symbol", protect(pprof.Symbol))
+	a.AddCustomRootRoute(http.MethodPost, path+"/symbol", protect(pprof.Symbol))
+	a.AddCustomRootRoute(http.MethodGet, path+"/trace", protect(pprof.Trace))
+
+	// pprof.Index only serves named profiles under /debug/pprof/, so they are routed separately to support any path
+	return a.AddCustomRootRoute(http.MethodGet, path+"/{profile}", protect(func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(chi.URLParam(r, "profile")).ServeHTTP(w, r)
+	}))
+}
@@ -0,0 +1,76 @@
+package babyapi
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// PreserveTag is the value of the `babyapi` struct tag used to mark server-managed fields, like `CreatedAt`. When a
+// PUT request replaces an existing resource, these fields are copied from the stored resource instead of using the
+// values from the request, so clients do not need to send them and cannot change them. Hooks like
+// SetOnCreateOrUpdate run after the fields are copied, so they can still modify them:
+//
+//	type Album struct {
+//		babyapi.DefaultResource
+//		Title     string    `json:"title"`
+//		CreatedAt time.Time `json:"created_at" babyapi:"preserve"`
+//	}
+const PreserveTag = "preserve"
+
+// preserveFields copies fields with the preserve tag from the previous resource to the new resource. Embedded structs
+// are checked recursively. Nothing is copied if either resource is not a non-nil pointer to a struct
+func preserveFields[T Resource](resource, previous T) {
+	rv := reflect.ValueOf(resource)
+	prev := reflect.ValueOf(previous)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || prev.Kind() != reflect.Pointer || prev.IsNil() {
+		return
+	}
+	if rv.Elem().Kind() != reflect.Struct {
+		return
+	}
+
+	copyPreservedFields(rv.Elem(), prev.Elem())
+}
+
+func copyPreservedFields(rv, prev reflect.Value) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fieldValue := rv.Field(i)
+		prevValue := prev.Field(i)
+
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		if hasBabyapiTag(field, PreserveTag) {
+			if fieldValue.CanSet() {
+				fieldValue.Set(prevValue)
+			}
+			continue
+		}
+
+		if !field.Anonymous {
+			continue
+		}
+
+		if field.Type.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() || prevValue.IsNil() || field.Type.Elem().Kind() != reflect.Struct {
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+			prevValue = prevValue.Elem()
+		}
+
+		if fieldValue.Kind() == reflect.Struct {
+			copyPreservedFields(fieldValue, prevValue)
+		}
+	}
+}
+
+// hasBabyapiTag checks if the field's `babyapi` struct tag has the value, like PreserveTag or RedactTag. Multiple
+// values are separated by commas
+func hasBabyapiTag(field reflect.StructField, value string) bool {
+	return slices.Contains(strings.Split(field.Tag.Get("babyapi"), ","), value)
+}
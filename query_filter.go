@@ -0,0 +1,177 @@
+package babyapi
+
+import (
+	"encoding"
+	"net/http"
+	"net/url"
+	"reflect"
+)
+
+// EnableQueryFilter adds a GetAll filter that uses QueryFilter so resources can be filtered by any field using query
+// parameters, like /todos?completed=true, without writing a custom filter
+func (a *API[T]) EnableQueryFilter() *API[T] {
+	a.panicIfReadOnly()
+
+	return a.AddGetAllFilter("query", func(r *http.Request) FilterFunc[T] {
+		return QueryFilter[T](r.URL.Query())
+	})
+}
+
+// QueryFilter creates a filter that only includes resources with fields matching the provided query parameters.
+// Query parameters are matched case-insensitively against the JSON tag name, or field name if there is no tag, so
+// parameters that do not match a field are ignored. Embedded structs are treated as part of the parent struct.
+// If a parameter has multiple values, the field can match any of them. Empty values are ignored.
+//
+// Supported field types are strings, bools, integers, unsigned integers, floats, types implementing
+// encoding.TextUnmarshaler like ID and time.Time, and pointers to these types. Nil pointers never match. Other
+// field types, like slices, maps, and structs, are ignored
+func QueryFilter[T any](query url.Values) FilterFunc[T] {
+	// Empty values are ignored so they do not match zero values
+	nonEmptyQuery := url.Values{}
+	for key, values := range query {
+		for _, value := range values {
+			if value != "" {
+				nonEmptyQuery.Add(key, value)
+			}
+		}
+	}
+	query = nonEmptyQuery
+
+	if len(query) == 0 {
+		return nil
+	}
+
+	return func(item T) bool {
+		rv := reflect.ValueOf(item)
+		for rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return false
+			}
+			rv = rv.Elem()
+		}
+
+		if rv.Kind() != reflect.Struct {
+			return true
+		}
+
+		return matchQueryFields(rv, query)
+	}
+}
+
+// matchQueryFields returns false if any field has a matching query parameter with values that do not match it
+func matchQueryFields(rv reflect.Value, query url.Values) bool {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fieldValue := rv.Field(i)
+
+		if field.Anonymous && !isTextUnmarshaler(addressable(fieldValue)) {
+			if field.Type.Kind() == reflect.Pointer {
+				if fieldValue.IsNil() || field.Type.Elem().Kind() != reflect.Struct {
+					continue
+				}
+				fieldValue = fieldValue.Elem()
+			}
+
+			if fieldValue.Kind() == reflect.Struct && !matchQueryFields(fieldValue, query) {
+				return false
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		name := formFieldName(field)
+		if name == "" {
+			continue
+		}
+
+		values, ok := getFormValues(query, name)
+		if !ok || !queryFilterSupported(field.Type) {
+			continue
+		}
+
+		if !matchAnyQueryValue(fieldValue, values) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func queryFilterSupported(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return queryFilterSupported(t.Elem())
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+// matchAnyQueryValue parses each query value into the field's type and compares it with the field's value
+func matchAnyQueryValue(fieldValue reflect.Value, values []string) bool {
+	for fieldValue.Kind() == reflect.Pointer && !isTextUnmarshaler(addressable(fieldValue)) {
+		if fieldValue.IsNil() {
+			return false
+		}
+		fieldValue = fieldValue.Elem()
+	}
+
+	for _, value := range values {
+		expected := reflect.New(fieldValue.Type()).Elem()
+		err := setFormValue(expected, []string{value})
+		if err != nil {
+			continue
+		}
+
+		if queryValuesEqual(fieldValue, expected) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// queryValuesEqual compares values using encoding.TextMarshaler if it is implemented so types like time.Time are
+// compared by their text representation instead of internal fields
+func queryValuesEqual(actual, expected reflect.Value) bool {
+	actualMarshaler, ok := actual.Interface().(encoding.TextMarshaler)
+	if !ok {
+		return reflect.DeepEqual(actual.Interface(), expected.Interface())
+	}
+
+	actualText, err := actualMarshaler.MarshalText()
+	if err != nil {
+		return false
+	}
+
+	expectedText, err := expected.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return false
+	}
+
+	return string(actualText) == string(expectedText)
+}
+
+// addressable returns an addressable copy of the value so it can be used with isTextUnmarshaler. Values from
+// unexported fields can't be copied, so they are returned as-is and isTextUnmarshaler returns false for them
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() || !v.CanInterface() {
+		return v
+	}
+
+	result := reflect.New(v.Type()).Elem()
+	result.Set(v)
+	return result
+}
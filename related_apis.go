@@ -1,10 +1,13 @@
 package babyapi
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
 )
 
 // RelatedAPI declares a subset of methods from the API struct that are required to enable
@@ -22,9 +25,22 @@ type RelatedAPI interface {
 type relatedAPI interface {
 	RelatedAPI
 
+	DisplayName() string
+	getNameFormatter() func(string) string
 	setParent(relatedAPI)
+	closeQuit()
+	closeStorage()
+	defaultRouteNames(prefix string, names map[string]string)
+	nestingDepth() int
+	hasDescendant(RelatedAPI) bool
 	getCustomResponseCodeMap() map[string]int
 	isRoot() bool
+	globalSearch(*http.Request) ([]render.Renderer, bool, error)
+	seed(context.Context, json.RawMessage, bool) (int, error)
+	collectAPIs(map[string]relatedAPI)
+	expandResources(*http.Request, string, []expandPath) ([]any, *ErrResponse)
+	hasCORS() bool
+	corsOptionsForPath(string) *CORSOptions
 }
 
 // Parent returns the API's parent API
@@ -37,7 +53,14 @@ func (a *API[T]) GetParentIDParam(r *http.Request) string {
 	return a.parent.GetIDParam(r)
 }
 
-// AddNestedAPI adds a child API to this API and initializes the parent relationship on the child's side
+// AddNestedAPI adds a child API to this API and initializes the parent relationship on the child's side. Every
+// ancestor in the path of a nested request is read from storage before the child's handler is used, starting from
+// the root, so the API responds with 404 Not Found for the first ancestor that does not exist. This applies to all
+// methods, including PUT requests that create the child resource, and to custom routes on the child API. Use
+// SetLookupForNestedAPIs to skip the lookup for an ancestor.
+//
+// Adding an API that already has this API nested under it, or adding an API to itself, creates a cycle and results in
+// an error. The depth of nested APIs is limited by SetMaxNestingDepth
 func (a *API[T]) AddNestedAPI(childAPI RelatedAPI) *API[T] {
 	a.panicIfReadOnly()
 
@@ -47,12 +70,75 @@ func (a *API[T]) AddNestedAPI(childAPI RelatedAPI) *API[T] {
 		return a
 	}
 
+	// The child's descendants are checked instead of this API's ancestors since adding a child to another API changes
+	// its parent, but it is still nested in the previous parent
+	if childAPI == RelatedAPI(a) || relAPI.hasDescendant(a) {
+		a.errors = append(a.errors, fmt.Errorf("AddNestedAPI: adding %q to %q creates a cycle", childAPI.Name(), a.name))
+		return a
+	}
+
 	a.subAPIs[childAPI.Name()] = relAPI
 	relAPI.setParent(a)
 
 	return a
 }
 
+// SetLookupForNestedAPIs enables or disables reading this API's resource from storage before handling requests to
+// its nested APIs. It is enabled by default so nested requests respond with 404 Not Found if the resource does not
+// exist. Disabling it avoids a storage read for each nested request when the nested APIs do not need to validate the
+// parent, but the resource is not available from GetResourceFromContext or GetParentChain. Default routes and custom
+// ID routes for this API, and middlewares from AddIDMiddleware, are not affected
+func (a *API[T]) SetLookupForNestedAPIs(enabled bool) *API[T] {
+	a.panicIfReadOnly()
+
+	a.skipLookupForNestedAPIs = !enabled
+	return a
+}
+
+// isNestedAPIRequest checks if the remaining path after the ID is for one of the nested APIs
+func (a *API[T]) isNestedAPIRequest(r *http.Request) bool {
+	firstSegment := firstPatternSegment(chi.URLParam(r, "*"))
+	if firstSegment == "" {
+		return false
+	}
+
+	for _, subAPI := range a.subAPIs {
+		if firstSegment == firstPatternSegment(subAPI.Base()) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetMaxNestingDepth sets the maximum number of levels of nested APIs, including this API, that are allowed when
+// routing a top-level API. Deeper trees result in an error from Route. The default is DefaultMaxNestingDepth and
+// zero or less disables the limit
+func (a *API[T]) SetMaxNestingDepth(depth int) *API[T] {
+	a.panicIfReadOnly()
+
+	a.maxNestingDepth = depth
+	return a
+}
+
+// nestingDepth returns the number of levels in the tree of APIs starting from this API
+func (a *API[T]) nestingDepth() int {
+	depth := 0
+	for _, child := range a.subAPIs {
+		depth = max(depth, child.nestingDepth())
+	}
+	return depth + 1
+}
+
+// hasDescendant checks if the target is nested anywhere under this API
+func (a *API[T]) hasDescendant(target RelatedAPI) bool {
+	for _, child := range a.subAPIs {
+		if RelatedAPI(child) == target || child.hasDescendant(target) {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *API[T]) setParent(parent relatedAPI) {
 	a.parent = parent
 }
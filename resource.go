@@ -1,9 +1,14 @@
 package babyapi
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"html/template"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/go-chi/render"
 	"github.com/rs/xid"
@@ -46,6 +51,12 @@ func (*DefaultRenderer) Render(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// IDResponse is used to respond with only a resource's ID, like when using CreateResponseIDOnly
+type IDResponse struct {
+	*DefaultRenderer
+	ID string `json:"id"`
+}
+
 // NilResource is an empty resource type which should be used when creating APIs without any real resource
 type NilResource struct{ *DefaultRenderer }
 
@@ -91,7 +102,8 @@ func (dr *DefaultResource) Bind(r *http.Request) error {
 // ID is a type that can be optionally used to improve Resources and their APIs. It uses xid to create unique
 // identifiers and implements a custom Bind method to:
 //   - Disallow POST requests with IDs
-//   - Automatically set new ID on POSTed resources
+//   - Automatically set new ID on POSTed resources. When the body is bound by the API, the ID is only generated once
+//     even if Bind is called again for the same resource
 //   - Enforce that ID is set
 //   - Do not allow changing ID with PATCH
 type ID struct {
@@ -105,11 +117,20 @@ func NewID() ID {
 func (id *ID) Bind(r *http.Request) error {
 	switch r.Method {
 	case http.MethodPost:
+		// Binding the same resource again in one request keeps the generated ID
+		generated, _ := r.Context().Value(generatedIDsCtxKey).(*generatedIDs)
+		if generated != nil && slices.Contains(generated.ids, id.ID) {
+			return nil
+		}
+
 		if !id.ID.IsNil() {
 			return errors.New("unable to manually set ID")
 		}
 
 		id.ID = xid.New()
+		if generated != nil {
+			generated.ids = append(generated.ids, id.ID)
+		}
 		fallthrough
 	case http.MethodPut:
 		if id.ID.IsNil() {
@@ -127,6 +148,11 @@ func (id *ID) Bind(r *http.Request) error {
 // ResourceList is used to automatically enable the GetAll endpoint that returns an array of Resources
 type ResourceList[T render.Renderer] struct {
 	Items []T `json:"items"`
+
+	// Total, Limit, and Offset are only set when pagination is enabled. Total is the number of resources in all pages
+	Total  int `json:"total,omitempty"`
+	Limit  int `json:"limit,omitempty"`
+	Offset int `json:"offset,omitempty"`
 }
 
 func (rl *ResourceList[T]) Render(w http.ResponseWriter, r *http.Request) error {
@@ -139,12 +165,83 @@ func (rl *ResourceList[T]) Render(w http.ResponseWriter, r *http.Request) error
 	return nil
 }
 
-// AnyResource is intended to create a "generic" Client
+// htmlResourceList is used by SetGetAllHTMLTemplate to render each item's HTML inside of a wrapper template
+type htmlResourceList struct {
+	*ResourceList[render.Renderer]
+	tmpl *template.Template
+}
+
+// HTML renders the items with the template. The GetAll handler responds with an error instead of using this if any
+// items do not implement HTMLer
+func (hrl *htmlResourceList) HTML(r *http.Request) string {
+	items := make([]template.HTML, 0, len(hrl.Items))
+	for _, item := range hrl.Items {
+		items = append(items, template.HTML(item.(HTMLer).HTML(r)))
+	}
+
+	return MustRenderHTML(hrl.tmpl, items)
+}
+
+// Resettable is implemented by resources that can be cleared for reuse by EnableInstancePool. Reset must set every
+// field to the same value as a new instance from the API's instance function, including defaults set by the function
+// or SetInstance. Reused instances are not created by the instance function, so defaults are only kept if Reset
+// restores them:
+//
+//	func (t *TODO) Reset() {
+//		*t = TODO{Priority: DefaultPriority}
+//	}
+type Resettable interface {
+	Reset()
+}
+
+// Timestamped is implemented by resources that know when they were last modified, like from an UpdatedAt field. When
+// EnableETag is used, it sets the Last-Modified header and allows conditional requests with If-Modified-Since and
+// If-Unmodified-Since
+type Timestamped interface {
+	LastModified() time.Time
+}
+
+// ChildResource is implemented by resources in nested APIs that store the ID of their parent resource. It allows
+// storage implementations, like KVStorage, to get the resources that belong to a parent
+type ChildResource interface {
+	ParentID() string
+}
+
+// MultiParentResource is implemented by resources that belong to more than one parent, like a Song that is on
+// multiple Playlists. It is used instead of ChildResource to get the resources that belong to a parent, so ParentIDs
+// should include every parent. The same storage can be used by multiple APIs nested under different parents
+type MultiParentResource interface {
+	ParentIDs() []string
+}
+
+// AnyResource is intended to create a "generic" Client. Numbers are decoded as json.Number instead of float64 so
+// large integers, like numeric IDs, are not corrupted when they are decoded and encoded again
 type AnyResource map[string]any
 
 func (ar AnyResource) GetID() string {
-	id, _ := ar["id"].(string)
-	return id
+	switch id := ar["id"].(type) {
+	case string:
+		return id
+	case json.Number:
+		return id.String()
+	default:
+		return ""
+	}
+}
+
+// UnmarshalJSON decodes the JSON object using json.Decoder.UseNumber so numeric fields round-trip precisely
+func (ar *AnyResource) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var result map[string]any
+	err := decoder.Decode(&result)
+	if err != nil {
+		return err
+	}
+
+	*ar = result
+	return nil
 }
 
 func (*AnyResource) Render(w http.ResponseWriter, r *http.Request) error {
@@ -0,0 +1,39 @@
+package babyapi
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// SetResponseHeaders sets static headers, like "X-Content-Type-Options: nosniff" or "Cache-Control", on every response
+// from the API and its nested APIs. Calling it again adds to the previous headers. A nested API can use it to replace
+// a header from its parent. The headers are set before the handlers run, so handlers and custom routes can still
+// change them
+func (a *API[T]) SetResponseHeaders(headers map[string]string) *API[T] {
+	a.panicIfReadOnly()
+
+	if a.responseHeaders == nil {
+		a.responseHeaders = http.Header{}
+	}
+
+	for key, value := range headers {
+		if key == "" {
+			a.errors = append(a.errors, fmt.Errorf("SetResponseHeaders: header name must not be empty"))
+			continue
+		}
+		a.responseHeaders.Set(key, value)
+	}
+
+	return a
+}
+
+// responseHeadersMiddleware sets the headers from SetResponseHeaders on the response
+func (a *API[T]) responseHeadersMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for key := range a.responseHeaders {
+			w.Header().Set(key, a.responseHeaders.Get(key))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,108 @@
+package babyapi
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SetRetryAfter enables retrying requests that receive a 429 Too Many Requests or 503 Service Unavailable response
+// with a Retry-After header. The Client waits for the duration from the header before retrying, up to maxRetries
+// times. Responses are not retried if the wait is longer than maxWait, unless maxWait is 0. Requests with a body are
+// only retried if the body can be read again using http.Request.GetBody, which is set for the Client's requests. The
+// last response is handled normally, so the ErrResponse has the RetryAfter duration if the request still fails
+func (c *Client[T]) SetRetryAfter(maxRetries int, maxWait time.Duration) *Client[T] {
+	c.retryAfter = &retryAfterPolicy{maxRetries, maxWait}
+	c.client = c.retryAfter.wrap(c.client)
+	return c
+}
+
+// retryAfterPolicy is the configuration from SetRetryAfter
+type retryAfterPolicy struct {
+	maxRetries int
+	maxWait    time.Duration
+}
+
+// wrap returns a copy of the http.Client that uses the policy to retry requests
+func (p *retryAfterPolicy) wrap(client *http.Client) *http.Client {
+	next := client.Transport
+	if existing, ok := next.(*retryAfterTransport); ok {
+		next = existing.next
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	wrapped := *client
+	wrapped.Transport = &retryAfterTransport{next, p}
+	return &wrapped
+}
+
+// retryAfterTransport retries requests using the Retry-After header from 429 and 503 responses
+type retryAfterTransport struct {
+	next   http.RoundTripper
+	policy *retryAfterPolicy
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil || attempt >= t.policy.maxRetries {
+			return resp, err
+		}
+
+		wait, ok := parseRetryAfter(resp)
+		if !ok || (t.policy.maxWait > 0 && wait > t.policy.maxWait) {
+			return resp, nil
+		}
+
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, nil
+			}
+
+			body, err := req.GetBody()
+			if err != nil {
+				return resp, nil
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// parseRetryAfter gets the duration from the Retry-After header for 429 and 503 responses. The header can be a number
+// of seconds or an HTTP date. Dates in the past result in no wait
+func parseRetryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, ok := parseHTTPDate(value)
+	if !ok {
+		return 0, false
+	}
+
+	return max(time.Until(date), 0), true
+}
@@ -1,9 +1,14 @@
 package babyapi
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -52,8 +57,15 @@ type HTMLer interface {
 func (a *API[T]) Route(r chi.Router) error {
 	a.readOnly.TryLock()
 
-	if len(a.errors) > 0 {
-		return BuilderError{a.errors}
+	errs := append(slices.Clone(a.errors), a.customRouteErrors()...)
+	if a.parent == nil && a.maxNestingDepth > 0 {
+		depth := a.nestingDepth()
+		if depth > a.maxNestingDepth {
+			errs = append(errs, fmt.Errorf("nested APIs have depth %d which exceeds the maximum of %d", depth, a.maxNestingDepth))
+		}
+	}
+	if len(errs) > 0 {
+		return BuilderError{errs}
 	}
 
 	respondOnce.Do(func() {
@@ -66,19 +78,73 @@ func (a *API[T]) Route(r chi.Router) error {
 				}
 			}
 
-			render.DefaultResponder(w, r, v)
+			respondJSON(w, r, v)
 		}
+		render.Decode = decode
 	})
 
 	// Only set these middleware for root-level API
 	if a.parent == nil {
 		a.DefaultMiddleware(r)
+
+		// Respond with the same error format as other responses when a method, like one removed with
+		// DisableMethods, is not allowed
+		r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+			_ = render.Render(w, r, ErrMethodNotAllowedResponse)
+		})
+	}
+
+	// This is used first so requests with long URIs, like complex query filters, are rejected before any other work
+	if a.maxURILength > 0 {
+		r = r.With(a.maxURILengthMiddleware)
+	}
+
+	// CORS is handled by the top-level API before other middlewares so preflight requests are not authenticated
+	if a.parent == nil && a.hasCORS() {
+		r = r.With(a.corsMiddleware)
+	}
+
+	if len(a.responseHeaders) > 0 {
+		r = r.With(a.responseHeadersMiddleware)
+	}
+
+	if len(a.contentNegotiation) > 0 {
+		r = r.With(a.contentNegotiationMiddleware)
+	}
+
+	// This is used before other middlewares so it also applies to their responses
+	if a.hideUnauthorized {
+		r = r.With(a.hideUnauthorizedMiddleware)
 	}
 
 	for _, m := range a.middlewares {
 		r = r.With(m)
 	}
 
+	if a.logBodies {
+		r = r.With(a.logBodiesMiddleware)
+	}
+
+	if a.jsonOptions != nil {
+		r = r.With(a.jsonOptionsMiddleware)
+	}
+
+	if a.strictBinding != nil {
+		r = r.With(a.strictBindingMiddleware)
+	}
+
+	if a.responseTransformer != nil {
+		r = r.With(a.responseTransformerMiddleware)
+	}
+
+	if a.streamingResponses != nil {
+		r = r.With(a.streamingResponsesMiddleware)
+	}
+
+	if a.dryRun {
+		r = r.With(a.dryRunMiddleware)
+	}
+
 	if a.parent == nil {
 		a.doCustomRoutes(r, a.rootRoutes)
 	}
@@ -90,18 +156,19 @@ func (a *API[T]) Route(r chi.Router) error {
 			return
 		}
 
-		routeIfNotNil(r.With(a.requestBodyMiddleware).Post, "/", a.Post)
-		routeIfNotNil(r.Get, "/", a.GetAll)
+		routeIfNotNil(a.withMethodMiddlewares(r.With(a.requestBodyMiddleware), http.MethodPost).Post, "/", a.mutationHandler(a.Post))
+		routeIfNotNil(a.withMethodMiddlewares(r.With(a.etagMiddleware), http.MethodGet, MethodGetAll).Get, "/", a.GetAll)
+		routeIfNotNil(a.withMethodMiddlewares(r, http.MethodPatch).Patch, "/", a.mutationHandler(a.BulkPatch))
 
 		r.With(a.resourceExistsMiddleware).Route(fmt.Sprintf("/{%s}", a.IDParamKey()), func(r chi.Router) {
 			for _, m := range a.idMiddlewares {
 				r = r.With(m)
 			}
 
-			routeIfNotNil(r.Get, "/", a.Get)
-			routeIfNotNil(r.Delete, "/", a.Delete)
-			routeIfNotNil(r.With(a.requestBodyMiddleware).Put, "/", a.Put)
-			routeIfNotNil(r.With(a.requestBodyMiddleware).Patch, "/", a.Patch)
+			routeIfNotNil(a.withMethodMiddlewares(r.With(a.lastModifiedMiddleware, a.etagMiddleware), http.MethodGet).Get, "/", a.Get)
+			routeIfNotNil(a.withMethodMiddlewares(r.With(a.lastModifiedMiddleware), http.MethodDelete).Delete, "/", a.mutationHandler(a.Delete))
+			routeIfNotNil(a.withMethodMiddlewares(r.With(a.lastModifiedMiddleware, a.requestBodyMiddleware), http.MethodPut).Put, "/", a.mutationHandler(a.Put))
+			routeIfNotNil(a.withMethodMiddlewares(r.With(a.lastModifiedMiddleware, a.requestBodyMiddleware), http.MethodPatch).Patch, "/", a.mutationHandler(a.Patch))
 
 			for _, subAPI := range a.subAPIs {
 				err := subAPI.Route(r)
@@ -125,11 +192,11 @@ func (a *API[T]) Route(r chi.Router) error {
 
 // rootAPIRoutes creates different routes for a root API that doesn't deal with any resources
 func (a *API[T]) rootAPIRoutes(r chi.Router) error {
-	routeIfNotNil(r.Post, "/", a.Post)
-	routeIfNotNil(r.Get, "/", a.Get)
-	routeIfNotNil(r.Delete, "/", a.Delete)
-	routeIfNotNil(r.Put, "/", a.Put)
-	routeIfNotNil(r.Patch, "/", a.Patch)
+	routeIfNotNil(a.withMethodMiddlewares(r, http.MethodPost).Post, "/", a.mutationHandler(a.Post))
+	routeIfNotNil(a.withMethodMiddlewares(r, http.MethodGet).Get, "/", a.Get)
+	routeIfNotNil(a.withMethodMiddlewares(r, http.MethodDelete).Delete, "/", a.mutationHandler(a.Delete))
+	routeIfNotNil(a.withMethodMiddlewares(r, http.MethodPut).Put, "/", a.mutationHandler(a.Put))
+	routeIfNotNil(a.withMethodMiddlewares(r, http.MethodPatch).Patch, "/", a.mutationHandler(a.Patch))
 
 	for _, subAPI := range a.subAPIs {
 		err := subAPI.Route(r)
@@ -151,10 +218,103 @@ func (a *API[T]) Router() (chi.Router, error) {
 	return r, err
 }
 
+// mutationHandler returns nil instead of the handler when SetReadOnly is enabled so the route is not registered
+func (a *API[T]) mutationHandler(handler http.HandlerFunc) http.HandlerFunc {
+	if a.readOnlyResources {
+		return nil
+	}
+	return handler
+}
+
+// customRouteErrors returns an error for each custom route that conflicts with a default route or nested API. Otherwise,
+// chi would replace the default handler or send requests to an unexpected handler
+func (a *API[T]) customRouteErrors() []error {
+	baseHandlers := map[string]http.HandlerFunc{
+		http.MethodGet:   a.GetAll,
+		http.MethodPost:  a.mutationHandler(a.Post),
+		http.MethodPatch: a.mutationHandler(a.BulkPatch),
+	}
+	idHandlers := map[string]http.HandlerFunc{
+		http.MethodGet:    a.Get,
+		http.MethodDelete: a.mutationHandler(a.Delete),
+		http.MethodPut:    a.mutationHandler(a.Put),
+		http.MethodPatch:  a.mutationHandler(a.Patch),
+	}
+	if a.rootAPI {
+		// Root APIs register all handlers and nested APIs on the base path and do not have ID routes
+		baseHandlers = maps.Clone(idHandlers)
+		baseHandlers[http.MethodPost] = a.mutationHandler(a.Post)
+	}
+
+	var errs []error
+	for _, cr := range a.customRoutes {
+		for method := range cr.Handlers {
+			firstSegment := firstPatternSegment(cr.Pattern)
+			switch {
+			case firstSegment == "" && baseHandlers[method] != nil:
+				errs = append(errs, fmt.Errorf("AddCustomRoute: %s %q conflicts with the default %s handler", method, cr.Pattern, method))
+			case !a.rootAPI && isPatternParam(firstSegment):
+				errs = append(errs, fmt.Errorf("AddCustomRoute: %s %q conflicts with the ID route", method, cr.Pattern))
+			case a.rootAPI:
+				if err := a.subAPIRouteError(firstSegment); err != nil {
+					errs = append(errs, fmt.Errorf("AddCustomRoute: %s %q %w", method, cr.Pattern, err))
+				}
+			}
+		}
+	}
+
+	for _, cr := range a.customIDRoutes {
+		for method := range cr.Handlers {
+			firstSegment := firstPatternSegment(cr.Pattern)
+			if firstSegment == "" && idHandlers[method] != nil {
+				errs = append(errs, fmt.Errorf("AddCustomIDRoute: %s %q conflicts with the default %s handler", method, cr.Pattern, method))
+				continue
+			}
+
+			if err := a.subAPIRouteError(firstSegment); err != nil {
+				errs = append(errs, fmt.Errorf("AddCustomIDRoute: %s %q %w", method, cr.Pattern, err))
+			}
+		}
+	}
+
+	return errs
+}
+
+// subAPIRouteError returns an error if a custom route starting with the path segment would conflict with the routes
+// for nested APIs
+func (a *API[T]) subAPIRouteError(firstSegment string) error {
+	if len(a.subAPIs) == 0 {
+		return nil
+	}
+
+	if isPatternParam(firstSegment) {
+		return errors.New("conflicts with the routes for nested APIs")
+	}
+
+	for _, subAPI := range a.subAPIs {
+		if firstSegment == firstPatternSegment(subAPI.Base()) {
+			return fmt.Errorf("conflicts with the routes for %q", subAPI.Name())
+		}
+	}
+
+	return nil
+}
+
+// firstPatternSegment returns the first path segment of a route pattern, or an empty string for the base path
+func firstPatternSegment(pattern string) string {
+	firstSegment, _, _ := strings.Cut(strings.Trim(pattern, "/"), "/")
+	return firstSegment
+}
+
+// isPatternParam returns true if the route pattern segment matches any value, like a URL param or wildcard
+func isPatternParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") || segment == "*"
+}
+
 func (a *API[T]) doCustomRoutes(r chi.Router, routes []chi.Route) {
 	for _, cr := range routes {
 		for method, handler := range cr.Handlers {
-			r.MethodFunc(method, cr.Pattern, handler.ServeHTTP)
+			a.withMethodMiddlewares(r, method).Method(method, cr.Pattern, handler)
 		}
 	}
 }
@@ -165,27 +325,64 @@ func (a *API[T]) defaultGet() http.HandlerFunc {
 
 		resource, httpErr := a.GetRequestedResource(r)
 		if httpErr != nil {
-			logger.Error("error getting requested resource", "error", httpErr.Error())
+			logError(r.Context(), logger, "error getting requested resource", httpErr)
 			return httpErr
 		}
 
+		var expanded orderedObject
+		if a.maxExpandDepth > 0 && r.URL.Query().Has(expandQueryParam) {
+			expanded, httpErr = a.expand(r, resource.GetID())
+			if httpErr != nil {
+				return httpErr
+			}
+		}
+
 		render.Status(r, a.responseCodes[http.MethodGet])
 
-		return a.responseWrapper(resource)
+		return a.wrapResponseWithFields(resource, expanded)
 	})
 }
 
+// getAllResources uses the Storage's GetAllByParent method for nested APIs if it implements ParentIndexed. Otherwise,
+// it uses GetAll
+func (a *API[T]) getAllResources(r *http.Request) ([]T, error) {
+	parentIndexed, ok := a.Storage.(ParentIndexed[T])
+	if ok && a.parent != nil && !a.parent.isRoot() {
+		return parentIndexed.GetAllByParent(r.Context(), a.GetParentIDParam(r), r.URL.Query())
+	}
+
+	return a.Storage.GetAll(r.Context(), r.URL.Query())
+}
+
 func (a *API[T]) defaultGetAll() http.HandlerFunc {
 	return Handler(func(w http.ResponseWriter, r *http.Request) render.Renderer {
 		logger := GetLoggerFromContext(r.Context())
 
-		resources, err := a.Storage.GetAll(r.Context(), r.URL.Query())
+		resources, err := a.getAllResources(r)
 		if err != nil {
-			logger.Error("error getting resources", "error", err)
+			logError(r.Context(), logger, "error getting resources", err)
 			return InternalServerError(err)
 		}
 
-		resources = a.getAllFilter(r).Filter(resources)
+		filters := []FilterFunc[T]{a.getAllFilter(r)}
+		for _, f := range a.getAllFilters {
+			filters = append(filters, f(r))
+		}
+		resources = FilterAnd(filters...).Filter(resources)
+
+		total := len(resources)
+		var limit, offset int
+		if a.pagination != nil {
+			limit, offset, err = a.pagination.parse(r)
+			if err != nil {
+				return ErrInvalidRequest(err)
+			}
+			// Storage does not guarantee order, so resources are sorted by ID to keep pages consistent
+			slices.SortStableFunc(resources, func(a, b T) int {
+				return strings.Compare(a.GetID(), b.GetID())
+			})
+			resources = paginate(resources, limit, offset)
+		}
 		logger.Debug("responding with resources", "count", len(resources))
 
 		var resp render.Renderer
@@ -194,9 +391,25 @@ func (a *API[T]) defaultGetAll() http.HandlerFunc {
 		} else {
 			items := []render.Renderer{}
 			for _, item := range resources {
-				items = append(items, a.responseWrapper(item))
+				items = append(items, a.wrapResponse(item))
+			}
+			list := &ResourceList[render.Renderer]{Items: items}
+			if a.pagination != nil {
+				list.Total = total
+				list.Limit = limit
+				list.Offset = offset
+			}
+			resp = list
+			if a.getAllHTMLTemplate != nil {
+				if render.GetAcceptedContentType(r) == render.ContentTypeHTML {
+					for _, item := range items {
+						if _, ok := item.(HTMLer); !ok {
+							return InternalServerError(fmt.Errorf("GetAll HTML template requires items to implement HTMLer: %T", item))
+						}
+					}
+				}
+				resp = &htmlResourceList{list, a.getAllHTMLTemplate}
 			}
-			resp = &ResourceList[render.Renderer]{Items: items}
 		}
 
 		render.Status(r, a.responseCodes[MethodGetAll])
@@ -206,105 +419,139 @@ func (a *API[T]) defaultGetAll() http.HandlerFunc {
 }
 
 func (a *API[T]) defaultPost() http.HandlerFunc {
-	return a.ReadRequestBodyAndDo(func(r *http.Request, resource T) (T, *ErrResponse) {
+	return Handler(func(w http.ResponseWriter, r *http.Request) render.Renderer {
 		logger := GetLoggerFromContext(r.Context())
 
-		httpErr := a.onCreateOrUpdate(r, resource)
+		resource, httpErr := a.GetFromRequest(r)
 		if httpErr != nil {
-			return *new(T), httpErr
+			return httpErr
 		}
 
-		logger.Info("storing resource", "resource", resource)
-		err := a.Storage.Set(r.Context(), resource)
-		if err != nil {
-			logger.Error("error storing resource", "error", err)
-			return *new(T), InternalServerError(err)
+		httpErr = a.onCreateOrUpdate(r, resource)
+		if httpErr != nil {
+			return httpErr
 		}
 
-		httpErr = a.afterCreateOrUpdate(r, resource)
+		logger.Info("storing resource", "resource", a.loggableResource(resource))
+		httpErr = a.storeResource(w, r, resource, *new(T))
 		if httpErr != nil {
-			return *new(T), httpErr
+			return httpErr
+		}
+
+		if a.createLocationHeader || a.createResponseMode == CreateResponseLocationOnly {
+			w.Header().Set("Location", path.Join(r.URL.Path, resource.GetID()))
+		}
+
+		switch a.createResponseMode {
+		case CreateResponseIDOnly:
+			render.Status(r, a.responseCodes[http.MethodPost])
+			return &IDResponse{ID: resource.GetID()}
+		case CreateResponseLocationOnly:
+			w.WriteHeader(a.responseCodes[http.MethodPost])
+			return nil
 		}
 
 		render.Status(r, a.responseCodes[http.MethodPost])
 
-		return resource, nil
+		return a.wrapResponse(resource)
 	})
 }
 
 func (a *API[T]) defaultPut() http.HandlerFunc {
-	return a.ReadRequestBodyAndDo(func(r *http.Request, resource T) (T, *ErrResponse) {
+	return Handler(func(w http.ResponseWriter, r *http.Request) render.Renderer {
 		logger := GetLoggerFromContext(r.Context())
 
+		resource, httpErr := a.GetFromRequest(r)
+		if httpErr != nil {
+			return httpErr
+		}
+
 		if resource.GetID() != a.GetIDParam(r) {
-			return *new(T), ErrInvalidRequest(fmt.Errorf("id must match URL path"))
+			return ErrInvalidRequest(fmt.Errorf("id must match URL path"))
 		}
 
-		httpErr := a.onCreateOrUpdate(r, resource)
-		if httpErr != nil {
-			return *new(T), httpErr
+		// resourceExistsMiddleware only adds the resource to the context if it already exists
+		previous, err := a.GetResourceFromContext(r.Context())
+		created := errors.Is(err, ErrNotFound)
+		switch {
+		case a.partialPut && r.Header.Get("If-Match") != "":
+			resource, httpErr = a.mergePartialPut(r, resource, !created)
+			if httpErr != nil {
+				return httpErr
+			}
+		case err == nil:
+			preserveFields(resource, previous)
 		}
 
-		logger.Info("storing resource", "resource", resource)
-		err := a.Storage.Set(r.Context(), resource)
-		if err != nil {
-			logger.Error("error storing resource", "error", err)
-			return *new(T), InternalServerError(err)
+		httpErr = a.onCreateOrUpdate(r, resource)
+		if httpErr != nil {
+			return httpErr
 		}
 
-		httpErr = a.afterCreateOrUpdate(r, resource)
+		logger.Info("storing resource", "resource", a.loggableResource(resource), "created", created)
+		httpErr = a.storeResource(w, r, resource, previous)
 		if httpErr != nil {
-			return *new(T), httpErr
+			return httpErr
+		}
+
+		if a.putCreatedHeader {
+			w.Header().Set(CreatedHeader, strconv.FormatBool(created))
 		}
 
-		render.Status(r, a.responseCodes[http.MethodPut])
+		// The PUT response code is used when a separate code for creating is not configured
+		code, ok := a.responseCodes[MethodPutCreate]
+		if !created || !ok {
+			code = a.responseCodes[http.MethodPut]
+		}
+		render.Status(r, code)
 
-		return resource, nil
+		return a.wrapResponse(resource)
 	})
 }
 
 func (a *API[T]) defaultPatch() http.HandlerFunc {
-	return a.ReadRequestBodyAndDo(func(r *http.Request, patchRequest T) (T, *ErrResponse) {
+	return Handler(func(w http.ResponseWriter, r *http.Request) render.Renderer {
 		logger := GetLoggerFromContext(r.Context())
 
+		patchRequest, httpErr := a.GetFromRequest(r)
+		if httpErr != nil {
+			return httpErr
+		}
+
 		resource, httpErr := a.GetRequestedResource(r)
 		if httpErr != nil {
-			logger.Error("error getting requested resource", "error", httpErr.Error())
-			return *new(T), httpErr
+			logError(r.Context(), logger, "error getting requested resource", httpErr)
+			return httpErr
 		}
 
 		patcher, ok := any(resource).(Patcher[T])
 		if !ok {
-			return *new(T), ErrMethodNotAllowedResponse
+			return ErrMethodNotAllowedResponse
 		}
 
 		httpErr = patcher.Patch(patchRequest)
 		if httpErr != nil {
 			logger.Error("error patching resource", "error", httpErr.Error())
-			return *new(T), httpErr
+			return httpErr
 		}
 
 		httpErr = a.onCreateOrUpdate(r, resource)
 		if httpErr != nil {
-			return *new(T), httpErr
+			return httpErr
 		}
 
-		logger.Info("storing updated resource", "resource", resource)
-
-		err := a.Storage.Set(r.Context(), resource)
-		if err != nil {
-			logger.Error("error storing updated resource", "error", err)
-			return *new(T), InternalServerError(err)
-		}
+		// resourceExistsMiddleware gets a separate copy of the resource before it is patched
+		previous, _ := a.GetResourceFromContext(r.Context())
 
-		httpErr = a.afterCreateOrUpdate(r, resource)
+		logger.Info("storing updated resource", "resource", a.loggableResource(resource))
+		httpErr = a.storeResource(w, r, resource, previous)
 		if httpErr != nil {
-			return *new(T), httpErr
+			return httpErr
 		}
 
 		render.Status(r, a.responseCodes[http.MethodPatch])
 
-		return resource, nil
+		return a.wrapResponse(resource)
 	})
 }
 
@@ -319,23 +566,48 @@ func (a *API[T]) defaultDelete() http.HandlerFunc {
 
 		id := a.GetIDParam(r)
 
-		logger.Info("deleting resource", "id", id)
+		// The deleted resource is only needed for the response. resourceExistsMiddleware usually adds it to the
+		// context, but it is read from storage if a custom handler or router is used
+		var deleted T
+		if a.deleteResponseMode == DeleteResponseFullBody {
+			var err error
+			deleted, err = a.GetResourceFromContext(r.Context())
+			if err != nil {
+				deleted, httpErr = a.GetRequestedResource(r)
+				if httpErr != nil {
+					logError(r.Context(), logger, "error getting requested resource", httpErr)
+					return httpErr
+				}
+			}
+		}
 
-		err := a.Storage.Delete(r.Context(), id)
-		if err != nil {
-			logger.Error("error deleting resource", "error", err)
+		if IsDryRun(r.Context()) {
+			logger.Info("skipping delete for dry run", "id", id)
+			w.Header().Set(DryRunHeader, "true")
+		} else {
+			logger.Info("deleting resource", "id", id)
+
+			err := a.Storage.Delete(r.Context(), id)
+			if err != nil {
+				logError(r.Context(), logger, "error deleting resource", err)
+
+				if errors.Is(err, ErrNotFound) {
+					return a.notFoundResponse
+				}
 
-			if errors.Is(err, ErrNotFound) {
-				return ErrNotFoundResponse
+				return InternalServerError(err)
 			}
 
-			return InternalServerError(err)
+			httpErr = a.afterDelete(r)
+			if httpErr != nil {
+				logger.Error("error executing after func", "error", httpErr)
+				return httpErr
+			}
 		}
 
-		httpErr = a.afterDelete(r)
-		if httpErr != nil {
-			logger.Error("error executing after func", "error", httpErr)
-			return httpErr
+		if a.deleteResponseMode == DeleteResponseFullBody {
+			render.Status(r, a.responseCodes[http.MethodDelete])
+			return a.wrapResponse(deleted)
 		}
 
 		w.WriteHeader(a.responseCodes[http.MethodDelete])
@@ -343,9 +615,128 @@ func (a *API[T]) defaultDelete() http.HandlerFunc {
 	})
 }
 
+// withMethodMiddlewares returns a router that uses the middlewares from AddMethodMiddleware for the methods
+func (a *API[T]) withMethodMiddlewares(r chi.Router, methods ...string) chi.Router {
+	for _, method := range methods {
+		if len(a.methodMiddlewares[method]) > 0 {
+			r = r.With(a.methodMiddlewares[method]...)
+		}
+	}
+	return r
+}
+
 func routeIfNotNil(routeFunc func(string, http.HandlerFunc), pattern string, h http.HandlerFunc) {
 	if h == nil {
 		return
 	}
 	routeFunc(pattern, h)
 }
+
+// storeResource saves the resource and runs afterCreateOrUpdate using WithTx, so they are atomic if the Storage
+// implements Transactional. previous is the resource before it was updated, or the zero value if it was created. Both
+// are skipped for dry run requests. Once the resource is stored, files from EnableFileField that it no longer
+// references are deleted
+func (a *API[T]) storeResource(w http.ResponseWriter, r *http.Request, resource, previous T) *ErrResponse {
+	logger := GetLoggerFromContext(r.Context())
+
+	if IsDryRun(r.Context()) {
+		logger.Info("skipping storage for dry run")
+		w.Header().Set(DryRunHeader, "true")
+		return nil
+	}
+
+	_, transactional := a.Storage.(Transactional[T])
+
+	var httpErr *ErrResponse
+	stored := false
+	err := a.WithTx(r.Context(), func(ctx context.Context, storage Storage[T]) error {
+		err := storage.Set(ctx, resource)
+		if err != nil {
+			logError(r.Context(), logger, "error storing resource", err)
+			httpErr = storageSetError(err)
+			return err
+		}
+
+		httpErr = a.runAfterCreateOrUpdate(w, r.WithContext(ctx), storage, resource, previous, transactional)
+		stored = httpErr == nil || a.afterCreateOrUpdateErrorMode != AfterCreateOrUpdateErrorRollback
+		// Returning the error rolls back the transaction
+		if httpErr != nil && transactional && a.afterCreateOrUpdateErrorMode == AfterCreateOrUpdateErrorRollback {
+			return httpErr
+		}
+
+		return nil
+	})
+	if stored && err == nil {
+		a.fileUploadsStored(r, resource, previous)
+	}
+	if httpErr != nil {
+		return httpErr
+	}
+	if err != nil {
+		logError(r.Context(), logger, "error completing transaction", err)
+		return InternalServerError(err)
+	}
+
+	return nil
+}
+
+// runAfterCreateOrUpdate runs afterCreateOrUpdate after a resource is stored and handles errors using the API's
+// AfterCreateOrUpdateErrorMode. When the storage is transactional, rolling back is handled by the transaction instead
+// of restoring the previous resource
+func (a *API[T]) runAfterCreateOrUpdate(w http.ResponseWriter, r *http.Request, storage Storage[T], resource, previous T, transactional bool) *ErrResponse {
+	httpErr := a.afterCreateOrUpdate(r, resource)
+	if httpErr == nil {
+		return nil
+	}
+
+	logger := GetLoggerFromContext(r.Context())
+
+	switch a.afterCreateOrUpdateErrorMode {
+	case AfterCreateOrUpdateErrorPartialSuccess:
+		warning := httpErr.ErrorText
+		if warning == "" {
+			warning = httpErr.StatusText
+		}
+
+		logger.Warn("responding with stored resource after error", "error", httpErr.Err)
+		w.Header().Add("Warning", fmt.Sprintf("199 - %q", warning))
+		return nil
+	case AfterCreateOrUpdateErrorRollback:
+		if transactional {
+			break
+		}
+
+		var err error
+		if previous == *new(T) {
+			err = hardDelete(r.Context(), storage, resource.GetID())
+		} else {
+			err = storage.Set(r.Context(), previous)
+		}
+
+		if err != nil {
+			logger.Error("error rolling back stored resource", "error", err)
+			return InternalServerError(fmt.Errorf("error rolling back stored resource: %w", err))
+		}
+	}
+
+	return httpErr
+}
+
+// hardDelete removes the resource from storage. Storage.Delete only end-dates EndDateable resources when it uses
+// SoftDelete, like KVStorage, so it is called again to delete the end-dated resource
+func hardDelete[T Resource](ctx context.Context, storage Storage[T], id string) error {
+	err := storage.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := any(*new(T)).(EndDateable); !ok {
+		return nil
+	}
+
+	err = storage.Delete(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
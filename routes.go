@@ -0,0 +1,119 @@
+package babyapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RouteInfo describes a route registered by the API. Handler is the API name and method, like "Albums.GetAll", for
+// default handlers. Otherwise, it is the name of the handler function or type
+type RouteInfo struct {
+	Method  string
+	Pattern string
+	Handler string
+}
+
+// Routes builds the API's router and returns information about every route, including custom routes and nested
+// APIs. Routes are sorted by pattern and then method
+func (a *API[T]) Routes() ([]RouteInfo, error) {
+	router, err := a.Router()
+	if err != nil {
+		return nil, fmt.Errorf("error creating router: %w", err)
+	}
+
+	names := map[string]string{}
+	a.defaultRouteNames("", names)
+
+	routes := []RouteInfo{}
+	err = chi.Walk(router, func(method, route string, handler http.Handler, _ ...func(http.Handler) http.Handler) error {
+		pattern := strings.ReplaceAll(route, "/*/", "/")
+		if len(pattern) > 1 {
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+
+		name, ok := names[method+" "+pattern]
+		if !ok {
+			name = handlerName(handler)
+		}
+
+		routes = append(routes, RouteInfo{method, pattern, name})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking routes: %w", err)
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	return routes, nil
+}
+
+// defaultRouteNames adds the names of the API's default handlers and its children's default handlers to the map. The
+// keys are the method and full route pattern
+func (a *API[T]) defaultRouteNames(prefix string, names map[string]string) {
+	base := strings.TrimSuffix(prefix, "/") + a.base
+	if len(base) > 1 {
+		base = strings.TrimSuffix(base, "/")
+	}
+
+	add := func(method, pattern string, handler http.HandlerFunc, name string) {
+		if handler != nil {
+			names[method+" "+pattern] = a.name + "." + name
+		}
+	}
+
+	if a.rootAPI {
+		add(http.MethodGet, base, a.Get, "Get")
+		add(http.MethodPost, base, a.mutationHandler(a.Post), "Post")
+		add(http.MethodPut, base, a.mutationHandler(a.Put), "Put")
+		add(http.MethodPatch, base, a.mutationHandler(a.Patch), "Patch")
+		add(http.MethodDelete, base, a.mutationHandler(a.Delete), "Delete")
+
+		for _, child := range a.subAPIs {
+			child.defaultRouteNames(base, names)
+		}
+		return
+	}
+
+	item := fmt.Sprintf("%s/{%s}", base, a.IDParamKey())
+
+	add(http.MethodGet, base, a.GetAll, MethodGetAll)
+	add(http.MethodPost, base, a.mutationHandler(a.Post), "Post")
+	add(http.MethodPatch, base, a.mutationHandler(a.BulkPatch), "BulkPatch")
+	add(http.MethodGet, item, a.Get, "Get")
+	add(http.MethodPut, item, a.mutationHandler(a.Put), "Put")
+	add(http.MethodPatch, item, a.mutationHandler(a.Patch), "Patch")
+	add(http.MethodDelete, item, a.mutationHandler(a.Delete), "Delete")
+
+	for _, child := range a.subAPIs {
+		child.defaultRouteNames(item, names)
+	}
+}
+
+// handlerName returns the function name for handler funcs or the type name for other handlers. Middleware chains are
+// unwrapped to name the endpoint handler
+func handlerName(handler http.Handler) string {
+	if chain, ok := handler.(*chi.ChainHandler); ok {
+		handler = chain.Endpoint
+	}
+
+	if handlerFunc, ok := handler.(http.HandlerFunc); ok {
+		fn := runtime.FuncForPC(reflect.ValueOf(handlerFunc).Pointer())
+		if fn != nil {
+			return fn.Name()
+		}
+	}
+
+	return fmt.Sprintf("%T", handler)
+}
@@ -0,0 +1,45 @@
+package babyapi
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/go-chi/render"
+	"github.com/invopop/jsonschema"
+)
+
+// SchemaResponse is used to respond with the JSON schema of a resource
+type SchemaResponse struct {
+	*DefaultRenderer
+	*jsonschema.Schema
+}
+
+// EnableSchemaEndpoint adds a GET /base/schema route which responds with the JSON schema of the API's resource.
+// Descriptions and other details can be added with `jsonschema` struct tags. Fields are only required if they use
+// the `jsonschema:"required"` tag
+func (a *API[T]) EnableSchemaEndpoint() *API[T] {
+	a.panicIfReadOnly()
+
+	return a.AddCustomRoute(http.MethodGet, "/schema", Handler(func(w http.ResponseWriter, r *http.Request) render.Renderer {
+		return &SchemaResponse{Schema: a.Schema()}
+	}))
+}
+
+// Schema returns the JSON schema for the API's resource type
+func (a *API[T]) Schema() *jsonschema.Schema {
+	reflector := jsonschema.Reflector{
+		Anonymous:                  true,
+		ExpandedStruct:             true,
+		DoNotReference:             true,
+		RequiredFromJSONSchemaTags: true,
+		Mapper: func(t reflect.Type) *jsonschema.Schema {
+			// ID is encoded as a string, but the underlying xid type is an array
+			if t == reflect.TypeOf(ID{}) {
+				return &jsonschema.Schema{Type: "string"}
+			}
+			return nil
+		},
+	}
+
+	return reflector.ReflectFromType(reflect.TypeOf(*new(T)))
+}
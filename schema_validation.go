@@ -0,0 +1,330 @@
+package babyapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/invopop/jsonschema"
+)
+
+// SchemaError describes a value in a payload that does not match the JSON schema
+type SchemaError struct {
+	// Path is a JSON pointer to the invalid value, like "/tracks/0". It is empty for the whole payload
+	Path    string
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// SchemaValidationError is returned by Client.ValidatePayload with every part of the payload that does not match the
+// API's JSON schema
+type SchemaValidationError struct {
+	Errors []SchemaError
+}
+
+func (e *SchemaValidationError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("encountered %d errors validating payload:\n", len(e.Errors)))
+
+	for _, err := range e.Errors {
+		sb.WriteString(fmt.Sprintf("- %v\n", err))
+	}
+
+	return sb.String()
+}
+
+var _ error = &SchemaValidationError{}
+
+// clientSchema caches the schema from the API's schema endpoint. It is shared by copies of the Client
+type clientSchema struct {
+	mu     sync.Mutex
+	schema *jsonschema.Schema
+}
+
+// ValidatePayload checks that the resource matches the JSON schema from the API's schema endpoint, which is added
+// by EnableSchemaEndpoint, so mistakes are found without sending the resource. The schema is requested the first time
+// and is cached by the Client. If the resource does not match, a *SchemaValidationError is returned with each invalid
+// value.
+//
+// This supports the keywords used by schemas from EnableSchemaEndpoint, like type, required, enum, pattern, and
+// minimums and maximums, but does not resolve references. Null values are accepted for any type since Go encodes nil
+// pointers, slices, and maps as null
+func (c *Client[T]) ValidatePayload(ctx context.Context, resource T, parentIDs ...string) error {
+	schema, err := c.getSchema(ctx, parentIDs...)
+	if err != nil {
+		return err
+	}
+
+	data, err := marshalJSON(resource)
+	if err != nil {
+		return fmt.Errorf("error encoding resource: %w", err)
+	}
+
+	// encoding/json is used so numbers are always decoded as float64
+	var payload any
+	err = json.Unmarshal(data, &payload)
+	if err != nil {
+		return fmt.Errorf("error decoding resource: %w", err)
+	}
+
+	errs := validateSchema(schema, payload, "")
+	if len(errs) > 0 {
+		return &SchemaValidationError{errs}
+	}
+	return nil
+}
+
+// getSchema returns the cached schema or gets it from the API's schema endpoint
+func (c *Client[T]) getSchema(ctx context.Context, parentIDs ...string) (*jsonschema.Schema, error) {
+	c.schema.mu.Lock()
+	defer c.schema.mu.Unlock()
+
+	if c.schema.schema != nil {
+		return c.schema.schema, nil
+	}
+
+	req, err := c.DoRequest(ctx, http.MethodGet, "schema", http.NoBody, parentIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := MakeRequest[*jsonschema.Schema](req, c.client, http.StatusOK, c.requestEditor)
+	if err != nil {
+		return nil, fmt.Errorf("error getting schema: %w", err)
+	}
+	if resp.Data == nil {
+		return nil, fmt.Errorf("error getting schema: unexpected response %q", resp.Body)
+	}
+
+	c.schema.schema = resp.Data
+	return resp.Data, nil
+}
+
+// validateSchema returns errors for each part of the JSON value that does not match the schema
+func validateSchema(schema *jsonschema.Schema, value any, path string) []SchemaError {
+	if schema == nil || value == nil {
+		return nil
+	}
+
+	if reflect.DeepEqual(schema, jsonschema.FalseSchema) {
+		return []SchemaError{{path, "is not allowed"}}
+	}
+
+	if schema.Type != "" && !matchesSchemaType(schema.Type, value) {
+		return []SchemaError{{path, fmt.Sprintf("expected %s, but got %s", schema.Type, jsonTypeName(value))}}
+	}
+
+	var errs []SchemaError
+	addErr := func(format string, args ...any) {
+		errs = append(errs, SchemaError{path, fmt.Sprintf(format, args...)})
+	}
+
+	if len(schema.Enum) > 0 && !slices.ContainsFunc(schema.Enum, func(allowed any) bool {
+		return reflect.DeepEqual(allowed, value)
+	}) {
+		addErr("must be one of %v", schema.Enum)
+	}
+	if schema.Const != nil && !reflect.DeepEqual(schema.Const, value) {
+		addErr("must be %v", schema.Const)
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := uint64(utf8.RuneCountInString(v))
+		if schema.MinLength != nil && length < *schema.MinLength {
+			addErr("must have at least %d characters", *schema.MinLength)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			addErr("must have at most %d characters", *schema.MaxLength)
+		}
+		if schema.Pattern != "" {
+			re, err := regexp.Compile(schema.Pattern)
+			switch {
+			case err != nil:
+				addErr("has invalid pattern %q in schema: %v", schema.Pattern, err)
+			case !re.MatchString(v):
+				addErr("must match pattern %q", schema.Pattern)
+			}
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				addErr("must be a date-time")
+			}
+		}
+	case float64:
+		if limit, err := schema.Minimum.Float64(); err == nil && v < limit {
+			addErr("must be at least %v", limit)
+		}
+		if limit, err := schema.Maximum.Float64(); err == nil && v > limit {
+			addErr("must be at most %v", limit)
+		}
+		if limit, err := schema.ExclusiveMinimum.Float64(); err == nil && v <= limit {
+			addErr("must be greater than %v", limit)
+		}
+		if limit, err := schema.ExclusiveMaximum.Float64(); err == nil && v >= limit {
+			addErr("must be less than %v", limit)
+		}
+		if multiple, err := schema.MultipleOf.Float64(); err == nil && multiple != 0 && math.Mod(v, multiple) != 0 {
+			addErr("must be a multiple of %v", multiple)
+		}
+	case []any:
+		length := uint64(len(v))
+		if schema.MinItems != nil && length < *schema.MinItems {
+			addErr("must have at least %d items", *schema.MinItems)
+		}
+		if schema.MaxItems != nil && length > *schema.MaxItems {
+			addErr("must have at most %d items", *schema.MaxItems)
+		}
+		if schema.UniqueItems {
+			for i := range v {
+				if slices.ContainsFunc(v[:i], func(item any) bool { return reflect.DeepEqual(item, v[i]) }) {
+					addErr("must have unique items")
+					break
+				}
+			}
+		}
+		for i, item := range v {
+			errs = append(errs, validateSchema(schema.Items, item, fmt.Sprintf("%s/%d", path, i))...)
+		}
+	case map[string]any:
+		errs = append(errs, validateObject(schema, v, path)...)
+	}
+
+	for _, sub := range schema.AllOf {
+		errs = append(errs, validateSchema(sub, value, path)...)
+	}
+	if len(schema.AnyOf) > 0 && !slices.ContainsFunc(schema.AnyOf, func(sub *jsonschema.Schema) bool {
+		return len(validateSchema(sub, value, path)) == 0
+	}) {
+		addErr("must match at least one schema in anyOf")
+	}
+	if len(schema.OneOf) > 0 {
+		matches := 0
+		for _, sub := range schema.OneOf {
+			if len(validateSchema(sub, value, path)) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			addErr("must match exactly one schema in oneOf")
+		}
+	}
+	if schema.Not != nil && len(validateSchema(schema.Not, value, path)) == 0 {
+		addErr("must not match the schema in not")
+	}
+
+	return errs
+}
+
+// validateObject validates the properties of a JSON object. Errors are ordered by the schema's properties, then by
+// the names of additional properties
+func validateObject(schema *jsonschema.Schema, obj map[string]any, path string) []SchemaError {
+	var errs []SchemaError
+
+	for _, name := range schema.Required {
+		if _, ok := obj[name]; !ok {
+			errs = append(errs, SchemaError{jsonPointer(path, name), "is required"})
+		}
+	}
+
+	count := uint64(len(obj))
+	if schema.MinProperties != nil && count < *schema.MinProperties {
+		errs = append(errs, SchemaError{path, fmt.Sprintf("must have at least %d properties", *schema.MinProperties)})
+	}
+	if schema.MaxProperties != nil && count > *schema.MaxProperties {
+		errs = append(errs, SchemaError{path, fmt.Sprintf("must have at most %d properties", *schema.MaxProperties)})
+	}
+
+	if schema.Properties != nil {
+		for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			value, ok := obj[pair.Key]
+			if !ok {
+				continue
+			}
+			errs = append(errs, validateSchema(pair.Value, value, jsonPointer(path, pair.Key))...)
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if schema.Properties != nil {
+			if _, ok := schema.Properties.Get(name); ok {
+				continue
+			}
+		}
+
+		matchedPattern := false
+		for pattern, sub := range schema.PatternProperties {
+			re, err := regexp.Compile(pattern)
+			if err != nil || !re.MatchString(name) {
+				continue
+			}
+			matchedPattern = true
+			errs = append(errs, validateSchema(sub, obj[name], jsonPointer(path, name))...)
+		}
+		if !matchedPattern {
+			errs = append(errs, validateSchema(schema.AdditionalProperties, obj[name], jsonPointer(path, name))...)
+		}
+	}
+
+	return errs
+}
+
+// matchesSchemaType checks if the value decoded from JSON has the schema type
+func matchesSchemaType(schemaType string, value any) bool {
+	switch v := value.(type) {
+	case string:
+		return schemaType == "string"
+	case bool:
+		return schemaType == "boolean"
+	case float64:
+		return schemaType == "number" || (schemaType == "integer" && v == math.Trunc(v))
+	case []any:
+		return schemaType == "array"
+	case map[string]any:
+		return schemaType == "object"
+	}
+	return false
+}
+
+// jsonTypeName returns the JSON schema type of the value decoded from JSON
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "null"
+}
+
+// jsonPointer adds the name to the JSON pointer path, escaping "~" and "/" as required by RFC 6901
+func jsonPointer(path, name string) string {
+	return path + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
@@ -0,0 +1,125 @@
+package babyapi
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+// GlobalSearchQueryParam is the query parameter used for the search query in the global search endpoint
+const GlobalSearchQueryParam = "q"
+
+// GlobalSearchResponse is the response from the global search endpoint. Results are grouped by the name of the
+// API that they belong to
+type GlobalSearchResponse struct {
+	Results map[string][]render.Renderer `json:"results"`
+}
+
+func (gsr *GlobalSearchResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	for name, items := range gsr.Results {
+		for _, item := range items {
+			err := item.Render(w, r)
+			if err != nil {
+				return fmt.Errorf("error rendering %s item: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// SetGlobalSearchFilter sets a function that creates a filter used when this API is included in a parent root
+// API's global search. The request can be used to get the search query with the GlobalSearchQueryParam. APIs
+// without a global search filter are not included in search results
+func (a *API[T]) SetGlobalSearchFilter(f func(*http.Request) FilterFunc[T]) *API[T] {
+	a.panicIfReadOnly()
+
+	a.globalSearchFilter = f
+	return a
+}
+
+// EnableGlobalSearch adds a GET /search route to a root API which searches all child APIs that have a global search
+// filter and returns the results grouped by API name. Each child's middlewares are executed before searching it so
+// any authorization is respected. A child is excluded from the results if its middleware responds instead of
+// continuing to the next handler
+func (a *API[T]) EnableGlobalSearch() *API[T] {
+	a.panicIfReadOnly()
+
+	if !a.rootAPI {
+		a.errors = append(a.errors, fmt.Errorf("EnableGlobalSearch: global search can only be used with a root API"))
+		return a
+	}
+
+	return a.AddCustomRoute(http.MethodGet, "/search", Handler(func(w http.ResponseWriter, r *http.Request) render.Renderer {
+		logger := GetLoggerFromContext(r.Context())
+
+		resp := &GlobalSearchResponse{Results: map[string][]render.Renderer{}}
+		for name, child := range a.subAPIs {
+			results, ok, err := child.globalSearch(r)
+			if err != nil {
+				logger.Error("error searching resources", "api", name, "error", err)
+				return InternalServerError(err)
+			}
+			if !ok {
+				continue
+			}
+
+			resp.Results[name] = results
+		}
+
+		return resp
+	}))
+}
+
+// globalSearch gets all resources from storage and filters them with the global search filter. The API's middlewares
+// wrap the search so they can prevent it. It returns false if the API does not have a global search filter or if
+// a middleware did not continue to the next handler
+func (a *API[T]) globalSearch(r *http.Request) ([]render.Renderer, bool, error) {
+	if a.globalSearchFilter == nil {
+		return nil, false, nil
+	}
+
+	var results []render.Renderer
+	var allowed bool
+	var err error
+
+	var handler http.Handler = http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		allowed = true
+
+		var resources []T
+		resources, err = a.Storage.GetAll(r.Context(), r.URL.Query())
+		if err != nil {
+			return
+		}
+
+		results = []render.Renderer{}
+		for _, item := range a.globalSearchFilter(r).Filter(resources) {
+			results = append(results, a.wrapResponse(item))
+		}
+	})
+
+	// Apply in reverse so the first middleware is the outermost, matching the order used by Route
+	for i := len(a.middlewares) - 1; i >= 0; i-- {
+		handler = a.middlewares[i](handler)
+	}
+
+	// Use a copy of the request since render.Status modifies the request that it is given
+	handler.ServeHTTP(discardResponseWriter{header: http.Header{}}, r.WithContext(r.Context()))
+
+	return results, allowed, err
+}
+
+// discardResponseWriter is used to execute middlewares without writing their responses
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (d discardResponseWriter) Header() http.Header {
+	return d.header
+}
+
+func (discardResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (discardResponseWriter) WriteHeader(int) {}
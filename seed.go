@@ -0,0 +1,145 @@
+package babyapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/render"
+)
+
+// SeedStorage stores each resource using the Storage's Set method. It is useful for loading initial resources for
+// demos and tests. It stops and returns an error for the first resource that cannot be stored
+func SeedStorage[T Resource](ctx context.Context, storage Storage[T], items ...T) error {
+	for _, item := range items {
+		err := storage.Set(ctx, item)
+		if err != nil {
+			return fmt.Errorf("error storing resource %q: %w", item.GetID(), err)
+		}
+	}
+
+	return nil
+}
+
+// Seed decodes resources from JSON and stores them with SeedStorage. The data can be an array of resources for this
+// API, or an object of API names to arrays of resources to seed nested APIs. When bind is true, each resource is
+// decoded with render.Bind like a request body, so its Bind method validates it. Resources without an ID are bound like
+// POST requests, so DefaultResource generates an ID. Otherwise, resources are decoded directly and must have an ID. It
+// returns the number of resources that were stored
+func (a *API[T]) Seed(ctx context.Context, data []byte, bind bool) (int, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		return a.seed(ctx, data, bind)
+	}
+
+	var itemsByAPI map[string]json.RawMessage
+	err := unmarshalJSON(data, &itemsByAPI)
+	if err != nil {
+		return 0, fmt.Errorf("error decoding seed data: %w", err)
+	}
+
+	apis := map[string]relatedAPI{}
+	a.collectAPIs(apis)
+
+	names := []string{}
+	for name := range itemsByAPI {
+		if _, ok := apis[name]; !ok {
+			return 0, fmt.Errorf("unknown API %q in seed data", name)
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	total := 0
+	for _, name := range names {
+		count, err := apis[name].seed(ctx, itemsByAPI[name], bind)
+		total += count
+		if err != nil {
+			return total, fmt.Errorf("error seeding %q: %w", name, err)
+		}
+	}
+
+	return total, nil
+}
+
+// seed decodes a JSON array of resources and stores them in this API's Storage
+func (a *API[T]) seed(ctx context.Context, data json.RawMessage, bind bool) (int, error) {
+	if a.rootAPI || a.instance == nil {
+		return 0, fmt.Errorf("API %q does not have resources to seed", a.name)
+	}
+
+	var items []json.RawMessage
+	err := unmarshalJSON(data, &items)
+	if err != nil {
+		return 0, fmt.Errorf("error decoding resources: %w", err)
+	}
+
+	resources := make([]T, 0, len(items))
+	for i, item := range items {
+		resource, err := a.decodeSeedResource(ctx, item, bind)
+		if err != nil {
+			return 0, fmt.Errorf("error decoding resource %d: %w", i, err)
+		}
+		resources = append(resources, resource)
+	}
+
+	err = SeedStorage(ctx, a.Storage, resources...)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(resources), nil
+}
+
+func (a *API[T]) decodeSeedResource(ctx context.Context, data json.RawMessage, bind bool) (T, error) {
+	// The id field is read directly because resources like DefaultResource have a non-empty ID string when unset
+	var idField struct {
+		ID string `json:"id"`
+	}
+	err := unmarshalJSON(data, &idField)
+	if err != nil {
+		return *new(T), err
+	}
+
+	resource := a.instance()
+	if !bind {
+		if idField.ID == "" {
+			return *new(T), fmt.Errorf("missing required id field")
+		}
+
+		err = unmarshalJSON(data, resource)
+		if err != nil {
+			return *new(T), err
+		}
+		return resource, nil
+	}
+
+	method := http.MethodPut
+	if idField.ID == "" {
+		method = http.MethodPost
+	}
+
+	r, err := http.NewRequestWithContext(ctx, method, a.base, bytes.NewReader(data))
+	if err != nil {
+		return *new(T), err
+	}
+	r.Header.Set("Content-Type", "application/json")
+
+	err = render.Bind(r, resource)
+	if err != nil {
+		return *new(T), err
+	}
+
+	return resource, nil
+}
+
+// collectAPIs adds this API and its children to the map by name
+func (a *API[T]) collectAPIs(apis map[string]relatedAPI) {
+	apis[a.name] = a
+	for _, child := range a.subAPIs {
+		child.collectAPIs(apis)
+	}
+}
@@ -1,23 +1,60 @@
 package babyapi
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/go-chi/render"
 )
 
+// listenerBufferSize is the number of events buffered for each listener before new events are dropped
+const listenerBufferSize = 16
+
+// broadcastChannel sends each input to all registered listeners. Each listener has a buffer so one slow listener
+// does not block delivery to the other listeners. When a listener's buffer is full, new events are dropped for that
+// listener instead of blocking. If replaySize is set, the most recent inputs are kept and sent to new listeners. If done
+// is set, input channel workers stop when it is closed. If debounce is set, input channel workers coalesce inputs with
+// the same debounceKey and only send the latest one at the end of each debounce window
 type broadcastChannel[T any] struct {
-	listeners []chan T
-	lock      sync.RWMutex
+	listeners   []chan T
+	replaySize  int
+	replay      []T
+	done        <-chan struct{}
+	debounce    time.Duration
+	debounceKey func(T) string
+	lock        sync.RWMutex
 }
 
 func (bc *broadcastChannel[T]) GetListener() chan T {
+	listener, _ := bc.getLimitedListener(0)
+	return listener
+}
+
+// getLimitedListener creates a new listener only if there are fewer than limit listeners already registered. A limit
+// of zero or less is unlimited. It returns false if the limit is reached
+func (bc *broadcastChannel[T]) getLimitedListener(limit int) (chan T, bool) {
 	bc.lock.Lock()
 	defer bc.lock.Unlock()
-	newChan := make(chan T)
+	if limit > 0 && len(bc.listeners) >= limit {
+		return nil, false
+	}
+	newChan := make(chan T, listenerBufferSize+bc.replaySize)
+	for _, input := range bc.replay {
+		newChan <- input
+	}
 	bc.listeners = append(bc.listeners, newChan)
-	return newChan
+	return newChan, true
+}
+
+// ListenerCount returns the number of currently registered listeners
+func (bc *broadcastChannel[T]) ListenerCount() int {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	return len(bc.listeners)
 }
 
 func (bc *broadcastChannel[T]) RemoveListener(removeChan chan T) {
@@ -34,26 +71,102 @@ func (bc *broadcastChannel[T]) RemoveListener(removeChan chan T) {
 }
 
 func (bc *broadcastChannel[T]) SendToAll(input T) {
-	bc.lock.RLock()
-	defer bc.lock.RUnlock()
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	if bc.replaySize > 0 {
+		bc.replay = append(bc.replay, input)
+		if len(bc.replay) > bc.replaySize {
+			bc.replay = bc.replay[len(bc.replay)-bc.replaySize:]
+		}
+	}
+
 	for _, listener := range bc.listeners {
-		listener <- input
+		select {
+		case listener <- input:
+		default:
+			// drop the event for this listener since it is not keeping up
+		}
 	}
 }
 
 func (bc *broadcastChannel[T]) runInputChannel(inputChan chan T) {
-	for input := range inputChan {
-		bc.SendToAll(input)
+	if bc.debounce > 0 {
+		bc.runDebouncedInputChannel(inputChan)
+		return
+	}
+
+	for {
+		select {
+		case input, ok := <-inputChan:
+			if !ok {
+				return
+			}
+			bc.SendToAll(input)
+		case <-bc.done:
+			return
+		}
+	}
+}
+
+// runDebouncedInputChannel keeps the latest input for each key and sends them in the order the keys were first seen
+// when the debounce window ends. The window starts when an input is received and none are pending. Pending inputs
+// are sent when the input channel is closed, but dropped when done is closed since listeners are closed too
+func (bc *broadcastChannel[T]) runDebouncedInputChannel(inputChan chan T) {
+	pending := map[string]T{}
+	order := []string{}
+
+	timer := time.NewTimer(bc.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	flush := func() {
+		for _, key := range order {
+			bc.SendToAll(pending[key])
+		}
+		clear(pending)
+		order = order[:0]
+	}
+
+	for {
+		select {
+		case input, ok := <-inputChan:
+			if !ok {
+				flush()
+				return
+			}
+
+			key := ""
+			if bc.debounceKey != nil {
+				key = bc.debounceKey(input)
+			}
+
+			if len(order) == 0 {
+				timer.Reset(bc.debounce)
+			}
+			if _, ok := pending[key]; !ok {
+				order = append(order, key)
+			}
+			pending[key] = input
+		case <-timer.C:
+			flush()
+		case <-bc.done:
+			return
+		}
 	}
 }
 
-// GetInputChannel returns a channel acting as an input to the broadcast channel, closing the channel will stop the worker goroutine
+// GetInputChannel returns a channel acting as an input to the broadcast channel. Closing the channel or closing done
+// will stop the worker goroutine. Sends block after the worker stops, so senders that may outlive the API should also
+// select on the API's Done channel
 func (bc *broadcastChannel[T]) GetInputChannel() chan T {
 	newInputChan := make(chan T)
 	go bc.runInputChannel(newInputChan)
 	return newInputChan
 }
 
+var errStreamingUnsupported = errors.New("streaming unsupported: response writer does not implement http.Flusher")
+
 // ServerSentEvent is a simple struct that represents an event used in HTTP event stream
 type ServerSentEvent struct {
 	Event string
@@ -70,24 +183,122 @@ func (sse *ServerSentEvent) Write(w http.ResponseWriter) {
 }
 
 // AddServerSentEventHandler is a shortcut for HandleServerSentEvents that automatically creates and returns
-// the events channel and adds a custom handler for GET requests matching the provided pattern
+// the events channel and adds a custom handler for GET requests matching the provided pattern. The goroutine reading
+// from the channel stops when the API stops, so sending events should also select on api.Done()
 func (a *API[T]) AddServerSentEventHandler(pattern string) chan *ServerSentEvent {
-	eventsBroadcastChannel := broadcastChannel[*ServerSentEvent]{}
+	return a.AddServerSentEventHandlerWithReplay(pattern, 0)
+}
+
+// AddServerSentEventHandlerWithReplay is the same as AddServerSentEventHandler, but it keeps the most recent replay
+// events and sends them to each new connection before any new events. This allows clients that connect slightly
+// after an event is sent to still receive it. Events are kept even if there are no listeners
+func (a *API[T]) AddServerSentEventHandlerWithReplay(pattern string, replay int) chan *ServerSentEvent {
+	return a.AddServerSentEventHandlerWithOptions(pattern, ServerSentEventOptions{Replay: replay})
+}
+
+// ServerSentEventOptions configure the server-sent events handler created by AddServerSentEventHandlerWithOptions
+type ServerSentEventOptions struct {
+	// Replay is the number of recent events sent to each new connection, like AddServerSentEventHandlerWithReplay
+	Replay int
+
+	// Debounce coalesces bursts of events to avoid flooding clients with rapid updates. Events with the same
+	// DebounceKey that are sent within the window are combined and only the latest is sent when the window ends. This
+	// delays events by up to the window duration. Zero, the default, sends every event immediately
+	Debounce time.Duration
+
+	// DebounceKey groups events for Debounce, like by the ID of the resource that the event is for. By default, events
+	// are only grouped if they have the same Event name and Data, so bursts of duplicate events are sent once but
+	// events for different resources with the same name are all sent. Set this to combine updates to the same resource
+	DebounceKey func(*ServerSentEvent) string
+}
+
+// defaultDebounceKey groups events with the same name and data. Event names cannot contain newlines in an event
+// stream, so the name and data are separated by one
+func defaultDebounceKey(sse *ServerSentEvent) string {
+	return sse.Event + "\n" + sse.Data
+}
+
+// AddServerSentEventHandlerWithOptions is the same as AddServerSentEventHandler, but it allows using the options
+// for replaying recent events to new connections and debouncing bursts of events
+func (a *API[T]) AddServerSentEventHandlerWithOptions(pattern string, opts ServerSentEventOptions) chan *ServerSentEvent {
+	debounceKey := opts.DebounceKey
+	if debounceKey == nil {
+		debounceKey = defaultDebounceKey
+	}
 
-	a.AddCustomRoute(http.MethodGet, pattern, a.HandleServerSentEvents(&eventsBroadcastChannel))
+	eventsBroadcastChannel := &broadcastChannel[*ServerSentEvent]{
+		replaySize:  max(opts.Replay, 0),
+		done:        a.Done(),
+		debounce:    opts.Debounce,
+		debounceKey: debounceKey,
+	}
+	a.AddCustomRoute(http.MethodGet, pattern, a.HandleServerSentEvents(eventsBroadcastChannel))
+	a.serverSentEvents[pattern] = eventsBroadcastChannel
 
 	return eventsBroadcastChannel.GetInputChannel()
 }
 
+// ServerSentEventListeners returns the number of clients currently connected to the server-sent events handler
+// added with AddServerSentEventHandler for the pattern. It returns zero if there is no handler for the pattern. This
+// can be used to skip creating the event when nobody is listening
+func (a *API[T]) ServerSentEventListeners(pattern string) int {
+	eventsBroadcastChannel, ok := a.serverSentEvents[pattern]
+	if !ok {
+		return 0
+	}
+
+	return eventsBroadcastChannel.ListenerCount()
+}
+
+// SetMaxSSEConnections limits the number of concurrent connections to each server-sent events handler. New connections
+// beyond the limit receive a 503 response. Zero, the default, allows unlimited connections
+func (a *API[T]) SetMaxSSEConnections(n int) *API[T] {
+	a.panicIfReadOnly()
+
+	a.maxSSEConnections = n
+	return a
+}
+
+// SetSSEHeaders sets additional headers on responses from server-sent events handlers. These are set after the default
+// Cache-Control, Connection, and Content-Type headers, so they can also be used to override the defaults. This is useful
+// when running behind a reverse proxy that buffers responses, like nginx, which can be disabled with the header
+// "X-Accel-Buffering: no"
+func (a *API[T]) SetSSEHeaders(headers map[string]string) *API[T] {
+	a.panicIfReadOnly()
+
+	a.sseHeaders = headers
+	return a
+}
+
 // HandleServerSentEvents is a handler function that will listen on the provided channel and write events
-// to the HTTP response
+// to the HTTP response. If the http.ResponseWriter does not implement http.Flusher, events cannot be streamed
+// so it responds with a 500 error instead
 func (a *API[T]) HandleServerSentEvents(EventsBroadcastChannel *broadcastChannel[*ServerSentEvent]) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		events := EventsBroadcastChannel.GetListener()
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			GetLoggerFromContext(r.Context()).Error("response writer does not support flushing", "type", fmt.Sprintf("%T", w))
+			_ = render.Render(w, r, InternalServerError(errStreamingUnsupported))
+			return
+		}
+
+		events, ok := EventsBroadcastChannel.getLimitedListener(a.maxSSEConnections)
+		if !ok {
+			GetLoggerFromContext(r.Context()).Warn("rejecting server-sent events connection", "max_connections", a.maxSSEConnections)
+			_ = render.Render(w, r, ErrTooManyConnectionsResponse)
+			return
+		}
 		defer EventsBroadcastChannel.RemoveListener(events)
 		w.Header().Set("Cache-Control", "no-cache")
 		w.Header().Set("Connection", "keep-alive")
-		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		for key, value := range a.sseHeaders {
+			w.Header().Set(key, value)
+		}
+
+		// Write headers immediately so clients know the connection is established before any events
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
 
 		for {
 			select {
@@ -0,0 +1,261 @@
+package babyapi
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroadcastChannelSlowListener(t *testing.T) {
+	bc := broadcastChannel[int]{}
+
+	slow := bc.GetListener()
+	fast := bc.GetListener()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < listenerBufferSize*2; i++ {
+			bc.SendToAll(i)
+			assert.Equal(t, i, <-fast)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "SendToAll blocked on slow listener")
+	}
+
+	t.Run("SlowListenerReceivesBufferedEvents", func(t *testing.T) {
+		require.Len(t, slow, listenerBufferSize)
+		require.Equal(t, 0, <-slow)
+	})
+
+	t.Run("RemoveListenerDoesNotBlock", func(t *testing.T) {
+		bc.RemoveListener(slow)
+		bc.RemoveListener(fast)
+		require.Empty(t, bc.listeners)
+	})
+}
+
+func TestBroadcastChannelReplay(t *testing.T) {
+	bc := broadcastChannel[int]{replaySize: 2}
+
+	t.Run("ReplayKeptWithoutListeners", func(t *testing.T) {
+		bc.SendToAll(1)
+		bc.SendToAll(2)
+		bc.SendToAll(3)
+		require.Equal(t, []int{2, 3}, bc.replay)
+	})
+
+	t.Run("NewListenerReceivesReplayFirst", func(t *testing.T) {
+		listener := bc.GetListener()
+		defer bc.RemoveListener(listener)
+
+		bc.SendToAll(4)
+
+		require.Equal(t, 2, <-listener)
+		require.Equal(t, 3, <-listener)
+		require.Equal(t, 4, <-listener)
+	})
+
+	t.Run("NoReplayByDefault", func(t *testing.T) {
+		bc := broadcastChannel[int]{}
+		bc.SendToAll(1)
+
+		listener := bc.GetListener()
+		defer bc.RemoveListener(listener)
+
+		require.Empty(t, bc.replay)
+		require.Empty(t, listener)
+	})
+}
+
+// nonFlushingWriter hides the http.Flusher implementation of the wrapped ResponseRecorder
+type nonFlushingWriter struct {
+	w *httptest.ResponseRecorder
+}
+
+func (nfw nonFlushingWriter) Header() http.Header {
+	return nfw.w.Header()
+}
+
+func (nfw nonFlushingWriter) Write(b []byte) (int, error) {
+	return nfw.w.Write(b)
+}
+
+func (nfw nonFlushingWriter) WriteHeader(statusCode int) {
+	nfw.w.WriteHeader(statusCode)
+}
+
+func TestHandleServerSentEventsWithoutFlusher(t *testing.T) {
+	api := NewAPI("Items", "/items", func() *TODO { return &TODO{} })
+	bc := broadcastChannel[*ServerSentEvent]{}
+
+	r := httptest.NewRequest(http.MethodGet, "/items/events", http.NoBody)
+	r = r.WithContext(NewContextWithLogger(r.Context(), slog.Default()))
+	w := httptest.NewRecorder()
+
+	api.HandleServerSentEvents(&bc).ServeHTTP(nonFlushingWriter{w}, r)
+
+	require.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+	require.Equal(t, `{"status":"Server Error.","error":"streaming unsupported: response writer does not implement http.Flusher"}`, strings.TrimSpace(w.Body.String()))
+	require.Empty(t, bc.listeners)
+}
+
+func TestBroadcastChannelInputStopsWhenDone(t *testing.T) {
+	done := make(chan struct{})
+	bc := broadcastChannel[int]{done: done}
+
+	listener := bc.GetListener()
+	input := make(chan int)
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		bc.runInputChannel(input)
+	}()
+
+	input <- 1
+	require.Equal(t, 1, <-listener)
+
+	close(done)
+
+	select {
+	case <-finished:
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "input worker did not stop when done was closed")
+	}
+}
+
+func TestStopClosesChildServerSentEvents(t *testing.T) {
+	parent := NewAPI("Lists", "/lists", func() *TODO { return &TODO{} })
+	child := NewAPI("Items", "/items", func() *ChildTODO { return &ChildTODO{} })
+	parent.AddNestedAPI(child)
+
+	bc := &broadcastChannel[*ServerSentEvent]{done: child.Done()}
+
+	r := httptest.NewRequest(http.MethodGet, "/lists/1/items/events", http.NoBody)
+	w := httptest.NewRecorder()
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		child.HandleServerSentEvents(bc).ServeHTTP(w, r)
+	}()
+
+	require.Eventually(t, func() bool { return bc.ListenerCount() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	parent.closeQuit()
+	// closing again, like when Stop is called after the context is cancelled, is safe
+	parent.closeQuit()
+
+	select {
+	case <-finished:
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "child server-sent events handler did not stop with the parent")
+	}
+
+	require.Zero(t, bc.ListenerCount())
+}
+
+func TestBroadcastChannelDebounce(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	bc := broadcastChannel[*ServerSentEvent]{
+		done:        done,
+		debounce:    50 * time.Millisecond,
+		debounceKey: func(sse *ServerSentEvent) string { return sse.Event },
+	}
+
+	listener := bc.GetListener()
+	input := bc.GetInputChannel()
+
+	input <- &ServerSentEvent{Event: "a", Data: "1"}
+	input <- &ServerSentEvent{Event: "b", Data: "1"}
+	input <- &ServerSentEvent{Event: "a", Data: "2"}
+	input <- &ServerSentEvent{Event: "a", Data: "3"}
+
+	// events are only sent at the end of the window, in the order their keys were first seen
+	select {
+	case sse := <-listener:
+		require.Failf(t, "event sent before the debounce window ended", "%+v", sse)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	require.Equal(t, &ServerSentEvent{Event: "a", Data: "3"}, <-listener)
+	require.Equal(t, &ServerSentEvent{Event: "b", Data: "1"}, <-listener)
+
+	// the next window starts with the next event
+	input <- &ServerSentEvent{Event: "a", Data: "4"}
+	require.Equal(t, &ServerSentEvent{Event: "a", Data: "4"}, <-listener)
+
+	select {
+	case sse := <-listener:
+		require.Failf(t, "unexpected event", "%+v", sse)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestBroadcastChannelDebounceDefaultKey(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	bc := broadcastChannel[*ServerSentEvent]{
+		done:        done,
+		debounce:    50 * time.Millisecond,
+		debounceKey: defaultDebounceKey,
+	}
+
+	listener := bc.GetListener()
+	input := bc.GetInputChannel()
+
+	input <- &ServerSentEvent{Event: "update", Data: "album1"}
+	input <- &ServerSentEvent{Event: "update", Data: "album2"}
+	input <- &ServerSentEvent{Event: "update", Data: "album1"}
+
+	// events with the same name for different resources are not combined
+	require.Equal(t, &ServerSentEvent{Event: "update", Data: "album1"}, <-listener)
+	require.Equal(t, &ServerSentEvent{Event: "update", Data: "album2"}, <-listener)
+
+	select {
+	case sse := <-listener:
+		require.Failf(t, "duplicate event was not combined", "%+v", sse)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestBroadcastChannelDebounceFlushesWhenInputClosed(t *testing.T) {
+	bc := broadcastChannel[int]{debounce: time.Hour}
+
+	listener := bc.GetListener()
+	input := make(chan int)
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		bc.runInputChannel(input)
+	}()
+
+	input <- 1
+	input <- 2
+	close(input)
+
+	select {
+	case <-finished:
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "input worker did not stop when input was closed")
+	}
+
+	// all inputs have the same key without a debounceKey, so only the latest is sent
+	require.Equal(t, 2, <-listener)
+	require.Empty(t, listener)
+}
@@ -8,6 +8,10 @@ import (
 
 var ErrNotFound = errors.New("resource not found")
 
+// ErrConflict can be returned by storage implementations when a resource cannot be saved because it conflicts with
+// an existing resource, like a unique constraint violation. The API responds with 409 Conflict
+var ErrConflict = errors.New("resource conflict")
+
 // FilterFunc is used for GetAll to filter resources that are read from storage
 type FilterFunc[T any] func(T) bool
 
@@ -27,14 +31,71 @@ func (f FilterFunc[T]) Filter(in []T) []T {
 	return out
 }
 
-// Storage defines how the API will interact with a storage backend
+// FilterAnd combines filters so a resource is only included if it is included by all of the filters. Nil filters
+// are ignored
+func FilterAnd[T any](filters ...FilterFunc[T]) FilterFunc[T] {
+	filters = nonNilFilters(filters)
+	if len(filters) == 0 {
+		return nil
+	}
+
+	return func(item T) bool {
+		for _, f := range filters {
+			if !f(item) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// FilterOr combines filters so a resource is included if it is included by any of the filters. Nil filters are
+// ignored
+func FilterOr[T any](filters ...FilterFunc[T]) FilterFunc[T] {
+	filters = nonNilFilters(filters)
+	if len(filters) == 0 {
+		return nil
+	}
+
+	return func(item T) bool {
+		for _, f := range filters {
+			if f(item) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func nonNilFilters[T any](filters []FilterFunc[T]) []FilterFunc[T] {
+	result := []FilterFunc[T]{}
+	for _, f := range filters {
+		if f != nil {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// Storage defines how the API will interact with a storage backend. It is the only storage interface used by the
+// API's default handlers, global search, and extensions, so any implementation can be used with SetStorage
 type Storage[T Resource] interface {
 	// Get a single resource by ID
 	Get(context.Context, string) (T, error)
-	// GetAll will return all resources that match the provided query filters
+	// GetAll will return all resources that match the provided query filters. The API passes the request's URL
+	// query parameters here, and then applies any filters from SetGetAllFilter or AddGetAllFilter to the results
 	GetAll(context.Context, url.Values) ([]T, error)
 	// Set will save the provided resource
 	Set(context.Context, T) error
 	// Delete will delete a resource by ID
 	Delete(context.Context, string) error
 }
+
+// ParentIndexed is an optional interface for Storage implementations that can efficiently get the resources that
+// belong to a parent resource, like a SQL query using an indexed foreign key. The default GetAll handler for nested
+// APIs uses it instead of GetAll when it is implemented. Filters from SetGetAllFilter and AddGetAllFilter are still
+// applied to the results
+type ParentIndexed[T Resource] interface {
+	// GetAllByParent will return all resources with the parent ID that match the provided query filters
+	GetAllByParent(ctx context.Context, parentID string, query url.Values) ([]T, error)
+}
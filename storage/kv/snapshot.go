@@ -0,0 +1,219 @@
+package kv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/madflojo/hord"
+	"github.com/madflojo/hord/drivers/hashmap"
+)
+
+// SnapshotDB is an in-memory database that loads data from a JSON file when it is created and writes all data to
+// the file periodically and when it is closed. Unlike NewFileDB, writes do not rewrite the file, so it is faster for
+// frequent writes, but changes since the last snapshot are lost if the process exits without closing the database.
+// The file uses the same format as NewFileDB with a ".json" filename, so either can read it
+type SnapshotDB struct {
+	*hashmap.Database
+
+	filename string
+	dirty    atomic.Bool
+
+	// writeLock makes sure only one snapshot is written at a time
+	writeLock sync.Mutex
+
+	stop      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+var _ hord.Database = &SnapshotDB{}
+
+// NewSnapshotDB creates a SnapshotDB that writes to the file every interval if the data changed. If the interval is
+// 0, snapshots are only written when the database is closed
+func NewSnapshotDB(filename string, interval time.Duration) (*SnapshotDB, error) {
+	if filename == "" {
+		return nil, errors.New("snapshot filename is required")
+	}
+	if interval < 0 {
+		return nil, errors.New("snapshot interval must not be negative")
+	}
+
+	db, err := NewFileDB(hashmap.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotDB := &SnapshotDB{
+		Database: db.(*hashmap.Database),
+		filename: filename,
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+
+	err = snapshotDB.load()
+	if err != nil {
+		return nil, err
+	}
+
+	go snapshotDB.run(interval)
+
+	return snapshotDB, nil
+}
+
+func (db *SnapshotDB) Set(key string, data []byte) error {
+	err := db.Database.Set(key, data)
+	if err == nil {
+		db.dirty.Store(true)
+	}
+	return err
+}
+
+func (db *SnapshotDB) Delete(key string) error {
+	err := db.Database.Delete(key)
+	if err == nil {
+		db.dirty.Store(true)
+	}
+	return err
+}
+
+// Snapshot writes all data to the file. It is used automatically, but can also be used to make sure recent changes
+// are written
+func (db *SnapshotDB) Snapshot() error {
+	db.writeLock.Lock()
+	defer db.writeLock.Unlock()
+
+	// The flag is cleared before reading so changes made while writing are included in the next snapshot
+	db.dirty.Store(false)
+
+	err := db.writeSnapshot()
+	if err != nil {
+		db.dirty.Store(true)
+	}
+	return err
+}
+
+func (db *SnapshotDB) writeSnapshot() error {
+	keys, err := db.Keys()
+	if err != nil {
+		return fmt.Errorf("error getting keys: %w", err)
+	}
+
+	data := map[string][]byte{}
+	for _, key := range keys {
+		value, err := db.Get(key)
+		if errors.Is(err, hord.ErrNil) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error getting %q: %w", key, err)
+		}
+		data[key] = value
+	}
+
+	content, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("error encoding snapshot: %w", err)
+	}
+
+	// Writing to a temporary file and renaming it makes sure the snapshot is not partially written
+	tmp, err := os.CreateTemp(filepath.Dir(db.filename), filepath.Base(db.filename)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("error creating snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing snapshot file: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), db.filename)
+	if err != nil {
+		return fmt.Errorf("error replacing snapshot file %q: %w", db.filename, err)
+	}
+
+	return nil
+}
+
+// Close stops writing periodic snapshots, writes the final snapshot, and closes the database
+func (db *SnapshotDB) Close() {
+	db.closeOnce.Do(func() {
+		close(db.stop)
+		<-db.stopped
+
+		err := db.Snapshot()
+		if err != nil {
+			slog.Error("error writing snapshot", "filename", db.filename, "error", err)
+		}
+
+		db.Database.Close()
+	})
+}
+
+// load reads the data from the snapshot file if it exists
+func (db *SnapshotDB) load() error {
+	content, err := os.ReadFile(db.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading snapshot file %q: %w", db.filename, err)
+	}
+	if len(content) == 0 {
+		return nil
+	}
+
+	data := map[string][]byte{}
+	err = json.Unmarshal(content, &data)
+	if err != nil {
+		return fmt.Errorf("error decoding snapshot file %q: %w", db.filename, err)
+	}
+
+	for key, value := range data {
+		err = db.Database.Set(key, value)
+		if err != nil {
+			return fmt.Errorf("error loading %q from snapshot: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// run writes snapshots every interval if the data changed until the database is closed
+func (db *SnapshotDB) run(interval time.Duration) {
+	defer close(db.stopped)
+
+	if interval == 0 {
+		<-db.stop
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.stop:
+			return
+		case <-ticker.C:
+			if !db.dirty.Load() {
+				continue
+			}
+
+			err := db.Snapshot()
+			if err != nil {
+				slog.Error("error writing snapshot", "filename", db.filename, "error", err)
+			}
+		}
+	}
+}
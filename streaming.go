@@ -0,0 +1,240 @@
+package babyapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+)
+
+// streamBufferSize is the size of JSON list responses that are buffered before they are written to the client. Smaller
+// responses are written at once with a Content-Length header. Larger responses are written in chunks while the items
+// are encoded, so the whole encoded list is never in memory
+const streamBufferSize = 32 << 10
+
+// SetStreamingResponses enables or disables flushing JSON list responses to the client after each item is encoded,
+// instead of buffering the beginning of the response. This improves the time to the first byte for large lists and
+// slow clients, but the responses do not have a Content-Length header. It applies to the default GetAll handler and
+// custom routes that respond with a ResourceList. Other responses, like from SetGetAllResponseWrapper, are still
+// buffered. GetAll responses are also buffered when EnableETag is used since the ETag is computed from the whole
+// body. Nested APIs use the same setting unless they set their own
+func (a *API[T]) SetStreamingResponses(enabled bool) *API[T] {
+	a.panicIfReadOnly()
+
+	a.streamingResponses = &enabled
+	return a
+}
+
+func (a *API[T]) streamingResponsesMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), streamingResponsesCtxKey, *a.streamingResponses)))
+	})
+}
+
+// jsonStreamer is implemented by responses that can encode their JSON directly to a writer, one part at a time
+type jsonStreamer interface {
+	streamJSON(w io.Writer, opts *jsonOptions) error
+
+	// streamedValue returns the value that streamJSON encodes. It is used to check that the response is not a type
+	// that embeds a ResourceList, since that type also has the ResourceList's methods
+	streamedValue() any
+}
+
+// getJSONStreamer returns the jsonStreamer if the response is a ResourceList. Types that embed a ResourceList, like
+// responses from SetGetAllResponseWrapper, are not streamed because they can have other fields or a MarshalJSON method
+func getJSONStreamer(v any) (jsonStreamer, bool) {
+	streamer, ok := v.(jsonStreamer)
+	if !ok || streamer.streamedValue() != v {
+		return nil, false
+	}
+	return streamer, true
+}
+
+func (rl *ResourceList[T]) streamedValue() any {
+	return rl
+}
+
+// streamedValue allows streaming HTML lists when JSON is accepted since they encode the same as the ResourceList
+func (hrl *htmlResourceList) streamedValue() any {
+	return hrl
+}
+
+// streamJSON encodes the list to the writer one item at a time. The result is the same as encoding the whole list
+func (rl *ResourceList[T]) streamJSON(w io.Writer, opts *jsonOptions) error {
+	if _, err := io.WriteString(w, "{"); err != nil {
+		return err
+	}
+
+	separator := ""
+	if len(rl.Items) > 0 || opts == nil || !opts.omitEmpty {
+		if _, err := io.WriteString(w, `"items":`); err != nil {
+			return err
+		}
+		if err := rl.streamItems(w, opts); err != nil {
+			return err
+		}
+		separator = ","
+	}
+
+	for _, field := range []orderedField{{"total", rl.Total}, {"limit", rl.Limit}, {"offset", rl.Offset}} {
+		if field.value == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, `%s"%s":%d`, separator, field.name, field.value); err != nil {
+			return err
+		}
+		separator = ","
+	}
+
+	_, err := io.WriteString(w, "}\n")
+	return err
+}
+
+// streamItems encodes the JSON array of items
+func (rl *ResourceList[T]) streamItems(w io.Writer, opts *jsonOptions) error {
+	if rl.Items == nil {
+		_, err := io.WriteString(w, "null")
+		return err
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	for i, item := range rl.Items {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+
+		var v any = item
+		if opts != nil {
+			var err error
+			v, err = opts.value(item)
+			if err != nil {
+				return err
+			}
+		}
+
+		data, err := marshalJSON(v)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+	_, err := io.WriteString(w, "]")
+	return err
+}
+
+// respondStreamJSON writes a JSON response from a jsonStreamer. Unless SetStreamingResponses is used, the response is
+// buffered until it is larger than streamBufferSize so errors can still respond with 500 Internal Server Error and
+// small responses have a Content-Length header. If there is an error after part of the response was written, it can
+// only be logged
+func respondStreamJSON(w http.ResponseWriter, r *http.Request, v jsonStreamer, opts *jsonOptions) {
+	status, _ := r.Context().Value(render.StatusCtxKey).(int)
+	flush, _ := r.Context().Value(streamingResponsesCtxKey).(bool)
+	sw := &streamResponseWriter{w: w, status: status, flush: flush}
+
+	err := v.streamJSON(sw, opts)
+	if err == nil {
+		err = sw.close()
+	}
+	if err == nil {
+		return
+	}
+
+	if !sw.streaming {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	logger := GetLoggerFromContext(r.Context())
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Error("error writing streamed response", "error", err)
+}
+
+// streamResponseWriter buffers the beginning of a response and writes the headers when the buffer is full or the
+// response is complete
+type streamResponseWriter struct {
+	w      http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+
+	// flush enables writing the response to the client when Flush is called instead of buffering it
+	flush     bool
+	streaming bool
+}
+
+func (sw *streamResponseWriter) Write(p []byte) (int, error) {
+	if sw.streaming {
+		return sw.w.Write(p)
+	}
+
+	if sw.buf.Len()+len(p) <= streamBufferSize {
+		return sw.buf.Write(p)
+	}
+
+	if err := sw.startStreaming(); err != nil {
+		return 0, err
+	}
+	return sw.w.Write(p)
+}
+
+// Flush writes the response to the client if SetStreamingResponses is used. Otherwise, the response stays buffered
+func (sw *streamResponseWriter) Flush() {
+	if !sw.flush {
+		return
+	}
+
+	if err := sw.startStreaming(); err != nil {
+		return
+	}
+	if flusher, ok := sw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// startStreaming writes the headers and the buffered part of the response so the rest is written directly
+func (sw *streamResponseWriter) startStreaming() error {
+	if sw.streaming {
+		return nil
+	}
+
+	sw.streaming = true
+	sw.writeHeader()
+	_, err := sw.w.Write(sw.buf.Bytes())
+	sw.buf = bytes.Buffer{}
+	return err
+}
+
+// close writes the buffered response with a Content-Length header if it was not already streamed
+func (sw *streamResponseWriter) close() error {
+	if sw.streaming {
+		return nil
+	}
+
+	sw.w.Header().Set("Content-Length", strconv.Itoa(sw.buf.Len()))
+	sw.writeHeader()
+	_, err := sw.w.Write(sw.buf.Bytes())
+	return err
+}
+
+func (sw *streamResponseWriter) writeHeader() {
+	sw.w.Header().Set("Content-Type", "application/json")
+	if sw.status != 0 {
+		sw.w.WriteHeader(sw.status)
+	}
+}
@@ -1,20 +1,40 @@
 package babytest
 
 import (
+	"os"
 	"testing"
 
 	"github.com/calvinmclean/babyapi"
 )
 
+// AddressEnvVar is the environment variable used by RunTableTest to run tests against an already-running server
+// instead of starting the API with TestServe. This allows the same table tests to be used for smoke-testing a
+// deployed instance
+const AddressEnvVar = "BABYTEST_ADDRESS"
+
 // PreviousResponseGetter is used to get the output of previous tests in a TableTest
 type PreviousResponseGetter func(testName string) *Response[*babyapi.AnyResource]
 
 // RunTableTest will start the provided API and execute all provided tests in-order. This allows the usage of a
 // PreviousResponseGetter in each test to access data from previous tests. The API's ClientMap is used to execute
-// tests with child clients if the test uses ClientName field
+// tests with child clients if the test uses ClientName field. If the BABYTEST_ADDRESS environment variable is set,
+// the tests are executed against the server at that address instead of starting the API
 func RunTableTest[T babyapi.Resource](t *testing.T, api *babyapi.API[T], tests []TestCase[*babyapi.AnyResource]) {
-	client, stop := NewTestAnyClient[T](t, api)
-	defer stop()
+	RunTableTestWithAddress(t, api, os.Getenv(AddressEnvVar), tests)
+}
+
+// RunTableTestWithAddress is the same as RunTableTest, but executes the tests against an already-running server at
+// the provided address. The API is not started, but it is still used to create clients. If the address is empty, the
+// API is started using TestServe
+func RunTableTestWithAddress[T babyapi.Resource](t *testing.T, api *babyapi.API[T], address string, tests []TestCase[*babyapi.AnyResource]) {
+	var client *babyapi.Client[*babyapi.AnyResource]
+	if address == "" {
+		var stop func()
+		client, stop = NewTestAnyClient[T](t, api)
+		defer stop()
+	} else {
+		client = api.AnyClient(address)
+	}
 
 	results := map[string]*Response[*babyapi.AnyResource]{}
 
@@ -3,7 +3,9 @@ package babytest
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -46,6 +48,10 @@ type ExpectedResponse struct {
 	Body string
 	// BodyRegexp allows comparing a request body by regex
 	BodyRegexp string
+	// JSONMatch unmarshals the response body and compares the value at each JSON path to the expected value.
+	// Paths are dot-separated keys, and numeric segments are used to index arrays (e.g. "items.0.title").
+	// Fields that are not included, such as generated IDs, are ignored
+	JSONMatch map[string]any
 	// Status is the expected HTTP response code
 	Status int
 	// Error is an expected error string to be returned by the client
@@ -157,4 +163,58 @@ func (tt TestCase[T]) assertResponse(t *testing.T, r *Response[T]) {
 		}
 		require.Equal(t, tt.ExpectedResponse.Body, strings.TrimSpace(body))
 	}
+
+	if len(tt.JSONMatch) > 0 {
+		assertJSONMatch(t, tt.JSONMatch, body)
+	}
+}
+
+// assertJSONMatch compares each expected value to the value found at the JSON path in the body. Expected values
+// are encoded and decoded as JSON before comparing so numeric types and structs compare the same as the response
+func assertJSONMatch(t *testing.T, expected map[string]any, body string) {
+	var data any
+	err := json.Unmarshal([]byte(body), &data)
+	require.NoError(t, err, "error decoding response body as JSON")
+
+	for path, expectedValue := range expected {
+		actual, err := getJSONPath(data, path)
+		require.NoError(t, err)
+
+		expectedJSON, err := json.Marshal(expectedValue)
+		require.NoError(t, err)
+
+		var normalizedExpected any
+		err = json.Unmarshal(expectedJSON, &normalizedExpected)
+		require.NoError(t, err)
+
+		require.Equal(t, normalizedExpected, actual, "unexpected value at JSON path %q", path)
+	}
+}
+
+// getJSONPath walks the decoded JSON data using the dot-separated path
+func getJSONPath(data any, path string) (any, error) {
+	current := data
+	for _, key := range strings.Split(path, ".") {
+		switch v := current.(type) {
+		case map[string]any:
+			next, ok := v[key]
+			if !ok {
+				return nil, fmt.Errorf("missing key %q in JSON path %q", key, path)
+			}
+			current = next
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid array index %q in JSON path %q: %w", key, path, err)
+			}
+			if index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("array index %d out of range in JSON path %q", index, path)
+			}
+			current = v[index]
+		default:
+			return nil, fmt.Errorf("unable to get key %q from non-object value in JSON path %q", key, path)
+		}
+	}
+
+	return current, nil
 }
@@ -0,0 +1,47 @@
+package babytest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetJSONPath(t *testing.T) {
+	var data any
+	err := json.Unmarshal([]byte(`{"name":"Party","count":2,"items":[{"title":"First"},{"title":"Second"}],"details":null}`), &data)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		path          string
+		expected      any
+		expectedError string
+	}{
+		{"TopLevelKey", "name", "Party", ""},
+		{"Number", "count", float64(2), ""},
+		{"NullValue", "details", nil, ""},
+		{"Array", "items.1", map[string]any{"title": "Second"}, ""},
+		{"ArrayIndexAndKey", "items.0.title", "First", ""},
+		{"MissingKey", "missing", nil, `missing key "missing" in JSON path "missing"`},
+		{"MissingNestedKey", "items.0.missing", nil, `missing key "missing" in JSON path "items.0.missing"`},
+		{"InvalidArrayIndex", "items.first", nil, `invalid array index "first" in JSON path "items.first": strconv.Atoi: parsing "first": invalid syntax`},
+		{"ArrayIndexOutOfRange", "items.2", nil, `array index 2 out of range in JSON path "items.2"`},
+		{"NegativeArrayIndex", "items.-1", nil, `array index -1 out of range in JSON path "items.-1"`},
+		{"NonObjectValue", "name.first", nil, `unable to get key "first" from non-object value in JSON path "name.first"`},
+		{"NullValueKey", "details.key", nil, `unable to get key "key" from non-object value in JSON path "details.key"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := getJSONPath(data, tt.path)
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package babyapi
+
+import "context"
+
+// Transactional can be implemented by a Storage to run multiple operations atomically. All operations in the
+// transaction should use the provided context and Storage. If the function returns an error, the transaction is
+// rolled back. Implementations can store the transaction in the context so other storages using the same backend
+// are able to join it instead of starting a new one
+type Transactional[T Resource] interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context, tx Storage[T]) error) error
+}
+
+// WithTx runs the function in a transaction if the API's Storage implements Transactional. Otherwise, the function
+// is called with the API's Storage and the operations are not atomic. This is used by the default POST, PUT, and
+// PATCH handlers so storing a resource and running the SetAfterCreateOrUpdate function can be atomic. It can also be
+// used in custom routes, and calls from multiple APIs can be nested to span multiple storages that support joining
+// a transaction from the context
+func (a *API[T]) WithTx(ctx context.Context, fn func(ctx context.Context, storage Storage[T]) error) error {
+	transactional, ok := a.Storage.(Transactional[T])
+	if !ok {
+		return fn(ctx, a.Storage)
+	}
+
+	return transactional.WithTx(ctx, fn)
+}